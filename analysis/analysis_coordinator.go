@@ -0,0 +1,202 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/go-webdl/media-codec/codec"
+	"github.com/go-webdl/media-codec/nalu"
+)
+
+// Sample - one demuxed sample in decode order
+type Sample struct {
+	DTS  int64
+	Data []byte
+	// Subsamples - CENC subsample map of a protected sample, nil if the
+	// sample is in the clear
+	Subsamples []nalu.Subsample
+}
+
+// SegmentData - demuxed content of a segment
+type SegmentData struct {
+	// SampleEntry, Record - sample entry four character code and
+	// configuration record payload in effect for the segment, typically from
+	// the initialization segment
+	SampleEntry string
+	Record      []byte
+	Samples     []Sample
+}
+
+// Segment - a segment to analyze
+type Segment struct {
+	// Name - provenance of the segment, e.g. its URL
+	Name string
+	// Load - fetch and demux the segment; called from a worker goroutine
+	Load func(ctx context.Context) (*SegmentData, error)
+}
+
+// Keyframe - random access point of the stream
+type Keyframe struct {
+	Segment int
+	Sample  int
+	DTS     int64
+}
+
+// SegmentReport - analysis result of one segment
+type SegmentReport struct {
+	Index      int
+	Name       string
+	Parameters *codec.Parameters
+	Samples    int
+	// Keyframes - random access samples of the segment
+	Keyframes []Keyframe
+	Err       error
+}
+
+// ConfigChange - segment from which on a new configuration is in effect
+type ConfigChange struct {
+	Segment    int
+	Name       string
+	Parameters *codec.Parameters
+}
+
+// StreamReport - merged analysis of all segments
+type StreamReport struct {
+	Segments []SegmentReport
+	// Configs - the first configuration and every change of it
+	Configs   []ConfigChange
+	Keyframes []Keyframe
+	// Warnings - stream level findings, prefixed with the segment name
+	Warnings []string
+}
+
+// Failed - segments whose analysis failed
+func (r *StreamReport) Failed() (failed []SegmentReport) {
+	for _, s := range r.Segments {
+		if s.Err != nil {
+			failed = append(failed, s)
+		}
+	}
+	return failed
+}
+
+// Analyze - analyze segments, e.g. the DASH or HLS segments of a download,
+// with at most workers concurrent loads
+// The results are merged in segment order into one StreamReport, and every
+// finding keeps the index and name of its segment. Failures of single
+// segments are recorded in their SegmentReport; an error is only returned if
+// ctx is done before all segments are analyzed.
+func Analyze(ctx context.Context, segments []Segment, workers int) (*StreamReport, error) {
+	if workers < 1 {
+		workers = 1
+	}
+	reports := make([]SegmentReport, len(segments))
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				reports[i] = analyzeSegment(ctx, i, &segments[i])
+			}
+		}()
+	}
+feed:
+	for i := range segments {
+		select {
+		case indices <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indices)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return merge(reports), nil
+}
+
+// analyzeSegment - probe the configuration and find the keyframes of one segment
+func analyzeSegment(ctx context.Context, index int, segment *Segment) (report SegmentReport) {
+	report = SegmentReport{Index: index, Name: segment.Name}
+	data, err := segment.Load(ctx)
+	if err != nil {
+		report.Err = err
+		return report
+	}
+	report.Samples = len(data.Samples)
+	c, record, err := codec.ReadRecord(data.SampleEntry, data.Record)
+	if err != nil {
+		report.Err = err
+		return report
+	}
+	if report.Parameters, err = codec.ProbeRecord(c, record, data.SampleEntry); err != nil {
+		report.Err = err
+		return report
+	}
+	if c.SplitSample == nil || c.IsSync == nil {
+		return report
+	}
+	for i, sample := range data.Samples {
+		units, err := splitSample(c, record, &sample)
+		if err != nil {
+			report.Err = fmt.Errorf("sample %d: %w", i, err)
+			return report
+		}
+		if c.IsSync(units) {
+			report.Keyframes = append(report.Keyframes, Keyframe{Segment: index, Sample: i, DTS: sample.DTS})
+		}
+	}
+	return report
+}
+
+// splitSample - the units of a sample; of a protected sample only the clear
+// parts of its NAL units, which suffice to detect keyframes
+func splitSample(c *codec.Codec, record codec.Record, sample *Sample) ([][]byte, error) {
+	if sample.Subsamples == nil {
+		return c.SplitSample(sample.Data, record)
+	}
+	if c.SplitProtectedSample == nil {
+		return nil, fmt.Errorf("protected %s samples not supported", c.Name)
+	}
+	nalus, err := c.SplitProtectedSample(sample.Data, record, sample.Subsamples)
+	if err != nil {
+		return nil, err
+	}
+	return nalu.ClearParts(nalus), nil
+}
+
+// merge - combine segment reports in segment order
+func merge(reports []SegmentReport) *StreamReport {
+	stream := &StreamReport{Segments: reports}
+	var current *codec.Parameters
+	for i := range reports {
+		r := &reports[i]
+		if r.Err != nil {
+			stream.Warnings = append(stream.Warnings, fmt.Sprintf("%s: %s", r.Name, r.Err))
+			continue
+		}
+		if current == nil || !sameParameters(r.Parameters, current) {
+			stream.Configs = append(stream.Configs, ConfigChange{Segment: r.Index, Name: r.Name, Parameters: r.Parameters})
+			current = r.Parameters
+		}
+		stream.Keyframes = append(stream.Keyframes, r.Keyframes...)
+		if r.Samples > 0 && (len(r.Keyframes) == 0 || r.Keyframes[0].Sample != 0) {
+			stream.Warnings = append(stream.Warnings, fmt.Sprintf("%s: does not start with a keyframe", r.Name))
+		}
+	}
+	return stream
+}
+
+// sameParameters - parameters equal including the colour description
+func sameParameters(a, b *codec.Parameters) bool {
+	x, y := *a, *b
+	if (x.Colour == nil) != (y.Colour == nil) || x.Colour != nil && *x.Colour != *y.Colour {
+		return false
+	}
+	x.Colour, y.Colour = nil, nil
+	return x == y
+}
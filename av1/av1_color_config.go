@@ -0,0 +1,63 @@
+package av1
+
+import "github.com/go-webdl/media-codec/colr"
+
+// ColorConfig - AV1 color_config
+// AV1 Bitstream & Decoding Process Specification Sec. 5.5.2
+// ColorPrimaries, TransferCharacteristics and MatrixCoefficients use the
+// ITU-T H.273 code points, so they map one to one onto the VUI colour
+// description.
+type ColorConfig struct {
+	BitDepth                    byte
+	MonoChrome                  bool
+	ColorDescriptionPresentFlag bool
+	ColorPrimaries              byte
+	TransferCharacteristics     byte
+	MatrixCoefficients          byte
+	ColorRange                  bool
+	SubsamplingX                bool
+	SubsamplingY                bool
+	ChromaSamplePosition        byte
+	SeparateUVDeltaQ            bool
+}
+
+// Chroma sample positions, AV1 Sec. 6.4.2
+const (
+	CSP_UNKNOWN   = byte(0)
+	CSP_VERTICAL  = byte(1)
+	CSP_COLOCATED = byte(2)
+)
+
+// NCLX - colour description equivalent to the VUI colour description triplet
+// Code points that are not signalled are unspecified.
+func (c *ColorConfig) NCLX() colr.NCLX {
+	n := colr.Unspecified()
+	if c.ColorDescriptionPresentFlag {
+		n.ColourPrimaries = uint16(c.ColorPrimaries)
+		n.TransferCharacteristics = uint16(c.TransferCharacteristics)
+		n.MatrixCoefficients = uint16(c.MatrixCoefficients)
+	}
+	n.FullRangeFlag = c.ColorRange
+	return n
+}
+
+// SetNCLX - set colour description and range from a VUI colour description
+// color_description_present_flag is cleared when all code points are
+// unspecified. For sRGB (BT.709 primaries, sRGB transfer, identity matrix) AV1
+// mandates full range 4:4:4, which is applied as well.
+func (c *ColorConfig) SetNCLX(n colr.NCLX) {
+	c.ColorPrimaries = byte(n.ColourPrimaries)
+	c.TransferCharacteristics = byte(n.TransferCharacteristics)
+	c.MatrixCoefficients = byte(n.MatrixCoefficients)
+	c.ColorDescriptionPresentFlag = n.ColourPrimaries != colr.COLOUR_PRIMARIES_UNSPECIFIED ||
+		n.TransferCharacteristics != colr.TRANSFER_UNSPECIFIED ||
+		n.MatrixCoefficients != colr.MATRIX_UNSPECIFIED
+	c.ColorRange = n.FullRangeFlag
+	if n.ColourPrimaries == colr.COLOUR_PRIMARIES_BT709 &&
+		n.TransferCharacteristics == colr.TRANSFER_SRGB &&
+		n.MatrixCoefficients == colr.MATRIX_IDENTITY {
+		c.ColorRange = true
+		c.SubsamplingX = false
+		c.SubsamplingY = false
+	}
+}
@@ -0,0 +1,202 @@
+package av1
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-webdl/media-codec/colr"
+)
+
+// AV1 Codec ISO Media File Format Binding Sec. 2.3 AV1 codec configuration box
+
+// AV1CodecConfigurationRecord - av1C payload
+// The fields duplicate the sequence header, which should be carried in
+// ConfigOBUs. Monochrome streams signal chroma subsampling 4:2:0 with an
+// unknown chroma sample position.
+type AV1CodecConfigurationRecord struct {
+	Version                          uint8
+	SeqProfile                       uint8
+	SeqLevelIdx0                     uint8
+	SeqTier0                         uint8
+	HighBitdepth                     bool
+	TwelveBit                        bool
+	Monochrome                       bool
+	ChromaSubsamplingX               bool
+	ChromaSubsamplingY               bool
+	ChromaSamplePosition             uint8
+	InitialPresentationDelayPresent  bool
+	InitialPresentationDelayMinusOne uint8
+	// ConfigOBUs - sequence header and metadata OBUs in low overhead
+	// bitstream format, each with obu_size
+	ConfigOBUs []byte
+}
+
+func (b *AV1CodecConfigurationRecord) RecordSize() (size uint32) {
+	// unsigned int(1) marker = 1;
+	// unsigned int(7) version = 1;
+	// unsigned int(3) seq_profile;
+	// unsigned int(5) seq_level_idx_0;
+	// unsigned int(1) seq_tier_0;
+	// unsigned int(1) high_bitdepth;
+	// unsigned int(1) twelve_bit;
+	// unsigned int(1) monochrome;
+	// unsigned int(1) chroma_subsampling_x;
+	// unsigned int(1) chroma_subsampling_y;
+	// unsigned int(2) chroma_sample_position;
+	// unsigned int(3) reserved = 0;
+	// unsigned int(1) initial_presentation_delay_present;
+	// unsigned int(4) initial_presentation_delay_minus_one or reserved = 0;
+	size += 4
+	// unsigned int(8) configOBUs[];
+	size += uint32(len(b.ConfigOBUs))
+	return
+}
+
+func (b *AV1CodecConfigurationRecord) RecordRead(r io.Reader) (err error) {
+	var tmp [4]uint8
+	if err = binary.Read(r, binary.BigEndian, &tmp); err != nil {
+		return
+	}
+	if tmp[0]&0x80 == 0 {
+		return errors.New("av1C marker bit not set")
+	}
+	b.Version = tmp[0] & 0x7f
+	b.SeqProfile = tmp[1] >> 5
+	b.SeqLevelIdx0 = tmp[1] & 0b11111
+	b.SeqTier0 = tmp[2] >> 7
+	b.HighBitdepth = tmp[2]&0x40 != 0
+	b.TwelveBit = tmp[2]&0x20 != 0
+	b.Monochrome = tmp[2]&0x10 != 0
+	b.ChromaSubsamplingX = tmp[2]&0x08 != 0
+	b.ChromaSubsamplingY = tmp[2]&0x04 != 0
+	b.ChromaSamplePosition = tmp[2] & 0b11
+	b.InitialPresentationDelayPresent = tmp[3]&0x10 != 0
+	if b.InitialPresentationDelayPresent {
+		b.InitialPresentationDelayMinusOne = tmp[3] & 0x0f
+	}
+	b.ConfigOBUs, err = io.ReadAll(r)
+	return
+}
+
+func (b *AV1CodecConfigurationRecord) RecordWrite(w io.Writer) (err error) {
+	var tmp [4]uint8
+	tmp[0] = 0x80 | b.Version&0x7f
+	tmp[1] = b.SeqProfile<<5 | b.SeqLevelIdx0&0b11111
+	tmp[2] = b.SeqTier0<<7 | b.ChromaSamplePosition&0b11
+	for i, flag := range []bool{b.HighBitdepth, b.TwelveBit, b.Monochrome, b.ChromaSubsamplingX, b.ChromaSubsamplingY} {
+		if flag {
+			tmp[2] |= 0x40 >> i
+		}
+	}
+	if b.InitialPresentationDelayPresent {
+		tmp[3] = 0x10 | b.InitialPresentationDelayMinusOne&0x0f
+	}
+	if _, err = w.Write(tmp[:]); err != nil {
+		return
+	}
+	_, err = w.Write(b.ConfigOBUs)
+	return
+}
+
+// CreateAV1CodecConfigurationRecord - fill an av1C from a sequence header OBU
+// The OBU is stored in ConfigOBUs, with obu_size added if it has none.
+func CreateAV1CodecConfigurationRecord(seqHdrOBU []byte) (AV1CodecConfigurationRecord, error) {
+	sh, err := ParseSequenceHeaderOBU(seqHdrOBU)
+	if err != nil {
+		return AV1CodecConfigurationRecord{}, err
+	}
+	hdr, _ := ParseOBUHeader(seqHdrOBU)
+	configOBUs := seqHdrOBU
+	if !hdr.HasSizeField {
+		configOBUs = append([]byte{seqHdrOBU[0] | 0x02}, seqHdrOBU[1:hdr.HeaderSize]...)
+		configOBUs = AppendLeb128(configOBUs, hdr.Size)
+		configOBUs = append(configOBUs, seqHdrOBU[hdr.HeaderSize:]...)
+	}
+	c := &sh.ColorConfig
+	op := sh.OperatingPoints[0]
+	return AV1CodecConfigurationRecord{
+		Version:                          1,
+		SeqProfile:                       sh.SeqProfile,
+		SeqLevelIdx0:                     op.SeqLevelIdx,
+		SeqTier0:                         op.SeqTier,
+		HighBitdepth:                     c.BitDepth > 8,
+		TwelveBit:                        c.BitDepth == 12,
+		Monochrome:                       c.MonoChrome,
+		ChromaSubsamplingX:               c.SubsamplingX,
+		ChromaSubsamplingY:               c.SubsamplingY,
+		ChromaSamplePosition:             c.ChromaSamplePosition,
+		InitialPresentationDelayPresent:  op.InitialDisplayDelayPresent,
+		InitialPresentationDelayMinusOne: op.InitialDisplayDelayMinus1,
+		ConfigOBUs:                       append([]byte(nil), configOBUs...),
+	}, nil
+}
+
+// SequenceHeader - parse the sequence header OBU in ConfigOBUs
+func (b *AV1CodecConfigurationRecord) SequenceHeader() (*SequenceHeader, error) {
+	obus, err := SplitOBUs(b.ConfigOBUs)
+	if err != nil {
+		return nil, err
+	}
+	for _, obu := range obus {
+		if hdr, _ := ParseOBUHeader(obu); hdr.Type == OBU_SEQUENCE_HEADER {
+			return ParseSequenceHeaderOBU(obu)
+		}
+	}
+	return nil, ErrNoSequenceHeader
+}
+
+// BitDepth - bit depth signalled by the record
+func (b *AV1CodecConfigurationRecord) BitDepth() byte {
+	switch {
+	case b.TwelveBit:
+		return 12
+	case b.HighBitdepth:
+		return 10
+	}
+	return 8
+}
+
+// CodecString - RFC 6381 codecs parameter according to the AV1 ISOBMFF
+// binding Sec. 5, e.g. av01.0.04M.10
+// The optional colour fields are appended when they differ from their
+// defaults; monochrome and the chroma subsampling and sample position are
+// taken from the record, the colour description from the sequence header.
+func (b *AV1CodecConfigurationRecord) CodecString(sampleEntry string) string {
+	var sb strings.Builder
+	tier := 'M'
+	if b.SeqTier0 == 1 {
+		tier = 'H'
+	}
+	fmt.Fprintf(&sb, "%s.%d.%02d%c.%02d", sampleEntry, b.SeqProfile, b.SeqLevelIdx0, tier, b.BitDepth())
+
+	monochrome := 0
+	if b.Monochrome {
+		monochrome = 1
+	}
+	chroma := fmt.Sprintf("%d%d%d", boolDigit(b.ChromaSubsamplingX), boolDigit(b.ChromaSubsamplingY), b.ChromaSamplePosition)
+	n := colr.Unspecified()
+	if sh, err := b.SequenceHeader(); err == nil {
+		n = sh.ColorConfig.NCLX()
+	}
+	cp, tc, mc := n.ColourPrimaries, n.TransferCharacteristics, n.MatrixCoefficients
+	if cp == uint16(CP_UNSPECIFIED) && tc == uint16(TC_UNSPECIFIED) && mc == uint16(MC_UNSPECIFIED) {
+		// unspecified maps to the BT.709 defaults of the codecs parameter
+		cp, tc, mc = 1, 1, 1
+	}
+	fullRange := boolDigit(n.FullRangeFlag)
+	if monochrome == 0 && chroma == "110" && cp == 1 && tc == 1 && mc == 1 && fullRange == 0 {
+		return sb.String()
+	}
+	fmt.Fprintf(&sb, ".%d.%s.%02d.%02d.%02d.%d", monochrome, chroma, cp, tc, mc, fullRange)
+	return sb.String()
+}
+
+func boolDigit(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
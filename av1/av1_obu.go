@@ -0,0 +1,178 @@
+package av1
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ObuType - AV1 obu_type, AV1 Bitstream & Decoding Process Specification Sec. 6.2.2
+type ObuType byte
+
+const (
+	OBU_SEQUENCE_HEADER        = ObuType(1)
+	OBU_TEMPORAL_DELIMITER     = ObuType(2)
+	OBU_FRAME_HEADER           = ObuType(3)
+	OBU_TILE_GROUP             = ObuType(4)
+	OBU_METADATA               = ObuType(5)
+	OBU_FRAME                  = ObuType(6)
+	OBU_REDUNDANT_FRAME_HEADER = ObuType(7)
+	OBU_TILE_LIST              = ObuType(8)
+	OBU_PADDING                = ObuType(15)
+)
+
+func (t ObuType) String() string {
+	switch t {
+	case OBU_SEQUENCE_HEADER:
+		return "SequenceHeader_1"
+	case OBU_TEMPORAL_DELIMITER:
+		return "TemporalDelimiter_2"
+	case OBU_FRAME_HEADER:
+		return "FrameHeader_3"
+	case OBU_TILE_GROUP:
+		return "TileGroup_4"
+	case OBU_METADATA:
+		return "Metadata_5"
+	case OBU_FRAME:
+		return "Frame_6"
+	case OBU_REDUNDANT_FRAME_HEADER:
+		return "RedundantFrameHeader_7"
+	case OBU_TILE_LIST:
+		return "TileList_8"
+	case OBU_PADDING:
+		return "Padding_15"
+	default:
+		return fmt.Sprintf("Other_%d", t)
+	}
+}
+
+// ObuHeader - obu_header() and obu_size of an OBU, Sec. 5.3
+type ObuHeader struct {
+	Type          ObuType
+	ExtensionFlag bool
+	HasSizeField  bool
+	TemporalID    byte
+	SpatialID     byte
+	// Size - obu_size, the payload size after the header
+	Size uint64
+	// HeaderSize - bytes of obu_header() and obu_size
+	HeaderSize int
+}
+
+// ErrTruncatedOBU - OBU data ends before the size it signals
+var ErrTruncatedOBU = errors.New("truncated OBU")
+
+// ReadLeb128 - decode a leb128() value, returning it and the number of bytes used, Sec. 4.10.5
+func ReadLeb128(data []byte) (value uint64, n int, err error) {
+	for i := 0; i < 8; i++ {
+		if i >= len(data) {
+			return 0, 0, ErrTruncatedOBU
+		}
+		value |= uint64(data[i]&0x7f) << (7 * i)
+		if data[i]&0x80 == 0 {
+			return value, i + 1, nil
+		}
+	}
+	return 0, 0, errors.New("leb128 longer than 8 bytes")
+}
+
+// AppendLeb128 - append the shortest leb128() encoding of value to dst
+func AppendLeb128(dst []byte, value uint64) []byte {
+	for value >= 0x80 {
+		dst = append(dst, byte(value)|0x80)
+		value >>= 7
+	}
+	return append(dst, byte(value))
+}
+
+// ParseOBUHeader - decode the header of the OBU starting data
+// Without obu_size, the OBU extends to the end of data.
+func ParseOBUHeader(data []byte) (hdr ObuHeader, err error) {
+	if len(data) < 1 {
+		return hdr, ErrTruncatedOBU
+	}
+	if data[0]&0x80 != 0 {
+		return hdr, errors.New("obu_forbidden_bit set")
+	}
+	hdr.Type = ObuType(data[0] >> 3 & 0x0f)
+	hdr.ExtensionFlag = data[0]&0x04 != 0
+	hdr.HasSizeField = data[0]&0x02 != 0
+	hdr.HeaderSize = 1
+	if hdr.ExtensionFlag {
+		if len(data) < 2 {
+			return hdr, ErrTruncatedOBU
+		}
+		hdr.TemporalID = data[1] >> 5
+		hdr.SpatialID = data[1] >> 3 & 0x03
+		hdr.HeaderSize = 2
+	}
+	if !hdr.HasSizeField {
+		hdr.Size = uint64(len(data) - hdr.HeaderSize)
+		return hdr, nil
+	}
+	size, n, err := ReadLeb128(data[hdr.HeaderSize:])
+	if err != nil {
+		return hdr, err
+	}
+	hdr.Size = size
+	hdr.HeaderSize += n
+	if uint64(len(data)-hdr.HeaderSize) < size {
+		return hdr, ErrTruncatedOBU
+	}
+	return hdr, nil
+}
+
+// SplitOBUs - split a temporal unit in low overhead bitstream format, e.g. an
+// AV1 sample or the configOBUs of an av1C, into OBUs including their headers
+func SplitOBUs(data []byte) (obus [][]byte, err error) {
+	for len(data) > 0 {
+		hdr, err := ParseOBUHeader(data)
+		if err != nil {
+			return obus, err
+		}
+		end := hdr.HeaderSize + int(hdr.Size)
+		obus = append(obus, data[:end])
+		data = data[end:]
+	}
+	return obus, nil
+}
+
+// OBUPayload - payload of an OBU following its header
+func OBUPayload(obu []byte) ([]byte, error) {
+	hdr, err := ParseOBUHeader(obu)
+	if err != nil {
+		return nil, err
+	}
+	return obu[hdr.HeaderSize : hdr.HeaderSize+int(hdr.Size)], nil
+}
+
+// IsSyncTemporalUnit - do the OBUs of a temporal unit start with a shown key
+// frame preceded by a sequence header, as required for AV1 sync samples
+func IsSyncTemporalUnit(obus [][]byte) bool {
+	var seqHdr *SequenceHeader
+	for _, obu := range obus {
+		hdr, err := ParseOBUHeader(obu)
+		if err != nil {
+			return false
+		}
+		switch hdr.Type {
+		case OBU_SEQUENCE_HEADER:
+			if seqHdr, err = ParseSequenceHeaderOBU(obu); err != nil {
+				return false
+			}
+		case OBU_FRAME, OBU_FRAME_HEADER:
+			if seqHdr == nil {
+				return false
+			}
+			if seqHdr.ReducedStillPictureHeader {
+				return true
+			}
+			payload := obu[hdr.HeaderSize:]
+			if len(payload) < 1 {
+				return false
+			}
+			// show_existing_frame f(1), frame_type f(2), show_frame f(1)
+			return payload[0]&0xf0 == 0x10
+		}
+	}
+	return false
+}
@@ -0,0 +1,262 @@
+package av1
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/go-webdl/bits"
+)
+
+// SequenceHeader - sequence_header_obu() up to color_config
+// AV1 Bitstream & Decoding Process Specification Sec. 5.5
+type SequenceHeader struct {
+	SeqProfile                byte
+	StillPicture              bool
+	ReducedStillPictureHeader bool
+	TimingInfoPresentFlag     bool
+	// NumUnitsInDisplayTick to NumTicksPerPictureMinus1 - timing_info(),
+	// only valid with TimingInfoPresentFlag
+	NumUnitsInDisplayTick          uint32
+	TimeScale                      uint32
+	EqualPictureInterval           bool
+	NumTicksPerPictureMinus1       uint32
+	DecoderModelInfoPresentFlag    bool
+	InitialDisplayDelayPresentFlag bool
+	OperatingPoints                []OperatingPoint
+	MaxFrameWidthMinus1            uint32
+	MaxFrameHeightMinus1           uint32
+	FrameIDNumbersPresentFlag      bool
+	Use128x128Superblock           bool
+	EnableSuperres                 bool
+	EnableCdef                     bool
+	EnableRestoration              bool
+	ColorConfig                    ColorConfig
+	FilmGrainParamsPresent         bool
+}
+
+// OperatingPoint - level and tier of one operating point
+type OperatingPoint struct {
+	Idc                        uint16
+	SeqLevelIdx                byte
+	SeqTier                    byte
+	InitialDisplayDelayPresent bool
+	InitialDisplayDelayMinus1  byte
+}
+
+// Color primaries, transfer characteristics and matrix coefficients with
+// special handling in color_config, Sec. 6.4.2
+const (
+	CP_BT_709      = byte(1)
+	CP_UNSPECIFIED = byte(2)
+	TC_UNSPECIFIED = byte(2)
+	TC_SRGB        = byte(13)
+	MC_IDENTITY    = byte(0)
+	MC_UNSPECIFIED = byte(2)
+)
+
+// ParseSequenceHeaderOBU - parse a sequence header OBU including its OBU header
+func ParseSequenceHeaderOBU(obu []byte) (*SequenceHeader, error) {
+	hdr, err := ParseOBUHeader(obu)
+	if err != nil {
+		return nil, err
+	}
+	if hdr.Type != OBU_SEQUENCE_HEADER {
+		return nil, fmt.Errorf("OBU type is %s not a sequence header", hdr.Type)
+	}
+	r := bits.NewAccErrReader(bytes.NewReader(obu[hdr.HeaderSize : hdr.HeaderSize+int(hdr.Size)]))
+	sh := &SequenceHeader{}
+	sh.SeqProfile = byte(r.Read(3))
+	if sh.SeqProfile > 2 {
+		return nil, fmt.Errorf("seq_profile %d reserved", sh.SeqProfile)
+	}
+	sh.StillPicture = r.ReadFlag()
+	sh.ReducedStillPictureHeader = r.ReadFlag()
+	if sh.ReducedStillPictureHeader {
+		sh.OperatingPoints = []OperatingPoint{{SeqLevelIdx: byte(r.Read(5))}}
+	} else {
+		var bufferDelayLengthMinus1 int
+		sh.TimingInfoPresentFlag = r.ReadFlag()
+		if sh.TimingInfoPresentFlag {
+			sh.NumUnitsInDisplayTick = uint32(r.Read(32))
+			sh.TimeScale = uint32(r.Read(32))
+			sh.EqualPictureInterval = r.ReadFlag()
+			if sh.EqualPictureInterval {
+				sh.NumTicksPerPictureMinus1 = readUvlc(r)
+			}
+			sh.DecoderModelInfoPresentFlag = r.ReadFlag()
+			if sh.DecoderModelInfoPresentFlag {
+				bufferDelayLengthMinus1 = int(r.Read(5))
+				_ = r.Read(32) // num_units_in_decoding_tick
+				_ = r.Read(5)  // buffer_removal_time_length_minus_1
+				_ = r.Read(5)  // frame_presentation_time_length_minus_1
+			}
+		}
+		sh.InitialDisplayDelayPresentFlag = r.ReadFlag()
+		operatingPointsCntMinus1 := int(r.Read(5))
+		sh.OperatingPoints = make([]OperatingPoint, operatingPointsCntMinus1+1)
+		for i := range sh.OperatingPoints {
+			op := &sh.OperatingPoints[i]
+			op.Idc = uint16(r.Read(12))
+			op.SeqLevelIdx = byte(r.Read(5))
+			if op.SeqLevelIdx > 7 {
+				op.SeqTier = byte(r.Read(1))
+			}
+			if sh.DecoderModelInfoPresentFlag && r.ReadFlag() {
+				n := bufferDelayLengthMinus1 + 1
+				_ = r.Read(n) // decoder_buffer_delay
+				_ = r.Read(n) // encoder_buffer_delay
+				_ = r.Read(1) // low_delay_mode_flag
+			}
+			if sh.InitialDisplayDelayPresentFlag {
+				op.InitialDisplayDelayPresent = r.ReadFlag()
+				if op.InitialDisplayDelayPresent {
+					op.InitialDisplayDelayMinus1 = byte(r.Read(4))
+				}
+			}
+		}
+	}
+	frameWidthBitsMinus1 := int(r.Read(4))
+	frameHeightBitsMinus1 := int(r.Read(4))
+	sh.MaxFrameWidthMinus1 = uint32(r.Read(frameWidthBitsMinus1 + 1))
+	sh.MaxFrameHeightMinus1 = uint32(r.Read(frameHeightBitsMinus1 + 1))
+	if !sh.ReducedStillPictureHeader {
+		sh.FrameIDNumbersPresentFlag = r.ReadFlag()
+	}
+	if sh.FrameIDNumbersPresentFlag {
+		_ = r.Read(4) // delta_frame_id_length_minus_2
+		_ = r.Read(3) // additional_frame_id_length_minus_1
+	}
+	sh.Use128x128Superblock = r.ReadFlag()
+	_ = r.ReadFlag() // enable_filter_intra
+	_ = r.ReadFlag() // enable_intra_edge_filter
+	if !sh.ReducedStillPictureHeader {
+		_ = r.Read(4) // enable_interintra_compound, enable_masked_compound, enable_warped_motion, enable_dual_filter
+		enableOrderHint := r.ReadFlag()
+		if enableOrderHint {
+			_ = r.Read(2) // enable_jnt_comp, enable_ref_frame_mvs
+		}
+		seqForceScreenContentTools := uint(2)
+		if !r.ReadFlag() { // seq_choose_screen_content_tools
+			seqForceScreenContentTools = r.Read(1)
+		}
+		if seqForceScreenContentTools > 0 {
+			if !r.ReadFlag() { // seq_choose_integer_mv
+				_ = r.Read(1) // seq_force_integer_mv
+			}
+		}
+		if enableOrderHint {
+			_ = r.Read(3) // order_hint_bits_minus_1
+		}
+	}
+	sh.EnableSuperres = r.ReadFlag()
+	sh.EnableCdef = r.ReadFlag()
+	sh.EnableRestoration = r.ReadFlag()
+	sh.ColorConfig = readColorConfig(r, sh.SeqProfile)
+	sh.FilmGrainParamsPresent = r.ReadFlag()
+	if err := r.AccError(); err != nil {
+		return nil, err
+	}
+	return sh, nil
+}
+
+// FrameRate - pictures per second, 0 unless timing info with
+// equal_picture_interval is present
+func (sh *SequenceHeader) FrameRate() float64 {
+	if !sh.TimingInfoPresentFlag || !sh.EqualPictureInterval || sh.NumUnitsInDisplayTick == 0 {
+		return 0
+	}
+	return float64(sh.TimeScale) / (float64(sh.NumUnitsInDisplayTick) * (float64(sh.NumTicksPerPictureMinus1) + 1))
+}
+
+// readColorConfig - color_config(), Sec. 5.5.2
+// Monochrome streams have no chroma planes; their subsampling is 4:2:0 and
+// the chroma sample position unknown by definition.
+func readColorConfig(r *bits.AccErrReader, seqProfile byte) (c ColorConfig) {
+	highBitdepth := r.ReadFlag()
+	c.BitDepth = 8
+	if seqProfile == 2 && highBitdepth {
+		c.BitDepth = 10
+		if r.ReadFlag() { // twelve_bit
+			c.BitDepth = 12
+		}
+	} else if highBitdepth {
+		c.BitDepth = 10
+	}
+	if seqProfile != 1 {
+		c.MonoChrome = r.ReadFlag()
+	}
+	c.ColorDescriptionPresentFlag = r.ReadFlag()
+	if c.ColorDescriptionPresentFlag {
+		c.ColorPrimaries = byte(r.Read(8))
+		c.TransferCharacteristics = byte(r.Read(8))
+		c.MatrixCoefficients = byte(r.Read(8))
+	} else {
+		c.ColorPrimaries = CP_UNSPECIFIED
+		c.TransferCharacteristics = TC_UNSPECIFIED
+		c.MatrixCoefficients = MC_UNSPECIFIED
+	}
+	switch {
+	case c.MonoChrome:
+		c.ColorRange = r.ReadFlag()
+		c.SubsamplingX, c.SubsamplingY = true, true
+		c.ChromaSamplePosition = CSP_UNKNOWN
+		return c
+	case c.ColorPrimaries == CP_BT_709 && c.TransferCharacteristics == TC_SRGB && c.MatrixCoefficients == MC_IDENTITY:
+		c.ColorRange = true
+	default:
+		c.ColorRange = r.ReadFlag()
+		switch seqProfile {
+		case 0:
+			c.SubsamplingX, c.SubsamplingY = true, true
+		case 1:
+		default:
+			if c.BitDepth == 12 {
+				c.SubsamplingX = r.ReadFlag()
+				if c.SubsamplingX {
+					c.SubsamplingY = r.ReadFlag()
+				}
+			} else {
+				c.SubsamplingX = true
+			}
+		}
+		if c.SubsamplingX && c.SubsamplingY {
+			c.ChromaSamplePosition = byte(r.Read(2))
+		}
+	}
+	c.SeparateUVDeltaQ = r.ReadFlag()
+	return c
+}
+
+// readUvlc - uvlc(), Sec. 4.10.3
+func readUvlc(r *bits.AccErrReader) uint32 {
+	leadingZeros := 0
+	for !r.ReadFlag() {
+		if r.AccError() != nil {
+			return 0
+		}
+		leadingZeros++
+	}
+	if leadingZeros >= 32 {
+		return 1<<32 - 1
+	}
+	return uint32(r.Read(leadingZeros)) + 1<<leadingZeros - 1
+}
+
+// ChromaFormat - chroma format as in chroma_format_idc: 0 monochrome,
+// 1 4:2:0, 2 4:2:2, 3 4:4:4
+func (c *ColorConfig) ChromaFormat() byte {
+	switch {
+	case c.MonoChrome:
+		return 0
+	case c.SubsamplingX && c.SubsamplingY:
+		return 1
+	case c.SubsamplingX:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// ErrNoSequenceHeader - no sequence header OBU found
+var ErrNoSequenceHeader = errors.New("no sequence header OBU")
@@ -0,0 +1,80 @@
+package avc
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/go-webdl/media-codec/nalu"
+)
+
+// AccessUnitReader - reads the access units of an Annex B byte stream one at a time
+// Access unit boundaries are detected from access unit delimiters, other
+// non-VCL NAL units preceding a picture and the slice header changes of
+// Sec. 7.4.1.2.4, e.g. of frame_num or first_mb_in_slice, so streams without
+// AUDs are split correctly. Each access unit becomes one sample when muxing.
+type AccessUnitReader struct {
+	s     *nalu.Scanner
+	d     accessUnitDetector
+	next  []byte // first NAL unit of the next access unit
+	count int
+	err   error
+	// skipToSPS - drop NAL units before the first SPS, for streams starting
+	// anywhere
+	skipToSPS bool
+}
+
+// NewAccessUnitReader - create an AccessUnitReader reading from r
+func NewAccessUnitReader(r io.Reader) *AccessUnitReader {
+	return &AccessUnitReader{
+		s: nalu.NewScanner(r),
+		d: accessUnitDetector{spsMap: make(map[byte]*SPS), ppsMap: make(map[byte]*PPS)},
+	}
+}
+
+// Read - NAL units of the next access unit in decode order, io.EOF at end of stream
+// The NAL units are copies and stay valid after the next call.
+func (r *AccessUnitReader) Read() (au [][]byte, err error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if r.next != nil {
+		au = append(au, r.next)
+		r.next = nil
+	}
+	for r.s.Scan() {
+		if r.skipToSPS {
+			if data := r.s.NALU(); len(data) == 0 || GetNaluType(data[0]) != NALU_SPS {
+				continue
+			}
+			r.skipToSPS = false
+		}
+		data := append([]byte(nil), r.s.NALU()...)
+		first, err := r.d.next(data)
+		if err != nil {
+			r.err = fmt.Errorf("access unit %d: %w", r.count, err)
+			return nil, r.err
+		}
+		if first && len(au) > 0 {
+			r.next = data
+			r.count++
+			return au, nil
+		}
+		au = append(au, data)
+	}
+	if err := r.s.Err(); err != nil {
+		r.err = err
+		return nil, err
+	}
+	r.err = io.EOF
+	if len(au) == 0 {
+		return nil, io.EOF
+	}
+	r.count++
+	return au, nil
+}
+
+// ParameterSets - SPSs and PPSs of the stream read so far, by ID
+// The maps are updated by Read and must not be modified.
+func (r *AccessUnitReader) ParameterSets() (spsMap map[byte]*SPS, ppsMap map[byte]*PPS) {
+	return r.d.spsMap, r.d.ppsMap
+}
@@ -0,0 +1,55 @@
+package avc
+
+import (
+	"github.com/go-webdl/media-codec/nalu"
+)
+
+// AppendAnnexB - convert a length-prefixed sample described by the record to
+// Annex B and append it to dst
+// The record's SPS, SPS extension and PPS NAL units are inserted before IDR
+// pictures (after a leading access unit delimiter) unless the sample already
+// carries both SPS and PPS.
+func (b *AVCDecoderConfigurationRecord) AppendAnnexB(dst, sample []byte) ([]byte, error) {
+	nalus, err := nalu.SplitSample(sample, int(b.LengthSizeMinusOne)+1)
+	if err != nil {
+		return dst, err
+	}
+	var hasIDR, hasSPS, hasPPS bool
+	for _, n := range nalus {
+		if len(n) == 0 {
+			continue
+		}
+		switch GetNaluType(n[0]) {
+		case NALU_IDR:
+			hasIDR = true
+		case NALU_SPS:
+			hasSPS = true
+		case NALU_PPS:
+			hasPPS = true
+		}
+	}
+	if !hasIDR || (hasSPS && hasPPS) {
+		return nalu.AppendAnnexB(dst, nalus), nil
+	}
+	pos := 0
+	if len(nalus) > 0 && len(nalus[0]) > 0 && GetNaluType(nalus[0][0]) == NALU_AUD {
+		pos = 1
+	}
+	dst = nalu.AppendAnnexB(dst, nalus[:pos])
+	dst = b.AppendParameterSetsAnnexB(dst)
+	return nalu.AppendAnnexB(dst, nalus[pos:]), nil
+}
+
+// AppendParameterSetsAnnexB - append the record's SPS, SPS extension and PPS NAL units to dst as Annex B
+func (b *AVCDecoderConfigurationRecord) AppendParameterSetsAnnexB(dst []byte) []byte {
+	for _, sps := range b.SequenceParameterSets {
+		dst = nalu.AppendAnnexB(dst, [][]byte{sps.NALUnit})
+	}
+	for _, spse := range b.SequenceParameterSetExts {
+		dst = nalu.AppendAnnexB(dst, [][]byte{spse.NALUnit})
+	}
+	for _, pps := range b.PictureParameterSets {
+		dst = nalu.AppendAnnexB(dst, [][]byte{pps.NALUnit})
+	}
+	return dst
+}
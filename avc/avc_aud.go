@@ -0,0 +1,169 @@
+package avc
+
+import (
+	"fmt"
+)
+
+// PrimaryPicType - primary_pic_type of an access unit delimiter, which lists
+// the slice types that may occur in the primary coded picture, Table 7-5
+type PrimaryPicType byte
+
+const (
+	PRIMARY_PIC_I           = PrimaryPicType(0)
+	PRIMARY_PIC_I_P         = PrimaryPicType(1)
+	PRIMARY_PIC_I_P_B       = PrimaryPicType(2)
+	PRIMARY_PIC_SI          = PrimaryPicType(3)
+	PRIMARY_PIC_SI_SP       = PrimaryPicType(4)
+	PRIMARY_PIC_I_SI        = PrimaryPicType(5)
+	PRIMARY_PIC_I_SI_P_SP   = PrimaryPicType(6)
+	PRIMARY_PIC_I_SI_P_SP_B = PrimaryPicType(7)
+)
+
+// primaryPicTypeSliceTypes - bit set of base slice types allowed by each primary_pic_type
+var primaryPicTypeSliceTypes = [...]uint{
+	1 << SLICE_I,
+	1<<SLICE_I | 1<<SLICE_P,
+	1<<SLICE_I | 1<<SLICE_P | 1<<SLICE_B,
+	1 << SLICE_SI,
+	1<<SLICE_SI | 1<<SLICE_SP,
+	1<<SLICE_I | 1<<SLICE_SI,
+	1<<SLICE_I | 1<<SLICE_SI | 1<<SLICE_P | 1<<SLICE_SP,
+	1<<SLICE_I | 1<<SLICE_SI | 1<<SLICE_P | 1<<SLICE_SP | 1<<SLICE_B,
+}
+
+// CreateAUDNALUnit - access unit delimiter NAL unit
+func CreateAUDNALUnit(primaryPicType PrimaryPicType) []byte {
+	// primary_pic_type followed by rbsp_stop_one_bit
+	return []byte{byte(NALU_AUD), byte(primaryPicType)<<5 | 0x10}
+}
+
+// GetPrimaryPicType - smallest primary_pic_type covering the slices of an access unit
+func GetPrimaryPicType(nalus [][]byte) PrimaryPicType {
+	var sliceTypes uint
+	for _, nalu := range nalus {
+		if len(nalu) == 0 {
+			continue
+		}
+		switch GetNaluType(nalu[0]) {
+		case NALU_NON_IDR, NALU_IDR:
+			if sliceType, ok := peekSliceType(nalu); ok {
+				sliceTypes |= 1 << sliceType.Base()
+			}
+		}
+	}
+	for t, allowed := range primaryPicTypeSliceTypes {
+		if sliceTypes&^allowed == 0 {
+			return PrimaryPicType(t)
+		}
+	}
+	return PRIMARY_PIC_I_SI_P_SP_B
+}
+
+// InsertAUD - prepend an access unit delimiter to the NAL units of an access
+// unit, unless it already starts with one
+func InsertAUD(nalus [][]byte) [][]byte {
+	if len(nalus) > 0 && len(nalus[0]) > 0 && GetNaluType(nalus[0][0]) == NALU_AUD {
+		return nalus
+	}
+	out := make([][]byte, 0, len(nalus)+1)
+	out = append(out, CreateAUDNALUnit(GetPrimaryPicType(nalus)))
+	return append(out, nalus...)
+}
+
+// RemoveAUDs - NAL units without access unit delimiters
+// Works on single access units as well as on whole streams.
+func RemoveAUDs(nalus [][]byte) [][]byte {
+	out := make([][]byte, 0, len(nalus))
+	for _, nalu := range nalus {
+		if len(nalu) > 0 && GetNaluType(nalu[0]) == NALU_AUD {
+			continue
+		}
+		out = append(out, nalu)
+	}
+	return out
+}
+
+// InsertAUDs - insert access unit delimiters at the access unit boundaries of
+// a NAL unit stream, e.g. one read from an Annex B file
+// Boundaries are detected according to ISO/IEC 14496-10 Sec. 7.4.1.2.3.
+// spsMap and ppsMap provide parameter sets not carried in the stream and are
+// updated with those that are; they may be empty but not nil.
+func InsertAUDs(nalus [][]byte, spsMap map[byte]*SPS, ppsMap map[byte]*PPS) ([][]byte, error) {
+	var starts []int
+	d := &accessUnitDetector{spsMap: spsMap, ppsMap: ppsMap}
+	for i, nalu := range nalus {
+		first, err := d.next(nalu)
+		if err != nil {
+			return nil, fmt.Errorf("NAL unit %d: %w", i, err)
+		}
+		if first {
+			starts = append(starts, i)
+		}
+	}
+	out := make([][]byte, 0, len(nalus)+len(starts))
+	for j, start := range starts {
+		end := len(nalus)
+		if j+1 < len(starts) {
+			end = starts[j+1]
+		}
+		out = append(out, InsertAUD(nalus[start:end])...)
+	}
+	return out, nil
+}
+
+// accessUnitDetector - finds the first NAL unit of each access unit,
+// Sec. 7.4.1.2.3, tracking the parameter sets of the stream
+type accessUnitDetector struct {
+	spsMap    map[byte]*SPS
+	ppsMap    map[byte]*PPS
+	prev      *SliceHeader
+	inPicture bool // VCL NAL units of the current access unit seen
+	started   bool
+}
+
+// next - does nalu, the next NAL unit in decode order, start an access unit
+func (d *accessUnitDetector) next(nalu []byte) (first bool, err error) {
+	if len(nalu) == 0 {
+		return false, nil
+	}
+	if !d.started {
+		d.started = true
+		first = true
+	}
+	naluType := GetNaluType(nalu[0])
+	switch naluType {
+	case NALU_SPS:
+		sps, err := ParseSPSNALUnit(nalu)
+		if err != nil {
+			return false, err
+		}
+		d.spsMap[sps.SpsID] = sps
+	case NALU_PPS:
+		pps, err := ParsePPSNALUnit(nalu, d.spsMap)
+		if err != nil {
+			return false, err
+		}
+		d.ppsMap[pps.PpsID] = pps
+	}
+	switch {
+	case naluType == NALU_AUD, naluType == NALU_SEI, naluType == NALU_SPS, naluType == NALU_PPS,
+		naluType >= 14 && naluType <= 18:
+		if d.inPicture {
+			first = true
+			d.inPicture = false
+		}
+	case naluType == NALU_NON_IDR, naluType == NALU_IDR:
+		sh, err := ParseSliceHeader(nalu, d.spsMap, d.ppsMap)
+		if err != nil {
+			return false, err
+		}
+		if d.inPicture && sh.IsFirstSliceOfNewPicture(d.prev) {
+			first = true
+		}
+		d.prev = sh
+		d.inPicture = true
+	case naluType >= 2 && naluType <= 4:
+		d.inPicture = true
+	}
+	return first, nil
+}
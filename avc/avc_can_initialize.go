@@ -0,0 +1,121 @@
+package avc
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// CanInitialize - simulate decoder initialization from the record, nil if it succeeds
+// All SPS and PPS are parsed, PPS references must resolve, picture sizes and
+// reference frame counts must fit the signalled level, and chroma format and
+// bit depths must be supported by the signalled profile.
+func (b *AVCDecoderConfigurationRecord) CanInitialize() (issues []string) {
+	switch b.LengthSizeMinusOne {
+	case 0, 1, 3:
+	default:
+		issues = append(issues, fmt.Sprintf("invalid lengthSizeMinusOne %d", b.LengthSizeMinusOne))
+	}
+	if len(b.SequenceParameterSets) == 0 {
+		issues = append(issues, "no SPS")
+	}
+	if len(b.PictureParameterSets) == 0 {
+		issues = append(issues, "no PPS")
+	}
+
+	spsMap := make(map[byte]*SPS)
+	spsData := make(map[byte][]byte)
+	for i, entry := range b.SequenceParameterSets {
+		sps, err := ParseSPSNALUnit(entry.NALUnit)
+		if err != nil {
+			issues = append(issues, fmt.Sprintf("SPS %d: %s", i, err))
+			continue
+		}
+		if data, ok := spsData[sps.SpsID]; ok && !bytes.Equal(data, entry.NALUnit) {
+			issues = append(issues, fmt.Sprintf("SPS %d: id %d used by different SPSs", i, sps.SpsID))
+		}
+		spsMap[sps.SpsID] = sps
+		spsData[sps.SpsID] = entry.NALUnit
+		if sps.ProfileIndicator != b.AVCProfileIndication {
+			issues = append(issues, fmt.Sprintf("SPS %d: profile %d, record has %d", i, sps.ProfileIndicator, b.AVCProfileIndication))
+		}
+		if sps.LevelIndicator > b.AVCLevelIndication {
+			issues = append(issues, fmt.Sprintf("SPS %d: level %d above record level %d", i, sps.LevelIndicator, b.AVCLevelIndication))
+		}
+		if Profile(b.AVCProfileIndication).HasRecordChromaInfo() {
+			if sps.ChromaFormatIndicator != b.ChromaFormat {
+				issues = append(issues, fmt.Sprintf("SPS %d: chroma format %d, record has %d", i, sps.ChromaFormatIndicator, b.ChromaFormat))
+			}
+			if sps.BitDepthLumaMinus8 != b.BitDepthLumaMinus8 || sps.BitDepthChromaMinus8 != b.BitDepthChromaMinus8 {
+				issues = append(issues, fmt.Sprintf("SPS %d: bit depths %d/%d, record has %d/%d", i,
+					sps.BitDepthLumaMinus8+8, sps.BitDepthChromaMinus8+8, b.BitDepthLumaMinus8+8, b.BitDepthChromaMinus8+8))
+			}
+		}
+		for _, issue := range sps.checkProfileSupport() {
+			issues = append(issues, fmt.Sprintf("SPS %d: %s", i, issue))
+		}
+		for _, issue := range sps.checkLevelLimits() {
+			issues = append(issues, fmt.Sprintf("SPS %d: %s", i, issue))
+		}
+	}
+
+	for i, entry := range b.PictureParameterSets {
+		if _, err := ParsePPSNALUnit(entry.NALUnit, spsMap); err != nil {
+			issues = append(issues, fmt.Sprintf("PPS %d: %s", i, err))
+		}
+	}
+	return issues
+}
+
+// checkProfileSupport - chroma format and bit depths allowed by the profile
+// Profiles without known limits are not checked.
+func (s *SPS) checkProfileSupport() (issues []string) {
+	var maxChromaFormat, maxBitDepthMinus8 byte
+	switch Profile(s.ProfileIndicator) {
+	case PROFILE_BASELINE, PROFILE_MAIN, PROFILE_EXTENDED, PROFILE_HIGH, PROFILE_MULTIVIEW_HIGH, PROFILE_STEREO_HIGH:
+		maxChromaFormat, maxBitDepthMinus8 = 1, 0
+	case PROFILE_HIGH_10:
+		maxChromaFormat, maxBitDepthMinus8 = 1, 2
+	case PROFILE_HIGH_422:
+		maxChromaFormat, maxBitDepthMinus8 = 2, 2
+	case PROFILE_HIGH_444_PREDICTIVE, PROFILE_CAVLC_444_INTRA:
+		maxChromaFormat, maxBitDepthMinus8 = 3, 6
+	default:
+		return nil
+	}
+	if s.ChromaFormatIndicator > maxChromaFormat {
+		issues = append(issues, fmt.Sprintf("chroma format %d not supported by profile %s", s.ChromaFormatIndicator, Profile(s.ProfileIndicator)))
+	}
+	if s.BitDepthLumaMinus8 > maxBitDepthMinus8 || s.BitDepthChromaMinus8 > maxBitDepthMinus8 {
+		issues = append(issues, fmt.Sprintf("bit depths %d/%d not supported by profile %s",
+			s.BitDepthLumaMinus8+8, s.BitDepthChromaMinus8+8, Profile(s.ProfileIndicator)))
+	}
+	return issues
+}
+
+// checkLevelLimits - frame size and DPB limits of the level, Sec. A.3.1
+func (s *SPS) checkLevelLimits() (issues []string) {
+	limits, ok := LookupLevel(s.Level())
+	if !ok {
+		return []string{fmt.Sprintf("unknown level %d", s.LevelIndicator)}
+	}
+	widthInMbs := s.PicWidthInMbsMinus1 + 1
+	heightInMbs := s.PicHeightInMapUnitsMinus1 + 1
+	if !s.FrameMbsOnlyFlag {
+		heightInMbs *= 2
+	}
+	frameSize := widthInMbs * heightInMbs
+	if frameSize > limits.MaxFS {
+		issues = append(issues, fmt.Sprintf("frame size %d MBs exceeds level %d limit %d", frameSize, s.LevelIndicator, limits.MaxFS))
+	}
+	if widthInMbs*widthInMbs > 8*limits.MaxFS || heightInMbs*heightInMbs > 8*limits.MaxFS {
+		issues = append(issues, fmt.Sprintf("%dx%d MBs exceeds level %d dimension limits", widthInMbs, heightInMbs, s.LevelIndicator))
+	}
+	maxDpbFrames := limits.MaxDpbMbs / frameSize
+	if maxDpbFrames > 16 {
+		maxDpbFrames = 16
+	}
+	if uint32(s.MaxNumRefFrames) > maxDpbFrames {
+		issues = append(issues, fmt.Sprintf("%d reference frames exceed level %d limit %d", s.MaxNumRefFrames, s.LevelIndicator, maxDpbFrames))
+	}
+	return issues
+}
@@ -0,0 +1,33 @@
+package avc
+
+import (
+	"github.com/go-webdl/media-codec/sei"
+)
+
+// ExtractCaptions - CEA-608/708 cc_data carried in ATSC A/53 user data SEI
+// messages of the NAL units of one access unit, split into the CEA-608
+// fields and the CEA-708 DTVCC channel, each in bitstream order
+// Access units are in decode order, so callers must reorder the results by
+// presentation time before feeding a caption decoder.
+func ExtractCaptions(nalus [][]byte) (sei.CaptionChannels, error) {
+	var ccs []sei.CCData
+	for _, nalu := range nalus {
+		if len(nalu) == 0 || GetNaluType(nalu[0]) != NALU_SEI {
+			continue
+		}
+		msgs, err := ParseSEINALUnit(nalu)
+		if err != nil {
+			return sei.SplitCCData(ccs), err
+		}
+		for i := range msgs {
+			data, ok, err := sei.ParseA53CCData(&msgs[i])
+			if err != nil {
+				return sei.SplitCCData(ccs), err
+			}
+			if ok {
+				ccs = append(ccs, data...)
+			}
+		}
+	}
+	return sei.SplitCCData(ccs), nil
+}
@@ -0,0 +1,9 @@
+package avc
+
+import "fmt"
+
+// CodecString - RFC 6381 codecs parameter, e.g. avc1.64001F
+// sampleEntry is the four character code of the sample entry (avc1, avc3, ...)
+func (b *AVCDecoderConfigurationRecord) CodecString(sampleEntry string) string {
+	return fmt.Sprintf("%s.%02X%02X%02X", sampleEntry, b.AVCProfileIndication, b.ProfileCompatibility, b.AVCLevelIndication)
+}
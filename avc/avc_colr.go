@@ -0,0 +1,26 @@
+package avc
+
+import "github.com/go-webdl/media-codec/colr"
+
+// NCLX - colour description of the VUI
+// Code points that are not signalled are unspecified.
+func (v *VUIParameters) NCLX() colr.NCLX {
+	n := colr.Unspecified()
+	if v.ColourDescriptionPresentFlag {
+		n.ColourPrimaries = uint16(v.ColourPrimaries)
+		n.TransferCharacteristics = uint16(v.TransferCharacteristics)
+		n.MatrixCoefficients = uint16(v.MatrixCoefficients)
+	}
+	n.FullRangeFlag = v.VideoFullRangeFlag
+	return n
+}
+
+// SetNCLX - set the VUI video signal type and colour description
+func (v *VUIParameters) SetNCLX(n colr.NCLX) {
+	v.VideoSignalTypePresentFlag = true
+	v.VideoFullRangeFlag = n.FullRangeFlag
+	v.ColourDescriptionPresentFlag = true
+	v.ColourPrimaries = byte(n.ColourPrimaries)
+	v.TransferCharacteristics = byte(n.TransferCharacteristics)
+	v.MatrixCoefficients = byte(n.MatrixCoefficients)
+}
@@ -2,7 +2,10 @@ package avc
 
 import (
 	"encoding/binary"
+	"errors"
 	"io"
+
+	"github.com/go-webdl/media-codec/debuglog"
 )
 
 // 5.3.3.1 AVC decoder configuration record
@@ -183,7 +186,7 @@ func (b *AVCDecoderConfigurationRecord) RecordSize() (size uint32) {
 	for _, pps := range b.PictureParameterSets {
 		size += 2 + uint32(len(pps.NALUnit))
 	}
-	if b.AVCProfileIndication == 100 || b.AVCProfileIndication == 110 || b.AVCProfileIndication == 122 || b.AVCProfileIndication == 144 {
+	if Profile(b.AVCProfileIndication).HasRecordChromaInfo() {
 		// bit(6) reserved = '111111'b;
 		// unsigned int(2) chroma_format;
 		// bit(5) reserved = '11111'b;
@@ -213,6 +216,7 @@ func (b *AVCDecoderConfigurationRecord) RecordRead(r io.Reader) (err error) {
 	b.ProfileCompatibility = tmp[2]
 	b.AVCLevelIndication = tmp[3]
 	b.LengthSizeMinusOne = tmp[4] & 0b11
+	b.debugHeader(tmp[4], tmp[5])
 	numOfSequenceParameterSets := tmp[5] & 0b11111
 	b.SequenceParameterSets = make([]AVCSequenceParameterSet, numOfSequenceParameterSets)
 	for i := uint8(0); i < numOfSequenceParameterSets; i++ {
@@ -240,7 +244,7 @@ func (b *AVCDecoderConfigurationRecord) RecordRead(r io.Reader) (err error) {
 			return
 		}
 	}
-	if b.AVCProfileIndication == 100 || b.AVCProfileIndication == 110 || b.AVCProfileIndication == 122 || b.AVCProfileIndication == 144 {
+	if Profile(b.AVCProfileIndication).HasRecordChromaInfo() {
 		if err = binary.Read(r, binary.BigEndian, tmp[:4]); err != nil {
 			return
 		}
@@ -301,7 +305,7 @@ func (b *AVCDecoderConfigurationRecord) RecordWrite(w io.Writer) (err error) {
 			return
 		}
 	}
-	if b.AVCProfileIndication == 100 || b.AVCProfileIndication == 110 || b.AVCProfileIndication == 122 || b.AVCProfileIndication == 144 {
+	if Profile(b.AVCProfileIndication).HasRecordChromaInfo() {
 		if err = binary.Write(w, binary.BigEndian, b.ChromaFormat|0b11111100); err != nil {
 			return
 		}
@@ -325,3 +329,65 @@ func (b *AVCDecoderConfigurationRecord) RecordWrite(w io.Writer) (err error) {
 	}
 	return
 }
+
+// CreateAVCDecoderConfigurationRecord - extract information from sps, pps and fill AVCDecoderConfigurationRecord with that
+func CreateAVCDecoderConfigurationRecord(spsNalus, ppsNalus [][]byte) (AVCDecoderConfigurationRecord, error) {
+	if len(spsNalus) == 0 {
+		return AVCDecoderConfigurationRecord{}, errors.New("no SPS NAL units")
+	}
+	sps, err := ParseSPSNALUnit(spsNalus[0])
+	if err != nil {
+		return AVCDecoderConfigurationRecord{}, err
+	}
+	profileCompatibility, err := IntersectProfileCompatibility(spsNalus)
+	if err != nil {
+		return AVCDecoderConfigurationRecord{}, err
+	}
+	spss := make([]AVCSequenceParameterSet, 0, len(spsNalus))
+	for _, nalu := range spsNalus {
+		spss = append(spss, AVCSequenceParameterSet{NALUnit: nalu})
+	}
+	ppss := make([]AVCPictureParameterSet, 0, len(ppsNalus))
+	for _, nalu := range ppsNalus {
+		ppss = append(ppss, AVCPictureParameterSet{NALUnit: nalu})
+	}
+	return AVCDecoderConfigurationRecord{
+		ConfigurationVersion:  1,
+		AVCProfileIndication:  sps.ProfileIndicator,
+		ProfileCompatibility:  profileCompatibility,
+		AVCLevelIndication:    sps.LevelIndicator,
+		LengthSizeMinusOne:    3, // only support 4-byte length
+		SequenceParameterSets: spss,
+		PictureParameterSets:  ppss,
+		ChromaFormat:          sps.ChromaFormatIndicator,
+		BitDepthLumaMinus8:    sps.BitDepthLumaMinus8,
+		BitDepthChromaMinus8:  sps.BitDepthChromaMinus8,
+	}, nil
+}
+
+// IntersectProfileCompatibility - profile_compatibility byte valid for all SPS NAL units
+// Each constraint_set flag may only be set in the record if all the SPSs set it.
+func IntersectProfileCompatibility(spsNalus [][]byte) (byte, error) {
+	if len(spsNalus) == 0 {
+		return 0, errors.New("no SPS NAL units")
+	}
+	compatibility := byte(0xff)
+	for _, nalu := range spsNalus {
+		sps, err := ParseSPSNALUnit(nalu)
+		if err != nil {
+			return 0, err
+		}
+		compatibility &= sps.ProfileCompatibility
+	}
+	return compatibility, nil
+}
+
+// debugHeader - report a header that deviates from the specification
+func (b *AVCDecoderConfigurationRecord) debugHeader(lengthSizeByte, numSPSByte byte) {
+	if b.ConfigurationVersion != 1 {
+		debuglog.Debug("avcC: unknown configurationVersion", "version", b.ConfigurationVersion)
+	}
+	if lengthSizeByte>>2 != 0b111111 || numSPSByte>>5 != 0b111 {
+		debuglog.Debug("avcC: reserved bits not set", "byte4", lengthSizeByte, "byte5", numSPSByte)
+	}
+}
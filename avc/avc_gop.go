@@ -0,0 +1,192 @@
+package avc
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/go-webdl/media-codec/nalu"
+)
+
+// GOPAnalysis - GOP structure of an AVC stream, built access unit by access unit in decode order
+// A GOP starts at each sync access unit as classified by AnalyzeRandomAccess,
+// so open GOPs starting at an intra picture with recovery point SEI are
+// counted as well as IDR GOPs.
+type GOPAnalysis struct {
+	AccessUnits int
+	// LeadingAccessUnits - access units before the first sync access unit
+	LeadingAccessUnits int
+	// SliceTypes - number of slices per slice type, indexed by SliceType.Base()
+	SliceTypes [5]int
+	// GOPLengths - access units of each GOP, the last one possibly incomplete
+	GOPLengths []int
+	// IDRIntervals - access units from each IDR picture to the next
+	IDRIntervals []int
+	// MaxConsecutiveB - B-frame depth, the longest run of B pictures in decode order
+	MaxConsecutiveB int
+	// ReferenceB - B pictures used for reference, as in a B-pyramid
+	ReferenceB int
+
+	lastIDR     int // access unit index of the last IDR picture, -1 if none
+	consecutive int
+}
+
+// NewGOPAnalysis - create an empty GOPAnalysis
+func NewGOPAnalysis() *GOPAnalysis {
+	return &GOPAnalysis{lastIDR: -1}
+}
+
+// AddAccessUnit - account for the NAL units of the next access unit in decode order
+func (a *GOPAnalysis) AddAccessUnit(nalus [][]byte) error {
+	ra, err := AnalyzeRandomAccess(nalus)
+	if err != nil {
+		return fmt.Errorf("access unit %d: %w", a.AccessUnits, err)
+	}
+	isB, isRef := false, false
+	for _, n := range nalus {
+		if len(n) == 0 {
+			continue
+		}
+		naluType := GetNaluType(n[0])
+		if naluType != NALU_NON_IDR && naluType != NALU_IDR {
+			continue
+		}
+		sliceType, ok := peekSliceType(n)
+		if !ok {
+			return fmt.Errorf("access unit %d: bad slice header", a.AccessUnits)
+		}
+		a.SliceTypes[sliceType.Base()]++
+		if sliceType.Base() == SLICE_B {
+			isB = true
+			isRef = isRef || (n[0]>>5)&0b11 != 0
+		}
+	}
+
+	if ra.IDR {
+		if a.lastIDR >= 0 {
+			a.IDRIntervals = append(a.IDRIntervals, a.AccessUnits-a.lastIDR)
+		}
+		a.lastIDR = a.AccessUnits
+	}
+	switch {
+	case ra.IsSync():
+		a.GOPLengths = append(a.GOPLengths, 1)
+	case len(a.GOPLengths) == 0:
+		a.LeadingAccessUnits++
+	default:
+		a.GOPLengths[len(a.GOPLengths)-1]++
+	}
+	if isB {
+		a.consecutive++
+		if a.consecutive > a.MaxConsecutiveB {
+			a.MaxConsecutiveB = a.consecutive
+		}
+		if isRef {
+			a.ReferenceB++
+		}
+	} else {
+		a.consecutive = 0
+	}
+	a.AccessUnits++
+	return nil
+}
+
+// MaxGOPLength - length of the longest GOP, 0 if there is none
+func (a *GOPAnalysis) MaxGOPLength() (max int) {
+	for _, l := range a.GOPLengths {
+		if l > max {
+			max = l
+		}
+	}
+	return max
+}
+
+// MeanGOPLength - average GOP length, 0 if there is none
+func (a *GOPAnalysis) MeanGOPLength() float64 {
+	if len(a.GOPLengths) == 0 {
+		return 0
+	}
+	total := 0
+	for _, l := range a.GOPLengths {
+		total += l
+	}
+	return float64(total) / float64(len(a.GOPLengths))
+}
+
+// FixedIDRInterval - IDR interval if all IDR pictures are equally spaced
+func (a *GOPAnalysis) FixedIDRInterval() (int, bool) {
+	if len(a.IDRIntervals) == 0 {
+		return 0, false
+	}
+	for _, interval := range a.IDRIntervals[1:] {
+		if interval != a.IDRIntervals[0] {
+			return 0, false
+		}
+	}
+	return a.IDRIntervals[0], true
+}
+
+// AnalyzeGOPAnnexB - GOP structure of an Annex B elementary stream
+// Access unit boundaries are detected from the slice headers, using the
+// parameter sets carried in the stream.
+func AnalyzeGOPAnnexB(r io.Reader) (*GOPAnalysis, error) {
+	a := NewGOPAnalysis()
+	aur := NewAccessUnitReader(r)
+	for {
+		au, err := aur.Read()
+		if err == io.EOF {
+			return a, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := a.AddAccessUnit(au); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// AnalyzeGOPSamples - GOP structure of length-prefixed samples, one access unit each
+func AnalyzeGOPSamples(samples [][]byte, lengthSize int) (*GOPAnalysis, error) {
+	a := NewGOPAnalysis()
+	for i, sample := range samples {
+		nalus, err := nalu.SplitSample(sample, lengthSize)
+		if err != nil {
+			return nil, fmt.Errorf("sample %d: %w", i, err)
+		}
+		if err := a.AddAccessUnit(nalus); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// AnalyzeGOPProtectedSamples - GOP structure of length-prefixed samples
+// protected with CENC, see AnalyzeGOPSamples
+// subsamples holds the subsample map of each sample, nil for samples in the
+// clear. Only the clear parts of the NAL units are read, which include the
+// slice headers as required by ISO/IEC 23001-7 Sec. 10.2.
+func AnalyzeGOPProtectedSamples(samples [][]byte, subsamples [][]nalu.Subsample, lengthSize int) (*GOPAnalysis, error) {
+	if len(subsamples) != len(samples) {
+		return nil, fmt.Errorf("%d subsample maps for %d samples", len(subsamples), len(samples))
+	}
+	a := NewGOPAnalysis()
+	for i, sample := range samples {
+		var nalus [][]byte
+		var err error
+		if subsamples[i] == nil {
+			nalus, err = nalu.SplitSample(sample, lengthSize)
+		} else {
+			var protected []nalu.ProtectedNALU
+			if protected, err = nalu.SplitProtectedSample(sample, lengthSize, subsamples[i]); err == nil {
+				nalus = nalu.ClearParts(protected)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("sample %d: %w", i, err)
+		}
+		if err := a.AddAccessUnit(nalus); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
@@ -0,0 +1,60 @@
+package avc
+
+// BitRate - bit rate of a SchedSelIdx in bits/s, Eq. E-37
+func (hrd *HRDParameters) BitRate(schedSelIdx int) uint64 {
+	sel := hrd.SchedSels[schedSelIdx]
+	return (uint64(sel.BitRateValueMinus1) + 1) << (6 + hrd.BitRateScale)
+}
+
+// CpbSize - CPB size of a SchedSelIdx in bits, Eq. E-38
+func (hrd *HRDParameters) CpbSize(schedSelIdx int) uint64 {
+	sel := hrd.SchedSels[schedSelIdx]
+	return (uint64(sel.CpbSizeValueMinus1) + 1) << (4 + hrd.CpbSizeScale)
+}
+
+// DeclaredBitRate - bit rate and buffer size declared by the HRD parameters of the VUI
+type DeclaredBitRate struct {
+	// MaxBitRate - bit rate of the highest SchedSelIdx in bits/s
+	MaxBitRate uint64
+	// CpbSize - CPB size of the highest SchedSelIdx in bits
+	CpbSize uint64
+	// CBR - the highest SchedSelIdx is constant bit rate
+	CBR bool
+	// NAL - taken from the NAL HRD, which includes NAL unit overhead, rather than the VCL HRD
+	NAL bool
+}
+
+// BufferSizeDB - CPB size in bytes, as bufferSizeDB of a 'btrt' box
+func (d *DeclaredBitRate) BufferSizeDB() uint32 {
+	size := (d.CpbSize + 7) / 8
+	if size > 1<<32-1 {
+		return 1<<32 - 1
+	}
+	return uint32(size)
+}
+
+// DeclaredBitRate - bit rate declared in the VUI
+// The NAL HRD is preferred since it describes the whole byte stream, as
+// needed for 'btrt' boxes and HLS BANDWIDTH attributes. The highest
+// SchedSelIdx is used, giving the peak rate. ok is false without HRD
+// parameters.
+func (s *SPS) DeclaredBitRate() (d DeclaredBitRate, ok bool) {
+	if !s.VUIParametersPresentFlag {
+		return d, false
+	}
+	vui := &s.VUI
+	hrd := &vui.NalHrdParameters
+	d.NAL = true
+	if !vui.NalHrdParametersPresentFlag || len(hrd.SchedSels) == 0 {
+		hrd = &vui.VclHrdParameters
+		d.NAL = false
+		if !vui.VclHrdParametersPresentFlag || len(hrd.SchedSels) == 0 {
+			return DeclaredBitRate{}, false
+		}
+	}
+	last := len(hrd.SchedSels) - 1
+	d.MaxBitRate = hrd.BitRate(last)
+	d.CpbSize = hrd.CpbSize(last)
+	d.CBR = hrd.SchedSels[last].CbrFlag
+	return d, true
+}
@@ -0,0 +1,185 @@
+package avc
+
+import (
+	"fmt"
+
+	"github.com/go-webdl/media-codec/sei"
+)
+
+// FieldOrder - scan type and field order of a stream
+type FieldOrder int
+
+const (
+	FIELD_ORDER_UNKNOWN     = FieldOrder(0)
+	FIELD_ORDER_PROGRESSIVE = FieldOrder(1)
+	// FIELD_ORDER_TFF - interlaced, top field first
+	FIELD_ORDER_TFF = FieldOrder(2)
+	// FIELD_ORDER_BFF - interlaced, bottom field first
+	FIELD_ORDER_BFF = FieldOrder(3)
+)
+
+func (f FieldOrder) String() string {
+	switch f {
+	case FIELD_ORDER_UNKNOWN:
+		return "Unknown"
+	case FIELD_ORDER_PROGRESSIVE:
+		return "Progressive"
+	case FIELD_ORDER_TFF:
+		return "TopFieldFirst"
+	case FIELD_ORDER_BFF:
+		return "BottomFieldFirst"
+	default:
+		return fmt.Sprintf("Other_%d", f)
+	}
+}
+
+// Fiel - fieldCount and fieldOrdering of a QuickTime/MP4 field handling box (fiel)
+// Unknown field order maps to a single field.
+func (f FieldOrder) Fiel() (fieldCount, fieldOrdering byte) {
+	switch f {
+	case FIELD_ORDER_TFF:
+		return 2, 9
+	case FIELD_ORDER_BFF:
+		return 2, 14
+	default:
+		return 1, 0
+	}
+}
+
+// InterlaceAnalysis - interlacing statistics of access units
+// Coded pictures are either frames, possibly with macroblock adaptive
+// frame/field coding (MBAFF) when the SPS allows it, or single fields with
+// picture adaptive frame/field coding (PAFF). SPS with frame_mbs_only_flag set
+// only allow progressive frames.
+type InterlaceAnalysis struct {
+	FramePictures int
+	FieldPictures int
+	// MBAFFFrames - frames of an SPS with mb_adaptive_frame_field_flag set
+	MBAFFFrames int
+	// PicStructs - number of picture timing SEI messages per pic_struct, Table D-1
+	PicStructs [9]int
+	// TopFirst, BottomFirst - frames and field pairs whose top or bottom
+	// field is output first according to their picture order counts
+	TopFirst    int
+	BottomFirst int
+
+	poc          POCCalculator
+	pendingField *SliceHeader
+	pendingPOC   int32
+}
+
+// AddAccessUnit - account for the NAL units of an access unit in decode order
+// spsMap and ppsMap are indexed by parameter set id.
+func (a *InterlaceAnalysis) AddAccessUnit(nalus [][]byte, spsMap map[byte]*SPS, ppsMap map[byte]*PPS) error {
+	var sh *SliceHeader
+	var seiNalus [][]byte
+	for _, nalu := range nalus {
+		if len(nalu) == 0 {
+			continue
+		}
+		switch GetNaluType(nalu[0]) {
+		case NALU_SEI:
+			seiNalus = append(seiNalus, nalu)
+		case NALU_NON_IDR, NALU_IDR:
+			if sh != nil {
+				continue
+			}
+			var err error
+			if sh, err = ParseSliceHeader(nalu, spsMap, ppsMap); err != nil {
+				return err
+			}
+		}
+	}
+	if sh == nil {
+		return nil
+	}
+	sps := spsMap[ppsMap[sh.PpsID].SpsID]
+
+	if sps.VUI.PicStructPresentFlag {
+		for _, nalu := range seiNalus {
+			msgs, err := ParseSEINALUnit(nalu)
+			if err != nil {
+				return err
+			}
+			for i := range msgs {
+				if msgs[i].PayloadType != sei.SEI_PIC_TIMING {
+					continue
+				}
+				pt, err := ParsePicTiming(msgs[i].Payload, sps)
+				if err != nil {
+					return err
+				}
+				a.PicStructs[pt.PicStruct]++
+			}
+		}
+	}
+
+	po, err := a.poc.Compute(sh, sps)
+	if err != nil {
+		return err
+	}
+	if !sh.FieldPicFlag {
+		a.FramePictures++
+		if sps.MbAdaptiveFrameFieldFlag {
+			a.MBAFFFrames++
+		}
+		a.pendingField = nil
+		a.countOrder(po.TopFieldOrderCnt, po.BottomFieldOrderCnt)
+		return nil
+	}
+	a.FieldPictures++
+	if a.pendingField != nil && a.pendingField.BottomFieldFlag != sh.BottomFieldFlag && a.pendingField.FrameNum == sh.FrameNum {
+		if sh.BottomFieldFlag {
+			a.countOrder(a.pendingPOC, po.PicOrderCnt)
+		} else {
+			a.countOrder(po.PicOrderCnt, a.pendingPOC)
+		}
+		a.pendingField = nil
+		return nil
+	}
+	a.pendingField = sh
+	a.pendingPOC = po.PicOrderCnt
+	return nil
+}
+
+func (a *InterlaceAnalysis) countOrder(top, bottom int32) {
+	switch {
+	case top < bottom:
+		a.TopFirst++
+	case bottom < top:
+		a.BottomFirst++
+	}
+}
+
+// FieldOrder - dominant scan type and field order
+// Picture timing SEI, when present, describes the intended display and takes
+// precedence over the coding structure, since interlaced content is also
+// coded as progressive frames with equal field order counts.
+func (a *InterlaceAnalysis) FieldOrder() FieldOrder {
+	progressive := a.PicStructs[0] + a.PicStructs[7] + a.PicStructs[8]
+	tff := a.PicStructs[3] + a.PicStructs[5]
+	bff := a.PicStructs[4] + a.PicStructs[6]
+	if progressive+tff+bff > 0 {
+		switch {
+		case progressive >= tff && progressive >= bff:
+			return FIELD_ORDER_PROGRESSIVE
+		case tff >= bff:
+			return FIELD_ORDER_TFF
+		default:
+			return FIELD_ORDER_BFF
+		}
+	}
+	if a.FramePictures+a.FieldPictures == 0 {
+		return FIELD_ORDER_UNKNOWN
+	}
+	if a.FieldPictures == 0 && a.MBAFFFrames == 0 && a.TopFirst == 0 && a.BottomFirst == 0 {
+		return FIELD_ORDER_PROGRESSIVE
+	}
+	switch {
+	case a.TopFirst > a.BottomFirst:
+		return FIELD_ORDER_TFF
+	case a.BottomFirst > a.TopFirst:
+		return FIELD_ORDER_BFF
+	}
+	return FIELD_ORDER_UNKNOWN
+}
@@ -0,0 +1,68 @@
+package avc
+
+// LevelLimits - limits of an AVC level
+// ISO/IEC 14496-10 Table A-1
+type LevelLimits struct {
+	// level_idc, level 1b is listed as 9
+	LevelIndicator byte
+	// MaxMBPS - max macroblock processing rate (MB/s)
+	MaxMBPS uint32
+	// MaxFS - max frame size (MBs)
+	MaxFS uint32
+	// MaxDpbMbs - max decoded picture buffer size (MBs)
+	MaxDpbMbs uint32
+	// MaxBR - max video bit rate (1000 bits/s for Baseline, Main and Extended)
+	MaxBR uint32
+	// MaxCPB - max CPB size (1000 bits for Baseline, Main and Extended)
+	MaxCPB uint32
+}
+
+// LEVEL_1B - level_idc used for level 1b in this package
+const LEVEL_1B = byte(9)
+
+// Levels - all levels of Table A-1 in ascending order
+var Levels = []LevelLimits{
+	{10, 1485, 99, 396, 64, 175},
+	{LEVEL_1B, 1485, 99, 396, 128, 350},
+	{11, 3000, 396, 900, 192, 500},
+	{12, 6000, 396, 2376, 384, 1000},
+	{13, 11880, 396, 2376, 768, 2000},
+	{20, 11880, 396, 2376, 2000, 2000},
+	{21, 19800, 792, 4752, 4000, 4000},
+	{22, 20250, 1620, 8100, 4000, 4000},
+	{30, 40500, 1620, 8100, 10000, 10000},
+	{31, 108000, 3600, 18000, 14000, 14000},
+	{32, 216000, 5120, 20480, 20000, 20000},
+	{40, 245760, 8192, 32768, 20000, 25000},
+	{41, 245760, 8192, 32768, 50000, 62500},
+	{42, 522240, 8704, 34816, 50000, 62500},
+	{50, 589824, 22080, 110400, 135000, 135000},
+	{51, 983040, 36864, 184320, 240000, 240000},
+	{52, 2073600, 36864, 184320, 240000, 240000},
+	{60, 4177920, 139264, 696320, 240000, 240000},
+	{61, 8355840, 139264, 696320, 480000, 480000},
+	{62, 16711680, 139264, 696320, 800000, 800000},
+}
+
+// LookupLevel - limits for a level_idc, with level 1b given as LEVEL_1B
+func LookupLevel(levelIndicator byte) (LevelLimits, bool) {
+	for _, l := range Levels {
+		if l.LevelIndicator == levelIndicator {
+			return l, true
+		}
+	}
+	return LevelLimits{}, false
+}
+
+// Level - level_idc of the SPS with level 1b mapped to LEVEL_1B
+// Baseline, Main and Extended signal level 1b as level_idc 11 with
+// constraint_set3_flag set.
+func (s *SPS) Level() byte {
+	switch Profile(s.ProfileIndicator) {
+	case PROFILE_BASELINE, PROFILE_MAIN, PROFILE_EXTENDED:
+		if s.LevelIndicator == LEVEL_1_1 && s.ProfileCompatibility&0x10 != 0 {
+			return LEVEL_1B
+		}
+	}
+	return s.LevelIndicator
+}
@@ -0,0 +1,132 @@
+package avc
+
+import (
+	"fmt"
+)
+
+// LevelRequirements - stream parameters that determine the lowest allowed level
+type LevelRequirements struct {
+	// Width, Height - coded frame size in luma samples, rounded up to macroblocks
+	Width, Height uint32
+	// FrameRate - frames per second, 0 if unknown
+	FrameRate float64
+	// DpbFrames - frames the decoded picture buffer must hold
+	DpbFrames uint32
+	// BitRate - peak bit rate in bits/s, 0 if unknown
+	BitRate uint64
+	// Profile - scales the bit rate limits, Table A-2
+	Profile Profile
+}
+
+// cpbBrVclFactor - scale of MaxBR of Table A-1 for a profile, Table A-2
+func cpbBrVclFactor(profile Profile) uint64 {
+	switch profile {
+	case PROFILE_HIGH, PROFILE_MULTIVIEW_HIGH, PROFILE_STEREO_HIGH:
+		return 1250
+	case PROFILE_HIGH_10:
+		return 3000
+	case PROFILE_HIGH_422, PROFILE_HIGH_444_PREDICTIVE, PROFILE_CAVLC_444_INTRA:
+		return 4000
+	}
+	return 1000
+}
+
+// exceeded - why the level cannot carry the requirements, empty if it can
+func (req *LevelRequirements) exceeded(l *LevelLimits) string {
+	widthInMbs, heightInMbs := (req.Width+15)/16, (req.Height+15)/16
+	frameSize := widthInMbs * heightInMbs
+	if frameSize > l.MaxFS {
+		return fmt.Sprintf("frame size %d MBs exceeds limit %d", frameSize, l.MaxFS)
+	}
+	if widthInMbs*widthInMbs > 8*l.MaxFS || heightInMbs*heightInMbs > 8*l.MaxFS {
+		return fmt.Sprintf("%dx%d MBs exceeds dimension limits", widthInMbs, heightInMbs)
+	}
+	if mbps := float64(frameSize) * req.FrameRate; mbps > float64(l.MaxMBPS) {
+		return fmt.Sprintf("%.0f MB/s exceeds limit %d", mbps, l.MaxMBPS)
+	}
+	if frameSize > 0 {
+		maxDpbFrames := l.MaxDpbMbs / frameSize
+		if maxDpbFrames > 16 {
+			maxDpbFrames = 16
+		}
+		if req.DpbFrames > maxDpbFrames {
+			return fmt.Sprintf("DPB of %d frames exceeds limit %d", req.DpbFrames, maxDpbFrames)
+		}
+	}
+	if maxBitRate := uint64(l.MaxBR) * cpbBrVclFactor(req.Profile); req.BitRate > maxBitRate {
+		return fmt.Sprintf("bit rate %d exceeds limit %d", req.BitRate, maxBitRate)
+	}
+	return ""
+}
+
+// MinLevel - lowest level of Table A-1 satisfying the requirements
+// Level 1b is returned as LEVEL_1B; Baseline, Main and Extended signal it as
+// level_idc 11 with constraint_set3_flag set.
+func MinLevel(req LevelRequirements) (LevelLimits, bool) {
+	for i := range Levels {
+		if req.exceeded(&Levels[i]) == "" {
+			return Levels[i], true
+		}
+	}
+	return LevelLimits{}, false
+}
+
+// LevelRequirements - requirements of the SPS
+// The frame rate is derived from VUI timing with fixed_frame_rate_flag set,
+// and unknown otherwise, the DPB size from
+// max_dec_frame_buffering if bitstream restrictions are present and from
+// max_num_ref_frames otherwise, and the bit rate from the highest HRD
+// SchedSelIdx.
+func (s *SPS) LevelRequirements() LevelRequirements {
+	req := LevelRequirements{Profile: Profile(s.ProfileIndicator)}
+	req.Width, req.Height = s.CodedSize()
+	vui := &s.VUI
+	if vui.TimingInfoPresentFlag && vui.FixedFrameRateFlag && vui.NumUnitsInTick > 0 {
+		req.FrameRate = float64(vui.TimeScale) / float64(2*vui.NumUnitsInTick)
+	}
+	req.DpbFrames = uint32(s.MaxNumRefFrames)
+	if vui.BitstreamRestrictionFlag {
+		req.DpbFrames = vui.MaxDecFrameBuffering
+	}
+	for _, hrd := range []*HRDParameters{&vui.NalHrdParameters, &vui.VclHrdParameters} {
+		for i := range hrd.SchedSels {
+			bitRate := hrd.BitRate(i)
+			if hrd == &vui.NalHrdParameters {
+				// NAL HRD limits are 1.2 times the VCL ones, Table A-2
+				bitRate = bitRate * 5 / 6
+			}
+			if bitRate > req.BitRate {
+				req.BitRate = bitRate
+			}
+		}
+	}
+	return req
+}
+
+// levelIndex - position of a level_idc in Levels, -1 if unknown
+func levelIndex(levelIndicator byte) int {
+	for i := range Levels {
+		if Levels[i].LevelIndicator == levelIndicator {
+			return i
+		}
+	}
+	return -1
+}
+
+// VerifyLevel - report if the signalled level is lower than the SPS parameters require
+func (s *SPS) VerifyLevel() (issues []string) {
+	level := s.Level()
+	index := levelIndex(level)
+	if index < 0 {
+		return []string{fmt.Sprintf("unknown level %d", s.LevelIndicator)}
+	}
+	req := s.LevelRequirements()
+	reason := req.exceeded(&Levels[index])
+	if reason == "" {
+		return nil
+	}
+	if required, ok := MinLevel(req); ok {
+		return []string{fmt.Sprintf("level %s too low, %s, level %s required", LevelName(level), reason, LevelName(required.LevelIndicator))}
+	}
+	return []string{fmt.Sprintf("level %s too low, %s, no level is sufficient", LevelName(level), reason)}
+}
@@ -0,0 +1,139 @@
+package avc
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// MVCDecoderConfigurationRecord - configuration of an MVC elementary stream,
+// ISO/IEC 14496-15
+// The record is shaped like AVCDecoderConfigurationRecord; its parameter set
+// arrays may also hold subset sequence parameter sets (NAL unit type 15).
+type MVCDecoderConfigurationRecord struct {
+	ConfigurationVersion uint8
+	AVCProfileIndication uint8
+	ProfileCompatibility uint8
+	AVCLevelIndication   uint8
+	// indicates that all NAL units of the stream, including those of other
+	// tracks the stream depends on, are contained in the track
+	CompleteRepresentation bool
+	// indicates that samples contain complete access units of the views
+	// carried in the track
+	ExplicitAUTrack       bool
+	LengthSizeMinusOne    uint8
+	SequenceParameterSets []AVCSequenceParameterSet
+	PictureParameterSets  []AVCPictureParameterSet
+}
+
+func (b *MVCDecoderConfigurationRecord) RecordSize() (size uint32) {
+	// unsigned int(8) configurationVersion = 1;
+	// unsigned int(8) AVCProfileIndication;
+	// unsigned int(8) profile_compatibility;
+	// unsigned int(8) AVCLevelIndication;
+	// bit(1) complete_representation;
+	// bit(1) explicit_au_track;
+	// bit(4) reserved = '1111'b;
+	// unsigned int(2) lengthSizeMinusOne;
+	// bit(1) reserved = '0'b;
+	// unsigned int(7) numOfSequenceParameterSets;
+	size += 6
+	for _, sps := range b.SequenceParameterSets {
+		size += 2 + uint32(len(sps.NALUnit))
+	}
+	// unsigned int(8) numOfPictureParameterSets;
+	size += 1
+	for _, pps := range b.PictureParameterSets {
+		size += 2 + uint32(len(pps.NALUnit))
+	}
+	return
+}
+
+func (b *MVCDecoderConfigurationRecord) RecordRead(r io.Reader) (err error) {
+	var tmp [6]uint8
+	if err = binary.Read(r, binary.BigEndian, &tmp); err != nil {
+		return
+	}
+	b.ConfigurationVersion = tmp[0]
+	b.AVCProfileIndication = tmp[1]
+	b.ProfileCompatibility = tmp[2]
+	b.AVCLevelIndication = tmp[3]
+	b.CompleteRepresentation = tmp[4]&0b10000000 != 0
+	b.ExplicitAUTrack = tmp[4]&0b01000000 != 0
+	b.LengthSizeMinusOne = tmp[4] & 0b11
+	b.SequenceParameterSets, b.PictureParameterSets, err = readParameterSetArrays(r, int(tmp[5]&0b1111111))
+	return
+}
+
+func (b *MVCDecoderConfigurationRecord) RecordWrite(w io.Writer) (err error) {
+	flags := b.LengthSizeMinusOne | 0b00111100
+	if b.CompleteRepresentation {
+		flags |= 0b10000000
+	}
+	if b.ExplicitAUTrack {
+		flags |= 0b01000000
+	}
+	tmp := []uint8{b.ConfigurationVersion, b.AVCProfileIndication, b.ProfileCompatibility, b.AVCLevelIndication, flags,
+		uint8(len(b.SequenceParameterSets)) & 0b1111111}
+	if _, err = w.Write(tmp); err != nil {
+		return
+	}
+	return writeParameterSetArrays(w, b.SequenceParameterSets, b.PictureParameterSets)
+}
+
+// readParameterSetArrays - numOfSequenceParameterSets length prefixed SPS
+// followed by the PPS count and length prefixed PPS
+func readParameterSetArrays(r io.Reader, numOfSequenceParameterSets int) (spss []AVCSequenceParameterSet, ppss []AVCPictureParameterSet, err error) {
+	spss = make([]AVCSequenceParameterSet, numOfSequenceParameterSets)
+	for i := range spss {
+		if spss[i].NALUnit, err = readLengthPrefixedNALUnit(r); err != nil {
+			return
+		}
+	}
+	var numOfPictureParameterSets uint8
+	if err = binary.Read(r, binary.BigEndian, &numOfPictureParameterSets); err != nil {
+		return
+	}
+	ppss = make([]AVCPictureParameterSet, numOfPictureParameterSets)
+	for i := range ppss {
+		if ppss[i].NALUnit, err = readLengthPrefixedNALUnit(r); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func readLengthPrefixedNALUnit(r io.Reader) (nalu []byte, err error) {
+	var length uint16
+	if err = binary.Read(r, binary.BigEndian, &length); err != nil {
+		return
+	}
+	nalu = make([]byte, length)
+	_, err = io.ReadFull(r, nalu)
+	return
+}
+
+// writeParameterSetArrays - the SPS, PPS count and PPS, each NAL unit length prefixed
+func writeParameterSetArrays(w io.Writer, spss []AVCSequenceParameterSet, ppss []AVCPictureParameterSet) (err error) {
+	for i := range spss {
+		if err = writeLengthPrefixedNALUnit(w, spss[i].NALUnit); err != nil {
+			return
+		}
+	}
+	if err = binary.Write(w, binary.BigEndian, uint8(len(ppss))); err != nil {
+		return
+	}
+	for i := range ppss {
+		if err = writeLengthPrefixedNALUnit(w, ppss[i].NALUnit); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func writeLengthPrefixedNALUnit(w io.Writer, nalu []byte) (err error) {
+	if err = binary.Write(w, binary.BigEndian, uint16(len(nalu))); err != nil {
+		return
+	}
+	_, err = w.Write(nalu)
+	return
+}
@@ -0,0 +1,202 @@
+package avc
+
+import (
+	"fmt"
+	"sort"
+)
+
+// PictureOrder - picture order counts of a coded picture
+// ISO/IEC 14496-10 Sec. 8.2.1
+type PictureOrder struct {
+	TopFieldOrderCnt    int32
+	BottomFieldOrderCnt int32
+	// PicOrderCnt - order of the picture: the minimum of both counts for a
+	// frame, the count of the field for a field
+	PicOrderCnt int32
+	// Reset - the picture starts a new POC period (IDR picture), so that later
+	// pictures are never presented before earlier ones of a previous period
+	Reset bool
+}
+
+// POCCalculator - derives picture order counts of consecutive pictures
+// The zero value is ready to use. Pictures must be passed in decode order,
+// one slice header per picture, e.g. the first slice of each picture.
+type POCCalculator struct {
+	prevPicOrderCntMsb int32
+	prevPicOrderCntLsb int32
+	prevFrameNumOffset int32
+	prevFrameNum       uint32
+}
+
+// Compute - picture order counts of the picture of slice header sh
+// sps must be the SPS the slice refers to.
+func (c *POCCalculator) Compute(sh *SliceHeader, sps *SPS) (po PictureOrder, err error) {
+	idr := sh.NaluType == NALU_IDR
+	po.Reset = idr
+	switch sps.PicOrderCntType {
+	case 0:
+		c.computeType0(sh, sps, &po)
+	case 1, 2:
+		maxFrameNum := int32(1) << (sps.Log2MaxFrameNumMinus4 + 4)
+		frameNumOffset := c.prevFrameNumOffset
+		switch {
+		case idr:
+			frameNumOffset = 0
+		case c.prevFrameNum > sh.FrameNum:
+			frameNumOffset += maxFrameNum
+		}
+		if sps.PicOrderCntType == 1 {
+			computeType1(sh, sps, frameNumOffset, &po)
+		} else {
+			computeType2(sh, frameNumOffset, &po)
+		}
+		c.prevFrameNumOffset = frameNumOffset
+	default:
+		return po, fmt.Errorf("pic_order_cnt_type %d reserved", sps.PicOrderCntType)
+	}
+	c.prevFrameNum = sh.FrameNum
+
+	switch {
+	case !sh.FieldPicFlag:
+		po.PicOrderCnt = po.TopFieldOrderCnt
+		if po.BottomFieldOrderCnt < po.PicOrderCnt {
+			po.PicOrderCnt = po.BottomFieldOrderCnt
+		}
+	case sh.BottomFieldFlag:
+		po.PicOrderCnt = po.BottomFieldOrderCnt
+	default:
+		po.PicOrderCnt = po.TopFieldOrderCnt
+	}
+	if sh.HasMMCO(MMCO_UNMARK_ALL) {
+		c.resetAfterMMCO5(sh, sps, &po)
+	}
+	return po, nil
+}
+
+// resetAfterMMCO5 - start a new POC period after the picture, Sec. 8.2.1
+// The counts of the picture are made relative to its PicOrderCnt and
+// frame_num is inferred to be 0 for the following pictures.
+func (c *POCCalculator) resetAfterMMCO5(sh *SliceHeader, sps *SPS, po *PictureOrder) {
+	tempPicOrderCnt := po.PicOrderCnt
+	po.TopFieldOrderCnt -= tempPicOrderCnt
+	po.BottomFieldOrderCnt -= tempPicOrderCnt
+	po.PicOrderCnt = 0
+	po.Reset = true
+	switch sps.PicOrderCntType {
+	case 0:
+		c.prevPicOrderCntMsb = 0
+		c.prevPicOrderCntLsb = 0
+		if !sh.BottomFieldFlag {
+			c.prevPicOrderCntLsb = po.TopFieldOrderCnt
+		}
+	default:
+		c.prevFrameNumOffset = 0
+	}
+	c.prevFrameNum = 0
+}
+
+// computeType0 - Sec. 8.2.1.1
+func (c *POCCalculator) computeType0(sh *SliceHeader, sps *SPS, po *PictureOrder) {
+	if sh.NaluType == NALU_IDR {
+		c.prevPicOrderCntMsb = 0
+		c.prevPicOrderCntLsb = 0
+	}
+	maxPicOrderCntLsb := int32(1) << (sps.Log2MaxPicOrderCntLsbMinus4 + 4)
+	lsb := int32(sh.PicOrderCntLsb)
+	msb := c.prevPicOrderCntMsb
+	switch {
+	case lsb < c.prevPicOrderCntLsb && c.prevPicOrderCntLsb-lsb >= maxPicOrderCntLsb/2:
+		msb += maxPicOrderCntLsb
+	case lsb > c.prevPicOrderCntLsb && lsb-c.prevPicOrderCntLsb > maxPicOrderCntLsb/2:
+		msb -= maxPicOrderCntLsb
+	}
+	if !sh.FieldPicFlag {
+		po.TopFieldOrderCnt = msb + lsb
+		po.BottomFieldOrderCnt = po.TopFieldOrderCnt + sh.DeltaPicOrderCntBottom
+	} else if sh.BottomFieldFlag {
+		po.BottomFieldOrderCnt = msb + lsb
+	} else {
+		po.TopFieldOrderCnt = msb + lsb
+	}
+	if sh.NalRefIdc != 0 {
+		c.prevPicOrderCntMsb = msb
+		c.prevPicOrderCntLsb = lsb
+	}
+}
+
+// computeType1 - Sec. 8.2.1.2
+func computeType1(sh *SliceHeader, sps *SPS, frameNumOffset int32, po *PictureOrder) {
+	numRefFramesInCycle := int32(len(sps.OffsetForRefFrames))
+	absFrameNum := int32(0)
+	if numRefFramesInCycle != 0 {
+		absFrameNum = frameNumOffset + int32(sh.FrameNum)
+	}
+	if sh.NalRefIdc == 0 && absFrameNum > 0 {
+		absFrameNum--
+	}
+	expectedPicOrderCnt := int32(0)
+	if absFrameNum > 0 {
+		picOrderCntCycleCnt := (absFrameNum - 1) / numRefFramesInCycle
+		frameNumInPicOrderCntCycle := (absFrameNum - 1) % numRefFramesInCycle
+		expectedDeltaPerPicOrderCntCycle := int32(0)
+		for _, offset := range sps.OffsetForRefFrames {
+			expectedDeltaPerPicOrderCntCycle += offset
+		}
+		expectedPicOrderCnt = picOrderCntCycleCnt * expectedDeltaPerPicOrderCntCycle
+		for i := int32(0); i <= frameNumInPicOrderCntCycle; i++ {
+			expectedPicOrderCnt += sps.OffsetForRefFrames[i]
+		}
+	}
+	if sh.NalRefIdc == 0 {
+		expectedPicOrderCnt += sps.OffsetForNonRefPic
+	}
+	if !sh.FieldPicFlag {
+		po.TopFieldOrderCnt = expectedPicOrderCnt + sh.DeltaPicOrderCnt[0]
+		po.BottomFieldOrderCnt = po.TopFieldOrderCnt + sps.OffsetForTopToBottomField + sh.DeltaPicOrderCnt[1]
+	} else if sh.BottomFieldFlag {
+		po.BottomFieldOrderCnt = expectedPicOrderCnt + sps.OffsetForTopToBottomField + sh.DeltaPicOrderCnt[0]
+	} else {
+		po.TopFieldOrderCnt = expectedPicOrderCnt + sh.DeltaPicOrderCnt[0]
+	}
+}
+
+// computeType2 - Sec. 8.2.1.3
+func computeType2(sh *SliceHeader, frameNumOffset int32, po *PictureOrder) {
+	tempPicOrderCnt := int32(0)
+	switch {
+	case sh.NaluType == NALU_IDR:
+	case sh.NalRefIdc == 0:
+		tempPicOrderCnt = 2*(frameNumOffset+int32(sh.FrameNum)) - 1
+	default:
+		tempPicOrderCnt = 2 * (frameNumOffset + int32(sh.FrameNum))
+	}
+	po.TopFieldOrderCnt = tempPicOrderCnt
+	po.BottomFieldOrderCnt = tempPicOrderCnt
+}
+
+// CompositionOffsets - composition time offsets of pictures in decode order
+// with a constant sample duration
+// Within each POC period pictures are presented in ascending PicOrderCnt
+// order. Offsets may be negative, as allowed by version 1 composition offset
+// boxes; for version 0 add the negated minimum and signal it with an edit.
+func CompositionOffsets(pictures []PictureOrder, duration uint32) []int64 {
+	offsets := make([]int64, len(pictures))
+	for start := 0; start < len(pictures); {
+		end := start + 1
+		for end < len(pictures) && !pictures[end].Reset {
+			end++
+		}
+		order := make([]int, end-start)
+		for i := range order {
+			order[i] = start + i
+		}
+		sort.SliceStable(order, func(i, j int) bool {
+			return pictures[order[i]].PicOrderCnt < pictures[order[j]].PicOrderCnt
+		})
+		for rank, i := range order {
+			offsets[i] = (int64(start+rank) - int64(i)) * int64(duration)
+		}
+		start = end
+	}
+	return offsets
+}
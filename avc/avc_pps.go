@@ -0,0 +1,150 @@
+package avc
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/go-webdl/bits"
+)
+
+// PPS - AVC PPS parameters
+// ISO/IEC 14496-10 Sec. 7.3.2.2
+type PPS struct {
+	PpsID                                 byte
+	SpsID                                 byte
+	EntropyCodingModeFlag                 bool
+	BottomFieldPicOrderInFramePresentFlag bool
+	NumSliceGroupsMinus1                  uint32
+	SliceGroupMapType                     uint32
+	RunLengthMinus1                       []uint32
+	TopLeft                               []uint32
+	BottomRight                           []uint32
+	SliceGroupChangeDirectionFlag         bool
+	SliceGroupChangeRateMinus1            uint32
+	PicSizeInMapUnitsMinus1               uint32
+	SliceGroupIDs                         []uint32
+	NumRefIdxL0DefaultActiveMinus1        byte
+	NumRefIdxL1DefaultActiveMinus1        byte
+	WeightedPredFlag                      bool
+	WeightedBipredIdc                     byte
+	PicInitQpMinus26                      int32
+	PicInitQsMinus26                      int32
+	ChromaQpIndexOffset                   int32
+	DeblockingFilterControlPresentFlag    bool
+	ConstrainedIntraPredFlag              bool
+	RedundantPicCntPresentFlag            bool
+	Transform8x8ModeFlag                  bool
+	PicScalingMatrixPresentFlag           bool
+	PicScalingLists                       []ScalingList
+	SecondChromaQpIndexOffset             int32
+}
+
+// ParsePPSNALUnit - Parse AVC PPS NAL unit starting with NAL unit header
+// spsMap, indexed by SPS id, must contain the referenced SPS since the
+// number of scaling lists depends on its chroma format.
+func ParsePPSNALUnit(data []byte, spsMap map[byte]*SPS) (*PPS, error) {
+
+	pps := &PPS{}
+
+	rd := bytes.NewReader(data)
+	r := bits.NewAccErrEBSPReader(rd)
+	// Note! First byte is NALU Header
+
+	naluType := GetNaluType(byte(r.Read(8)))
+	if naluType != NALU_PPS {
+		return nil, fmt.Errorf("NALU type is %s not PPS", naluType)
+	}
+	pps.PpsID = byte(r.ReadExpGolomb())
+	pps.SpsID = byte(r.ReadExpGolomb())
+	if err := r.AccError(); err != nil {
+		return nil, err
+	}
+	sps, ok := spsMap[pps.SpsID]
+	if !ok {
+		return nil, fmt.Errorf("SPS %d not found", pps.SpsID)
+	}
+	pps.EntropyCodingModeFlag = r.ReadFlag()
+	pps.BottomFieldPicOrderInFramePresentFlag = r.ReadFlag()
+	pps.NumSliceGroupsMinus1 = uint32(r.ReadExpGolomb())
+	if pps.NumSliceGroupsMinus1 > 7 {
+		return nil, fmt.Errorf("num_slice_groups_minus1 %d out of range", pps.NumSliceGroupsMinus1)
+	}
+	if pps.NumSliceGroupsMinus1 > 0 {
+		pps.SliceGroupMapType = uint32(r.ReadExpGolomb())
+		switch pps.SliceGroupMapType {
+		case 0:
+			for i := uint32(0); i <= pps.NumSliceGroupsMinus1; i++ {
+				pps.RunLengthMinus1 = append(pps.RunLengthMinus1, uint32(r.ReadExpGolomb()))
+			}
+		case 2:
+			for i := uint32(0); i < pps.NumSliceGroupsMinus1; i++ {
+				pps.TopLeft = append(pps.TopLeft, uint32(r.ReadExpGolomb()))
+				pps.BottomRight = append(pps.BottomRight, uint32(r.ReadExpGolomb()))
+			}
+		case 3, 4, 5:
+			pps.SliceGroupChangeDirectionFlag = r.ReadFlag()
+			pps.SliceGroupChangeRateMinus1 = uint32(r.ReadExpGolomb())
+		case 6:
+			pps.PicSizeInMapUnitsMinus1 = uint32(r.ReadExpGolomb())
+			if pps.PicSizeInMapUnitsMinus1 >= sps.PicSizeInMapUnits() {
+				return nil, fmt.Errorf("pic_size_in_map_units_minus1 %d out of range", pps.PicSizeInMapUnitsMinus1)
+			}
+			n := ceilLog2(pps.NumSliceGroupsMinus1 + 1)
+			for i := uint32(0); i <= pps.PicSizeInMapUnitsMinus1 && r.AccError() == nil; i++ {
+				pps.SliceGroupIDs = append(pps.SliceGroupIDs, uint32(r.Read(n)))
+			}
+		}
+	}
+	pps.NumRefIdxL0DefaultActiveMinus1 = byte(r.ReadExpGolomb())
+	pps.NumRefIdxL1DefaultActiveMinus1 = byte(r.ReadExpGolomb())
+	pps.WeightedPredFlag = r.ReadFlag()
+	pps.WeightedBipredIdc = byte(r.Read(2))
+	pps.PicInitQpMinus26 = int32(r.ReadSignedGolomb())
+	pps.PicInitQsMinus26 = int32(r.ReadSignedGolomb())
+	pps.ChromaQpIndexOffset = int32(r.ReadSignedGolomb())
+	pps.DeblockingFilterControlPresentFlag = r.ReadFlag()
+	pps.ConstrainedIntraPredFlag = r.ReadFlag()
+	pps.RedundantPicCntPresentFlag = r.ReadFlag()
+	pps.SecondChromaQpIndexOffset = pps.ChromaQpIndexOffset
+	if err := r.AccError(); err != nil {
+		return nil, err
+	}
+	moreRbspData, err := r.MoreRbspData()
+	if err != nil {
+		return nil, err
+	}
+	if moreRbspData {
+		pps.Transform8x8ModeFlag = r.ReadFlag()
+		pps.PicScalingMatrixPresentFlag = r.ReadFlag()
+		if pps.PicScalingMatrixPresentFlag {
+			count := 6
+			if pps.Transform8x8ModeFlag {
+				if sps.ChromaFormatIndicator == 3 {
+					count += 6
+				} else {
+					count += 2
+				}
+			}
+			pps.PicScalingLists = make([]ScalingList, count)
+			for i := range pps.PicScalingLists {
+				size := 64
+				if i < 6 {
+					size = 16
+				}
+				pps.PicScalingLists[i] = readScalingList(r, size)
+			}
+		}
+		pps.SecondChromaQpIndexOffset = int32(r.ReadSignedGolomb())
+	}
+
+	return pps, r.AccError()
+}
+
+// ceilLog2 - Ceil(Log2(n)) as used for u(v) lengths
+func ceilLog2(n uint32) int {
+	l := 0
+	for (uint32(1) << l) < n {
+		l++
+	}
+	return l
+}
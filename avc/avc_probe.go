@@ -0,0 +1,90 @@
+package avc
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// DefaultProbeSize - bytes of a stream read by Probe at most
+	DefaultProbeSize = 8 << 20
+	// probeAccessUnits - access units analyzed for the field order
+	probeAccessUnits = 60
+)
+
+// ProbeResult - properties of an Annex B stream found by Probe
+type ProbeResult struct {
+	Profile Profile
+	// Level - level_idc, LEVEL_1B for level 1b
+	Level byte
+	// Width, Height - displayed size in luma samples, after cropping
+	Width, Height uint32
+	// FrameRate - frames per second from VUI timing info, 0 if not signalled
+	FrameRate float64
+	// ChromaFormat - 0 monochrome, 1 4:2:0, 2 4:2:2, 3 4:4:4
+	ChromaFormat   byte
+	BitDepthLuma   byte
+	BitDepthChroma byte
+	Interlaced     bool
+	FieldOrder     FieldOrder
+	// SPS - the first SPS of the stream
+	SPS *SPS
+}
+
+// Probe - properties of an AVC Annex B stream from its first DefaultProbeSize bytes
+// NAL units before the first SPS are skipped, so the stream may start
+// anywhere, e.g. within a broadcast recording. Up to 60 access units from
+// there are analyzed to tell progressive from interlaced content.
+func Probe(rd io.Reader) (*ProbeResult, error) {
+	return ProbeN(rd, DefaultProbeSize)
+}
+
+// ProbeN - Probe reading at most maxBytes bytes, DefaultProbeSize if maxBytes is 0
+func ProbeN(rd io.Reader, maxBytes int64) (*ProbeResult, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultProbeSize
+	}
+	r := NewAccessUnitReader(io.LimitReader(rd, maxBytes))
+	r.skipToSPS = true
+	var first *SPS
+	var analysis InterlaceAnalysis
+	for i := 0; i < probeAccessUnits; i++ {
+		au, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if first == nil {
+			if first, err = ParseSPSNALUnit(au[0]); err != nil {
+				return nil, err
+			}
+		}
+		spsMap, ppsMap := r.ParameterSets()
+		if err := analysis.AddAccessUnit(au, spsMap, ppsMap); err != nil {
+			return nil, fmt.Errorf("access unit %d: %w", i, err)
+		}
+	}
+	if first == nil {
+		return nil, errors.New("no SPS in stream")
+	}
+
+	p := &ProbeResult{
+		Profile:        Profile(first.ProfileIndicator),
+		Level:          first.Level(),
+		FrameRate:      first.LevelRequirements().FrameRate,
+		ChromaFormat:   first.ChromaFormatIndicator,
+		BitDepthLuma:   first.BitDepthLumaMinus8 + 8,
+		BitDepthChroma: first.BitDepthChromaMinus8 + 8,
+		FieldOrder:     FIELD_ORDER_PROGRESSIVE,
+		SPS:            first,
+	}
+	p.Width, p.Height = first.ImageSize()
+	if !first.FrameMbsOnlyFlag {
+		p.FieldOrder = analysis.FieldOrder()
+		p.Interlaced = p.FieldOrder == FIELD_ORDER_TFF || p.FieldOrder == FIELD_ORDER_BFF
+	}
+	return p, nil
+}
@@ -0,0 +1,153 @@
+package avc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Profile - AVC profile_idc
+// ISO/IEC 14496-10 Annex A, G and H
+type Profile byte
+
+const (
+	// PROFILE_CAVLC_444_INTRA - CAVLC 4:4:4 Intra profile
+	PROFILE_CAVLC_444_INTRA = Profile(44)
+	// PROFILE_BASELINE - Baseline profile, Constrained Baseline if constraint_set1_flag is set
+	PROFILE_BASELINE = Profile(66)
+	// PROFILE_MAIN - Main profile
+	PROFILE_MAIN = Profile(77)
+	// PROFILE_SCALABLE_BASELINE - Scalable Baseline profile (SVC)
+	PROFILE_SCALABLE_BASELINE = Profile(83)
+	// PROFILE_SCALABLE_HIGH - Scalable High profile (SVC)
+	PROFILE_SCALABLE_HIGH = Profile(86)
+	// PROFILE_EXTENDED - Extended profile
+	PROFILE_EXTENDED = Profile(88)
+	// PROFILE_HIGH - High profile
+	PROFILE_HIGH = Profile(100)
+	// PROFILE_HIGH_10 - High 10 profile, High 10 Intra if constraint_set3_flag is set
+	PROFILE_HIGH_10 = Profile(110)
+	// PROFILE_MULTIVIEW_HIGH - Multiview High profile (MVC)
+	PROFILE_MULTIVIEW_HIGH = Profile(118)
+	// PROFILE_HIGH_422 - High 4:2:2 profile, High 4:2:2 Intra if constraint_set3_flag is set
+	PROFILE_HIGH_422 = Profile(122)
+	// PROFILE_STEREO_HIGH - Stereo High profile (MVC)
+	PROFILE_STEREO_HIGH = Profile(128)
+	// PROFILE_MFC_HIGH - MFC High profile
+	PROFILE_MFC_HIGH = Profile(134)
+	// PROFILE_MFC_DEPTH_HIGH - MFC Depth High profile
+	PROFILE_MFC_DEPTH_HIGH = Profile(135)
+	// PROFILE_MULTIVIEW_DEPTH_HIGH - Multiview Depth High profile (MVCD)
+	PROFILE_MULTIVIEW_DEPTH_HIGH = Profile(138)
+	// PROFILE_ENHANCED_MULTIVIEW_DEPTH_HIGH - Enhanced Multiview Depth High profile (3D-AVC)
+	PROFILE_ENHANCED_MULTIVIEW_DEPTH_HIGH = Profile(139)
+	// PROFILE_HIGH_444 - High 4:4:4 profile, removed from ISO/IEC 14496-10
+	PROFILE_HIGH_444 = Profile(144)
+	// PROFILE_HIGH_444_PREDICTIVE - High 4:4:4 Predictive profile, High 4:4:4
+	// Intra if constraint_set3_flag is set
+	PROFILE_HIGH_444_PREDICTIVE = Profile(244)
+)
+
+// profileNames - display names of the known profiles
+var profileNames = map[Profile]string{
+	PROFILE_CAVLC_444_INTRA:               "CAVLC 4:4:4 Intra",
+	PROFILE_BASELINE:                      "Baseline",
+	PROFILE_MAIN:                          "Main",
+	PROFILE_SCALABLE_BASELINE:             "Scalable Baseline",
+	PROFILE_SCALABLE_HIGH:                 "Scalable High",
+	PROFILE_EXTENDED:                      "Extended",
+	PROFILE_HIGH:                          "High",
+	PROFILE_HIGH_10:                       "High 10",
+	PROFILE_MULTIVIEW_HIGH:                "Multiview High",
+	PROFILE_HIGH_422:                      "High 4:2:2",
+	PROFILE_STEREO_HIGH:                   "Stereo High",
+	PROFILE_MFC_HIGH:                      "MFC High",
+	PROFILE_MFC_DEPTH_HIGH:                "MFC Depth High",
+	PROFILE_MULTIVIEW_DEPTH_HIGH:          "Multiview Depth High",
+	PROFILE_ENHANCED_MULTIVIEW_DEPTH_HIGH: "Enhanced Multiview Depth High",
+	PROFILE_HIGH_444:                      "High 4:4:4",
+	PROFILE_HIGH_444_PREDICTIVE:           "High 4:4:4 Predictive",
+}
+
+func (p Profile) String() string {
+	if name, ok := profileNames[p]; ok {
+		return fmt.Sprintf("%s_%d", strings.NewReplacer(" ", "", ":", "").Replace(name), byte(p))
+	}
+	return fmt.Sprintf("Other_%d", byte(p))
+}
+
+// Name - display name of the profile, e.g. "High 4:2:2", or empty if unknown
+func (p Profile) Name() string {
+	return profileNames[p]
+}
+
+// HasRecordChromaInfo - whether an AVCDecoderConfigurationRecord of this
+// profile carries chroma format, bit depths and SPS extensions
+// ISO/IEC 14496-15 Sec. 5.3.3.1.2
+func (p Profile) HasRecordChromaInfo() bool {
+	switch p {
+	case PROFILE_HIGH, PROFILE_HIGH_10, PROFILE_HIGH_422, PROFILE_HIGH_444:
+		return true
+	}
+	return false
+}
+
+// LookupProfile - profile of a display name such as "High 10" or a String()
+// form such as "High10_110", matched case-insensitively
+func LookupProfile(name string) (Profile, bool) {
+	key := normalizeProfileName(name)
+	for p, n := range profileNames {
+		if normalizeProfileName(n) == key || strings.EqualFold(p.String(), name) {
+			return p, true
+		}
+	}
+	return 0, false
+}
+
+func normalizeProfileName(name string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", ":", "", "_", "", "-", "").Replace(name))
+}
+
+// level_idc of the levels of Table A-1, see also LEVEL_1B
+const (
+	LEVEL_1   = byte(10)
+	LEVEL_1_1 = byte(11)
+	LEVEL_1_2 = byte(12)
+	LEVEL_1_3 = byte(13)
+	LEVEL_2   = byte(20)
+	LEVEL_2_1 = byte(21)
+	LEVEL_2_2 = byte(22)
+	LEVEL_3   = byte(30)
+	LEVEL_3_1 = byte(31)
+	LEVEL_3_2 = byte(32)
+	LEVEL_4   = byte(40)
+	LEVEL_4_1 = byte(41)
+	LEVEL_4_2 = byte(42)
+	LEVEL_5   = byte(50)
+	LEVEL_5_1 = byte(51)
+	LEVEL_5_2 = byte(52)
+	LEVEL_6   = byte(60)
+	LEVEL_6_1 = byte(61)
+	LEVEL_6_2 = byte(62)
+)
+
+// LevelName - level number of a level_idc, e.g. "3.1", or "1b" for LEVEL_1B
+func LevelName(levelIndicator byte) string {
+	if levelIndicator == LEVEL_1B {
+		return "1b"
+	}
+	if levelIndicator%10 == 0 {
+		return fmt.Sprintf("%d", levelIndicator/10)
+	}
+	return fmt.Sprintf("%d.%d", levelIndicator/10, levelIndicator%10)
+}
+
+// LookupLevelName - level_idc of a level number such as "4.1" or "1b"
+// Only levels of Table A-1 are accepted.
+func LookupLevelName(name string) (byte, bool) {
+	for _, l := range Levels {
+		if strings.EqualFold(LevelName(l.LevelIndicator), name) {
+			return l.LevelIndicator, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,99 @@
+package avc
+
+import (
+	"bytes"
+
+	"github.com/go-webdl/bits"
+	"github.com/go-webdl/media-codec/sei"
+)
+
+// RandomAccess - random access properties of an access unit
+type RandomAccess struct {
+	// IDR - access unit is an IDR picture
+	IDR bool
+	// IntraOnly - all slices are I or SI slices
+	IntraOnly bool
+	// RecoveryPoint - recovery point SEI of the access unit, nil if absent
+	RecoveryPoint *RecoveryPoint
+}
+
+// IsSync - can decoding start at the access unit with all following pictures
+// in output order correct
+// This holds for IDR pictures and for intra pictures carrying a recovery point
+// SEI with recovery_frame_cnt 0, as used for open-GOP encodes.
+func (r *RandomAccess) IsSync() bool {
+	if r.IDR {
+		return true
+	}
+	return r.IntraOnly && r.RecoveryPoint != nil && r.RecoveryPoint.RecoveryFrameCnt == 0
+}
+
+// IsGradualDecodingRefresh - recovery point SEI with a recovery_frame_cnt
+// above 0, where output is only correct after that many frames
+// Such access units belong in a roll recovery sample group rather than being
+// sync samples.
+func (r *RandomAccess) IsGradualDecodingRefresh() bool {
+	return r.RecoveryPoint != nil && r.RecoveryPoint.RecoveryFrameCnt > 0
+}
+
+// IsIDR - is NAL unit an IDR slice
+func IsIDR(nalu []byte) bool {
+	return len(nalu) > 0 && GetNaluType(nalu[0]) == NALU_IDR
+}
+
+// AnalyzeRandomAccess - determine random access properties of the NAL units of an access unit
+func AnalyzeRandomAccess(nalus [][]byte) (RandomAccess, error) {
+	var ra RandomAccess
+	slices := 0
+	intra := 0
+	for _, nalu := range nalus {
+		if len(nalu) == 0 {
+			continue
+		}
+		switch GetNaluType(nalu[0]) {
+		case NALU_IDR:
+			ra.IDR = true
+			fallthrough
+		case NALU_NON_IDR:
+			slices++
+			if sliceType, ok := peekSliceType(nalu); ok && (sliceType.Base() == SLICE_I || sliceType.Base() == SLICE_SI) {
+				intra++
+			}
+		case NALU_SEI:
+			if ra.RecoveryPoint != nil {
+				continue
+			}
+			msgs, err := ParseSEINALUnit(nalu)
+			if err != nil {
+				return ra, err
+			}
+			for _, msg := range msgs {
+				if msg.PayloadType == sei.SEI_RECOVERY_POINT {
+					if ra.RecoveryPoint, err = ParseRecoveryPoint(msg.Payload); err != nil {
+						return ra, err
+					}
+					break
+				}
+			}
+		}
+	}
+	ra.IntraOnly = slices > 0 && intra == slices
+	return ra, nil
+}
+
+// IsRandomAccessUnit - classify the NAL units of an access unit as a sync sample
+func IsRandomAccessUnit(nalus [][]byte) bool {
+	ra, err := AnalyzeRandomAccess(nalus)
+	if err != nil {
+		return false
+	}
+	return ra.IsSync()
+}
+
+// peekSliceType - read slice_type, which does not depend on parameter sets
+func peekSliceType(nalu []byte) (SliceType, bool) {
+	r := bits.NewAccErrEBSPReader(bytes.NewReader(nalu[1:]))
+	_ = r.ReadExpGolomb() // first_mb_in_slice
+	sliceType := SliceType(r.ReadExpGolomb())
+	return sliceType, r.AccError() == nil && sliceType <= 9
+}
@@ -0,0 +1,127 @@
+package avc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrRecordArenaFull - a record has more parameter sets than the arena can hold
+var ErrRecordArenaFull = errors.New("record arena full")
+
+// RecordArena - caller-owned storage for the parameter set entries of records
+// A record holds at most 31 SPS, 255 PPS and 255 SPS extensions, so
+// NewRecordArena(255) can hold any record, in about 18 KiB on 64-bit
+// platforms. Smaller limits make RecordReadArena fail with
+// ErrRecordArenaFull instead of growing.
+type RecordArena struct {
+	sps  []AVCSequenceParameterSet
+	pps  []AVCPictureParameterSet
+	spse []AVCSequenceParameterSetExt
+}
+
+// NewRecordArena - arena for records with at most maxParameterSets entries of each kind
+func NewRecordArena(maxParameterSets int) *RecordArena {
+	return &RecordArena{
+		sps:  make([]AVCSequenceParameterSet, 0, maxParameterSets),
+		pps:  make([]AVCPictureParameterSet, 0, maxParameterSets),
+		spse: make([]AVCSequenceParameterSetExt, 0, maxParameterSets),
+	}
+}
+
+// Reset - make all entries available again
+// Records previously read into the arena must not be used afterwards.
+func (a *RecordArena) Reset() {
+	a.sps = a.sps[:0]
+	a.pps = a.pps[:0]
+	a.spse = a.spse[:0]
+}
+
+// RecordReadArena - decode the record in data without allocating, taking its entries from arena
+// NAL units alias data, which must not be modified while the record is in use.
+func (b *AVCDecoderConfigurationRecord) RecordReadArena(data []byte, arena *RecordArena) (err error) {
+	if len(data) < 6 {
+		return io.ErrUnexpectedEOF
+	}
+	b.ConfigurationVersion = data[0]
+	b.AVCProfileIndication = data[1]
+	b.ProfileCompatibility = data[2]
+	b.AVCLevelIndication = data[3]
+	b.LengthSizeMinusOne = data[4] & 0b11
+	b.debugHeader(data[4], data[5])
+	numOfSequenceParameterSets := int(data[5] & 0b11111)
+	pos := 6
+
+	// nextNALUnit - next length prefixed NAL unit, aliasing data
+	nextNALUnit := func() ([]byte, error) {
+		if len(data)-pos < 2 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		length := int(binary.BigEndian.Uint16(data[pos:]))
+		pos += 2
+		if len(data)-pos < length {
+			return nil, io.ErrUnexpectedEOF
+		}
+		nalu := data[pos : pos+length : pos+length]
+		pos += length
+		return nalu, nil
+	}
+
+	if cap(arena.sps)-len(arena.sps) < numOfSequenceParameterSets {
+		return fmt.Errorf("%w: %d SPS", ErrRecordArenaFull, numOfSequenceParameterSets)
+	}
+	start := len(arena.sps)
+	for i := 0; i < numOfSequenceParameterSets; i++ {
+		nalu, err := nextNALUnit()
+		if err != nil {
+			return err
+		}
+		arena.sps = append(arena.sps, AVCSequenceParameterSet{NALUnit: nalu})
+	}
+	b.SequenceParameterSets = arena.sps[start:len(arena.sps):len(arena.sps)]
+
+	if len(data)-pos < 1 {
+		return io.ErrUnexpectedEOF
+	}
+	numOfPictureParameterSets := int(data[pos])
+	pos++
+	if cap(arena.pps)-len(arena.pps) < numOfPictureParameterSets {
+		return fmt.Errorf("%w: %d PPS", ErrRecordArenaFull, numOfPictureParameterSets)
+	}
+	start = len(arena.pps)
+	for i := 0; i < numOfPictureParameterSets; i++ {
+		nalu, err := nextNALUnit()
+		if err != nil {
+			return err
+		}
+		arena.pps = append(arena.pps, AVCPictureParameterSet{NALUnit: nalu})
+	}
+	b.PictureParameterSets = arena.pps[start:len(arena.pps):len(arena.pps)]
+
+	b.ChromaFormat, b.BitDepthLumaMinus8, b.BitDepthChromaMinus8 = 0, 0, 0
+	b.SequenceParameterSetExts = nil
+	if Profile(b.AVCProfileIndication).HasRecordChromaInfo() {
+		if len(data)-pos < 4 {
+			return io.ErrUnexpectedEOF
+		}
+		b.ChromaFormat = data[pos] & 0b11
+		b.BitDepthLumaMinus8 = data[pos+1] & 0b111
+		b.BitDepthChromaMinus8 = data[pos+2] & 0b111
+		numOfSequenceParameterSetExt := int(data[pos+3])
+		pos += 4
+		if cap(arena.spse)-len(arena.spse) < numOfSequenceParameterSetExt {
+			return fmt.Errorf("%w: %d SPS extensions", ErrRecordArenaFull, numOfSequenceParameterSetExt)
+		}
+		start = len(arena.spse)
+		for i := 0; i < numOfSequenceParameterSetExt; i++ {
+			nalu, err := nextNALUnit()
+			if err != nil {
+				return err
+			}
+			arena.spse = append(arena.spse, AVCSequenceParameterSetExt{NALUnit: nalu})
+		}
+		b.SequenceParameterSetExts = arena.spse[start:len(arena.spse):len(arena.spse)]
+	}
+	return nil
+}
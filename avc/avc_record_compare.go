@@ -0,0 +1,110 @@
+package avc
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// RecordDifference - one difference between two configuration records
+type RecordDifference struct {
+	// Field - record field or parameter set, e.g. AVCLevelIndication or SPS 0
+	Field string
+	A, B  string
+	// Blocking - the difference prevents sharing one sample entry. Non-blocking
+	// differences, such as a parameter set only present in one record, can be
+	// resolved by merging the records.
+	Blocking bool
+}
+
+func (d RecordDifference) String() string {
+	return fmt.Sprintf("%s: %s != %s", d.Field, d.A, d.B)
+}
+
+// RecordComparison - structured difference of two configuration records
+type RecordComparison struct {
+	Differences []RecordDifference
+}
+
+// Compatible - can streams of both records share a single sample entry
+func (c *RecordComparison) Compatible() bool {
+	for _, d := range c.Differences {
+		if d.Blocking {
+			return false
+		}
+	}
+	return true
+}
+
+// Compare - check whether streams described by b and other are compatible
+// Profile, level, profile compatibility, chroma format, bit depths and NAL unit
+// length size must match, and parameter sets with the same id must be
+// identical.
+func (b *AVCDecoderConfigurationRecord) Compare(other *AVCDecoderConfigurationRecord) *RecordComparison {
+	c := &RecordComparison{}
+	field := func(name string, a, o uint8) {
+		if a != o {
+			c.Differences = append(c.Differences, RecordDifference{name, fmt.Sprint(a), fmt.Sprint(o), true})
+		}
+	}
+	field("AVCProfileIndication", b.AVCProfileIndication, other.AVCProfileIndication)
+	field("ProfileCompatibility", b.ProfileCompatibility, other.ProfileCompatibility)
+	field("AVCLevelIndication", b.AVCLevelIndication, other.AVCLevelIndication)
+	field("LengthSizeMinusOne", b.LengthSizeMinusOne, other.LengthSizeMinusOne)
+	field("ChromaFormat", b.ChromaFormat, other.ChromaFormat)
+	field("BitDepthLumaMinus8", b.BitDepthLumaMinus8, other.BitDepthLumaMinus8)
+	field("BitDepthChromaMinus8", b.BitDepthChromaMinus8, other.BitDepthChromaMinus8)
+
+	setsA := b.parameterSetsByID(c, "A")
+	setsB := other.parameterSetsByID(c, "B")
+	c.compareParameterSets("SPS", setsA.sps, setsB.sps)
+	c.compareParameterSets("PPS", setsA.pps, setsB.pps)
+	return c
+}
+
+// recordParameterSets - NAL units of a record by parameter set id
+type recordParameterSets struct {
+	sps map[byte][]byte
+	pps map[byte][]byte
+}
+
+// parameterSetsByID - index the record's parameter sets, reporting unparsable ones
+func (b *AVCDecoderConfigurationRecord) parameterSetsByID(c *RecordComparison, side string) (sets recordParameterSets) {
+	sets = recordParameterSets{sps: make(map[byte][]byte), pps: make(map[byte][]byte)}
+	spsMap := make(map[byte]*SPS)
+	for i, ps := range b.SequenceParameterSets {
+		sps, err := ParseSPSNALUnit(ps.NALUnit)
+		if err != nil {
+			c.Differences = append(c.Differences, RecordDifference{fmt.Sprintf("%s SPS %d", side, i), err.Error(), "", true})
+			continue
+		}
+		spsMap[sps.SpsID] = sps
+		sets.sps[sps.SpsID] = ps.NALUnit
+	}
+	for i, ps := range b.PictureParameterSets {
+		pps, err := ParsePPSNALUnit(ps.NALUnit, spsMap)
+		if err != nil {
+			c.Differences = append(c.Differences, RecordDifference{fmt.Sprintf("%s PPS %d", side, i), err.Error(), "", true})
+			continue
+		}
+		sets.pps[pps.PpsID] = ps.NALUnit
+	}
+	return sets
+}
+
+// compareParameterSets - report conflicting and one-sided parameter sets in id order
+func (c *RecordComparison) compareParameterSets(kind string, a, o map[byte][]byte) {
+	for id := 0; id < 256; id++ {
+		na, okA := a[byte(id)]
+		no, okO := o[byte(id)]
+		switch {
+		case okA && okO:
+			if !bytes.Equal(na, no) {
+				c.Differences = append(c.Differences, RecordDifference{fmt.Sprintf("%s %d", kind, id), fmt.Sprintf("%x", na), fmt.Sprintf("%x", no), true})
+			}
+		case okA:
+			c.Differences = append(c.Differences, RecordDifference{fmt.Sprintf("%s %d", kind, id), fmt.Sprintf("%x", na), "absent", false})
+		case okO:
+			c.Differences = append(c.Differences, RecordDifference{fmt.Sprintf("%s %d", kind, id), "absent", fmt.Sprintf("%x", no), false})
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package avc
+
+import (
+	"errors"
+	"fmt"
+)
+
+// CheckSPSSignaling - report record fields that do not match its SPSs
+// Profile, profile compatibility, level and, for profiles with chroma info,
+// chroma format and bit depths are compared.
+func (b *AVCDecoderConfigurationRecord) CheckSPSSignaling() (issues []string, err error) {
+	return b.spsSignaling(false)
+}
+
+// FixSPSSignaling - correct the record fields from its SPSs
+// The profile and chroma info are taken from the first SPS, the
+// compatibility flags are those set by all SPSs and the level is the highest
+// of all SPSs. The returned list describes the corrections made.
+func (b *AVCDecoderConfigurationRecord) FixSPSSignaling() (fixes []string, err error) {
+	return b.spsSignaling(true)
+}
+
+func (b *AVCDecoderConfigurationRecord) spsSignaling(fix bool) (issues []string, err error) {
+	if len(b.SequenceParameterSets) == 0 {
+		return nil, errors.New("no SPS in record")
+	}
+	var first, highest *SPS
+	spsNalus := make([][]byte, 0, len(b.SequenceParameterSets))
+	for i, entry := range b.SequenceParameterSets {
+		sps, err := ParseSPSNALUnit(entry.NALUnit)
+		if err != nil {
+			return nil, fmt.Errorf("SPS %d: %w", i, err)
+		}
+		if first == nil {
+			first, highest = sps, sps
+		} else if levelIndex(sps.Level()) > levelIndex(highest.Level()) {
+			highest = sps
+		}
+		spsNalus = append(spsNalus, entry.NALUnit)
+	}
+	level := highest.LevelIndicator
+	compatibility, err := IntersectProfileCompatibility(spsNalus)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.AVCProfileIndication != first.ProfileIndicator {
+		issues = append(issues, fmt.Sprintf("profile %d, SPS has %d", b.AVCProfileIndication, first.ProfileIndicator))
+		if fix {
+			b.AVCProfileIndication = first.ProfileIndicator
+		}
+	}
+	if b.ProfileCompatibility != compatibility {
+		issues = append(issues, fmt.Sprintf("profile compatibility %#02x, SPSs have %#02x", b.ProfileCompatibility, compatibility))
+		if fix {
+			b.ProfileCompatibility = compatibility
+		}
+	}
+	if b.AVCLevelIndication != level {
+		issues = append(issues, fmt.Sprintf("level %d, SPSs signal up to %d", b.AVCLevelIndication, level))
+		if fix {
+			b.AVCLevelIndication = level
+		}
+	}
+	if !Profile(b.AVCProfileIndication).HasRecordChromaInfo() {
+		return issues, nil
+	}
+	if b.ChromaFormat != first.ChromaFormatIndicator {
+		issues = append(issues, fmt.Sprintf("chroma format %d, SPS has %d", b.ChromaFormat, first.ChromaFormatIndicator))
+		if fix {
+			b.ChromaFormat = first.ChromaFormatIndicator
+		}
+	}
+	if b.BitDepthLumaMinus8 != first.BitDepthLumaMinus8 {
+		issues = append(issues, fmt.Sprintf("luma bit depth %d, SPS has %d", b.BitDepthLumaMinus8+8, first.BitDepthLumaMinus8+8))
+		if fix {
+			b.BitDepthLumaMinus8 = first.BitDepthLumaMinus8
+		}
+	}
+	if b.BitDepthChromaMinus8 != first.BitDepthChromaMinus8 {
+		issues = append(issues, fmt.Sprintf("chroma bit depth %d, SPS has %d", b.BitDepthChromaMinus8+8, first.BitDepthChromaMinus8+8))
+		if fix {
+			b.BitDepthChromaMinus8 = first.BitDepthChromaMinus8
+		}
+	}
+	return issues, nil
+}
+
+// RaiseLevel - raise the level of the SPS to the lowest level its parameters allow
+// bitRate is the peak bit rate of the stream in bits/s, 0 if unknown; the
+// HRD bit rate is used if higher. The level is never lowered. Level 1b is
+// signalled with constraint_set3_flag for Baseline, Main and Extended.
+func (s *SPS) RaiseLevel(bitRate uint64) (changes []string, err error) {
+	req := s.LevelRequirements()
+	if bitRate > req.BitRate {
+		req.BitRate = bitRate
+	}
+	required, ok := MinLevel(req)
+	if !ok {
+		return nil, fmt.Errorf("no level supports %dx%d at %d bits/s", req.Width, req.Height, req.BitRate)
+	}
+	level := s.Level()
+	if levelIndex(level) >= levelIndex(required.LevelIndicator) {
+		return nil, nil
+	}
+	constraintSet3 := byte(0x10)
+	switch Profile(s.ProfileIndicator) {
+	case PROFILE_BASELINE, PROFILE_MAIN, PROFILE_EXTENDED:
+		if level == LEVEL_1B {
+			s.ProfileCompatibility &^= constraintSet3
+		}
+		if required.LevelIndicator == LEVEL_1B {
+			s.LevelIndicator = LEVEL_1_1
+			s.ProfileCompatibility |= constraintSet3
+			break
+		}
+		s.LevelIndicator = required.LevelIndicator
+	default:
+		s.LevelIndicator = required.LevelIndicator
+	}
+	return []string{fmt.Sprintf("level %s to %s", LevelName(level), LevelName(required.LevelIndicator))}, nil
+}
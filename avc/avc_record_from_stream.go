@@ -0,0 +1,133 @@
+package avc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/go-webdl/bits"
+	"github.com/go-webdl/media-codec/nalu"
+)
+
+// CreateAVCDecoderConfigurationRecordFromStream - build a record covering
+// all parameter sets of an Annex B elementary stream
+// The whole stream is scanned first and every distinct SPS and PPS is
+// collected. A parameter set id reused with different content, SPSs
+// disagreeing on profile, chroma format or bit depths, and PPSs referring to
+// missing SPSs are errors since no single record can describe them. The
+// profile compatibility flags are the intersection over all SPSs and the level
+// is raised to the highest signalled by an SPS or required by its parameters.
+func CreateAVCDecoderConfigurationRecordFromStream(r io.Reader) (AVCDecoderConfigurationRecord, error) {
+	spsNalus := make(map[byte][]byte)
+	ppsNalus := make(map[byte][]byte)
+	s := nalu.NewScanner(r)
+	for s.Scan() {
+		data := s.NALU()
+		if len(data) == 0 {
+			continue
+		}
+		var sets map[byte][]byte
+		var id byte
+		switch GetNaluType(data[0]) {
+		case NALU_SPS:
+			sps, err := ParseSPSNALUnit(data)
+			if err != nil {
+				return AVCDecoderConfigurationRecord{}, err
+			}
+			sets, id = spsNalus, sps.SpsID
+		case NALU_PPS:
+			// the SPS may follow later in the stream, so only the id is read here
+			id = ppsID(data)
+			sets = ppsNalus
+		default:
+			continue
+		}
+		if existing, ok := sets[id]; ok {
+			if !bytes.Equal(existing, data) {
+				return AVCDecoderConfigurationRecord{}, fmt.Errorf("%s %d changes within the stream", GetNaluType(data[0]), id)
+			}
+			continue
+		}
+		sets[id] = append([]byte(nil), data...)
+	}
+	if err := s.Err(); err != nil {
+		return AVCDecoderConfigurationRecord{}, err
+	}
+	if len(spsNalus) == 0 {
+		return AVCDecoderConfigurationRecord{}, errors.New("no SPS NAL units")
+	}
+	if len(ppsNalus) == 0 {
+		return AVCDecoderConfigurationRecord{}, errors.New("no PPS NAL units")
+	}
+
+	var spss, ppss [][]byte
+	for _, id := range sortedParameterSetIDs(spsNalus) {
+		spss = append(spss, spsNalus[id])
+	}
+	for _, id := range sortedParameterSetIDs(ppsNalus) {
+		ppss = append(ppss, ppsNalus[id])
+	}
+	rec, err := CreateAVCDecoderConfigurationRecord(spss, ppss)
+	if err != nil {
+		return AVCDecoderConfigurationRecord{}, err
+	}
+
+	spsMap := make(map[byte]*SPS)
+	var level byte
+	for _, data := range spss {
+		sps, err := ParseSPSNALUnit(data)
+		if err != nil {
+			return AVCDecoderConfigurationRecord{}, err
+		}
+		spsMap[sps.SpsID] = sps
+		if sps.ProfileIndicator != rec.AVCProfileIndication {
+			return AVCDecoderConfigurationRecord{}, fmt.Errorf("SPS %d has profile %s, first SPS has %s",
+				sps.SpsID, Profile(sps.ProfileIndicator), Profile(rec.AVCProfileIndication))
+		}
+		if sps.ChromaFormatIndicator != rec.ChromaFormat ||
+			sps.BitDepthLumaMinus8 != rec.BitDepthLumaMinus8 || sps.BitDepthChromaMinus8 != rec.BitDepthChromaMinus8 {
+			return AVCDecoderConfigurationRecord{}, fmt.Errorf("SPS %d has chroma format %d and bit depths %d/%d, record has %d and %d/%d",
+				sps.SpsID, sps.ChromaFormatIndicator, sps.BitDepthLumaMinus8+8, sps.BitDepthChromaMinus8+8,
+				rec.ChromaFormat, rec.BitDepthLumaMinus8+8, rec.BitDepthChromaMinus8+8)
+		}
+		if levelIndex(sps.Level()) > levelIndex(level) {
+			level = sps.Level()
+		}
+		if required, ok := MinLevel(sps.LevelRequirements()); ok && levelIndex(required.LevelIndicator) > levelIndex(level) {
+			level = required.LevelIndicator
+		}
+	}
+	for _, data := range ppss {
+		if _, err := ParsePPSNALUnit(data, spsMap); err != nil {
+			return AVCDecoderConfigurationRecord{}, err
+		}
+	}
+	rec.setLevel(level)
+	return rec, nil
+}
+
+// setLevel - set AVCLevelIndication, signalling level 1b as the profile requires
+// Baseline, Main and Extended signal level 1b as level_idc 11 with
+// constraint_set3_flag set, other profiles as level_idc 9.
+func (b *AVCDecoderConfigurationRecord) setLevel(level byte) {
+	switch Profile(b.AVCProfileIndication) {
+	case PROFILE_BASELINE, PROFILE_MAIN, PROFILE_EXTENDED:
+		switch level {
+		case LEVEL_1B:
+			b.AVCLevelIndication = LEVEL_1_1
+			b.ProfileCompatibility |= 0x10
+			return
+		case LEVEL_1_1:
+			b.ProfileCompatibility &^= 0x10
+		}
+	}
+	b.AVCLevelIndication = level
+}
+
+// ppsID - pic_parameter_set_id of a PPS NAL unit
+// The rest of the PPS can only be parsed with its SPS at hand.
+func ppsID(data []byte) byte {
+	r := bits.NewAccErrEBSPReader(bytes.NewReader(data[1:]))
+	return byte(r.ReadExpGolomb())
+}
@@ -0,0 +1,146 @@
+package avc
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// ParameterSetUpdate - result of merging in-band parameter sets into a record
+type ParameterSetUpdate struct {
+	// Added - parameter set NAL units added to the record
+	Added [][]byte
+	// Duplicates - number of parameter sets already in the record byte by byte
+	Duplicates int
+	// Incompatible - changes the record cannot describe, e.g. a parameter set
+	// replacing one with the same id or an SPS of another profile. A new
+	// sample entry with its own record is needed for the samples using them.
+	Incompatible []string
+}
+
+// NeedsNewSampleEntry - the parameter sets cannot be covered by the record
+func (u *ParameterSetUpdate) NeedsNewSampleEntry() bool {
+	return len(u.Incompatible) > 0
+}
+
+// UpdateParameterSets - merge in-band SPS and PPS NAL units into the record
+// Parameter sets identical to one in the record are skipped, new ones are
+// added and both lists are ordered by ascending parameter set id as required
+// by ISO/IEC 14496-15. Other NAL unit types are ignored. If any change is
+// incompatible, the record is left unmodified and the changes are listed in
+// the result.
+func (b *AVCDecoderConfigurationRecord) UpdateParameterSets(nalus [][]byte) (*ParameterSetUpdate, error) {
+	u := &ParameterSetUpdate{}
+	spsMap := make(map[byte]*SPS)
+	spsNalus := make(map[byte][]byte)
+	for i, ps := range b.SequenceParameterSets {
+		sps, err := ParseSPSNALUnit(ps.NALUnit)
+		if err != nil {
+			return nil, fmt.Errorf("record SPS %d: %w", i, err)
+		}
+		spsMap[sps.SpsID] = sps
+		spsNalus[sps.SpsID] = ps.NALUnit
+	}
+	ppsNalus := make(map[byte][]byte)
+	for i, ps := range b.PictureParameterSets {
+		pps, err := ParsePPSNALUnit(ps.NALUnit, spsMap)
+		if err != nil {
+			return nil, fmt.Errorf("record PPS %d: %w", i, err)
+		}
+		ppsNalus[pps.PpsID] = ps.NALUnit
+	}
+
+	// SPSs first so that PPSs referring to new SPSs can be parsed
+	for _, nalu := range nalus {
+		if len(nalu) == 0 || GetNaluType(nalu[0]) != NALU_SPS {
+			continue
+		}
+		sps, err := ParseSPSNALUnit(nalu)
+		if err != nil {
+			return nil, err
+		}
+		if existing, ok := spsNalus[sps.SpsID]; ok {
+			if bytes.Equal(existing, nalu) {
+				u.Duplicates++
+			} else {
+				u.Incompatible = append(u.Incompatible, fmt.Sprintf("SPS %d changed", sps.SpsID))
+			}
+			continue
+		}
+		u.Incompatible = append(u.Incompatible, b.incompatibleSPS(sps)...)
+		spsMap[sps.SpsID] = sps
+		spsNalus[sps.SpsID] = nalu
+		u.Added = append(u.Added, nalu)
+	}
+	for _, nalu := range nalus {
+		if len(nalu) == 0 || GetNaluType(nalu[0]) != NALU_PPS {
+			continue
+		}
+		pps, err := ParsePPSNALUnit(nalu, spsMap)
+		if err != nil {
+			return nil, err
+		}
+		if existing, ok := ppsNalus[pps.PpsID]; ok {
+			if bytes.Equal(existing, nalu) {
+				u.Duplicates++
+			} else {
+				u.Incompatible = append(u.Incompatible, fmt.Sprintf("PPS %d changed", pps.PpsID))
+			}
+			continue
+		}
+		ppsNalus[pps.PpsID] = nalu
+		u.Added = append(u.Added, nalu)
+	}
+	if len(spsNalus) > 31 {
+		u.Incompatible = append(u.Incompatible, fmt.Sprintf("%d SPSs exceed the record limit of 31", len(spsNalus)))
+	}
+	if len(ppsNalus) > 255 {
+		u.Incompatible = append(u.Incompatible, fmt.Sprintf("%d PPSs exceed the record limit of 255", len(ppsNalus)))
+	}
+	if u.NeedsNewSampleEntry() {
+		return u, nil
+	}
+
+	b.SequenceParameterSets = b.SequenceParameterSets[:0]
+	for _, id := range sortedParameterSetIDs(spsNalus) {
+		b.SequenceParameterSets = append(b.SequenceParameterSets, AVCSequenceParameterSet{NALUnit: spsNalus[id]})
+	}
+	b.PictureParameterSets = b.PictureParameterSets[:0]
+	for _, id := range sortedParameterSetIDs(ppsNalus) {
+		b.PictureParameterSets = append(b.PictureParameterSets, AVCPictureParameterSet{NALUnit: ppsNalus[id]})
+	}
+	return u, nil
+}
+
+// incompatibleSPS - fields of a new SPS that differ from what the record signals
+func (b *AVCDecoderConfigurationRecord) incompatibleSPS(sps *SPS) (issues []string) {
+	if sps.ProfileIndicator != b.AVCProfileIndication {
+		issues = append(issues, fmt.Sprintf("SPS %d: profile %s, record has %s", sps.SpsID, Profile(sps.ProfileIndicator), Profile(b.AVCProfileIndication)))
+	}
+	if b.ProfileCompatibility&^sps.ProfileCompatibility != 0 {
+		issues = append(issues, fmt.Sprintf("SPS %d: profile compatibility %#02x lacks constraint flags %#02x of the record", sps.SpsID, sps.ProfileCompatibility, b.ProfileCompatibility))
+	}
+	if levelIndex(sps.Level()) > levelIndex(b.AVCLevelIndication) {
+		issues = append(issues, fmt.Sprintf("SPS %d: level %d above record level %d", sps.SpsID, sps.LevelIndicator, b.AVCLevelIndication))
+	}
+	if Profile(b.AVCProfileIndication).HasRecordChromaInfo() {
+		if sps.ChromaFormatIndicator != b.ChromaFormat {
+			issues = append(issues, fmt.Sprintf("SPS %d: chroma format %d, record has %d", sps.SpsID, sps.ChromaFormatIndicator, b.ChromaFormat))
+		}
+		if sps.BitDepthLumaMinus8 != b.BitDepthLumaMinus8 || sps.BitDepthChromaMinus8 != b.BitDepthChromaMinus8 {
+			issues = append(issues, fmt.Sprintf("SPS %d: bit depths %d/%d, record has %d/%d", sps.SpsID,
+				sps.BitDepthLumaMinus8+8, sps.BitDepthChromaMinus8+8, b.BitDepthLumaMinus8+8, b.BitDepthChromaMinus8+8))
+		}
+	}
+	return issues
+}
+
+// sortedParameterSetIDs - ids of the parameter sets in ascending order
+func sortedParameterSetIDs(sets map[byte][]byte) []byte {
+	ids := make([]byte, 0, len(sets))
+	for id := range sets {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
@@ -0,0 +1,262 @@
+package avc
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-webdl/bits"
+)
+
+// RefPicListModification - one modification_of_pic_nums_idc operation of ref_pic_list_modification
+// ISO/IEC 14496-10 Sec. 7.3.3.1
+type RefPicListModification struct {
+	// ModificationOfPicNumsIdc - 0 and 1 subtract and add AbsDiffPicNumMinus1+1
+	// to a short-term picture number, 2 selects a long-term picture
+	ModificationOfPicNumsIdc byte
+	AbsDiffPicNumMinus1      uint32
+	LongTermPicNum           uint32
+}
+
+// MMCO - memory_management_control_operation according to ISO/IEC 14496-10 Table 7-9
+type MMCO byte
+
+const (
+	MMCO_END = MMCO(0)
+	// MMCO_UNMARK_SHORT_TERM - mark a short-term picture as unused for reference
+	MMCO_UNMARK_SHORT_TERM = MMCO(1)
+	// MMCO_UNMARK_LONG_TERM - mark a long-term picture as unused for reference
+	MMCO_UNMARK_LONG_TERM = MMCO(2)
+	// MMCO_SHORT_TO_LONG_TERM - turn a short-term picture into a long-term one
+	MMCO_SHORT_TO_LONG_TERM = MMCO(3)
+	// MMCO_MAX_LONG_TERM_FRAME_IDX - set the maximum long-term frame index
+	MMCO_MAX_LONG_TERM_FRAME_IDX = MMCO(4)
+	// MMCO_UNMARK_ALL - mark all pictures as unused for reference and reset frame_num and POC
+	MMCO_UNMARK_ALL = MMCO(5)
+	// MMCO_CURRENT_TO_LONG_TERM - mark the current picture as long-term
+	MMCO_CURRENT_TO_LONG_TERM = MMCO(6)
+)
+
+func (m MMCO) String() string {
+	switch m {
+	case MMCO_END:
+		return "End"
+	case MMCO_UNMARK_SHORT_TERM:
+		return "UnmarkShortTerm"
+	case MMCO_UNMARK_LONG_TERM:
+		return "UnmarkLongTerm"
+	case MMCO_SHORT_TO_LONG_TERM:
+		return "ShortToLongTerm"
+	case MMCO_MAX_LONG_TERM_FRAME_IDX:
+		return "MaxLongTermFrameIdx"
+	case MMCO_UNMARK_ALL:
+		return "UnmarkAll"
+	case MMCO_CURRENT_TO_LONG_TERM:
+		return "CurrentToLongTerm"
+	default:
+		return fmt.Sprintf("Other_%d", m)
+	}
+}
+
+// MemoryManagementOperation - one operation of adaptive reference picture marking
+// Only the fields used by the operation are set.
+type MemoryManagementOperation struct {
+	Operation                 MMCO
+	DifferenceOfPicNumsMinus1 uint32
+	LongTermPicNum            uint32
+	LongTermFrameIdx          uint32
+	MaxLongTermFrameIdxPlus1  uint32
+}
+
+// DecRefPicMarking - dec_ref_pic_marking of a reference picture
+// ISO/IEC 14496-10 Sec. 7.3.3.3
+type DecRefPicMarking struct {
+	// NoOutputOfPriorPicsFlag, LongTermReferenceFlag - IDR pictures only
+	NoOutputOfPriorPicsFlag bool
+	LongTermReferenceFlag   bool
+	// AdaptiveRefPicMarkingModeFlag - non-IDR pictures only, sliding window marking if false
+	AdaptiveRefPicMarkingModeFlag bool
+	// Operations - MMCOs without the terminating MMCO_END
+	Operations []MemoryManagementOperation
+}
+
+// maxRefPicOperations - bound for operation loops of corrupt slice headers
+// Lists hold at most 32 entries, and no more than one MMCO per entry of the
+// DPB and per long-term index makes sense.
+const maxRefPicOperations = 66
+
+func readRefPicListModification(r *bits.AccErrEBSPReader) (mods []RefPicListModification, err error) {
+	if !r.ReadFlag() {
+		return nil, r.AccError()
+	}
+	for {
+		idc := r.ReadExpGolomb()
+		if err := r.AccError(); err != nil {
+			return nil, err
+		}
+		if idc == 3 {
+			return mods, nil
+		}
+		if idc > 3 {
+			return nil, fmt.Errorf("modification_of_pic_nums_idc %d out of range", idc)
+		}
+		if len(mods) == maxRefPicOperations {
+			return nil, errors.New("too many ref_pic_list_modification operations")
+		}
+		mod := RefPicListModification{ModificationOfPicNumsIdc: byte(idc)}
+		if idc == 2 {
+			mod.LongTermPicNum = uint32(r.ReadExpGolomb())
+		} else {
+			mod.AbsDiffPicNumMinus1 = uint32(r.ReadExpGolomb())
+		}
+		mods = append(mods, mod)
+	}
+}
+
+// skipPredWeightTable - Sec. 7.3.3.2
+func skipPredWeightTable(r *bits.AccErrEBSPReader, sh *SliceHeader, sps *SPS) {
+	chromaArrayType := sps.ChromaFormatIndicator
+	if sps.SeparateColourPlaneFlag {
+		chromaArrayType = 0
+	}
+	r.ReadExpGolomb() // luma_log2_weight_denom
+	if chromaArrayType != 0 {
+		r.ReadExpGolomb() // chroma_log2_weight_denom
+	}
+	lists := []byte{sh.NumRefIdxL0ActiveMinus1}
+	if sh.SliceType.Base() == SLICE_B {
+		lists = append(lists, sh.NumRefIdxL1ActiveMinus1)
+	}
+	for _, numRefIdxActiveMinus1 := range lists {
+		for i := 0; i <= int(numRefIdxActiveMinus1); i++ {
+			if r.ReadFlag() { // luma_weight_flag
+				r.ReadSignedGolomb()
+				r.ReadSignedGolomb()
+			}
+			if chromaArrayType != 0 && r.ReadFlag() { // chroma_weight_flag
+				for j := 0; j < 4; j++ {
+					r.ReadSignedGolomb()
+				}
+			}
+		}
+	}
+}
+
+func readDecRefPicMarking(r *bits.AccErrEBSPReader, idr bool) (m DecRefPicMarking, err error) {
+	if idr {
+		m.NoOutputOfPriorPicsFlag = r.ReadFlag()
+		m.LongTermReferenceFlag = r.ReadFlag()
+		return m, r.AccError()
+	}
+	m.AdaptiveRefPicMarkingModeFlag = r.ReadFlag()
+	if !m.AdaptiveRefPicMarkingModeFlag {
+		return m, r.AccError()
+	}
+	for {
+		op := r.ReadExpGolomb()
+		if err := r.AccError(); err != nil {
+			return m, err
+		}
+		if op == uint(MMCO_END) {
+			return m, nil
+		}
+		if op > uint(MMCO_CURRENT_TO_LONG_TERM) {
+			return m, fmt.Errorf("memory_management_control_operation %d out of range", op)
+		}
+		if len(m.Operations) == maxRefPicOperations {
+			return m, errors.New("too many memory_management_control_operations")
+		}
+		mmo := MemoryManagementOperation{Operation: MMCO(op)}
+		switch mmo.Operation {
+		case MMCO_UNMARK_SHORT_TERM:
+			mmo.DifferenceOfPicNumsMinus1 = uint32(r.ReadExpGolomb())
+		case MMCO_UNMARK_LONG_TERM:
+			mmo.LongTermPicNum = uint32(r.ReadExpGolomb())
+		case MMCO_SHORT_TO_LONG_TERM:
+			mmo.DifferenceOfPicNumsMinus1 = uint32(r.ReadExpGolomb())
+			mmo.LongTermFrameIdx = uint32(r.ReadExpGolomb())
+		case MMCO_MAX_LONG_TERM_FRAME_IDX:
+			mmo.MaxLongTermFrameIdxPlus1 = uint32(r.ReadExpGolomb())
+		case MMCO_CURRENT_TO_LONG_TERM:
+			mmo.LongTermFrameIdx = uint32(r.ReadExpGolomb())
+		}
+		m.Operations = append(m.Operations, mmo)
+	}
+}
+
+// HasMMCO - does the slice carry memory_management_control_operation op
+func (s *SliceHeader) HasMMCO(op MMCO) bool {
+	for _, mmo := range s.DecRefPicMarking.Operations {
+		if mmo.Operation == op {
+			return true
+		}
+	}
+	return false
+}
+
+// MarksLongTerm - the picture is marked as long-term reference
+// by long_term_reference_flag of an IDR picture or MMCO_CURRENT_TO_LONG_TERM
+func (s *SliceHeader) MarksLongTerm() bool {
+	return s.DecRefPicMarking.LongTermReferenceFlag || s.HasMMCO(MMCO_CURRENT_TO_LONG_TERM)
+}
+
+// RefersToLongTerm - a reference picture list of the slice is modified to use a long-term picture
+func (s *SliceHeader) RefersToLongTerm() bool {
+	for _, mods := range [][]RefPicListModification{s.RefPicListModificationL0, s.RefPicListModificationL1} {
+		for _, mod := range mods {
+			if mod.ModificationOfPicNumsIdc == 2 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PictureReferences - reference picture usage of a coded picture, from all its slices
+type PictureReferences struct {
+	// Reference - nal_ref_idc is not 0, later pictures may predict from the picture
+	Reference bool
+	// LongTerm - the picture is marked as long-term reference
+	LongTerm bool
+	// ConvertsToLongTerm - an earlier short-term picture is made long-term by MMCO_SHORT_TO_LONG_TERM
+	ConvertsToLongTerm bool
+	// UsesLongTerm - the picture predicts from a long-term picture
+	UsesLongTerm bool
+	// ClearsReferences - all earlier pictures are unused for reference afterwards, by IDR or MMCO_UNMARK_ALL
+	ClearsReferences bool
+}
+
+// Discardable - the picture can be dropped without affecting other pictures
+func (p *PictureReferences) Discardable() bool {
+	return !p.Reference
+}
+
+// AnalyzeReferences - reference picture usage of the picture of an access unit
+// spsMap and ppsMap are indexed by parameter set id. Redundant slices are
+// ignored. Long-term pictures may be referenced across sync points, so a
+// sync point is a safe place to drop all earlier pictures for trick play
+// only if no LongTerm or ConvertsToLongTerm picture precedes it since the
+// last ClearsReferences picture.
+func AnalyzeReferences(nalus [][]byte, spsMap map[byte]*SPS, ppsMap map[byte]*PPS) (refs PictureReferences, err error) {
+	for _, nalu := range nalus {
+		if len(nalu) == 0 {
+			continue
+		}
+		naluType := GetNaluType(nalu[0])
+		if naluType != NALU_NON_IDR && naluType != NALU_IDR {
+			continue
+		}
+		sh, err := ParseSliceHeader(nalu, spsMap, ppsMap)
+		if err != nil {
+			return refs, err
+		}
+		if sh.RedundantPicCnt > 0 {
+			continue
+		}
+		refs.Reference = refs.Reference || sh.NalRefIdc != 0
+		refs.LongTerm = refs.LongTerm || sh.MarksLongTerm()
+		refs.ConvertsToLongTerm = refs.ConvertsToLongTerm || sh.HasMMCO(MMCO_SHORT_TO_LONG_TERM)
+		refs.UsesLongTerm = refs.UsesLongTerm || sh.RefersToLongTerm()
+		refs.ClearsReferences = refs.ClearsReferences || sh.NaluType == NALU_IDR || sh.HasMMCO(MMCO_UNMARK_ALL)
+	}
+	return refs, nil
+}
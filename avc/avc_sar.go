@@ -0,0 +1,79 @@
+package avc
+
+import (
+	"errors"
+
+	"github.com/go-webdl/media-codec/sar"
+)
+
+// EXTENDED_SAR - aspect_ratio_idc signalling sar_width and sar_height explicitly
+const EXTENDED_SAR = sar.EXTENDED_SAR
+
+// SAR - sample aspect ratio signalled in the VUI
+// 0:0 is returned if the aspect ratio is unspecified or uses a reserved aspect_ratio_idc.
+func (v *VUIParameters) SAR() (width, height uint16) {
+	if !v.AspectRatioInfoPresentFlag {
+		return 0, 0
+	}
+	return sar.FromIdc(v.AspectRatioIndicator, v.SarWidth, v.SarHeight)
+}
+
+// SetSAR - signal the sample aspect ratio width:height
+// A table entry is used if there is one, else Extended_SAR. 0:0 removes the
+// aspect ratio info, leaving it unspecified.
+func (v *VUIParameters) SetSAR(width, height uint16) {
+	if width == 0 || height == 0 {
+		v.AspectRatioInfoPresentFlag = false
+		v.AspectRatioIndicator, v.SarWidth, v.SarHeight = 0, 0, 0
+		return
+	}
+	v.AspectRatioInfoPresentFlag = true
+	v.AspectRatioIndicator, v.SarWidth, v.SarHeight = sar.Idc(width, height)
+}
+
+// SAR - sample aspect ratio, 1:1 if not signalled
+func (s *SPS) SAR() (width, height uint16) {
+	if s.VUIParametersPresentFlag {
+		if width, height = s.VUI.SAR(); width != 0 {
+			return width, height
+		}
+	}
+	return 1, 1
+}
+
+// SetSAR - signal the sample aspect ratio width:height, adding VUI if needed
+func (s *SPS) SetSAR(width, height uint16) {
+	s.VUIParametersPresentFlag = true
+	s.VUI.SetSAR(width, height)
+}
+
+// DisplayAspectRatio - aspect ratio of the cropped picture scaled by the SAR,
+// reduced to lowest terms, e.g. 16:9
+func (s *SPS) DisplayAspectRatio() (width, height uint32) {
+	sarWidth, sarHeight := s.SAR()
+	imageWidth, imageHeight := s.ImageSize()
+	return sar.DisplayAspectRatio(imageWidth, imageHeight, sarWidth, sarHeight)
+}
+
+// DisplaySize - picture size after scaling the width by the SAR, as used for
+// the track header of an MP4 track
+func (s *SPS) DisplaySize() (width, height uint32) {
+	sarWidth, sarHeight := s.SAR()
+	imageWidth, imageHeight := s.ImageSize()
+	return sar.DisplaySize(imageWidth, imageHeight, sarWidth, sarHeight)
+}
+
+// OverrideSAR - re-serialize an SPS NAL unit with the sample aspect ratio set to width:height
+// All other fields are written back unchanged. This fixes anamorphic content
+// with a missing or wrong SAR without re-encoding.
+func OverrideSAR(spsNalu []byte, width, height uint16) ([]byte, error) {
+	if width == 0 || height == 0 {
+		return nil, errors.New("SAR must be non-zero")
+	}
+	sps, err := ParseSPSNALUnit(spsNalu)
+	if err != nil {
+		return nil, err
+	}
+	sps.SetSAR(width, height)
+	return CreateSPSNALUnit(sps)
+}
@@ -0,0 +1,368 @@
+package avc
+
+import (
+	"fmt"
+
+	"github.com/go-webdl/media-codec/nalu"
+	"github.com/go-webdl/media-codec/sei"
+)
+
+// CreateSEINALUnit - build an SEI NAL unit carrying msgs, e.g. declarative
+// user_data_unregistered messages to be stored in the parameter set arrays of
+// an AVCDecoderConfigurationRecord or inserted in-band
+func CreateSEINALUnit(msgs []sei.Message) ([]byte, error) {
+	return sei.CreateNALUnit([]byte{byte(NALU_SEI)}, msgs)
+}
+
+// ParseSEINALUnit - split an SEI NAL unit starting with NAL unit header into its messages
+func ParseSEINALUnit(data []byte) ([]sei.Message, error) {
+	if len(data) < 1 || GetNaluType(data[0]) != NALU_SEI {
+		return nil, fmt.Errorf("NALU is not SEI")
+	}
+	return sei.ParseMessages(nalu.UnescapeEBSP(data[1:]))
+}
+
+// FindEncoderSettings - x264 settings, or those of another encoder using the
+// same format, from the SEI NAL units among nalus
+// Encoders write them into the first access unit.
+func FindEncoderSettings(nalus [][]byte) (*sei.EncoderSettings, bool) {
+	for _, data := range nalus {
+		if len(data) == 0 || GetNaluType(data[0]) != NALU_SEI {
+			continue
+		}
+		msgs, err := ParseSEINALUnit(data)
+		if err != nil {
+			continue
+		}
+		if s, ok := sei.FindEncoderSettings(msgs); ok {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// RecoveryPoint - recovery point SEI message
+// ISO/IEC 14496-10 Sec. D.1.8
+type RecoveryPoint struct {
+	RecoveryFrameCnt      uint32
+	ExactMatchFlag        bool
+	BrokenLinkFlag        bool
+	ChangingSliceGroupIdc byte
+}
+
+// ParseRecoveryPoint - decode a recovery point SEI payload
+func ParseRecoveryPoint(payload []byte) (*RecoveryPoint, error) {
+	r := nalu.NewRBSPReader(payload)
+	rp := &RecoveryPoint{}
+	rp.RecoveryFrameCnt = uint32(r.ReadExpGolomb())
+	rp.ExactMatchFlag = r.ReadFlag()
+	rp.BrokenLinkFlag = r.ReadFlag()
+	rp.ChangingSliceGroupIdc = byte(r.Read(2))
+	return rp, r.AccError()
+}
+
+// BufferingPeriod - buffering period SEI message
+// ISO/IEC 14496-10 Sec. D.1.2
+type BufferingPeriod struct {
+	SpsID                byte
+	NalInitialCpbRemoval []InitialCpbRemoval
+	VclInitialCpbRemoval []InitialCpbRemoval
+}
+
+// InitialCpbRemoval - initial CPB removal delay and offset of one SchedSelIdx
+type InitialCpbRemoval struct {
+	Delay  uint32
+	Offset uint32
+}
+
+// ParseBufferingPeriod - decode a buffering period SEI payload
+// sps must be the SPS referred to by the message.
+func ParseBufferingPeriod(payload []byte, sps *SPS) (*BufferingPeriod, error) {
+	r := nalu.NewRBSPReader(payload)
+	bp := &BufferingPeriod{}
+	bp.SpsID = byte(r.ReadExpGolomb())
+	if err := r.AccError(); err != nil {
+		return nil, err
+	}
+	if bp.SpsID != sps.SpsID {
+		return nil, fmt.Errorf("buffering period refers to SPS %d, got SPS %d", bp.SpsID, sps.SpsID)
+	}
+	readInitialCpbRemovals := func(hrd *HRDParameters) (removals []InitialCpbRemoval) {
+		n := int(hrd.InitialCpbRemovalDelayLengthMinus1) + 1
+		for i := 0; i <= int(hrd.CpbCntMinus1); i++ {
+			removals = append(removals, InitialCpbRemoval{
+				Delay:  uint32(r.Read(n)),
+				Offset: uint32(r.Read(n)),
+			})
+		}
+		return removals
+	}
+	if sps.VUI.NalHrdParametersPresentFlag {
+		bp.NalInitialCpbRemoval = readInitialCpbRemovals(&sps.VUI.NalHrdParameters)
+	}
+	if sps.VUI.VclHrdParametersPresentFlag {
+		bp.VclInitialCpbRemoval = readInitialCpbRemovals(&sps.VUI.VclHrdParameters)
+	}
+	return bp, r.AccError()
+}
+
+// PicTiming - picture timing SEI message
+// ISO/IEC 14496-10 Sec. D.1.3
+type PicTiming struct {
+	CpbRemovalDelay uint32
+	DpbOutputDelay  uint32
+	// PicStruct - only valid if the SPS VUI has pic_struct_present_flag set
+	PicStruct       byte
+	ClockTimestamps []ClockTimestamp
+}
+
+// ClockTimestamp - clock timestamp of a picture timing SEI message
+// Only timestamps with clock_timestamp_flag set are listed.
+type ClockTimestamp struct {
+	CtType             byte
+	NuitFieldBasedFlag bool
+	CountingType       byte
+	FullTimestampFlag  bool
+	DiscontinuityFlag  bool
+	CntDroppedFlag     bool
+	NFrames            byte
+	SecondsValue       byte
+	MinutesValue       byte
+	HoursValue         byte
+	// SecondsFlag, MinutesFlag, HoursFlag - which values are present when
+	// FullTimestampFlag is not set
+	SecondsFlag bool
+	MinutesFlag bool
+	HoursFlag   bool
+	TimeOffset  int32
+}
+
+// numClockTS - NumClockTS per pic_struct, Table D-1
+var numClockTS = [...]int{1, 1, 1, 2, 2, 3, 3, 2, 3}
+
+// ParsePicTiming - decode a picture timing SEI payload
+// sps must be the active SPS.
+func ParsePicTiming(payload []byte, sps *SPS) (*PicTiming, error) {
+	r := nalu.NewRBSPReader(payload)
+	pt := &PicTiming{}
+	vui := &sps.VUI
+	var hrd *HRDParameters
+	switch {
+	case vui.NalHrdParametersPresentFlag:
+		hrd = &vui.NalHrdParameters
+	case vui.VclHrdParametersPresentFlag:
+		hrd = &vui.VclHrdParameters
+	}
+	if hrd != nil {
+		pt.CpbRemovalDelay = uint32(r.Read(int(hrd.CpbRemovalDelayLengthMinus1) + 1))
+		pt.DpbOutputDelay = uint32(r.Read(int(hrd.DpbOutputDelayLengthMinus1) + 1))
+	}
+	if vui.PicStructPresentFlag {
+		pt.PicStruct = byte(r.Read(4))
+		if int(pt.PicStruct) >= len(numClockTS) {
+			return nil, fmt.Errorf("pic_struct %d reserved", pt.PicStruct)
+		}
+		timeOffsetLength := 24
+		if hrd != nil {
+			timeOffsetLength = int(hrd.TimeOffsetLength)
+		}
+		for i := 0; i < numClockTS[pt.PicStruct]; i++ {
+			if !r.ReadFlag() {
+				continue
+			}
+			var ts ClockTimestamp
+			ts.CtType = byte(r.Read(2))
+			ts.NuitFieldBasedFlag = r.ReadFlag()
+			ts.CountingType = byte(r.Read(5))
+			ts.FullTimestampFlag = r.ReadFlag()
+			ts.DiscontinuityFlag = r.ReadFlag()
+			ts.CntDroppedFlag = r.ReadFlag()
+			ts.NFrames = byte(r.Read(8))
+			if ts.FullTimestampFlag {
+				ts.SecondsValue = byte(r.Read(6))
+				ts.MinutesValue = byte(r.Read(6))
+				ts.HoursValue = byte(r.Read(5))
+			} else {
+				ts.SecondsFlag = r.ReadFlag()
+				if ts.SecondsFlag {
+					ts.SecondsValue = byte(r.Read(6))
+					ts.MinutesFlag = r.ReadFlag()
+					if ts.MinutesFlag {
+						ts.MinutesValue = byte(r.Read(6))
+						ts.HoursFlag = r.ReadFlag()
+						if ts.HoursFlag {
+							ts.HoursValue = byte(r.Read(5))
+						}
+					}
+				}
+			}
+			if timeOffsetLength > 0 {
+				v := int64(r.Read(timeOffsetLength))
+				if v&(1<<(timeOffsetLength-1)) != 0 {
+					v -= 1 << timeOffsetLength
+				}
+				ts.TimeOffset = int32(v)
+			}
+			pt.ClockTimestamps = append(pt.ClockTimestamps, ts)
+		}
+	}
+	return pt, r.AccError()
+}
+
+// Message - encode the picture timing into an SEI message
+// sps must be the active SPS. Clock timestamps fill the NumClockTS slots of
+// PicStruct in order, remaining slots have clock_timestamp_flag cleared.
+func (pt *PicTiming) Message(sps *SPS) (sei.Message, error) {
+	w := nalu.NewRBSPWriter()
+	vui := &sps.VUI
+	var hrd *HRDParameters
+	switch {
+	case vui.NalHrdParametersPresentFlag:
+		hrd = &vui.NalHrdParameters
+	case vui.VclHrdParametersPresentFlag:
+		hrd = &vui.VclHrdParameters
+	}
+	if hrd != nil {
+		w.Write(uint(pt.CpbRemovalDelay), int(hrd.CpbRemovalDelayLengthMinus1)+1)
+		w.Write(uint(pt.DpbOutputDelay), int(hrd.DpbOutputDelayLengthMinus1)+1)
+	}
+	if vui.PicStructPresentFlag {
+		if int(pt.PicStruct) >= len(numClockTS) {
+			return sei.Message{}, fmt.Errorf("pic_struct %d reserved", pt.PicStruct)
+		}
+		if len(pt.ClockTimestamps) > numClockTS[pt.PicStruct] {
+			return sei.Message{}, fmt.Errorf("pic_struct %d has %d clock timestamps, got %d",
+				pt.PicStruct, numClockTS[pt.PicStruct], len(pt.ClockTimestamps))
+		}
+		timeOffsetLength := 24
+		if hrd != nil {
+			timeOffsetLength = int(hrd.TimeOffsetLength)
+		}
+		w.Write(uint(pt.PicStruct), 4)
+		for i := 0; i < numClockTS[pt.PicStruct]; i++ {
+			w.WriteFlag(i < len(pt.ClockTimestamps))
+			if i >= len(pt.ClockTimestamps) {
+				continue
+			}
+			ts := &pt.ClockTimestamps[i]
+			w.Write(uint(ts.CtType), 2)
+			w.WriteFlag(ts.NuitFieldBasedFlag)
+			w.Write(uint(ts.CountingType), 5)
+			w.WriteFlag(ts.FullTimestampFlag)
+			w.WriteFlag(ts.DiscontinuityFlag)
+			w.WriteFlag(ts.CntDroppedFlag)
+			w.Write(uint(ts.NFrames), 8)
+			if ts.FullTimestampFlag {
+				w.Write(uint(ts.SecondsValue), 6)
+				w.Write(uint(ts.MinutesValue), 6)
+				w.Write(uint(ts.HoursValue), 5)
+			} else {
+				w.WriteFlag(ts.SecondsFlag)
+				if ts.SecondsFlag {
+					w.Write(uint(ts.SecondsValue), 6)
+					w.WriteFlag(ts.MinutesFlag)
+					if ts.MinutesFlag {
+						w.Write(uint(ts.MinutesValue), 6)
+						w.WriteFlag(ts.HoursFlag)
+						if ts.HoursFlag {
+							w.Write(uint(ts.HoursValue), 5)
+						}
+					}
+				}
+			}
+			if timeOffsetLength > 0 {
+				w.Write(uint(ts.TimeOffset), timeOffsetLength)
+			}
+		}
+	}
+	payload, err := w.SEIPayload()
+	if err != nil {
+		return sei.Message{}, err
+	}
+	return sei.Message{PayloadType: sei.SEI_PIC_TIMING, Payload: payload}, nil
+}
+
+// NewClockTimestamp - full clock timestamp of t for a progressive frame
+// Drop-frame time code sets counting_type 4 and cnt_dropped_flag. It is an
+// error if t.Frames does not fit the 8-bit n_frames.
+func NewClockTimestamp(t sei.Timecode) (ClockTimestamp, error) {
+	if t.Frames > 255 {
+		return ClockTimestamp{}, fmt.Errorf("time code %s: n_frames %d out of range", t, t.Frames)
+	}
+	ts := ClockTimestamp{
+		FullTimestampFlag: true,
+		NFrames:           byte(t.Frames),
+		SecondsValue:      t.Seconds,
+		MinutesValue:      t.Minutes,
+		HoursValue:        t.Hours,
+	}
+	if t.DropFrame {
+		ts.CountingType = sei.COUNTING_TYPE_DROP_FRAME
+		ts.CntDroppedFlag = true
+	}
+	return ts, nil
+}
+
+// Timecode - hours, minutes, seconds and frames of the timestamp
+// Values that are not present, which the decoder infers from the previous
+// timestamp, are 0. Drop-frame is counting_type 4 or cnt_dropped_flag.
+func (ts *ClockTimestamp) Timecode() sei.Timecode {
+	return sei.Timecode{
+		Hours:     ts.HoursValue,
+		Minutes:   ts.MinutesValue,
+		Seconds:   ts.SecondsValue,
+		Frames:    uint16(ts.NFrames),
+		DropFrame: ts.CountingType == sei.COUNTING_TYPE_DROP_FRAME || ts.CntDroppedFlag,
+	}
+}
+
+// FindTimeCode - the time code of the first clock timestamp of a picture
+// timing SEI message among the SEI NAL units of an access unit
+// sps must be the active SPS.
+func FindTimeCode(nalus [][]byte, sps *SPS) (sei.Timecode, bool) {
+	for _, data := range nalus {
+		if len(data) == 0 || GetNaluType(data[0]) != NALU_SEI {
+			continue
+		}
+		msgs, err := ParseSEINALUnit(data)
+		if err != nil {
+			continue
+		}
+		for i := range msgs {
+			if msgs[i].PayloadType != sei.SEI_PIC_TIMING {
+				continue
+			}
+			pt, err := ParsePicTiming(msgs[i].Payload, sps)
+			if err != nil || len(pt.ClockTimestamps) == 0 {
+				continue
+			}
+			return pt.ClockTimestamps[0].Timecode(), true
+		}
+	}
+	return sei.Timecode{}, false
+}
+
+// DecodeSEIMessage - decode a message of a known payload type
+// The result is one of *BufferingPeriod, *PicTiming, *RecoveryPoint,
+// *sei.UserDataUnregistered or *sei.UserDataRegisteredT35, or nil for other
+// payload types. sps is the active SPS and is only needed for buffering
+// period and picture timing messages.
+func DecodeSEIMessage(msg *sei.Message, sps *SPS) (interface{}, error) {
+	switch msg.PayloadType {
+	case sei.SEI_BUFFERING_PERIOD, sei.SEI_PIC_TIMING:
+		if sps == nil {
+			return nil, fmt.Errorf("%s needs the active SPS", msg.PayloadType)
+		}
+		if msg.PayloadType == sei.SEI_BUFFERING_PERIOD {
+			return ParseBufferingPeriod(msg.Payload, sps)
+		}
+		return ParsePicTiming(msg.Payload, sps)
+	case sei.SEI_RECOVERY_POINT:
+		return ParseRecoveryPoint(msg.Payload)
+	case sei.SEI_USER_DATA_UNREGISTERED:
+		return sei.ParseUserDataUnregistered(msg.Payload)
+	case sei.SEI_USER_DATA_REGISTERED_ITU_T_T35:
+		return sei.ParseUserDataRegisteredT35(msg.Payload)
+	}
+	return nil, nil
+}
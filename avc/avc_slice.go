@@ -0,0 +1,260 @@
+package avc
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/go-webdl/bits"
+)
+
+// SliceType - AVC slice_type according to ISO/IEC 14496-10 Table 7-6
+// Values 5 to 9 have the same meaning as 0 to 4, and additionally signal
+// that all slices of the picture have the same type.
+type SliceType uint
+
+const (
+	SLICE_P  = SliceType(0)
+	SLICE_B  = SliceType(1)
+	SLICE_I  = SliceType(2)
+	SLICE_SP = SliceType(3)
+	SLICE_SI = SliceType(4)
+)
+
+// Base - slice type in the range 0 to 4
+func (s SliceType) Base() SliceType {
+	return s % 5
+}
+
+func (s SliceType) String() string {
+	switch s.Base() {
+	case SLICE_P:
+		return "P"
+	case SLICE_B:
+		return "B"
+	case SLICE_I:
+		return "I"
+	case SLICE_SP:
+		return "SP"
+	default:
+		return "SI"
+	}
+}
+
+// SliceHeader - AVC slice header
+// ISO/IEC 14496-10 Sec. 7.3.3
+// pred_weight_table is skipped.
+type SliceHeader struct {
+	NalRefIdc              byte
+	NaluType               NaluType
+	FirstMbInSlice         uint32
+	SliceType              SliceType
+	PpsID                  byte
+	ColourPlaneID          byte
+	FrameNum               uint32
+	FieldPicFlag           bool
+	BottomFieldFlag        bool
+	IdrPicID               uint32
+	PicOrderCntLsb         uint32
+	DeltaPicOrderCntBottom int32
+	DeltaPicOrderCnt       [2]int32
+	RedundantPicCnt        uint32
+	// DirectSpatialMvPredFlag - B slices only
+	DirectSpatialMvPredFlag     bool
+	NumRefIdxActiveOverrideFlag bool
+	// NumRefIdxL0ActiveMinus1, NumRefIdxL1ActiveMinus1 - the PPS defaults
+	// unless overridden, 0 for lists the slice does not use
+	NumRefIdxL0ActiveMinus1  byte
+	NumRefIdxL1ActiveMinus1  byte
+	RefPicListModificationL0 []RefPicListModification
+	RefPicListModificationL1 []RefPicListModification
+	// DecRefPicMarking - present for reference pictures, nal_ref_idc not 0
+	DecRefPicMarking DecRefPicMarking
+	// CabacInitIdc - CABAC P, SP and B slices only
+	CabacInitIdc byte
+	SliceQpDelta int32
+	// SpForSwitchFlag, SliceQsDelta - SP and SI slices only
+	SpForSwitchFlag            bool
+	SliceQsDelta               int32
+	DisableDeblockingFilterIdc byte
+	SliceAlphaC0OffsetDiv2     int32
+	SliceBetaOffsetDiv2        int32
+	SliceGroupChangeCycle      uint32
+	// Size - bytes of the NAL unit up to the end of the slice header,
+	// including the NAL unit header and emulation prevention bytes. A
+	// partially used last byte is included, so slice data starts in it for
+	// CAVLC and after it for CABAC.
+	Size            int
+	picOrderCntType byte
+}
+
+// PicSizeInMapUnits - number of slice group map units in a picture
+func (s *SPS) PicSizeInMapUnits() uint32 {
+	return (s.PicWidthInMbsMinus1 + 1) * (s.PicHeightInMapUnitsMinus1 + 1)
+}
+
+// sliceGroupChangeCycleBits - Ceil(Log2(PicSizeInMapUnits ÷ SliceGroupChangeRate + 1)), Eq. 7-35
+func sliceGroupChangeCycleBits(sps *SPS, pps *PPS) int {
+	picSize := uint64(sps.PicSizeInMapUnits())
+	rate := uint64(pps.SliceGroupChangeRateMinus1) + 1
+	n := 0
+	for (uint64(1)<<n-1)*rate < picSize {
+		n++
+	}
+	return n
+}
+
+// ParseSliceHeader - Parse AVC slice header of a NAL unit starting with NAL unit header
+// spsMap and ppsMap are indexed by parameter set id.
+func ParseSliceHeader(data []byte, spsMap map[byte]*SPS, ppsMap map[byte]*PPS) (*SliceHeader, error) {
+	sh := &SliceHeader{}
+
+	rd := bytes.NewReader(data)
+	r := bits.NewAccErrEBSPReader(rd)
+	// Note! First byte is NALU Header
+
+	naluHdr := byte(r.Read(8))
+	sh.NalRefIdc = (naluHdr >> 5) & 0b11
+	sh.NaluType = GetNaluType(naluHdr)
+	if sh.NaluType != NALU_NON_IDR && sh.NaluType != NALU_IDR {
+		return nil, fmt.Errorf("NALU type is %s not a slice", sh.NaluType)
+	}
+	sh.FirstMbInSlice = uint32(r.ReadExpGolomb())
+	sh.SliceType = SliceType(r.ReadExpGolomb())
+	if sh.SliceType > 9 {
+		return nil, fmt.Errorf("slice_type %d out of range", sh.SliceType)
+	}
+	sh.PpsID = byte(r.ReadExpGolomb())
+	if err := r.AccError(); err != nil {
+		return nil, err
+	}
+	pps, ok := ppsMap[sh.PpsID]
+	if !ok {
+		return nil, fmt.Errorf("PPS %d not found", sh.PpsID)
+	}
+	sps, ok := spsMap[pps.SpsID]
+	if !ok {
+		return nil, fmt.Errorf("SPS %d not found", pps.SpsID)
+	}
+	if sps.SeparateColourPlaneFlag {
+		sh.ColourPlaneID = byte(r.Read(2))
+	}
+	sh.FrameNum = uint32(r.Read(int(sps.Log2MaxFrameNumMinus4) + 4))
+	if !sps.FrameMbsOnlyFlag {
+		sh.FieldPicFlag = r.ReadFlag()
+		if sh.FieldPicFlag {
+			sh.BottomFieldFlag = r.ReadFlag()
+		}
+	}
+	if sh.NaluType == NALU_IDR {
+		sh.IdrPicID = uint32(r.ReadExpGolomb())
+	}
+	sh.picOrderCntType = sps.PicOrderCntType
+	if sps.PicOrderCntType == 0 {
+		sh.PicOrderCntLsb = uint32(r.Read(int(sps.Log2MaxPicOrderCntLsbMinus4) + 4))
+		if pps.BottomFieldPicOrderInFramePresentFlag && !sh.FieldPicFlag {
+			sh.DeltaPicOrderCntBottom = int32(r.ReadSignedGolomb())
+		}
+	}
+	if sps.PicOrderCntType == 1 && !sps.DeltaPicOrderAlwaysZeroFlag {
+		sh.DeltaPicOrderCnt[0] = int32(r.ReadSignedGolomb())
+		if pps.BottomFieldPicOrderInFramePresentFlag && !sh.FieldPicFlag {
+			sh.DeltaPicOrderCnt[1] = int32(r.ReadSignedGolomb())
+		}
+	}
+	if pps.RedundantPicCntPresentFlag {
+		sh.RedundantPicCnt = uint32(r.ReadExpGolomb())
+	}
+	base := sh.SliceType.Base()
+	if base == SLICE_B {
+		sh.DirectSpatialMvPredFlag = r.ReadFlag()
+	}
+	if base == SLICE_P || base == SLICE_SP || base == SLICE_B {
+		sh.NumRefIdxL0ActiveMinus1 = pps.NumRefIdxL0DefaultActiveMinus1
+		if base == SLICE_B {
+			sh.NumRefIdxL1ActiveMinus1 = pps.NumRefIdxL1DefaultActiveMinus1
+		}
+		sh.NumRefIdxActiveOverrideFlag = r.ReadFlag()
+		if sh.NumRefIdxActiveOverrideFlag {
+			l0, l1 := r.ReadExpGolomb(), uint(0)
+			if base == SLICE_B {
+				l1 = r.ReadExpGolomb()
+			}
+			if l0 > 31 || l1 > 31 {
+				return nil, fmt.Errorf("num_ref_idx_active_minus1 %d/%d out of range", l0, l1)
+			}
+			sh.NumRefIdxL0ActiveMinus1, sh.NumRefIdxL1ActiveMinus1 = byte(l0), byte(l1)
+		}
+	}
+	var err error
+	if base != SLICE_I && base != SLICE_SI {
+		if sh.RefPicListModificationL0, err = readRefPicListModification(r); err != nil {
+			return nil, err
+		}
+	}
+	if base == SLICE_B {
+		if sh.RefPicListModificationL1, err = readRefPicListModification(r); err != nil {
+			return nil, err
+		}
+	}
+	if (pps.WeightedPredFlag && (base == SLICE_P || base == SLICE_SP)) ||
+		(pps.WeightedBipredIdc == 1 && base == SLICE_B) {
+		skipPredWeightTable(r, sh, sps)
+	}
+	if sh.NalRefIdc != 0 {
+		if sh.DecRefPicMarking, err = readDecRefPicMarking(r, sh.NaluType == NALU_IDR); err != nil {
+			return nil, err
+		}
+	}
+	if pps.EntropyCodingModeFlag && base != SLICE_I && base != SLICE_SI {
+		sh.CabacInitIdc = byte(r.ReadExpGolomb())
+	}
+	sh.SliceQpDelta = int32(r.ReadSignedGolomb())
+	if base == SLICE_SP || base == SLICE_SI {
+		if base == SLICE_SP {
+			sh.SpForSwitchFlag = r.ReadFlag()
+		}
+		sh.SliceQsDelta = int32(r.ReadSignedGolomb())
+	}
+	if pps.DeblockingFilterControlPresentFlag {
+		sh.DisableDeblockingFilterIdc = byte(r.ReadExpGolomb())
+		if sh.DisableDeblockingFilterIdc != 1 {
+			sh.SliceAlphaC0OffsetDiv2 = int32(r.ReadSignedGolomb())
+			sh.SliceBetaOffsetDiv2 = int32(r.ReadSignedGolomb())
+		}
+	}
+	if pps.NumSliceGroupsMinus1 > 0 && pps.SliceGroupMapType >= 3 && pps.SliceGroupMapType <= 5 {
+		sh.SliceGroupChangeCycle = uint32(r.Read(sliceGroupChangeCycleBits(sps, pps)))
+	}
+	sh.Size = r.NrBytesRead()
+
+	return sh, r.AccError()
+}
+
+// IsFirstSliceOfNewPicture - does this slice start a new primary coded picture
+// after the slice prev, according to the rules of ISO/IEC 14496-10 Sec. 7.4.1.2.4
+// A nil prev always starts a new picture. Redundant slices never do.
+func (s *SliceHeader) IsFirstSliceOfNewPicture(prev *SliceHeader) bool {
+	if prev == nil {
+		return true
+	}
+	if s.RedundantPicCnt > 0 {
+		return false
+	}
+	switch {
+	case s.FrameNum != prev.FrameNum,
+		s.PpsID != prev.PpsID,
+		s.FieldPicFlag != prev.FieldPicFlag,
+		s.FieldPicFlag && s.BottomFieldFlag != prev.BottomFieldFlag,
+		(s.NalRefIdc == 0) != (prev.NalRefIdc == 0),
+		(s.NaluType == NALU_IDR) != (prev.NaluType == NALU_IDR),
+		s.NaluType == NALU_IDR && s.IdrPicID != prev.IdrPicID:
+		return true
+	}
+	switch s.picOrderCntType {
+	case 0:
+		return s.PicOrderCntLsb != prev.PicOrderCntLsb || s.DeltaPicOrderCntBottom != prev.DeltaPicOrderCntBottom
+	case 1:
+		return s.DeltaPicOrderCnt != prev.DeltaPicOrderCnt
+	}
+	return false
+}
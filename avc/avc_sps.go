@@ -0,0 +1,375 @@
+package avc
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/go-webdl/bits"
+)
+
+// SPS - AVC SPS parameters
+// ISO/IEC 14496-10 Sec. 7.3.2.1.1
+type SPS struct {
+	// NalRefIdc - nal_ref_idc of the NAL unit header
+	NalRefIdc        byte
+	ProfileIndicator byte
+	// constraint_set0_flag to constraint_set5_flag and reserved_zero_2bits,
+	// the byte stored as profile_compatibility in the configuration record
+	ProfileCompatibility            byte
+	LevelIndicator                  byte
+	SpsID                           byte
+	ChromaFormatIndicator           byte
+	SeparateColourPlaneFlag         bool
+	BitDepthLumaMinus8              byte
+	BitDepthChromaMinus8            byte
+	QpprimeYZeroTransformBypassFlag bool
+	SeqScalingMatrixPresentFlag     bool
+	SeqScalingLists                 []ScalingList
+	Log2MaxFrameNumMinus4           byte
+	PicOrderCntType                 byte
+	Log2MaxPicOrderCntLsbMinus4     byte
+	DeltaPicOrderAlwaysZeroFlag     bool
+	OffsetForNonRefPic              int32
+	OffsetForTopToBottomField       int32
+	OffsetForRefFrames              []int32
+	MaxNumRefFrames                 byte
+	GapsInFrameNumValueAllowedFlag  bool
+	PicWidthInMbsMinus1             uint32
+	PicHeightInMapUnitsMinus1       uint32
+	FrameMbsOnlyFlag                bool
+	MbAdaptiveFrameFieldFlag        bool
+	Direct8x8InferenceFlag          bool
+	FrameCroppingFlag               bool
+	FrameCropping                   FrameCropping
+	VUIParametersPresentFlag        bool
+	VUI                             VUIParameters
+}
+
+// ScalingList - scaling_list() as coded, ISO/IEC 14496-10 Sec. 7.3.2.1.1.1
+type ScalingList struct {
+	PresentFlag bool
+	// delta_scale values in coding order. Coding stops early when the
+	// resulting nextScale is 0.
+	DeltaScales []int32
+}
+
+type FrameCropping struct {
+	LeftOffset   uint32
+	RightOffset  uint32
+	TopOffset    uint32
+	BottomOffset uint32
+}
+
+// VUIParameters - ISO/IEC 14496-10 Sec. E.1.1
+type VUIParameters struct {
+	AspectRatioInfoPresentFlag         bool
+	AspectRatioIndicator               byte
+	SarWidth                           uint16
+	SarHeight                          uint16
+	OverscanInfoPresentFlag            bool
+	OverscanAppropriateFlag            bool
+	VideoSignalTypePresentFlag         bool
+	VideoFormat                        byte
+	VideoFullRangeFlag                 bool
+	ColourDescriptionPresentFlag       bool
+	ColourPrimaries                    byte
+	TransferCharacteristics            byte
+	MatrixCoefficients                 byte
+	ChromaLocInfoPresentFlag           bool
+	ChromaSampleLocTypeTopField        byte
+	ChromaSampleLocTypeBottomField     byte
+	TimingInfoPresentFlag              bool
+	NumUnitsInTick                     uint32
+	TimeScale                          uint32
+	FixedFrameRateFlag                 bool
+	NalHrdParametersPresentFlag        bool
+	NalHrdParameters                   HRDParameters
+	VclHrdParametersPresentFlag        bool
+	VclHrdParameters                   HRDParameters
+	LowDelayHrdFlag                    bool
+	PicStructPresentFlag               bool
+	BitstreamRestrictionFlag           bool
+	MotionVectorsOverPicBoundariesFlag bool
+	MaxBytesPerPicDenom                uint32
+	MaxBitsPerMbDenom                  uint32
+	Log2MaxMvLengthHorizontal          uint32
+	Log2MaxMvLengthVertical            uint32
+	MaxNumReorderFrames                uint32
+	MaxDecFrameBuffering               uint32
+}
+
+// HRDParameters - ISO/IEC 14496-10 Sec. E.1.2
+type HRDParameters struct {
+	CpbCntMinus1                       byte
+	BitRateScale                       byte
+	CpbSizeScale                       byte
+	SchedSels                          []HRDSchedSel
+	InitialCpbRemovalDelayLengthMinus1 byte
+	CpbRemovalDelayLengthMinus1        byte
+	DpbOutputDelayLengthMinus1         byte
+	TimeOffsetLength                   byte
+}
+
+type HRDSchedSel struct {
+	BitRateValueMinus1 uint32
+	CpbSizeValueMinus1 uint32
+	CbrFlag            bool
+}
+
+// hasChromaInfo - profiles whose SPS carry chroma_format_idc, bit depths and
+// scaling matrices
+func hasChromaInfo(profileIndicator byte) bool {
+	switch Profile(profileIndicator) {
+	case PROFILE_HIGH, PROFILE_HIGH_10, PROFILE_HIGH_422, PROFILE_HIGH_444_PREDICTIVE,
+		PROFILE_CAVLC_444_INTRA, PROFILE_SCALABLE_BASELINE, PROFILE_SCALABLE_HIGH,
+		PROFILE_MULTIVIEW_HIGH, PROFILE_STEREO_HIGH, PROFILE_MULTIVIEW_DEPTH_HIGH,
+		PROFILE_ENHANCED_MULTIVIEW_DEPTH_HIGH, PROFILE_MFC_HIGH, PROFILE_MFC_DEPTH_HIGH:
+		return true
+	}
+	return false
+}
+
+// ParseSPSNALUnit - Parse AVC SPS NAL unit starting with NAL unit header
+func ParseSPSNALUnit(data []byte) (*SPS, error) {
+
+	sps := &SPS{}
+
+	rd := bytes.NewReader(data)
+	r := bits.NewAccErrEBSPReader(rd)
+	// Note! First byte is NALU Header
+
+	naluHdr := byte(r.Read(8))
+	naluType := GetNaluType(naluHdr)
+	if naluType != NALU_SPS {
+		return nil, fmt.Errorf("NALU type is %s not SPS", naluType)
+	}
+	sps.NalRefIdc = (naluHdr >> 5) & 0b11
+	sps.ProfileIndicator = byte(r.Read(8))
+	sps.ProfileCompatibility = byte(r.Read(8))
+	sps.LevelIndicator = byte(r.Read(8))
+	sps.SpsID = byte(r.ReadExpGolomb())
+	sps.ChromaFormatIndicator = 1 // 4:2:0 when not present
+	if hasChromaInfo(sps.ProfileIndicator) {
+		sps.ChromaFormatIndicator = byte(r.ReadExpGolomb())
+		if sps.ChromaFormatIndicator == 3 {
+			sps.SeparateColourPlaneFlag = r.ReadFlag()
+		}
+		sps.BitDepthLumaMinus8 = byte(r.ReadExpGolomb())
+		sps.BitDepthChromaMinus8 = byte(r.ReadExpGolomb())
+		sps.QpprimeYZeroTransformBypassFlag = r.ReadFlag()
+		sps.SeqScalingMatrixPresentFlag = r.ReadFlag()
+		if sps.SeqScalingMatrixPresentFlag {
+			count := 8
+			if sps.ChromaFormatIndicator == 3 {
+				count = 12
+			}
+			sps.SeqScalingLists = make([]ScalingList, count)
+			for i := range sps.SeqScalingLists {
+				size := 64
+				if i < 6 {
+					size = 16
+				}
+				sps.SeqScalingLists[i] = readScalingList(r, size)
+			}
+		}
+	}
+	sps.Log2MaxFrameNumMinus4 = byte(r.ReadExpGolomb())
+	sps.PicOrderCntType = byte(r.ReadExpGolomb())
+	switch sps.PicOrderCntType {
+	case 0:
+		sps.Log2MaxPicOrderCntLsbMinus4 = byte(r.ReadExpGolomb())
+	case 1:
+		sps.DeltaPicOrderAlwaysZeroFlag = r.ReadFlag()
+		sps.OffsetForNonRefPic = int32(r.ReadSignedGolomb())
+		sps.OffsetForTopToBottomField = int32(r.ReadSignedGolomb())
+		numRefFramesInPicOrderCntCycle := r.ReadExpGolomb()
+		if numRefFramesInPicOrderCntCycle > 255 {
+			return nil, fmt.Errorf("num_ref_frames_in_pic_order_cnt_cycle %d out of range", numRefFramesInPicOrderCntCycle)
+		}
+		for i := uint(0); i < numRefFramesInPicOrderCntCycle; i++ {
+			sps.OffsetForRefFrames = append(sps.OffsetForRefFrames, int32(r.ReadSignedGolomb()))
+		}
+	}
+	sps.MaxNumRefFrames = byte(r.ReadExpGolomb())
+	sps.GapsInFrameNumValueAllowedFlag = r.ReadFlag()
+	sps.PicWidthInMbsMinus1 = uint32(r.ReadExpGolomb())
+	sps.PicHeightInMapUnitsMinus1 = uint32(r.ReadExpGolomb())
+	sps.FrameMbsOnlyFlag = r.ReadFlag()
+	if !sps.FrameMbsOnlyFlag {
+		sps.MbAdaptiveFrameFieldFlag = r.ReadFlag()
+	}
+	sps.Direct8x8InferenceFlag = r.ReadFlag()
+	sps.FrameCroppingFlag = r.ReadFlag()
+	if sps.FrameCroppingFlag {
+		sps.FrameCropping = FrameCropping{
+			LeftOffset:   uint32(r.ReadExpGolomb()),
+			RightOffset:  uint32(r.ReadExpGolomb()),
+			TopOffset:    uint32(r.ReadExpGolomb()),
+			BottomOffset: uint32(r.ReadExpGolomb()),
+		}
+	}
+	sps.VUIParametersPresentFlag = r.ReadFlag()
+	if sps.VUIParametersPresentFlag {
+		var err error
+		if sps.VUI, err = readVUIParameters(r); err != nil {
+			return nil, err
+		}
+	}
+
+	return sps, r.AccError()
+}
+
+// CodedSize - width and height of the decoded frame in luma samples, before cropping
+// Field coded streams have two map units per macroblock row pair, Sec. 7.4.2.1.1.
+func (s *SPS) CodedSize() (width, height uint32) {
+	frameHeightInMbs := s.PicHeightInMapUnitsMinus1 + 1
+	if !s.FrameMbsOnlyFlag {
+		frameHeightInMbs *= 2
+	}
+	return (s.PicWidthInMbsMinus1 + 1) * 16, frameHeightInMbs * 16
+}
+
+// ImageSize - calculated width and height using FrameCropping
+// A malformed cropping window larger than the frame gives a size of 0.
+func (s *SPS) ImageSize() (width, height uint32) {
+	width, height = s.CodedSize()
+	if !s.FrameCroppingFlag {
+		return width, height
+	}
+	// CropUnitX and CropUnitY, Sec. 7.4.2.1.1
+	var cropUnitX, cropUnitY uint32 = 1, 1
+	if !s.SeparateColourPlaneFlag {
+		switch s.ChromaFormatIndicator {
+		case 1: // 4:2:0
+			cropUnitX, cropUnitY = 2, 2
+		case 2: // 4:2:2
+			cropUnitX = 2
+		}
+	}
+	if !s.FrameMbsOnlyFlag {
+		cropUnitY *= 2
+	}
+	c := s.FrameCropping
+	width = cropSize(width, uint64(c.LeftOffset)+uint64(c.RightOffset), cropUnitX)
+	height = cropSize(height, uint64(c.TopOffset)+uint64(c.BottomOffset), cropUnitY)
+	return width, height
+}
+
+// cropSize - size less offsets in crop units, 0 if they exceed it
+func cropSize(size uint32, offsets uint64, cropUnit uint32) uint32 {
+	if crop := offsets * uint64(cropUnit); crop < uint64(size) {
+		return size - uint32(crop)
+	}
+	return 0
+}
+
+func readScalingList(r *bits.AccErrEBSPReader, size int) (sl ScalingList) {
+	sl.PresentFlag = r.ReadFlag()
+	if !sl.PresentFlag {
+		return
+	}
+	lastScale, nextScale := int32(8), int32(8)
+	for j := 0; j < size && nextScale != 0 && r.AccError() == nil; j++ {
+		deltaScale := int32(r.ReadSignedGolomb())
+		sl.DeltaScales = append(sl.DeltaScales, deltaScale)
+		nextScale = (lastScale + deltaScale + 256) % 256
+		if nextScale != 0 {
+			lastScale = nextScale
+		}
+	}
+	return
+}
+
+func readVUIParameters(r *bits.AccErrEBSPReader) (vui VUIParameters, err error) {
+	vui.AspectRatioInfoPresentFlag = r.ReadFlag()
+	if vui.AspectRatioInfoPresentFlag {
+		vui.AspectRatioIndicator = byte(r.Read(8))
+		if vui.AspectRatioIndicator == 255 { // Extended_SAR
+			vui.SarWidth = uint16(r.Read(16))
+			vui.SarHeight = uint16(r.Read(16))
+		}
+	}
+	vui.OverscanInfoPresentFlag = r.ReadFlag()
+	if vui.OverscanInfoPresentFlag {
+		vui.OverscanAppropriateFlag = r.ReadFlag()
+	}
+	vui.VideoFormat = 5 // Unspecified video format when not present
+	vui.ColourPrimaries = 2
+	vui.TransferCharacteristics = 2
+	vui.MatrixCoefficients = 2
+	vui.VideoSignalTypePresentFlag = r.ReadFlag()
+	if vui.VideoSignalTypePresentFlag {
+		vui.VideoFormat = byte(r.Read(3))
+		vui.VideoFullRangeFlag = r.ReadFlag()
+		vui.ColourDescriptionPresentFlag = r.ReadFlag()
+		if vui.ColourDescriptionPresentFlag {
+			vui.ColourPrimaries = byte(r.Read(8))
+			vui.TransferCharacteristics = byte(r.Read(8))
+			vui.MatrixCoefficients = byte(r.Read(8))
+		}
+	}
+	vui.ChromaLocInfoPresentFlag = r.ReadFlag()
+	if vui.ChromaLocInfoPresentFlag {
+		vui.ChromaSampleLocTypeTopField = byte(r.ReadExpGolomb())
+		vui.ChromaSampleLocTypeBottomField = byte(r.ReadExpGolomb())
+	}
+	vui.TimingInfoPresentFlag = r.ReadFlag()
+	if vui.TimingInfoPresentFlag {
+		vui.NumUnitsInTick = uint32(r.Read(32))
+		vui.TimeScale = uint32(r.Read(32))
+		vui.FixedFrameRateFlag = r.ReadFlag()
+	}
+	vui.NalHrdParametersPresentFlag = r.ReadFlag()
+	if vui.NalHrdParametersPresentFlag {
+		if vui.NalHrdParameters, err = readHRDParameters(r); err != nil {
+			return vui, err
+		}
+	}
+	vui.VclHrdParametersPresentFlag = r.ReadFlag()
+	if vui.VclHrdParametersPresentFlag {
+		if vui.VclHrdParameters, err = readHRDParameters(r); err != nil {
+			return vui, err
+		}
+	}
+	if vui.NalHrdParametersPresentFlag || vui.VclHrdParametersPresentFlag {
+		vui.LowDelayHrdFlag = r.ReadFlag()
+	}
+	vui.PicStructPresentFlag = r.ReadFlag()
+	vui.BitstreamRestrictionFlag = r.ReadFlag()
+	if vui.BitstreamRestrictionFlag {
+		vui.MotionVectorsOverPicBoundariesFlag = r.ReadFlag()
+		vui.MaxBytesPerPicDenom = uint32(r.ReadExpGolomb())
+		vui.MaxBitsPerMbDenom = uint32(r.ReadExpGolomb())
+		vui.Log2MaxMvLengthHorizontal = uint32(r.ReadExpGolomb())
+		vui.Log2MaxMvLengthVertical = uint32(r.ReadExpGolomb())
+		vui.MaxNumReorderFrames = uint32(r.ReadExpGolomb())
+		vui.MaxDecFrameBuffering = uint32(r.ReadExpGolomb())
+	}
+	return vui, nil
+}
+
+// readHRDParameters - read hrd_parameters(), Sec. E.1.2
+func readHRDParameters(r *bits.AccErrEBSPReader) (hrd HRDParameters, err error) {
+	cpbCntMinus1 := r.ReadExpGolomb()
+	if err := r.AccError(); err != nil {
+		return hrd, err
+	}
+	if cpbCntMinus1 > 31 {
+		return hrd, fmt.Errorf("cpb_cnt_minus1 %d out of range", cpbCntMinus1)
+	}
+	hrd.CpbCntMinus1 = byte(cpbCntMinus1)
+	hrd.BitRateScale = byte(r.Read(4))
+	hrd.CpbSizeScale = byte(r.Read(4))
+	for i := 0; i <= int(hrd.CpbCntMinus1) && r.AccError() == nil; i++ {
+		hrd.SchedSels = append(hrd.SchedSels, HRDSchedSel{
+			BitRateValueMinus1: uint32(r.ReadExpGolomb()),
+			CpbSizeValueMinus1: uint32(r.ReadExpGolomb()),
+			CbrFlag:            r.ReadFlag(),
+		})
+	}
+	hrd.InitialCpbRemovalDelayLengthMinus1 = byte(r.Read(5))
+	hrd.CpbRemovalDelayLengthMinus1 = byte(r.Read(5))
+	hrd.DpbOutputDelayLengthMinus1 = byte(r.Read(5))
+	hrd.TimeOffsetLength = byte(r.Read(5))
+	return hrd, nil
+}
@@ -0,0 +1,175 @@
+package avc
+
+import (
+	"fmt"
+
+	"github.com/go-webdl/media-codec/nalu"
+)
+
+// CreateSPSNALUnit - encode sps as an SPS NAL unit starting with NAL unit header
+// Parsing an SPS NAL unit and encoding the result gives back the same bytes,
+// so an SPS can be modified, e.g. to add VUI timing info or fix the sample
+// aspect ratio, and written out again. Emulation prevention bytes are
+// recomputed. Presence flags decide which fields are written, e.g. VUI
+// timing info is only written if VUI.TimingInfoPresentFlag is set.
+func CreateSPSNALUnit(sps *SPS) ([]byte, error) {
+	if sps.PicOrderCntType == 1 && len(sps.OffsetForRefFrames) > 255 {
+		return nil, fmt.Errorf("%d offsets for ref frames out of range", len(sps.OffsetForRefFrames))
+	}
+	if sps.VUI.NalHrdParametersPresentFlag && !sps.VUI.NalHrdParameters.schedSelsComplete() ||
+		sps.VUI.VclHrdParametersPresentFlag && !sps.VUI.VclHrdParameters.schedSelsComplete() {
+		return nil, fmt.Errorf("HRD parameters need cpb_cnt_minus1 + 1 SchedSels")
+	}
+	w := nalu.NewRBSPWriter()
+	w.Write(uint(sps.ProfileIndicator), 8)
+	w.Write(uint(sps.ProfileCompatibility), 8)
+	w.Write(uint(sps.LevelIndicator), 8)
+	w.WriteExpGolomb(uint(sps.SpsID))
+	if hasChromaInfo(sps.ProfileIndicator) {
+		w.WriteExpGolomb(uint(sps.ChromaFormatIndicator))
+		if sps.ChromaFormatIndicator == 3 {
+			w.WriteFlag(sps.SeparateColourPlaneFlag)
+		}
+		w.WriteExpGolomb(uint(sps.BitDepthLumaMinus8))
+		w.WriteExpGolomb(uint(sps.BitDepthChromaMinus8))
+		w.WriteFlag(sps.QpprimeYZeroTransformBypassFlag)
+		w.WriteFlag(sps.SeqScalingMatrixPresentFlag)
+		if sps.SeqScalingMatrixPresentFlag {
+			count := 8
+			if sps.ChromaFormatIndicator == 3 {
+				count = 12
+			}
+			if len(sps.SeqScalingLists) != count {
+				return nil, fmt.Errorf("%d scaling lists, need %d", len(sps.SeqScalingLists), count)
+			}
+			for _, sl := range sps.SeqScalingLists {
+				writeScalingList(w, sl)
+			}
+		}
+	}
+	w.WriteExpGolomb(uint(sps.Log2MaxFrameNumMinus4))
+	w.WriteExpGolomb(uint(sps.PicOrderCntType))
+	switch sps.PicOrderCntType {
+	case 0:
+		w.WriteExpGolomb(uint(sps.Log2MaxPicOrderCntLsbMinus4))
+	case 1:
+		w.WriteFlag(sps.DeltaPicOrderAlwaysZeroFlag)
+		w.WriteSignedGolomb(int(sps.OffsetForNonRefPic))
+		w.WriteSignedGolomb(int(sps.OffsetForTopToBottomField))
+		w.WriteExpGolomb(uint(len(sps.OffsetForRefFrames)))
+		for _, offset := range sps.OffsetForRefFrames {
+			w.WriteSignedGolomb(int(offset))
+		}
+	}
+	w.WriteExpGolomb(uint(sps.MaxNumRefFrames))
+	w.WriteFlag(sps.GapsInFrameNumValueAllowedFlag)
+	w.WriteExpGolomb(uint(sps.PicWidthInMbsMinus1))
+	w.WriteExpGolomb(uint(sps.PicHeightInMapUnitsMinus1))
+	w.WriteFlag(sps.FrameMbsOnlyFlag)
+	if !sps.FrameMbsOnlyFlag {
+		w.WriteFlag(sps.MbAdaptiveFrameFieldFlag)
+	}
+	w.WriteFlag(sps.Direct8x8InferenceFlag)
+	w.WriteFlag(sps.FrameCroppingFlag)
+	if sps.FrameCroppingFlag {
+		w.WriteExpGolomb(uint(sps.FrameCropping.LeftOffset))
+		w.WriteExpGolomb(uint(sps.FrameCropping.RightOffset))
+		w.WriteExpGolomb(uint(sps.FrameCropping.TopOffset))
+		w.WriteExpGolomb(uint(sps.FrameCropping.BottomOffset))
+	}
+	w.WriteFlag(sps.VUIParametersPresentFlag)
+	if sps.VUIParametersPresentFlag {
+		writeVUIParameters(w, &sps.VUI)
+	}
+	w.WriteTrailingBits()
+	return w.NALUnit([]byte{sps.NalRefIdc<<5 | byte(NALU_SPS)})
+}
+
+func writeScalingList(w *nalu.RBSPWriter, sl ScalingList) {
+	w.WriteFlag(sl.PresentFlag)
+	if !sl.PresentFlag {
+		return
+	}
+	for _, deltaScale := range sl.DeltaScales {
+		w.WriteSignedGolomb(int(deltaScale))
+	}
+}
+
+func writeVUIParameters(w *nalu.RBSPWriter, vui *VUIParameters) {
+	w.WriteFlag(vui.AspectRatioInfoPresentFlag)
+	if vui.AspectRatioInfoPresentFlag {
+		w.Write(uint(vui.AspectRatioIndicator), 8)
+		if vui.AspectRatioIndicator == 255 { // Extended_SAR
+			w.Write(uint(vui.SarWidth), 16)
+			w.Write(uint(vui.SarHeight), 16)
+		}
+	}
+	w.WriteFlag(vui.OverscanInfoPresentFlag)
+	if vui.OverscanInfoPresentFlag {
+		w.WriteFlag(vui.OverscanAppropriateFlag)
+	}
+	w.WriteFlag(vui.VideoSignalTypePresentFlag)
+	if vui.VideoSignalTypePresentFlag {
+		w.Write(uint(vui.VideoFormat), 3)
+		w.WriteFlag(vui.VideoFullRangeFlag)
+		w.WriteFlag(vui.ColourDescriptionPresentFlag)
+		if vui.ColourDescriptionPresentFlag {
+			w.Write(uint(vui.ColourPrimaries), 8)
+			w.Write(uint(vui.TransferCharacteristics), 8)
+			w.Write(uint(vui.MatrixCoefficients), 8)
+		}
+	}
+	w.WriteFlag(vui.ChromaLocInfoPresentFlag)
+	if vui.ChromaLocInfoPresentFlag {
+		w.WriteExpGolomb(uint(vui.ChromaSampleLocTypeTopField))
+		w.WriteExpGolomb(uint(vui.ChromaSampleLocTypeBottomField))
+	}
+	w.WriteFlag(vui.TimingInfoPresentFlag)
+	if vui.TimingInfoPresentFlag {
+		w.Write(uint(vui.NumUnitsInTick), 32)
+		w.Write(uint(vui.TimeScale), 32)
+		w.WriteFlag(vui.FixedFrameRateFlag)
+	}
+	w.WriteFlag(vui.NalHrdParametersPresentFlag)
+	if vui.NalHrdParametersPresentFlag {
+		writeHRDParameters(w, &vui.NalHrdParameters)
+	}
+	w.WriteFlag(vui.VclHrdParametersPresentFlag)
+	if vui.VclHrdParametersPresentFlag {
+		writeHRDParameters(w, &vui.VclHrdParameters)
+	}
+	if vui.NalHrdParametersPresentFlag || vui.VclHrdParametersPresentFlag {
+		w.WriteFlag(vui.LowDelayHrdFlag)
+	}
+	w.WriteFlag(vui.PicStructPresentFlag)
+	w.WriteFlag(vui.BitstreamRestrictionFlag)
+	if vui.BitstreamRestrictionFlag {
+		w.WriteFlag(vui.MotionVectorsOverPicBoundariesFlag)
+		w.WriteExpGolomb(uint(vui.MaxBytesPerPicDenom))
+		w.WriteExpGolomb(uint(vui.MaxBitsPerMbDenom))
+		w.WriteExpGolomb(uint(vui.Log2MaxMvLengthHorizontal))
+		w.WriteExpGolomb(uint(vui.Log2MaxMvLengthVertical))
+		w.WriteExpGolomb(uint(vui.MaxNumReorderFrames))
+		w.WriteExpGolomb(uint(vui.MaxDecFrameBuffering))
+	}
+}
+
+// schedSelsComplete - one SchedSel per CPB as signalled by cpb_cnt_minus1
+func (hrd *HRDParameters) schedSelsComplete() bool {
+	return len(hrd.SchedSels) == int(hrd.CpbCntMinus1)+1
+}
+
+func writeHRDParameters(w *nalu.RBSPWriter, hrd *HRDParameters) {
+	w.WriteExpGolomb(uint(hrd.CpbCntMinus1))
+	w.Write(uint(hrd.BitRateScale), 4)
+	w.Write(uint(hrd.CpbSizeScale), 4)
+	for _, sel := range hrd.SchedSels {
+		w.WriteExpGolomb(uint(sel.BitRateValueMinus1))
+		w.WriteExpGolomb(uint(sel.CpbSizeValueMinus1))
+		w.WriteFlag(sel.CbrFlag)
+	}
+	w.Write(uint(hrd.InitialCpbRemovalDelayLengthMinus1), 5)
+	w.Write(uint(hrd.CpbRemovalDelayLengthMinus1), 5)
+	w.Write(uint(hrd.DpbOutputDelayLengthMinus1), 5)
+	w.Write(uint(hrd.TimeOffsetLength), 5)
+}
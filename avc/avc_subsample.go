@@ -0,0 +1,108 @@
+package avc
+
+import (
+	"fmt"
+
+	"github.com/go-webdl/media-codec/nalu"
+)
+
+// cencBlockSize - AES block size
+const cencBlockSize = 16
+
+// maxClearBytes - largest BytesOfClearData of a subsample
+const maxClearBytes = 1<<16 - 1
+
+// SubsampleMap - CENC subsample map of a length-prefixed sample
+// Only slice data is protected, ISO/IEC 23001-7 Sec. 10.2: length fields, NAL
+// unit headers, slice headers and non-VCL NAL units stay in the clear. The
+// slice headers are parsed with the parameter sets of the record and
+// those carried in the sample, as for avc3. With blockAlign the protected
+// part of each slice is shortened to a multiple of 16 bytes, as required by
+// the 'cbc1' and 'cens' schemes; slices with less slice data are left in the
+// clear. For 'cenc' and 'cbcs' blockAlign is false: 'cbcs' leaves a final
+// partial block of the protected part in the clear by its pattern
+// encryption, Sec. 10.4, so the whole slice data is protected. Data
+// partitioning and MVC slices are not supported.
+func SubsampleMap(sample []byte, record *AVCDecoderConfigurationRecord, blockAlign bool) ([]nalu.Subsample, error) {
+	spsMap := make(map[byte]*SPS)
+	ppsMap := make(map[byte]*PPS)
+	for i, entry := range record.SequenceParameterSets {
+		sps, err := ParseSPSNALUnit(entry.NALUnit)
+		if err != nil {
+			return nil, fmt.Errorf("record SPS %d: %w", i, err)
+		}
+		spsMap[sps.SpsID] = sps
+	}
+	for i, entry := range record.PictureParameterSets {
+		pps, err := ParsePPSNALUnit(entry.NALUnit, spsMap)
+		if err != nil {
+			return nil, fmt.Errorf("record PPS %d: %w", i, err)
+		}
+		ppsMap[pps.PpsID] = pps
+	}
+	lengthSize := int(record.LengthSizeMinusOne&0b11) + 1
+	nalus, err := nalu.SplitSample(sample, lengthSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var subsamples []nalu.Subsample
+	clear := 0
+	for i, data := range nalus {
+		clear += lengthSize
+		if len(data) == 0 {
+			continue
+		}
+		naluType := GetNaluType(data[0])
+		switch {
+		case naluType == NALU_SPS:
+			sps, err := ParseSPSNALUnit(data)
+			if err != nil {
+				return nil, fmt.Errorf("NAL unit %d: %w", i, err)
+			}
+			spsMap[sps.SpsID] = sps
+		case naluType == NALU_PPS:
+			pps, err := ParsePPSNALUnit(data, spsMap)
+			if err != nil {
+				return nil, fmt.Errorf("NAL unit %d: %w", i, err)
+			}
+			ppsMap[pps.PpsID] = pps
+		case naluType >= 2 && naluType <= 4, naluType == 20:
+			return nil, fmt.Errorf("NAL unit %d: encryption of %s not supported", i, naluType)
+		}
+		if naluType != NALU_NON_IDR && naluType != NALU_IDR {
+			clear += len(data)
+			continue
+		}
+		sh, err := ParseSliceHeader(data, spsMap, ppsMap)
+		if err != nil {
+			return nil, fmt.Errorf("NAL unit %d: %w", i, err)
+		}
+		protected := len(data) - sh.Size
+		if blockAlign {
+			protected -= protected % cencBlockSize
+		}
+		clear += len(data) - protected
+		if protected == 0 {
+			continue
+		}
+		subsamples = appendSubsample(subsamples, clear, protected)
+		clear = 0
+	}
+	if clear > 0 || len(subsamples) == 0 {
+		subsamples = appendSubsample(subsamples, clear, 0)
+	}
+	return subsamples, nil
+}
+
+// appendSubsample - append a subsample, preceded by clear-only ones if clear exceeds 16 bits
+func appendSubsample(subsamples []nalu.Subsample, clear, protected int) []nalu.Subsample {
+	for clear > maxClearBytes {
+		subsamples = append(subsamples, nalu.Subsample{BytesOfClearData: maxClearBytes})
+		clear -= maxClearBytes
+	}
+	return append(subsamples, nalu.Subsample{
+		BytesOfClearData:     uint16(clear),
+		BytesOfProtectedData: uint32(protected),
+	})
+}
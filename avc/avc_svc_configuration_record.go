@@ -0,0 +1,70 @@
+package avc
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// SVCDecoderConfigurationRecord - configuration of an SVC elementary stream
+type SVCDecoderConfigurationRecord struct {
+	ConfigurationVersion uint8
+	AVCProfileIndication uint8
+	ProfileCompatibility uint8
+	AVCLevelIndication   uint8
+	// indicates that all NAL units of the stream, including those of other
+	// tracks the stream depends on, are contained in the track
+	CompleteRepresentation bool
+	LengthSizeMinusOne     uint8
+	SequenceParameterSets  []AVCSequenceParameterSet
+	PictureParameterSets   []AVCPictureParameterSet
+}
+
+func (b *SVCDecoderConfigurationRecord) RecordSize() (size uint32) {
+	// unsigned int(8) configurationVersion = 1;
+	// unsigned int(8) AVCProfileIndication;
+	// unsigned int(8) profile_compatibility;
+	// unsigned int(8) AVCLevelIndication;
+	// bit(1) complete_represenation;
+	// bit(5) reserved = '11111'b;
+	// unsigned int(2) lengthSizeMinusOne;
+	// bit(1) reserved = '0'b;
+	// unsigned int(7) numOfSequenceParameterSets;
+	size += 6
+	for _, sps := range b.SequenceParameterSets {
+		size += 2 + uint32(len(sps.NALUnit))
+	}
+	// unsigned int(8) numOfPictureParameterSets;
+	size += 1
+	for _, pps := range b.PictureParameterSets {
+		size += 2 + uint32(len(pps.NALUnit))
+	}
+	return
+}
+
+func (b *SVCDecoderConfigurationRecord) RecordRead(r io.Reader) (err error) {
+	var tmp [6]uint8
+	if err = binary.Read(r, binary.BigEndian, &tmp); err != nil {
+		return
+	}
+	b.ConfigurationVersion = tmp[0]
+	b.AVCProfileIndication = tmp[1]
+	b.ProfileCompatibility = tmp[2]
+	b.AVCLevelIndication = tmp[3]
+	b.CompleteRepresentation = tmp[4]&0b10000000 != 0
+	b.LengthSizeMinusOne = tmp[4] & 0b11
+	b.SequenceParameterSets, b.PictureParameterSets, err = readParameterSetArrays(r, int(tmp[5]&0b1111111))
+	return
+}
+
+func (b *SVCDecoderConfigurationRecord) RecordWrite(w io.Writer) (err error) {
+	flags := b.LengthSizeMinusOne | 0b01111100
+	if b.CompleteRepresentation {
+		flags |= 0b10000000
+	}
+	tmp := []uint8{b.ConfigurationVersion, b.AVCProfileIndication, b.ProfileCompatibility, b.AVCLevelIndication, flags,
+		uint8(len(b.SequenceParameterSets)) & 0b1111111}
+	if _, err = w.Write(tmp); err != nil {
+		return
+	}
+	return writeParameterSetArrays(w, b.SequenceParameterSets, b.PictureParameterSets)
+}
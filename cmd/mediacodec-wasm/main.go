@@ -0,0 +1,97 @@
+//go:build js && wasm
+// +build js,wasm
+
+// Command mediacodec-wasm exposes configuration record probing and Annex B
+// splitting to JavaScript as the global object mediaCodec.
+//
+//	GOOS=js GOARCH=wasm go build -o mediacodec.wasm ./cmd/mediacodec-wasm
+//
+// mediaCodec.probe(sampleEntry, recordBytes) returns the normalized
+// parameters of a configuration record, e.g. the payload of an avcC box, or
+// throws. mediaCodec.newSplitter() returns an object with push(chunk) and
+// flush() methods wrapping nalu.Splitter; NAL units are returned as
+// Uint8Arrays.
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/go-webdl/media-codec/codec"
+	"github.com/go-webdl/media-codec/nalu"
+)
+
+func main() {
+	js.Global().Set("mediaCodec", js.ValueOf(map[string]interface{}{
+		"probe":       js.FuncOf(probe),
+		"newSplitter": js.FuncOf(newSplitter),
+	}))
+	select {}
+}
+
+// bytesFromJS - copy of a Uint8Array
+func bytesFromJS(v js.Value) []byte {
+	b := make([]byte, v.Get("length").Int())
+	js.CopyBytesToGo(b, v)
+	return b
+}
+
+// bytesToJS - Uint8Array copy of b
+func bytesToJS(b []byte) js.Value {
+	v := js.Global().Get("Uint8Array").New(len(b))
+	js.CopyBytesToJS(v, b)
+	return v
+}
+
+func throw(err error) {
+	panic(js.Global().Get("Error").New(err.Error()))
+}
+
+func probe(this js.Value, args []js.Value) interface{} {
+	if len(args) != 2 {
+		panic(js.Global().Get("TypeError").New("probe(sampleEntry, recordBytes)"))
+	}
+	params, err := codec.Probe(args[0].String(), bytesFromJS(args[1]))
+	if err != nil {
+		throw(err)
+	}
+	result := map[string]interface{}{
+		"codec":          params.Codec,
+		"sampleEntry":    params.SampleEntry,
+		"codecString":    params.CodecString,
+		"profile":        params.Profile,
+		"level":          params.Level,
+		"width":          params.Width,
+		"height":         params.Height,
+		"chromaFormat":   params.ChromaFormat,
+		"bitDepthLuma":   params.BitDepthLuma,
+		"bitDepthChroma": params.BitDepthChroma,
+	}
+	if params.Colour != nil {
+		result["colour"] = map[string]interface{}{
+			"colourPrimaries":         params.Colour.ColourPrimaries,
+			"transferCharacteristics": params.Colour.TransferCharacteristics,
+			"matrixCoefficients":      params.Colour.MatrixCoefficients,
+			"fullRange":               params.Colour.FullRangeFlag,
+		}
+	}
+	return js.ValueOf(result)
+}
+
+func newSplitter(this js.Value, args []js.Value) interface{} {
+	s := &nalu.Splitter{}
+	push := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		nalus := s.Push(bytesFromJS(args[0]))
+		result := make([]interface{}, len(nalus))
+		for i, n := range nalus {
+			result[i] = bytesToJS(n)
+		}
+		return js.ValueOf(result)
+	})
+	flush := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if n := s.Flush(); n != nil {
+			return bytesToJS(n)
+		}
+		return js.Null()
+	})
+	return js.ValueOf(map[string]interface{}{"push": push, "flush": flush})
+}
@@ -0,0 +1,122 @@
+// Command mediacodec runs stream maintenance tasks on configuration records,
+// e.g. the payload of an avcC or hvcC box.
+//
+//	mediacodec repair -entry hev1 -fps 30000/1001 -o fixed.hvcC record.hvcC
+//
+// The repair verb chains the fixers of the repair package and prints each
+// change. With -n or without -o it only reports what would change.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-webdl/media-codec/repair"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: mediacodec repair [flags] record")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	switch os.Args[1] {
+	case "repair":
+		os.Exit(runRepair(os.Args[2:]))
+	default:
+		usage()
+	}
+}
+
+// skipFlags - fixer names accepted by -skip
+var skipFlags = map[string]repair.Fix{
+	repair.FIX_DEDUP.String():  repair.FIX_DEDUP,
+	repair.FIX_TIMING.String(): repair.FIX_TIMING,
+	repair.FIX_LEVEL.String():  repair.FIX_LEVEL,
+	repair.FIX_CONFIG.String(): repair.FIX_CONFIG,
+	repair.FIX_HVC1.String():   repair.FIX_HVC1,
+}
+
+func runRepair(args []string) int {
+	fs := flag.NewFlagSet("repair", flag.ExitOnError)
+	sampleEntry := fs.String("entry", "avc1", "sample entry of the record, e.g. avc1 or hev1")
+	dryRun := fs.Bool("n", false, "dry run, only report changes")
+	fps := fs.String("fps", "", "frame rate for missing VUI timing, e.g. 25 or 30000/1001")
+	bitRate := fs.Uint64("bitrate", 0, "peak bit rate in bits/s for level recomputation")
+	skip := fs.String("skip", "", "comma separated fixers not to run: dedup,timing,level,config,hvc1")
+	output := fs.String("o", "", "file to write the repaired record to, dry run without")
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		usage()
+	}
+	*dryRun = *dryRun || *output == ""
+
+	opts := repair.Options{DryRun: *dryRun, BitRate: *bitRate}
+	if *fps != "" {
+		num, den, err := parseFrameRate(*fps)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		opts.FrameRateNum, opts.FrameRateDen = num, den
+	}
+	if *skip != "" {
+		for _, name := range strings.Split(*skip, ",") {
+			f, ok := skipFlags[name]
+			if !ok {
+				fmt.Fprintf(os.Stderr, "unknown fixer %q\n", name)
+				return 2
+			}
+			opts.Skip |= f
+		}
+	}
+
+	record, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	report, err := repair.Repair(*sampleEntry, record, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", fs.Arg(0), err)
+		return 1
+	}
+	for _, change := range report.Changes {
+		fmt.Println(change)
+	}
+	if report.SampleEntry != *sampleEntry {
+		fmt.Printf("use sample entry %s and remove parameter sets from samples\n", report.SampleEntry)
+	}
+	if *dryRun {
+		fmt.Println("dry run, nothing written")
+		return 0
+	}
+	if err := os.WriteFile(*output, report.Record, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// parseFrameRate - frame rate given as an integer or num/den
+func parseFrameRate(s string) (num, den uint32, err error) {
+	numStr, denStr := s, "1"
+	if i := strings.IndexByte(s, '/'); i >= 0 {
+		numStr, denStr = s[:i], s[i+1:]
+	}
+	n, err := strconv.ParseUint(numStr, 10, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("frame rate %q: %w", s, err)
+	}
+	d, err := strconv.ParseUint(denStr, 10, 32)
+	if err != nil || d == 0 {
+		return 0, 0, fmt.Errorf("frame rate %q: bad denominator", s)
+	}
+	return uint32(n), uint32(d), nil
+}
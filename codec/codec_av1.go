@@ -0,0 +1,50 @@
+package codec
+
+import (
+	"github.com/go-webdl/media-codec/av1"
+)
+
+func init() {
+	MustRegister(&Codec{
+		Name:          "av1",
+		SampleEntries: []string{"av01"},
+		NewRecord:     func() Record { return &av1.AV1CodecConfigurationRecord{} },
+		Parameters:    av1Parameters,
+		SplitSample: func(sample []byte, record Record) ([][]byte, error) {
+			return av1.SplitOBUs(sample)
+		},
+		IsSync: av1.IsSyncTemporalUnit,
+	})
+}
+
+// av1Parameters - parameters of the sequence header in configOBUs, or only
+// those of the record fields if there is none
+func av1Parameters(record Record, sampleEntry string) (*Parameters, error) {
+	b := record.(*av1.AV1CodecConfigurationRecord)
+	params := &Parameters{
+		Profile:        int(b.SeqProfile),
+		Level:          int(b.SeqLevelIdx0),
+		BitDepthLuma:   b.BitDepth(),
+		BitDepthChroma: b.BitDepth(),
+	}
+	c := av1.ColorConfig{
+		MonoChrome:   b.Monochrome,
+		SubsamplingX: b.ChromaSubsamplingX,
+		SubsamplingY: b.ChromaSubsamplingY,
+	}
+	params.ChromaFormat = c.ChromaFormat()
+	sh, err := b.SequenceHeader()
+	if err == av1.ErrNoSequenceHeader {
+		return params, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	params.Width = sh.MaxFrameWidthMinus1 + 1
+	params.Height = sh.MaxFrameHeightMinus1 + 1
+	if sh.ColorConfig.ColorDescriptionPresentFlag {
+		nclx := sh.ColorConfig.NCLX()
+		params.Colour = &nclx
+	}
+	return params, nil
+}
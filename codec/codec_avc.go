@@ -0,0 +1,48 @@
+package codec
+
+import (
+	"errors"
+
+	"github.com/go-webdl/media-codec/avc"
+	"github.com/go-webdl/media-codec/nalu"
+)
+
+func init() {
+	MustRegister(&Codec{
+		Name:          "avc",
+		SampleEntries: []string{"avc1", "avc2", "avc3", "avc4"},
+		NewRecord:     func() Record { return &avc.AVCDecoderConfigurationRecord{} },
+		Parameters:    avcParameters,
+		SplitSample: func(sample []byte, record Record) ([][]byte, error) {
+			return nalu.SplitSample(sample, int(record.(*avc.AVCDecoderConfigurationRecord).LengthSizeMinusOne)+1)
+		},
+		IsSync: avc.IsRandomAccessUnit,
+		SplitProtectedSample: func(sample []byte, record Record, subsamples []nalu.Subsample) ([]nalu.ProtectedNALU, error) {
+			return nalu.SplitProtectedSample(sample, int(record.(*avc.AVCDecoderConfigurationRecord).LengthSizeMinusOne)+1, subsamples)
+		},
+	})
+}
+
+func avcParameters(record Record, sampleEntry string) (*Parameters, error) {
+	b := record.(*avc.AVCDecoderConfigurationRecord)
+	if len(b.SequenceParameterSets) == 0 {
+		return nil, errors.New("no SPS")
+	}
+	sps, err := avc.ParseSPSNALUnit(b.SequenceParameterSets[0].NALUnit)
+	if err != nil {
+		return nil, err
+	}
+	params := &Parameters{
+		Profile:        int(sps.ProfileIndicator),
+		Level:          int(sps.LevelIndicator),
+		ChromaFormat:   sps.ChromaFormatIndicator,
+		BitDepthLuma:   sps.BitDepthLumaMinus8 + 8,
+		BitDepthChroma: sps.BitDepthChromaMinus8 + 8,
+	}
+	params.Width, params.Height = sps.ImageSize()
+	if sps.VUI.ColourDescriptionPresentFlag {
+		nclx := sps.VUI.NCLX()
+		params.Colour = &nclx
+	}
+	return params, nil
+}
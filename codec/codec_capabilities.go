@@ -0,0 +1,93 @@
+package codec
+
+import (
+	"fmt"
+
+	"github.com/go-webdl/media-codec/colr"
+)
+
+// HDR metadata types of the MediaCapabilities API
+const (
+	HDR_METADATA_SMPTE_ST_2086    = "smpteSt2086"
+	HDR_METADATA_SMPTE_ST_2094_10 = "smpteSt2094-10"
+	HDR_METADATA_SMPTE_ST_2094_40 = "smpteSt2094-40"
+)
+
+// VideoConfiguration - video configuration of a MediaCapabilities query
+// (navigator.mediaCapabilities.decodingInfo) for the stream a record
+// describes, so server and client agree on the values derived from it
+// Fields with an empty value are not known from the record and are omitted
+// from the JSON form.
+type VideoConfiguration struct {
+	ContentType      string  `json:"contentType"`
+	Width            uint32  `json:"width"`
+	Height           uint32  `json:"height"`
+	Bitrate          uint64  `json:"bitrate"`
+	Framerate        float64 `json:"framerate"`
+	HdrMetadataType  string  `json:"hdrMetadataType,omitempty"`
+	ColorGamut       string  `json:"colorGamut,omitempty"`
+	TransferFunction string  `json:"transferFunction,omitempty"`
+}
+
+// CapabilityOptions - stream properties not described by a record
+type CapabilityOptions struct {
+	// MimeType - container MIME type, video/mp4 if empty
+	MimeType string
+	// Bitrate - peak or average bit rate in bits/s
+	Bitrate uint64
+	// Framerate - frames per second
+	Framerate float64
+	// HdrMetadataType - dynamic HDR metadata carried in the stream, e.g.
+	// HDR_METADATA_SMPTE_ST_2094_40 for HDR10+. If empty, static SMPTE ST 2086
+	// metadata is assumed for PQ streams.
+	HdrMetadataType string
+}
+
+// VideoConfiguration - MediaCapabilities video configuration of the stream
+func (p *Parameters) VideoConfiguration(opts CapabilityOptions) VideoConfiguration {
+	mimeType := opts.MimeType
+	if mimeType == "" {
+		mimeType = "video/mp4"
+	}
+	c := VideoConfiguration{
+		ContentType:     fmt.Sprintf("%s; codecs=\"%s\"", mimeType, p.CodecString),
+		Width:           p.Width,
+		Height:          p.Height,
+		Bitrate:         opts.Bitrate,
+		Framerate:       opts.Framerate,
+		HdrMetadataType: opts.HdrMetadataType,
+	}
+	if p.Colour == nil {
+		return c
+	}
+	switch p.Colour.ColourPrimaries {
+	case colr.COLOUR_PRIMARIES_BT709:
+		c.ColorGamut = "srgb"
+	case colr.COLOUR_PRIMARIES_SMPTE431, colr.COLOUR_PRIMARIES_SMPTE432:
+		c.ColorGamut = "p3"
+	case colr.COLOUR_PRIMARIES_BT2020:
+		c.ColorGamut = "rec2020"
+	}
+	switch p.Colour.TransferCharacteristics {
+	case colr.TRANSFER_BT709, colr.TRANSFER_SMPTE170M, colr.TRANSFER_SRGB,
+		colr.TRANSFER_BT2020_10BIT, colr.TRANSFER_BT2020_12BIT:
+		c.TransferFunction = "srgb"
+	case colr.TRANSFER_SMPTE2084:
+		c.TransferFunction = "pq"
+		if c.HdrMetadataType == "" {
+			c.HdrMetadataType = HDR_METADATA_SMPTE_ST_2086
+		}
+	case colr.TRANSFER_HLG:
+		c.TransferFunction = "hlg"
+	}
+	return c
+}
+
+// ProbeVideoConfiguration - MediaCapabilities video configuration of a sample entry and its record
+func ProbeVideoConfiguration(sampleEntry string, record []byte, opts CapabilityOptions) (VideoConfiguration, error) {
+	p, err := Probe(sampleEntry, record)
+	if err != nil {
+		return VideoConfiguration{}, err
+	}
+	return p.VideoConfiguration(opts), nil
+}
@@ -0,0 +1,58 @@
+package codec
+
+import (
+	"errors"
+
+	"github.com/go-webdl/media-codec/hevc"
+	"github.com/go-webdl/media-codec/nalu"
+)
+
+func init() {
+	MustRegister(&Codec{
+		Name:          "hevc",
+		SampleEntries: []string{"hvc1", "hev1", "hvc2", "hev2"},
+		NewRecord:     func() Record { return &hevc.HEVCDecoderConfigurationRecord{} },
+		Parameters:    hevcParameters,
+		SplitSample: func(sample []byte, record Record) ([][]byte, error) {
+			return nalu.SplitSample(sample, record.(*hevc.HEVCDecoderConfigurationRecord).LengthSize())
+		},
+		IsSync: func(nalus [][]byte) bool {
+			for _, nalu := range nalus {
+				if len(nalu) > 0 && hevc.GetNaluType(nalu[0]).IsIRAP() {
+					return true
+				}
+			}
+			return false
+		},
+		SplitProtectedSample: func(sample []byte, record Record, subsamples []nalu.Subsample) ([]nalu.ProtectedNALU, error) {
+			return nalu.SplitProtectedSample(sample, record.(*hevc.HEVCDecoderConfigurationRecord).LengthSize(), subsamples)
+		},
+	})
+}
+
+func hevcParameters(record Record, sampleEntry string) (*Parameters, error) {
+	b := record.(*hevc.HEVCDecoderConfigurationRecord)
+	for _, array := range b.NaluArrays {
+		if array.NALUnitType != hevc.NALU_SPS || len(array.NALUs) == 0 {
+			continue
+		}
+		sps, err := hevc.ParseSPSNALUnit(array.NALUs[0])
+		if err != nil {
+			return nil, err
+		}
+		params := &Parameters{
+			Profile:        int(b.GenertalProfileIndicator),
+			Level:          int(b.GeneralLevelIndicator),
+			ChromaFormat:   sps.ChromaFormatIndicator,
+			BitDepthLuma:   sps.BitDepthLumaMinus8 + 8,
+			BitDepthChroma: sps.BitDepthChromaMinus8 + 8,
+		}
+		params.Width, params.Height = sps.ImageSize()
+		if sps.VUIParametersPresentFlag && sps.VUI.ColourDescriptionPresentFlag {
+			nclx := sps.VUI.NCLX()
+			params.Colour = &nclx
+		}
+		return params, nil
+	}
+	return nil, errors.New("no SPS")
+}
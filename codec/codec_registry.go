@@ -0,0 +1,189 @@
+package codec
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/go-webdl/media-codec/colr"
+	"github.com/go-webdl/media-codec/nalu"
+)
+
+// Record - decoder configuration record stored in a sample entry
+type Record interface {
+	RecordSize() (size uint32)
+	RecordRead(r io.Reader) (err error)
+	RecordWrite(w io.Writer) (err error)
+	CodecString(sampleEntry string) string
+}
+
+// SizedRecord - a Record that keeps data following its known fields, such as
+// extension data, when reading a record of known size
+type SizedRecord interface {
+	Record
+	RecordReadSize(r io.Reader, size int) (err error)
+}
+
+// Parameters - codec independent description of a video stream
+type Parameters struct {
+	// Codec - name of the registered codec
+	Codec       string
+	SampleEntry string
+	// CodecString - RFC 6381 codecs parameter
+	CodecString string
+	// Profile, Level - codec specific profile and level indicators
+	Profile int
+	Level   int
+	// Width, Height - displayed size in luma samples, after cropping
+	Width  uint32
+	Height uint32
+	// ChromaFormat - 0 monochrome, 1 4:2:0, 2 4:2:2, 3 4:4:4
+	ChromaFormat   byte
+	BitDepthLuma   byte
+	BitDepthChroma byte
+	// Colour - colour description, nil if not signalled
+	Colour *colr.NCLX
+}
+
+// Codec - handlers of one codec
+type Codec struct {
+	// Name - short lower case name, e.g. avc
+	Name string
+	// SampleEntries - four character codes of the sample entries of the codec
+	SampleEntries []string
+	// NewRecord - empty decoder configuration record to read into
+	NewRecord func() Record
+	// Parameters - normalized parameters described by a record
+	Parameters func(record Record, sampleEntry string) (*Parameters, error)
+	// SplitSample - split a sample into its NAL units or OBUs, optional
+	SplitSample func(sample []byte, record Record) ([][]byte, error)
+	// IsSync - is a sample, split by SplitSample, a random access point, optional
+	IsSync func(units [][]byte) bool
+	// SplitProtectedSample - split a CENC protected sample with the given
+	// subsample map into its NAL units, optional
+	SplitProtectedSample func(sample []byte, record Record, subsamples []nalu.Subsample) ([]nalu.ProtectedNALU, error)
+}
+
+var (
+	// ErrUnknownSampleEntry - no codec is registered for the sample entry
+	ErrUnknownSampleEntry = errors.New("unknown sample entry")
+
+	registryMu    sync.RWMutex
+	codecsByName  = make(map[string]*Codec)
+	codecsByEntry = make(map[string]*Codec)
+)
+
+// Register - make a codec available to Lookup and Probe
+// Codec packages outside this module take part in probing and parameter
+// extraction this way; AVC and HEVC are registered by this package. Names
+// and sample entries must not be registered already.
+func Register(c *Codec) error {
+	if c.Name == "" || c.NewRecord == nil || c.Parameters == nil {
+		return errors.New("codec needs a name, NewRecord and Parameters")
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := codecsByName[c.Name]; ok {
+		return fmt.Errorf("codec %s already registered", c.Name)
+	}
+	for _, entry := range c.SampleEntries {
+		if len(entry) != 4 {
+			return fmt.Errorf("codec %s: sample entry %q is not a four character code", c.Name, entry)
+		}
+		if other, ok := codecsByEntry[entry]; ok {
+			return fmt.Errorf("codec %s: sample entry %s already registered by %s", c.Name, entry, other.Name)
+		}
+	}
+	codecsByName[c.Name] = c
+	for _, entry := range c.SampleEntries {
+		codecsByEntry[entry] = c
+	}
+	return nil
+}
+
+// MustRegister - Register, panicking on error, typically from an init function:
+//
+//	func init() {
+//		codec.MustRegister(&codec.Codec{
+//			Name:          "vvc",
+//			SampleEntries: []string{"vvc1", "vvi1"},
+//			NewRecord:     func() codec.Record { return &VVCDecoderConfigurationRecord{} },
+//			Parameters:    parameters,
+//			SplitSample:   splitSample,
+//		})
+//	}
+func MustRegister(c *Codec) {
+	if err := Register(c); err != nil {
+		panic(err)
+	}
+}
+
+// Lookup - codec registered for a sample entry
+func Lookup(sampleEntry string) (*Codec, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	c, ok := codecsByEntry[sampleEntry]
+	return c, ok
+}
+
+// LookupName - codec registered under name
+func LookupName(name string) (*Codec, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	c, ok := codecsByName[name]
+	return c, ok
+}
+
+// Codecs - all registered codecs, ordered by name
+func Codecs() (codecs []*Codec) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	for _, c := range codecsByName {
+		codecs = append(codecs, c)
+	}
+	sort.Slice(codecs, func(i, j int) bool { return codecs[i].Name < codecs[j].Name })
+	return codecs
+}
+
+// ReadRecord - decode the configuration record data of a sample entry
+func ReadRecord(sampleEntry string, data []byte) (*Codec, Record, error) {
+	c, ok := Lookup(sampleEntry)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnknownSampleEntry, sampleEntry)
+	}
+	record := c.NewRecord()
+	read := record.RecordRead
+	if sized, ok := record.(SizedRecord); ok {
+		read = func(r io.Reader) error { return sized.RecordReadSize(r, len(data)) }
+	}
+	if err := read(bytes.NewReader(data)); err != nil {
+		return c, nil, fmt.Errorf("%s: %w", c.Name, err)
+	}
+	return c, record, nil
+}
+
+// Probe - normalized parameters of a sample entry and its configuration record data
+func Probe(sampleEntry string, data []byte) (*Parameters, error) {
+	c, record, err := ReadRecord(sampleEntry, data)
+	if err != nil {
+		return nil, err
+	}
+	return ProbeRecord(c, record, sampleEntry)
+}
+
+// ProbeRecord - normalized parameters of a record already decoded by ReadRecord
+func ProbeRecord(c *Codec, record Record, sampleEntry string) (*Parameters, error) {
+	params, err := c.Parameters(record, sampleEntry)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", c.Name, err)
+	}
+	params.Codec = c.Name
+	params.SampleEntry = sampleEntry
+	if params.CodecString == "" {
+		params.CodecString = record.CodecString(sampleEntry)
+	}
+	return params, nil
+}
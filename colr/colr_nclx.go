@@ -0,0 +1,122 @@
+package colr
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Colour description code points shared by H.264/H.265 VUI, AV1, VP9 and the
+// nclx colour information box (ITU-T H.273)
+const (
+	COLOUR_PRIMARIES_BT709       = uint16(1)
+	COLOUR_PRIMARIES_UNSPECIFIED = uint16(2)
+	COLOUR_PRIMARIES_BT470M      = uint16(4)
+	COLOUR_PRIMARIES_BT470BG     = uint16(5)
+	COLOUR_PRIMARIES_SMPTE170M   = uint16(6)
+	COLOUR_PRIMARIES_SMPTE240M   = uint16(7)
+	COLOUR_PRIMARIES_FILM        = uint16(8)
+	COLOUR_PRIMARIES_BT2020      = uint16(9)
+	COLOUR_PRIMARIES_XYZ         = uint16(10)
+	COLOUR_PRIMARIES_SMPTE431    = uint16(11)
+	COLOUR_PRIMARIES_SMPTE432    = uint16(12)
+	COLOUR_PRIMARIES_EBU3213     = uint16(22)
+
+	TRANSFER_BT709        = uint16(1)
+	TRANSFER_UNSPECIFIED  = uint16(2)
+	TRANSFER_BT470M       = uint16(4)
+	TRANSFER_BT470BG      = uint16(5)
+	TRANSFER_SMPTE170M    = uint16(6)
+	TRANSFER_SMPTE240M    = uint16(7)
+	TRANSFER_LINEAR       = uint16(8)
+	TRANSFER_LOG100       = uint16(9)
+	TRANSFER_LOG100_SQRT  = uint16(10)
+	TRANSFER_IEC61966     = uint16(11)
+	TRANSFER_BT1361       = uint16(12)
+	TRANSFER_SRGB         = uint16(13)
+	TRANSFER_BT2020_10BIT = uint16(14)
+	TRANSFER_BT2020_12BIT = uint16(15)
+	TRANSFER_SMPTE2084    = uint16(16)
+	TRANSFER_SMPTE428     = uint16(17)
+	TRANSFER_HLG          = uint16(18)
+
+	MATRIX_IDENTITY    = uint16(0)
+	MATRIX_BT709       = uint16(1)
+	MATRIX_UNSPECIFIED = uint16(2)
+	MATRIX_FCC         = uint16(4)
+	MATRIX_BT470BG     = uint16(5)
+	MATRIX_SMPTE170M   = uint16(6)
+	MATRIX_SMPTE240M   = uint16(7)
+	MATRIX_YCGCO       = uint16(8)
+	MATRIX_BT2020_NCL  = uint16(9)
+	MATRIX_BT2020_CL   = uint16(10)
+	MATRIX_SMPTE2085   = uint16(11)
+	MATRIX_CHROMAT_NCL = uint16(12)
+	MATRIX_CHROMAT_CL  = uint16(13)
+	MATRIX_ICTCP       = uint16(14)
+)
+
+// ColourTypeNCLX - colour_type of an on-screen colours colr box
+const ColourTypeNCLX = "nclx"
+
+// NCLX - colour information of type nclx
+// ISO/IEC 14496-12 Sec. 12.1.5
+type NCLX struct {
+	ColourPrimaries         uint16
+	TransferCharacteristics uint16
+	MatrixCoefficients      uint16
+	FullRangeFlag           bool
+}
+
+// Unspecified - NCLX with all code points unspecified and limited range
+func Unspecified() NCLX {
+	return NCLX{
+		ColourPrimaries:         COLOUR_PRIMARIES_UNSPECIFIED,
+		TransferCharacteristics: TRANSFER_UNSPECIFIED,
+		MatrixCoefficients:      MATRIX_UNSPECIFIED,
+	}
+}
+
+// IsHDR - transfer characteristics is PQ or HLG
+func (b *NCLX) IsHDR() bool {
+	return b.TransferCharacteristics == TRANSFER_SMPTE2084 || b.TransferCharacteristics == TRANSFER_HLG
+}
+
+func (b *NCLX) RecordSize() (size uint32) {
+	// unsigned int(32) colour_type;
+	// unsigned int(16) colour_primaries;
+	// unsigned int(16) transfer_characteristics;
+	// unsigned int(16) matrix_coefficients;
+	// unsigned int(1) full_range_flag;
+	// unsigned int(7) reserved = 0;
+	size = 11
+	return
+}
+
+func (b *NCLX) RecordRead(r io.Reader) (err error) {
+	var tmp [11]uint8
+	if err = binary.Read(r, binary.BigEndian, &tmp); err != nil {
+		return
+	}
+	if colourType := string(tmp[0:4]); colourType != ColourTypeNCLX {
+		return fmt.Errorf("colour type is %q not %s", colourType, ColourTypeNCLX)
+	}
+	b.ColourPrimaries = binary.BigEndian.Uint16(tmp[4:6])
+	b.TransferCharacteristics = binary.BigEndian.Uint16(tmp[6:8])
+	b.MatrixCoefficients = binary.BigEndian.Uint16(tmp[8:10])
+	b.FullRangeFlag = (tmp[10] >> 7) > 0
+	return
+}
+
+func (b *NCLX) RecordWrite(w io.Writer) (err error) {
+	var tmp [11]uint8
+	copy(tmp[0:4], ColourTypeNCLX)
+	binary.BigEndian.PutUint16(tmp[4:6], b.ColourPrimaries)
+	binary.BigEndian.PutUint16(tmp[6:8], b.TransferCharacteristics)
+	binary.BigEndian.PutUint16(tmp[8:10], b.MatrixCoefficients)
+	if b.FullRangeFlag {
+		tmp[10] = 0b10000000
+	}
+	_, err = w.Write(tmp[:])
+	return
+}
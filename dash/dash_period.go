@@ -0,0 +1,145 @@
+package dash
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Record - decoder configuration record that can be placed in a DASH initialization segment
+type Record interface {
+	RecordSize() (size uint32)
+	RecordWrite(w io.Writer) (err error)
+	CodecString(sampleEntry string) string
+}
+
+// Config - decoder configuration in effect from Start until the Start of the next Config
+type Config struct {
+	Start       time.Duration
+	SampleEntry string // four character code of the sample entry, e.g. avc1, hev1
+	Record      Record
+}
+
+// BoundaryReason - why a new Period was started
+type BoundaryReason int
+
+const (
+	// BOUNDARY_START - first Period
+	BOUNDARY_START = BoundaryReason(0)
+	// BOUNDARY_SAMPLE_ENTRY - sample entry type changed, e.g. avc1 to hvc1
+	BOUNDARY_SAMPLE_ENTRY = BoundaryReason(1)
+	// BOUNDARY_CODECS - codecs string changed, e.g. a different profile or level
+	BOUNDARY_CODECS = BoundaryReason(2)
+	// BOUNDARY_INITIALIZATION - same codecs string, but the record differs and
+	// parameter sets are not carried in-band
+	BOUNDARY_INITIALIZATION = BoundaryReason(3)
+)
+
+func (b BoundaryReason) String() string {
+	switch b {
+	case BOUNDARY_START:
+		return "Start"
+	case BOUNDARY_SAMPLE_ENTRY:
+		return "SampleEntry"
+	case BOUNDARY_CODECS:
+		return "Codecs"
+	case BOUNDARY_INITIALIZATION:
+		return "Initialization"
+	default:
+		return fmt.Sprintf("Other_%d", b)
+	}
+}
+
+// AdaptationSet - proposed AdaptationSet of a Period
+// ID is reused across Periods carrying the same codecs string so that Period
+// connectivity can be signalled.
+type AdaptationSet struct {
+	ID          int
+	Codecs      string
+	SampleEntry string
+	Record      Record
+}
+
+// Period - proposed DASH Period
+// Duration is zero for the last Period, whose end is not known.
+type Period struct {
+	Start          time.Duration
+	Duration       time.Duration
+	Reason         BoundaryReason
+	AdaptationSets []AdaptationSet
+}
+
+// ErrNoConfigs - no configurations to plan
+var ErrNoConfigs = errors.New("no configurations")
+
+// inBandParameterSets - sample entries that allow parameter sets to change in-band
+var inBandParameterSets = map[string]bool{
+	"avc3": true,
+	"avc4": true,
+	"hev1": true,
+	"dvav": true,
+	"dvhe": true,
+}
+
+// Plan - propose Periods for a sequence of configurations ordered by Start
+// A new Period is started whenever the sample entry or codecs string changes, or
+// when the record changes and the sample entry does not carry parameter sets in-band.
+func Plan(configs []Config) (periods []Period, err error) {
+	if len(configs) == 0 {
+		return nil, ErrNoConfigs
+	}
+	ids := make(map[string]int)
+	var prev *Config
+	var prevRecord []byte
+	for i := range configs {
+		cfg := &configs[i]
+		if cfg.Record == nil {
+			return nil, fmt.Errorf("config %d: no record", i)
+		}
+		if prev != nil && cfg.Start < prev.Start {
+			return nil, fmt.Errorf("config %d: start %s before previous start %s", i, cfg.Start, prev.Start)
+		}
+		buf := bytes.NewBuffer(make([]byte, 0, cfg.Record.RecordSize()))
+		if err = cfg.Record.RecordWrite(buf); err != nil {
+			return nil, fmt.Errorf("config %d: %w", i, err)
+		}
+		record := buf.Bytes()
+		codecs := cfg.Record.CodecString(cfg.SampleEntry)
+
+		reason := BOUNDARY_START
+		if prev != nil {
+			switch {
+			case cfg.SampleEntry != prev.SampleEntry:
+				reason = BOUNDARY_SAMPLE_ENTRY
+			case codecs != prev.Record.CodecString(prev.SampleEntry):
+				reason = BOUNDARY_CODECS
+			case !bytes.Equal(record, prevRecord) && !inBandParameterSets[cfg.SampleEntry]:
+				reason = BOUNDARY_INITIALIZATION
+			default:
+				prev, prevRecord = cfg, record
+				continue
+			}
+			periods[len(periods)-1].Duration = cfg.Start - periods[len(periods)-1].Start
+		}
+
+		id, ok := ids[codecs]
+		if !ok {
+			id = len(ids) + 1
+			ids[codecs] = id
+		}
+		periods = append(periods, Period{
+			Start:  cfg.Start,
+			Reason: reason,
+			AdaptationSets: []AdaptationSet{{
+				ID:          id,
+				Codecs:      codecs,
+				SampleEntry: cfg.SampleEntry,
+				Record:      cfg.Record,
+			}},
+		})
+		prev, prevRecord = cfg, record
+	}
+	return periods, nil
+}
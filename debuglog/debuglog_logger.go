@@ -0,0 +1,43 @@
+package debuglog
+
+import (
+	"sync/atomic"
+)
+
+// Logger - receiver of debug records, satisfied by *slog.Logger
+// args are alternating keys and values.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+}
+
+// loggerHolder - wraps Logger so that atomic.Value always stores one type
+type loggerHolder struct {
+	logger Logger
+}
+
+var current atomic.Value
+
+// SetLogger - install the logger receiving debug records, nil to disable
+// Parsers report malformed input they accept, e.g. reserved bits not set as
+// required, and reserved fields they skip; without a logger this happens
+// silently. It is safe to call concurrently with parsing. A *slog.Logger can
+// be passed directly:
+//
+//	debuglog.SetLogger(slog.Default())
+func SetLogger(l Logger) {
+	current.Store(loggerHolder{l})
+}
+
+// Enabled - is a logger installed
+// Callers may use it to skip preparing expensive arguments.
+func Enabled() bool {
+	h, _ := current.Load().(loggerHolder)
+	return h.logger != nil
+}
+
+// Debug - emit a debug record if a logger is installed
+func Debug(msg string, args ...interface{}) {
+	if h, _ := current.Load().(loggerHolder); h.logger != nil {
+		h.logger.Debug(msg, args...)
+	}
+}
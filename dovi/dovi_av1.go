@@ -0,0 +1,121 @@
+package dovi
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-webdl/media-codec/av1"
+)
+
+// METADATA_TYPE_ITUT_T35 - metadata_type of ITU-T T.35 metadata OBUs
+const METADATA_TYPE_ITUT_T35 = 4
+
+// av1T35Payload - the T.35 data of a metadata OBU, starting with
+// itu_t_t35_country_code, nil if obu is not ITU-T T.35 metadata
+func av1T35Payload(obu []byte) []byte {
+	hdr, err := av1.ParseOBUHeader(obu)
+	if err != nil || hdr.Type != av1.OBU_METADATA {
+		return nil
+	}
+	payload := obu[hdr.HeaderSize : hdr.HeaderSize+int(hdr.Size)]
+	metadataType, n, err := av1.ReadLeb128(payload)
+	if err != nil || metadataType != METADATA_TYPE_ITUT_T35 {
+		return nil
+	}
+	return payload[n:]
+}
+
+// IsAV1RPUOBU - is obu a metadata OBU carrying a Dolby Vision RPU
+// Profile 10 carries each RPU in an ITU-T T.35 metadata OBU, wrapped as by
+// WrapT35RPU, and has no enhancement layer.
+func IsAV1RPUOBU(obu []byte) bool {
+	return IsT35RPU(av1T35Payload(obu))
+}
+
+// AV1RPU - the RPU of a metadata OBU, starting with rpu_nal_prefix and with
+// emulation prevention bytes, see UnwrapT35RPU
+func AV1RPU(obu []byte) ([]byte, error) {
+	t35 := av1T35Payload(obu)
+	if t35 == nil {
+		return nil, errors.New("not an ITU-T T.35 metadata OBU")
+	}
+	return UnwrapT35RPU(t35)
+}
+
+// CreateAV1RPUOBU - metadata OBU with obu_size carrying an RPU starting
+// with rpu_nal_prefix, with emulation prevention bytes
+// The T.35 data is followed by trailing bits, see Sec. 5.3.4 and 6.7.2.
+func CreateAV1RPUOBU(rpu []byte) ([]byte, error) {
+	t35, err := WrapT35RPU(rpu)
+	if err != nil {
+		return nil, err
+	}
+	payload := av1.AppendLeb128(nil, METADATA_TYPE_ITUT_T35)
+	payload = append(payload, t35...)
+	payload = append(payload, 0x80) // trailing_one_bit and zero bits
+	obu := []byte{byte(av1.OBU_METADATA)<<3 | 0x02}
+	obu = av1.AppendLeb128(obu, uint64(len(payload)))
+	return append(obu, payload...), nil
+}
+
+// DetectAV1Profile - Dolby Vision configuration record of an AV1 stream
+// from its OBUs, e.g. those of the configOBUs and first temporal units
+// The first sequence header and RPU are used.
+func DetectAV1Profile(obus [][]byte) (*DOVIDecoderConfigurationRecord, error) {
+	var seq *av1.SequenceHeader
+	var rpu []byte
+	for _, obu := range obus {
+		hdr, err := av1.ParseOBUHeader(obu)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case seq == nil && hdr.Type == av1.OBU_SEQUENCE_HEADER:
+			if seq, err = av1.ParseSequenceHeaderOBU(obu); err != nil {
+				return nil, err
+			}
+		case rpu == nil && IsAV1RPUOBU(obu):
+			if rpu, err = AV1RPU(obu); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if seq == nil {
+		return nil, errors.New("no sequence header")
+	}
+	if rpu == nil {
+		return nil, errors.New("no RPU")
+	}
+	bl := BaseLayer{
+		Codec:                   CODEC_AV1,
+		TransferCharacteristics: seq.ColorConfig.TransferCharacteristics,
+		Width:                   seq.MaxFrameWidthMinus1 + 1,
+		Height:                  seq.MaxFrameHeightMinus1 + 1,
+		FrameRate:               seq.FrameRate(),
+	}
+	return DetectProfile(rpu, bl)
+}
+
+// CreateAV1DOVIDecoderConfigurationRecord - profile 10 Dolby Vision
+// configuration record of an AV1 stream from its sequence header and the
+// base layer signal compatibility id, 0, 1, 2 or 4
+// dv_level is derived from the maximum frame size and the timing info of
+// seq; without equal picture interval timing, opts must include WithLevel.
+func CreateAV1DOVIDecoderConfigurationRecord(seq *av1.SequenceHeader, rpuPresent bool, blSignalCompatibilityID uint8, opts ...RecordOption) (DOVIDecoderConfigurationRecord, error) {
+	switch blSignalCompatibilityID {
+	case BL_COMPATIBILITY_NONE, BL_COMPATIBILITY_HDR10, BL_COMPATIBILITY_SDR, BL_COMPATIBILITY_HLG:
+	default:
+		return DOVIDecoderConfigurationRecord{}, fmt.Errorf("profile 10: dv_bl_signal_compatibility_id %d not supported", blSignalCompatibilityID)
+	}
+	rec := DOVIDecoderConfigurationRecord{
+		VersionMajor:            1,
+		Profile:                 10,
+		RPUPresent:              rpuPresent,
+		BLPresent:               true,
+		BLSignalCompatibilityID: blSignalCompatibilityID,
+	}
+	if err := rec.setLevel(seq.MaxFrameWidthMinus1+1, seq.MaxFrameHeightMinus1+1, seq.FrameRate(), opts); err != nil {
+		return DOVIDecoderConfigurationRecord{}, err
+	}
+	return rec, nil
+}
@@ -0,0 +1,46 @@
+package dovi
+
+import (
+	"fmt"
+
+	"github.com/go-webdl/media-codec/avc"
+)
+
+const (
+	// NALU_AVC_RPU - Dolby Vision RPU carried in the AVC NAL unit type 28
+	NALU_AVC_RPU = avc.NaluType(28)
+	// NALU_AVC_EL - Dolby Vision enhancement layer carried in the AVC NAL
+	// unit type 30 (deprecated dual layer profiles)
+	NALU_AVC_EL = avc.NaluType(30)
+)
+
+// IsAVCRPUNALUnit - is data a Dolby Vision RPU NAL unit of a profile 9 AVC
+// stream, type 28 starting with rpu_nal_prefix
+func IsAVCRPUNALUnit(data []byte) bool {
+	return len(data) > 1 && avc.GetNaluType(data[0]) == NALU_AVC_RPU && data[1] == rpuNALPrefix
+}
+
+// CreateAVCDOVIDecoderConfigurationRecord - profile 9 Dolby Vision
+// configuration record of an AVC stream from its SPS
+// dv_level is derived as by CreateDOVIDecoderConfigurationRecord. The SPS
+// must not signal a PQ or HLG transfer function.
+func CreateAVCDOVIDecoderConfigurationRecord(sps *avc.SPS, rpuPresent bool, opts ...RecordOption) (DOVIDecoderConfigurationRecord, error) {
+	if sps.VUIParametersPresentFlag && sps.VUI.ColourDescriptionPresentFlag {
+		switch tc := sps.VUI.TransferCharacteristics; tc {
+		case 16, 18:
+			return DOVIDecoderConfigurationRecord{}, fmt.Errorf("profile 9: base layer transfer characteristics %d not SDR", tc)
+		}
+	}
+	rec := DOVIDecoderConfigurationRecord{
+		VersionMajor:            1,
+		Profile:                 9,
+		RPUPresent:              rpuPresent,
+		BLPresent:               true,
+		BLSignalCompatibilityID: BL_COMPATIBILITY_SDR,
+	}
+	width, height := sps.ImageSize()
+	if err := rec.setLevel(width, height, sps.LevelRequirements().FrameRate, opts); err != nil {
+		return DOVIDecoderConfigurationRecord{}, err
+	}
+	return rec, nil
+}
@@ -0,0 +1,72 @@
+package dovi
+
+import "fmt"
+
+// Codecs of the base layer, as returned by BaseLayerCodec, named as in the
+// codec registry
+const (
+	CODEC_AVC  = "avc"
+	CODEC_HEVC = "hevc"
+	CODEC_AV1  = "av1"
+)
+
+// BaseLayerCodec - codec of the base layer of profile, CODEC_AVC, CODEC_HEVC or CODEC_AV1
+func BaseLayerCodec(profile uint8) (string, error) {
+	switch profile {
+	case 0, 1, 9:
+		return CODEC_AVC, nil
+	case 2, 3, 4, 5, 6, 7, 8:
+		return CODEC_HEVC, nil
+	case 10:
+		return CODEC_AV1, nil
+	}
+	return "", fmt.Errorf("unknown Dolby Vision profile %d", profile)
+}
+
+// BoxType - four character code of the box carrying the record: dvcC up to
+// profile 7, dvvC for profiles 8 to 10 and dvwC above
+func (b *DOVIDecoderConfigurationRecord) BoxType() string {
+	switch {
+	case b.Profile <= 7:
+		return "dvcC"
+	case b.Profile <= 10:
+		return "dvvC"
+	default:
+		return "dvwC"
+	}
+}
+
+// Carriage - box type and sample entry four character code for a track of
+// the stream
+// inBand selects the sample entry allowing parameter sets in samples (dvav,
+// dvhe, avc3, hev1). With compatible set, the sample entry of the base layer
+// codec is returned, which requires a cross-compatible base layer, i.e. a
+// non-zero dv_bl_signal_compatibility_id. AV1 has a single sample entry of
+// each kind.
+func (b *DOVIDecoderConfigurationRecord) Carriage(inBand, compatible bool) (boxType, sampleEntry string, err error) {
+	if b.VersionMajor != 1 && b.VersionMajor != 2 {
+		return "", "", fmt.Errorf("dv_version_major %d not supported", b.VersionMajor)
+	}
+	codec, err := BaseLayerCodec(b.Profile)
+	if err != nil {
+		return "", "", err
+	}
+	if compatible && b.BLSignalCompatibilityID == BL_COMPATIBILITY_NONE {
+		return "", "", fmt.Errorf("profile %d: base layer not cross-compatible", b.Profile)
+	}
+	sampleEntries := map[string][2][2]string{
+		// [compatible][inBand]
+		CODEC_AVC:  {{"dva1", "dvav"}, {"avc1", "avc3"}},
+		CODEC_HEVC: {{"dvh1", "dvhe"}, {"hvc1", "hev1"}},
+		CODEC_AV1:  {{"dav1", "dav1"}, {"av01", "av01"}},
+	}[codec]
+	return b.BoxType(), sampleEntries[boolIndex(compatible)][boolIndex(inBand)], nil
+}
+
+// boolIndex - 1 if f is set, 0 otherwise
+func boolIndex(f bool) int {
+	if f {
+		return 1
+	}
+	return 0
+}
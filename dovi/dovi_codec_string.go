@@ -0,0 +1,106 @@
+package dovi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CodecString - codecs parameter for Dolby Vision, e.g. dvh1.05.06
+// sampleEntry is the four character code of the sample entry (dvh1, dvhe, dvav, ...)
+func (b *DOVIDecoderConfigurationRecord) CodecString(sampleEntry string) string {
+	return fmt.Sprintf("%s.%02d.%02d", sampleEntry, b.Profile, b.Level)
+}
+
+// compatibilityBrands - compatible brands of cross-compatible base layers by dv_bl_signal_compatibility_id
+var compatibilityBrands = map[uint8]string{
+	BL_COMPATIBILITY_HDR10: "db1p",
+	BL_COMPATIBILITY_SDR:   "db2g",
+	BL_COMPATIBILITY_HLG:   "db4h",
+}
+
+// dolbyVisionSampleEntries - Dolby Vision sample entry of each base layer
+// codec sample entry, and of each Dolby Vision sample entry itself
+var dolbyVisionSampleEntries = map[string]string{
+	"avc1": "dva1",
+	"avc3": "dvav",
+	"hvc1": "dvh1",
+	"hev1": "dvhe",
+	"av01": "dav1",
+	"dva1": "dva1",
+	"dvav": "dvav",
+	"dvh1": "dvh1",
+	"dvhe": "dvhe",
+	"dav1": "dav1",
+}
+
+// CompatibilityBrand - compatible brand of the cross-compatible base layer,
+// db1p for HDR10, db2g for SDR and db4h for HLG, empty if there is none
+func (b *DOVIDecoderConfigurationRecord) CompatibilityBrand() string {
+	if b.Profile != 8 && b.Profile != 9 && b.Profile != 10 {
+		return ""
+	}
+	return compatibilityBrands[b.BLSignalCompatibilityID]
+}
+
+// HLSCodecs - CODECS and SUPPLEMENTAL-CODECS attribute values of an HLS variant
+// sampleEntry is that of the track, a Dolby Vision or a base layer codec
+// sample entry, and blCodecString the codecs parameter of the base layer,
+// e.g. hvc1.2.4.L153.B0. A cross-compatible stream lists the base layer in
+// CODECS and the Dolby Vision codec with its compatible brand in
+// SUPPLEMENTAL-CODECS, e.g. dvh1.08.07/db1p, so that players without Dolby
+// Vision support still select it. Other streams list only the Dolby Vision
+// codec in CODECS.
+func (b *DOVIDecoderConfigurationRecord) HLSCodecs(sampleEntry, blCodecString string) (codecs, supplementalCodecs string, err error) {
+	dvSampleEntry, ok := dolbyVisionSampleEntries[sampleEntry]
+	if !ok {
+		return "", "", fmt.Errorf("sample entry %q not supported for Dolby Vision", sampleEntry)
+	}
+	brand := b.CompatibilityBrand()
+	if brand == "" {
+		return b.CodecString(dvSampleEntry), "", nil
+	}
+	return blCodecString, b.CodecString(dvSampleEntry) + "/" + brand, nil
+}
+
+// ParseCodecString - sample entry, profile and level of a Dolby Vision
+// codecs parameter, the inverse of CodecString
+// A compatible brand following a slash, as in SUPPLEMENTAL-CODECS, is
+// accepted. Only Profile, Level and, with a brand, BLSignalCompatibilityID
+// are set in the returned record.
+func ParseCodecString(codecs string) (sampleEntry string, b DOVIDecoderConfigurationRecord, err error) {
+	codec, brand := codecs, ""
+	if i := strings.IndexByte(codecs, '/'); i >= 0 {
+		codec, brand = codecs[:i], codecs[i+1:]
+	}
+	parts := strings.Split(codec, ".")
+	if len(parts) != 3 {
+		return "", b, fmt.Errorf("codecs %q: expected 3 elements", codecs)
+	}
+	sampleEntry = parts[0]
+	if dolbyVisionSampleEntries[sampleEntry] != sampleEntry {
+		return "", b, fmt.Errorf("codecs %q: sample entry %q is not a Dolby Vision sample entry", codecs, sampleEntry)
+	}
+	profile, err := strconv.ParseUint(parts[1], 10, 7)
+	if err != nil {
+		return "", b, fmt.Errorf("codecs %q: profile: %w", codecs, err)
+	}
+	b.Profile = uint8(profile)
+	level, err := strconv.ParseUint(parts[2], 10, 6)
+	if err != nil {
+		return "", b, fmt.Errorf("codecs %q: level: %w", codecs, err)
+	}
+	b.Level = uint8(level)
+	if brand != "" {
+		found := false
+		for id, name := range compatibilityBrands {
+			if name == brand {
+				b.BLSignalCompatibilityID, found = id, true
+			}
+		}
+		if !found {
+			return "", b, fmt.Errorf("codecs %q: unknown compatible brand %q", codecs, brand)
+		}
+	}
+	return sampleEntry, b, nil
+}
@@ -0,0 +1,170 @@
+package dovi
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-webdl/media-codec/avc"
+	"github.com/go-webdl/media-codec/hevc"
+)
+
+// Base layer signal compatibility ids, dv_bl_signal_compatibility_id
+const (
+	BL_COMPATIBILITY_NONE   = uint8(0)
+	BL_COMPATIBILITY_HDR10  = uint8(1)
+	BL_COMPATIBILITY_SDR    = uint8(2)
+	BL_COMPATIBILITY_HLG    = uint8(4)
+	BL_COMPATIBILITY_BLURAY = uint8(6)
+)
+
+// BaseLayer - base layer properties needed for profile detection
+type BaseLayer struct {
+	// Codec - codec of the base layer, CODEC_AVC, CODEC_HEVC or CODEC_AV1;
+	// empty is CODEC_HEVC
+	Codec string
+	// TransferCharacteristics - effective transfer characteristics, taking
+	// an alternative_transfer_characteristics SEI into account
+	TransferCharacteristics byte
+	Width, Height           uint32
+	// FrameRate - pictures per second, 0 if unknown
+	FrameRate float64
+	// ELPresent - enhancement layer NAL units are interleaved with the base layer
+	ELPresent bool
+}
+
+// DetectProfile - Dolby Vision configuration record of a stream carried
+// without dvcC or dvvC box from one of its RPUs, starting with
+// rpu_nal_prefix, and base layer properties
+// The RPU tells profiles 4, 5, 7 and 8 apart; for profile 8 the transfer
+// function of the base layer selects HDR10 (8.1), SDR (8.2) or HLG (8.4)
+// compatibility. Level is 0 if the frame rate is unknown.
+func DetectProfile(rpu []byte, bl BaseLayer) (*DOVIDecoderConfigurationRecord, error) {
+	h, err := ParseRPUHeader(rpu)
+	if err != nil {
+		return nil, err
+	}
+	b := &DOVIDecoderConfigurationRecord{
+		VersionMajor: 1,
+		Profile:      h.Profile(),
+		RPUPresent:   true,
+		ELPresent:    bl.ELPresent,
+		BLPresent:    true,
+	}
+	if bl.FrameRate > 0 {
+		b.Level = Level(bl.Width, bl.Height, bl.FrameRate)
+	}
+	switch b.Profile {
+	case 0:
+		return nil, fmt.Errorf("unknown profile, vdr_rpu_profile %d", h.VDRRPUProfile)
+	case 4:
+		b.BLSignalCompatibilityID = BL_COMPATIBILITY_SDR
+	case 5:
+		b.BLSignalCompatibilityID = BL_COMPATIBILITY_NONE
+		if bl.Codec == CODEC_AV1 {
+			b.Profile = 10
+		}
+	case 7:
+		b.BLSignalCompatibilityID = BL_COMPATIBILITY_BLURAY
+	case 8:
+		switch bl.Codec {
+		case CODEC_AVC:
+			b.Profile = 9
+		case CODEC_AV1:
+			b.Profile = 10
+		}
+		switch bl.TransferCharacteristics {
+		case 16: // PQ
+			b.BLSignalCompatibilityID = BL_COMPATIBILITY_HDR10
+		case 18: // HLG
+			b.BLSignalCompatibilityID = BL_COMPATIBILITY_HLG
+		case 1, 6, 14, 15: // BT.709 and equivalents
+			b.BLSignalCompatibilityID = BL_COMPATIBILITY_SDR
+		case 2: // unspecified, SDR as profile 9 has no other base layer
+			if b.Profile != 9 {
+				return nil, fmt.Errorf("profile %d: base layer transfer characteristics unspecified", b.Profile)
+			}
+			b.BLSignalCompatibilityID = BL_COMPATIBILITY_SDR
+		default:
+			return nil, fmt.Errorf("profile %d: base layer transfer characteristics %d not supported", b.Profile, bl.TransferCharacteristics)
+		}
+		if b.Profile == 9 && b.BLSignalCompatibilityID != BL_COMPATIBILITY_SDR {
+			return nil, fmt.Errorf("profile 9: base layer is not SDR")
+		}
+	}
+	if codec, _ := BaseLayerCodec(b.Profile); bl.Codec != "" && bl.Codec != codec {
+		return nil, fmt.Errorf("profile %d: %s base layer not supported", b.Profile, bl.Codec)
+	}
+	return b, nil
+}
+
+// DetectHEVCProfile - Dolby Vision configuration record of an HEVC stream
+// from its NAL units, e.g. the parameter sets and first access units
+// The first SPS and RPU are used.
+func DetectHEVCProfile(nalus [][]byte) (*DOVIDecoderConfigurationRecord, error) {
+	var sps *hevc.SPS
+	var rpu []byte
+	elPresent := false
+	for _, data := range nalus {
+		switch {
+		case len(data) < 2:
+		case sps == nil && hevc.GetNaluType(data[0]) == hevc.NALU_SPS:
+			var err error
+			if sps, err = hevc.ParseSPSNALUnit(data); err != nil {
+				return nil, err
+			}
+		case rpu == nil && IsRPUNALUnit(data):
+			rpu = data[2:]
+		case IsELNALUnit(data):
+			elPresent = true
+		}
+	}
+	if sps == nil {
+		return nil, errors.New("no SPS")
+	}
+	if rpu == nil {
+		return nil, errors.New("no RPU")
+	}
+	bl := BaseLayer{
+		TransferCharacteristics: hevc.EffectiveTransferCharacteristics(sps, nalus),
+		FrameRate:               sps.LevelRequirements().FrameRate,
+		ELPresent:               elPresent,
+	}
+	bl.Width, bl.Height = sps.ImageSize()
+	return DetectProfile(rpu, bl)
+}
+
+// DetectAVCProfile - Dolby Vision configuration record of an AVC stream
+// from its NAL units, e.g. the parameter sets and first access units
+// The first SPS and RPU are used.
+func DetectAVCProfile(nalus [][]byte) (*DOVIDecoderConfigurationRecord, error) {
+	var sps *avc.SPS
+	var rpu []byte
+	for _, data := range nalus {
+		switch {
+		case len(data) < 1:
+		case sps == nil && avc.GetNaluType(data[0]) == avc.NALU_SPS:
+			var err error
+			if sps, err = avc.ParseSPSNALUnit(data); err != nil {
+				return nil, err
+			}
+		case rpu == nil && IsAVCRPUNALUnit(data):
+			rpu = data[1:]
+		}
+	}
+	if sps == nil {
+		return nil, errors.New("no SPS")
+	}
+	if rpu == nil {
+		return nil, errors.New("no RPU")
+	}
+	bl := BaseLayer{
+		Codec:                   CODEC_AVC,
+		TransferCharacteristics: 2,
+		FrameRate:               sps.LevelRequirements().FrameRate,
+	}
+	if sps.VUIParametersPresentFlag && sps.VUI.ColourDescriptionPresentFlag {
+		bl.TransferCharacteristics = sps.VUI.TransferCharacteristics
+	}
+	bl.Width, bl.Height = sps.ImageSize()
+	return DetectProfile(rpu, bl)
+}
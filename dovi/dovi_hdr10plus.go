@@ -0,0 +1,223 @@
+package dovi
+
+import (
+	"fmt"
+
+	"github.com/go-webdl/media-codec/hevc"
+	"github.com/go-webdl/media-codec/nalu"
+	"github.com/go-webdl/media-codec/sei"
+)
+
+const (
+	// NALU_RPU - Dolby Vision RPU carried in the HEVC NAL unit type UNSPEC62
+	NALU_RPU = hevc.NALU_UNSPEC62
+	// NALU_EL - Dolby Vision enhancement layer carried in the HEVC NAL unit
+	// type UNSPEC63 (single track dual layer profiles)
+	NALU_EL = hevc.NALU_UNSPEC63
+)
+
+// HDRPolicy - which dynamic metadata to keep when both are present
+// HEVC streams carrying both Dolby Vision RPUs and HDR10+ SEI messages are
+// valid, but a number of players pick the wrong one or fail outright.
+type HDRPolicy int
+
+const (
+	// HDR_KEEP_BOTH - leave the stream as it is
+	HDR_KEEP_BOTH = HDRPolicy(0)
+	// HDR_KEEP_DOLBY_VISION - strip HDR10+ SEI messages
+	HDR_KEEP_DOLBY_VISION = HDRPolicy(1)
+	// HDR_KEEP_HDR10_PLUS - strip Dolby Vision RPU and enhancement layer NAL units
+	HDR_KEEP_HDR10_PLUS = HDRPolicy(2)
+)
+
+func (p HDRPolicy) String() string {
+	switch p {
+	case HDR_KEEP_BOTH:
+		return "KeepBoth"
+	case HDR_KEEP_DOLBY_VISION:
+		return "KeepDolbyVision"
+	case HDR_KEEP_HDR10_PLUS:
+		return "KeepHDR10Plus"
+	default:
+		return fmt.Sprintf("Other_%d", p)
+	}
+}
+
+// HDRAnalysis - counts of samples carrying Dolby Vision RPUs and HDR10+ metadata
+type HDRAnalysis struct {
+	Samples          int
+	RPUSamples       int
+	HDR10PlusSamples int
+	BothSamples      int
+	// EncryptedSEIs - SEI NAL units of protected samples that were skipped
+	// as they are not entirely in the clear
+	EncryptedSEIs int
+}
+
+// HasBoth - are both Dolby Vision RPUs and HDR10+ metadata present in the stream
+func (a *HDRAnalysis) HasBoth() bool {
+	return a.RPUSamples > 0 && a.HDR10PlusSamples > 0
+}
+
+// AddSample - account for a length-prefixed HEVC sample
+func (a *HDRAnalysis) AddSample(sample []byte, lengthSize int) error {
+	nalus, err := nalu.SplitSample(sample, lengthSize)
+	if err != nil {
+		return err
+	}
+	protected := make([]nalu.ProtectedNALU, len(nalus))
+	for i, n := range nalus {
+		protected[i] = nalu.ProtectedNALU{Data: n, ClearBytes: len(n)}
+	}
+	return a.addNALUs(protected)
+}
+
+// AddProtectedSample - account for a length-prefixed HEVC sample protected
+// with the given CENC subsample map
+// RPUs are recognized by their clear NAL unit header. SEI NAL units with
+// encrypted bytes cannot be parsed and are counted in EncryptedSEIs.
+func (a *HDRAnalysis) AddProtectedSample(sample []byte, lengthSize int, subsamples []nalu.Subsample) error {
+	nalus, err := nalu.SplitProtectedSample(sample, lengthSize, subsamples)
+	if err != nil {
+		return err
+	}
+	return a.addNALUs(nalus)
+}
+
+// addNALUs - account for the NAL units of one sample
+func (a *HDRAnalysis) addNALUs(nalus []nalu.ProtectedNALU) error {
+	var hasRPU, hasHDR10Plus bool
+	for i := range nalus {
+		n := nalus[i].Data
+		if nalus[i].ClearBytes < 2 {
+			continue
+		}
+		switch hevc.GetNaluType(n[0]) {
+		case NALU_RPU:
+			hasRPU = true
+		case hevc.NALU_SEI_PREFIX, hevc.NALU_SEI_SUFFIX:
+			if nalus[i].Encrypted() {
+				a.EncryptedSEIs++
+				continue
+			}
+			msgs, err := sei.ParseMessages(nalu.UnescapeEBSP(n[2:]))
+			if err != nil {
+				return err
+			}
+			for i := range msgs {
+				if msgs[i].IsHDR10Plus() {
+					hasHDR10Plus = true
+				}
+			}
+		}
+	}
+	a.Samples++
+	if hasRPU {
+		a.RPUSamples++
+	}
+	if hasHDR10Plus {
+		a.HDR10PlusSamples++
+	}
+	if hasRPU && hasHDR10Plus {
+		a.BothSamples++
+	}
+	return nil
+}
+
+// FilterHDRSample - strip dynamic metadata from a length-prefixed HEVC sample according to policy
+// NAL units that are kept are copied verbatim. SEI NAL units carrying HDR10+
+// along with other messages are rebuilt without the HDR10+ messages.
+func FilterHDRSample(sample []byte, lengthSize int, policy HDRPolicy) ([]byte, error) {
+	if policy == HDR_KEEP_BOTH {
+		return sample, nil
+	}
+	return nalu.TransformSample(sample, lengthSize, func(units []nalu.Unit) ([]nalu.Unit, error) {
+		out := units[:0:0]
+		for _, unit := range units {
+			keep, err := filterHDRNALUnit(&unit, policy)
+			if err != nil {
+				return nil, err
+			}
+			if keep {
+				out = append(out, unit)
+			}
+		}
+		return out, nil
+	})
+}
+
+// filterHDRNALUnit - apply policy to a single NAL unit, rewriting it in unit if needed
+func filterHDRNALUnit(unit *nalu.Unit, policy HDRPolicy) (keep bool, err error) {
+	if len(unit.Data) < 2 {
+		return true, nil
+	}
+	naluType := hevc.GetNaluType(unit.Data[0])
+	switch policy {
+	case HDR_KEEP_HDR10_PLUS:
+		return naluType != NALU_RPU && naluType != NALU_EL, nil
+	case HDR_KEEP_DOLBY_VISION:
+		if naluType != hevc.NALU_SEI_PREFIX && naluType != hevc.NALU_SEI_SUFFIX {
+			return true, nil
+		}
+		msgs, err := sei.ParseMessages(nalu.UnescapeEBSP(unit.Data[2:]))
+		if err != nil {
+			return false, err
+		}
+		kept := msgs[:0:0]
+		for i := range msgs {
+			if !msgs[i].IsHDR10Plus() {
+				kept = append(kept, msgs[i])
+			}
+		}
+		if len(kept) == len(msgs) {
+			return true, nil
+		}
+		if len(kept) == 0 {
+			return false, nil
+		}
+		data, err := sei.CreateNALUnit(unit.Data[:2], kept)
+		if err != nil {
+			return false, err
+		}
+		unit.Data = data
+		unit.Modified = true
+	}
+	return true, nil
+}
+
+// FilterHDRRecords - regenerate the configuration records after applying policy
+// HDR10+ SEI messages are removed from the SEI arrays of hvcC in place;
+// arrays left empty are removed, arrays that were empty already are kept. When
+// Dolby Vision is stripped, the returned Dolby Vision record is nil and the
+// returned sample entry is the plain HEVC one (dvh1 to hvc1, dvhe to hev1).
+func FilterHDRRecords(hvcC *hevc.HEVCDecoderConfigurationRecord, dvcC *DOVIDecoderConfigurationRecord, sampleEntry string, policy HDRPolicy) (*DOVIDecoderConfigurationRecord, string, error) {
+	arrays := hvcC.NaluArrays[:0:0]
+	for _, array := range hvcC.NaluArrays {
+		var nalus [][]byte
+		for _, data := range array.NALUs {
+			unit := nalu.Unit{Data: data}
+			keep, err := filterHDRNALUnit(&unit, policy)
+			if err != nil {
+				return nil, "", err
+			}
+			if keep {
+				nalus = append(nalus, unit.Data)
+			}
+		}
+		if len(nalus) > 0 || len(array.NALUs) == 0 {
+			array.NALUs = nalus
+			arrays = append(arrays, array)
+		}
+	}
+	hvcC.NaluArrays = arrays
+	if policy != HDR_KEEP_HDR10_PLUS {
+		return dvcC, sampleEntry, nil
+	}
+	switch sampleEntry {
+	case "dvh1":
+		sampleEntry = "hvc1"
+	case "dvhe":
+		sampleEntry = "hev1"
+	}
+	return nil, sampleEntry, nil
+}
@@ -0,0 +1,113 @@
+package dovi
+
+import (
+	"fmt"
+
+	"github.com/go-webdl/media-codec/hevc"
+	"github.com/go-webdl/media-codec/nalu"
+)
+
+// rpuNALPrefix - rpu_nal_prefix, the first payload byte of an RPU NAL unit
+const rpuNALPrefix = 0x19
+
+// rpuHeader - NAL unit header of RPU NAL units, type UNSPEC62 with nuh_layer_id 0 and TemporalId 0
+var rpuHeader = []byte{byte(NALU_RPU) << 1, 1}
+
+// elHeader - NAL unit header of EL NAL units, type UNSPEC63 with nuh_layer_id 0 and TemporalId 0
+var elHeader = []byte{byte(NALU_EL) << 1, 1}
+
+// IsRPUNALUnit - is data a Dolby Vision RPU NAL unit, type UNSPEC62 starting with rpu_nal_prefix
+func IsRPUNALUnit(data []byte) bool {
+	return len(data) > 2 && hevc.GetNaluType(data[0]) == NALU_RPU && data[2] == rpuNALPrefix
+}
+
+// IsELNALUnit - is data a Dolby Vision EL NAL unit, type UNSPEC63 wrapping
+// an HEVC NAL unit
+func IsELNALUnit(data []byte) bool {
+	if len(data) < 4 || hevc.GetNaluType(data[0]) != NALU_EL {
+		return false
+	}
+	// forbidden_zero_bit and nuh_temporal_id_plus1 of the wrapped header
+	return data[2]&0x80 == 0 && data[3]&0b111 != 0
+}
+
+// UnwrapEL - the enhancement layer NAL unit wrapped by an EL NAL unit
+// The result aliases data.
+func UnwrapEL(data []byte) ([]byte, error) {
+	if !IsELNALUnit(data) {
+		return nil, fmt.Errorf("not a Dolby Vision EL NAL unit")
+	}
+	return data[2:], nil
+}
+
+// WrapEL - EL NAL unit wrapping an enhancement layer NAL unit, for
+// interleaving it with the base layer
+func WrapEL(elNALUnit []byte) []byte {
+	return append(append(make([]byte, 0, len(elNALUnit)+2), elHeader...), elNALUnit...)
+}
+
+// Layers - NAL units of a single stream Dolby Vision stream split by layer
+type Layers struct {
+	// BL - base layer NAL units
+	BL [][]byte
+	// EL - enhancement layer NAL units, unwrapped; empty for profile 8
+	EL [][]byte
+	// RPU - RPU NAL units. In dual track MP4 files RPUs are carried in the
+	// enhancement layer track, after the EL NAL units of each sample.
+	RPU [][]byte
+}
+
+// SplitLayers - split the NAL units of an access unit, or of a whole stream,
+// into base layer, enhancement layer and RPU NAL units, keeping their order
+// Outside MP4, e.g. in MPEG-TS, Matroska or raw .hevc files, profile 7 and 8
+// streams interleave all layers in a single HEVC stream.
+// NAL units of type UNSPEC62 and UNSPEC63 that are not Dolby Vision NAL
+// units are an error. The results alias nalus.
+func SplitLayers(nalus [][]byte) (layers Layers, err error) {
+	for i, data := range nalus {
+		if len(data) < 2 {
+			continue
+		}
+		switch hevc.GetNaluType(data[0]) {
+		case NALU_RPU:
+			if !IsRPUNALUnit(data) {
+				return Layers{}, fmt.Errorf("NAL unit %d: UNSPEC62 without rpu_nal_prefix", i)
+			}
+			layers.RPU = append(layers.RPU, data)
+		case NALU_EL:
+			el, err := UnwrapEL(data)
+			if err != nil {
+				return Layers{}, fmt.Errorf("NAL unit %d: %w", i, err)
+			}
+			layers.EL = append(layers.EL, el)
+		default:
+			layers.BL = append(layers.BL, data)
+		}
+	}
+	return layers, nil
+}
+
+// SplitLayerSample - split a length-prefixed sample of a single track Dolby
+// Vision stream into base layer, enhancement layer and RPU samples
+// el is empty for profile 8 and rpu for streams without RPUs. For a dual
+// track file, append rpu to el.
+func SplitLayerSample(sample []byte, lengthSize int) (bl, el, rpu []byte, err error) {
+	nalus, err := nalu.SplitSample(sample, lengthSize)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	layers, err := SplitLayers(nalus)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if bl, err = nalu.AppendSample(nil, layers.BL, lengthSize); err != nil {
+		return nil, nil, nil, err
+	}
+	if el, err = nalu.AppendSample(nil, layers.EL, lengthSize); err != nil {
+		return nil, nil, nil, err
+	}
+	if rpu, err = nalu.AppendSample(nil, layers.RPU, lengthSize); err != nil {
+		return nil, nil, nil, err
+	}
+	return bl, el, rpu, nil
+}
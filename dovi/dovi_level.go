@@ -0,0 +1,80 @@
+package dovi
+
+import (
+	"fmt"
+
+	"github.com/go-webdl/media-codec/avc"
+	"github.com/go-webdl/media-codec/hevc"
+)
+
+// dvLevels - maximum luma samples per second and picture width of each
+// dv_level, from the Dolby Vision profiles and levels specification
+var dvLevels = [...]struct {
+	pixelRate uint64
+	width     uint32
+}{
+	1:  {1280 * 720 * 24, 1280},
+	2:  {1280 * 720 * 30, 1280},
+	3:  {1920 * 1080 * 24, 1920},
+	4:  {1920 * 1080 * 30, 2560},
+	5:  {1920 * 1080 * 60, 3840},
+	6:  {3840 * 2160 * 24, 3840},
+	7:  {3840 * 2160 * 30, 3840},
+	8:  {3840 * 2160 * 48, 3840},
+	9:  {3840 * 2160 * 60, 3840},
+	10: {3840 * 2160 * 120, 3840},
+	11: {3840 * 2160 * 120, 7680},
+	12: {7680 * 4320 * 60, 7680},
+	13: {7680 * 4320 * 120, 7680},
+}
+
+// MaxLevel - highest defined dv_level
+const MaxLevel = uint8(len(dvLevels) - 1)
+
+// Level - lowest dv_level for pictures of width x height at frameRate, 0 if none fits
+// dv_level limits the picture width and the luma pixel rate, width x height
+// x frame rate.
+func Level(width, height uint32, frameRate float64) uint8 {
+	pixelRate := float64(width) * float64(height) * frameRate
+	for level := 1; level < len(dvLevels); level++ {
+		if width <= dvLevels[level].width && pixelRate <= float64(dvLevels[level].pixelRate) {
+			return uint8(level)
+		}
+	}
+	return 0
+}
+
+// CheckLevel - check that the record's dv_level carries the pictures of sps
+// The pixel rate is only checked if the SPS has VUI timing. A level higher
+// than needed is not an error.
+func (b *DOVIDecoderConfigurationRecord) CheckLevel(sps *hevc.SPS) error {
+	width, height := sps.ImageSize()
+	return b.checkLevel(width, height, sps.LevelRequirements().FrameRate)
+}
+
+// CheckAVCLevel - check that the record's dv_level carries the pictures of
+// an AVC SPS, see CheckLevel
+func (b *DOVIDecoderConfigurationRecord) CheckAVCLevel(sps *avc.SPS) error {
+	width, height := sps.ImageSize()
+	return b.checkLevel(width, height, sps.LevelRequirements().FrameRate)
+}
+
+// checkLevel - check the record's dv_level for pictures of width x height
+// at frameRate, 0 if unknown
+func (b *DOVIDecoderConfigurationRecord) checkLevel(width, height uint32, frameRate float64) error {
+	if b.Level == 0 || b.Level > MaxLevel {
+		return fmt.Errorf("invalid dv_level %d", b.Level)
+	}
+	limits := dvLevels[b.Level]
+	if width > limits.width {
+		return fmt.Errorf("width %d exceeds limit %d of dv_level %d", width, limits.width, b.Level)
+	}
+	if frameRate <= 0 {
+		return nil
+	}
+	if pixelRate := float64(width) * float64(height) * frameRate; pixelRate > float64(limits.pixelRate) {
+		return fmt.Errorf("%dx%d at %.3f fps exceeds pixel rate limit %d of dv_level %d, dv_level %d needed",
+			width, height, frameRate, limits.pixelRate, b.Level, Level(width, height, frameRate))
+	}
+	return nil
+}
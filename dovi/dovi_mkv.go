@@ -0,0 +1,70 @@
+package dovi
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-webdl/media-codec/hevc"
+	"github.com/go-webdl/media-codec/nalu"
+)
+
+// MKV_BLOCK_ADD_ID_ITUT_T35 - BlockAddID of BlockAdditional elements carrying ITU-T T.35 metadata
+const MKV_BLOCK_ADD_ID_ITUT_T35 = 4
+
+// ExtractBlockAdditional - remove the RPU NAL unit from a length-prefixed
+// HEVC sample and return it as BlockAdditional data, as Matroska may carry it
+// Together with InjectBlockAdditional this remuxes Dolby Vision between MP4
+// and Matroska without touching the base and enhancement layers.
+// blockAdditional is nil for samples without RPU. All other NAL units,
+// including EL NAL units, are kept in sample.
+func ExtractBlockAdditional(sample []byte, lengthSize int) (out, blockAdditional []byte, err error) {
+	var rpu []byte
+	out, err = nalu.TransformSample(sample, lengthSize, func(units []nalu.Unit) ([]nalu.Unit, error) {
+		kept := units[:0:0]
+		for i, unit := range units {
+			if len(unit.Data) < 2 || hevc.GetNaluType(unit.Data[0]) != NALU_RPU {
+				kept = append(kept, unit)
+				continue
+			}
+			if !IsRPUNALUnit(unit.Data) {
+				return nil, fmt.Errorf("NAL unit %d: UNSPEC62 without rpu_nal_prefix", i)
+			}
+			if rpu != nil {
+				return nil, fmt.Errorf("NAL unit %d: more than one RPU in sample", i)
+			}
+			rpu = unit.Data[2:]
+		}
+		return kept, nil
+	})
+	if err != nil || rpu == nil {
+		return out, nil, err
+	}
+	if blockAdditional, err = WrapT35RPU(rpu); err != nil {
+		return nil, nil, err
+	}
+	return out, blockAdditional, nil
+}
+
+// InjectBlockAdditional - append the RPU carried by BlockAdditional data to
+// a length-prefixed HEVC sample as an RPU NAL unit, the inverse of
+// ExtractBlockAdditional
+// sample is returned as it is if blockAdditional is empty. A sample that
+// already has an RPU is an error.
+func InjectBlockAdditional(sample []byte, lengthSize int, blockAdditional []byte) ([]byte, error) {
+	if len(blockAdditional) == 0 {
+		return sample, nil
+	}
+	rpu, err := UnwrapT35RPU(blockAdditional)
+	if err != nil {
+		return nil, err
+	}
+	return nalu.TransformSample(sample, lengthSize, func(units []nalu.Unit) ([]nalu.Unit, error) {
+		for _, unit := range units {
+			if IsRPUNALUnit(unit.Data) {
+				return nil, errors.New("sample already has an RPU")
+			}
+		}
+		unit := append(append(make([]byte, 0, len(rpu)+len(rpuHeader)), rpuHeader...), rpu...)
+		return append(units, nalu.Unit{Data: unit, Modified: true}), nil
+	})
+}
@@ -0,0 +1,157 @@
+package dovi
+
+import (
+	"fmt"
+
+	"github.com/go-webdl/media-codec/hevc"
+	"github.com/go-webdl/media-codec/nalu"
+)
+
+// ConvertRPUTo81 - rewrite a profile 7 RPU starting with rpu_nal_prefix,
+// with emulation prevention bytes, as a profile 8.1 RPU
+// The residual is disabled and the NLQ parameters are removed. The mapping
+// curves and display management metadata are copied verbatim, realigning
+// its extension blocks, and rpu_data_crc32 is recomputed. Profile 8 RPUs are
+// returned as they are.
+func ConvertRPUTo81(rpu []byte) ([]byte, error) {
+	l, err := parseRPULayout(rpu)
+	if err != nil {
+		return nil, err
+	}
+	switch profile := l.Header.Profile(); profile {
+	case 7:
+	case 8:
+		return rpu, nil
+	default:
+		return nil, fmt.Errorf("profile %d RPU, not profile 7", profile)
+	}
+	h := *l.Header
+	h.ELSpatialResamplingFilterFlag = false
+	h.DisableResidualFlag = true
+	h.NLQMethodIdc = 0
+
+	w := nalu.NewRBSPWriter()
+	writeRPUHeader(w, &h)
+	writeRBSPBits(w, l.RBSP, l.HeaderEnd, l.NLQStart)
+	writeRPUSegments(w, l.RBSP, l.DM)
+	for w.NrBits()%8 != 0 {
+		w.Write(0, 1) // rpu_alignment_zero_bit
+	}
+	data, err := w.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	w.Write(uint(crc32MPEG2(data[1:])), 32)
+	w.WriteTrailingBits()
+	return w.NALUnit(nil)
+}
+
+// convertNALUnitTo81 - convert a single NAL unit, rewriting it in unit if needed
+func convertNALUnitTo81(unit *nalu.Unit) (keep bool, err error) {
+	if len(unit.Data) < 2 {
+		return true, nil
+	}
+	switch hevc.GetNaluType(unit.Data[0]) {
+	case NALU_EL:
+		return false, nil
+	case NALU_RPU:
+		if !IsRPUNALUnit(unit.Data) {
+			return false, fmt.Errorf("UNSPEC62 without rpu_nal_prefix")
+		}
+		rpu, err := ConvertRPUTo81(unit.Data[2:])
+		if err != nil {
+			return false, err
+		}
+		unit.Data = append(append(make([]byte, 0, len(rpu)+2), unit.Data[:2]...), rpu...)
+		unit.Modified = true
+	}
+	return true, nil
+}
+
+// ConvertTo81 - convert the NAL units of a single track profile 7 stream,
+// an access unit or more, to profile 8.1, which plays on far more devices
+// EL NAL units are dropped and RPU NAL units rewritten. All other NAL units,
+// the HDR10 base layer, are kept verbatim. Nothing is lost with a minimal
+// enhancement layer; the residual of a full one is discarded.
+func ConvertTo81(nalus [][]byte) ([][]byte, error) {
+	out := nalus[:0:0]
+	for i, data := range nalus {
+		unit := nalu.Unit{Data: data}
+		keep, err := convertNALUnitTo81(&unit)
+		if err != nil {
+			return nil, fmt.Errorf("NAL unit %d: %w", i, err)
+		}
+		if keep {
+			out = append(out, unit.Data)
+		}
+	}
+	return out, nil
+}
+
+// ConvertSampleTo81 - convert a length-prefixed sample of a single track
+// profile 7 stream to profile 8.1, see ConvertTo81
+func ConvertSampleTo81(sample []byte, lengthSize int) ([]byte, error) {
+	return nalu.TransformSample(sample, lengthSize, func(units []nalu.Unit) ([]nalu.Unit, error) {
+		out := units[:0:0]
+		for i, unit := range units {
+			keep, err := convertNALUnitTo81(&unit)
+			if err != nil {
+				return nil, fmt.Errorf("NAL unit %d: %w", i, err)
+			}
+			if keep {
+				out = append(out, unit)
+			}
+		}
+		return out, nil
+	})
+}
+
+// ConvertDualTrackSampleTo81 - profile 8.1 sample from the samples of the
+// base and enhancement layer tracks of a dual track profile 7 file
+// The RPUs of the EL sample are converted and appended to a copy of the BL
+// sample; the EL NAL units are dropped.
+func ConvertDualTrackSampleTo81(bl []byte, blLengthSize int, el []byte, elLengthSize int) ([]byte, error) {
+	nalus, err := nalu.SplitSample(el, elLengthSize)
+	if err != nil {
+		return nil, err
+	}
+	var rpus [][]byte
+	for i, data := range nalus {
+		if len(data) < 2 || hevc.GetNaluType(data[0]) != NALU_RPU {
+			continue
+		}
+		unit := nalu.Unit{Data: data}
+		if _, err := convertNALUnitTo81(&unit); err != nil {
+			return nil, fmt.Errorf("EL NAL unit %d: %w", i, err)
+		}
+		rpus = append(rpus, unit.Data)
+	}
+	sample := append(make([]byte, 0, len(bl)+len(el)), bl...)
+	return nalu.AppendSample(sample, rpus, blLengthSize)
+}
+
+// ConvertRecordsTo81 - configuration records of the converted stream
+// EL and RPU NAL unit arrays are removed from hvcC in place; hvcC may be nil
+// for dual track files. The returned record signals profile 8.1 with HDR10
+// compatibility at the level of dvcC, without enhancement layer.
+func ConvertRecordsTo81(hvcC *hevc.HEVCDecoderConfigurationRecord, dvcC *DOVIDecoderConfigurationRecord) (*DOVIDecoderConfigurationRecord, error) {
+	if dvcC.Profile != 7 {
+		return nil, fmt.Errorf("profile %d, not profile 7", dvcC.Profile)
+	}
+	if hvcC != nil {
+		arrays := hvcC.NaluArrays[:0:0]
+		for _, array := range hvcC.NaluArrays {
+			if array.NALUnitType != NALU_EL && array.NALUnitType != NALU_RPU {
+				arrays = append(arrays, array)
+			}
+		}
+		hvcC.NaluArrays = arrays
+	}
+	b := *dvcC
+	b.Profile = 8
+	b.RPUPresent = true
+	b.ELPresent = false
+	b.BLPresent = true
+	b.BLSignalCompatibilityID = BL_COMPATIBILITY_HDR10
+	return &b, nil
+}
@@ -0,0 +1,73 @@
+package dovi
+
+import (
+	"fmt"
+
+	"github.com/go-webdl/media-codec/hevc"
+)
+
+// RecordOption - optional content of a record created by CreateDOVIDecoderConfigurationRecord
+type RecordOption func(b *DOVIDecoderConfigurationRecord) error
+
+// WithLevel - use dv_level level instead of the one derived from the SPS,
+// e.g. for streams without VUI timing
+func WithLevel(level uint8) RecordOption {
+	return func(b *DOVIDecoderConfigurationRecord) error {
+		if level == 0 || level > MaxLevel {
+			return fmt.Errorf("invalid dv_level %d", level)
+		}
+		b.Level = level
+		return nil
+	}
+}
+
+// CreateDOVIDecoderConfigurationRecord - Dolby Vision configuration record
+// of an HEVC stream from its SPS and the base layer signal compatibility id
+// The profile follows from blSignalCompatibilityID: 0 is profile 5, 1, 2
+// and 4 are profile 8 and 6 is single track dual layer profile 7. dv_level
+// is the lowest level for the picture size and VUI frame rate of sps; if the
+// SPS has no timing, opts must include WithLevel. A level given with
+// WithLevel must carry the pictures of sps.
+func CreateDOVIDecoderConfigurationRecord(sps *hevc.SPS, rpuPresent bool, blSignalCompatibilityID uint8, opts ...RecordOption) (DOVIDecoderConfigurationRecord, error) {
+	rec := DOVIDecoderConfigurationRecord{
+		VersionMajor:            1,
+		RPUPresent:              rpuPresent,
+		BLPresent:               true,
+		BLSignalCompatibilityID: blSignalCompatibilityID,
+	}
+	switch blSignalCompatibilityID {
+	case BL_COMPATIBILITY_NONE:
+		rec.Profile = 5
+	case BL_COMPATIBILITY_HDR10, BL_COMPATIBILITY_SDR, BL_COMPATIBILITY_HLG:
+		rec.Profile = 8
+	case BL_COMPATIBILITY_BLURAY:
+		rec.Profile = 7
+		rec.ELPresent = true
+	default:
+		return DOVIDecoderConfigurationRecord{}, fmt.Errorf("dv_bl_signal_compatibility_id %d not supported", blSignalCompatibilityID)
+	}
+	width, height := sps.ImageSize()
+	if err := rec.setLevel(width, height, sps.LevelRequirements().FrameRate, opts); err != nil {
+		return DOVIDecoderConfigurationRecord{}, err
+	}
+	return rec, nil
+}
+
+// setLevel - set dv_level for pictures of width x height at frameRate, 0 if
+// unknown, and apply opts
+func (b *DOVIDecoderConfigurationRecord) setLevel(width, height uint32, frameRate float64, opts []RecordOption) error {
+	if frameRate > 0 {
+		if b.Level = Level(width, height, frameRate); b.Level == 0 {
+			return fmt.Errorf("%dx%d at %.3f fps exceeds all Dolby Vision levels", width, height, frameRate)
+		}
+	}
+	for _, opt := range opts {
+		if err := opt(b); err != nil {
+			return err
+		}
+	}
+	if b.Level == 0 {
+		return fmt.Errorf("SPS without frame rate, dv_level unknown")
+	}
+	return b.checkLevel(width, height, frameRate)
+}
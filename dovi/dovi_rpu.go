@@ -0,0 +1,220 @@
+package dovi
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/go-webdl/bits"
+	"github.com/go-webdl/media-codec/nalu"
+)
+
+// RPUHeader - rpu_data_header() of a Dolby Vision RPU
+// The header is only decoded for rpu_type 2, the type of all Dolby Vision
+// streams in use. Fields after VDRSeqInfoPresentFlag are only valid if it is
+// set, the bit depths and following flags only if RPUFormat&0x700 is 0. The
+// mapping fields are only decoded with sequence info, as the pivot values
+// are coded with the base layer bit depth.
+type RPUHeader struct {
+	RPUType                            byte
+	RPUFormat                          uint16
+	VDRRPUProfile                      byte
+	VDRRPULevel                        byte
+	VDRSeqInfoPresentFlag              bool
+	ChromaResamplingExplicitFilterFlag bool
+	CoefficientDataType                byte
+	CoefficientLog2Denom               uint32
+	VDRRPUNormalizedIdc                byte
+	BLVideoFullRangeFlag               bool
+	BLBitDepthMinus8                   uint32
+	// ELBitDepthMinus8 - el_bit_depth_minus8 as coded; newer streams carry
+	// ext_mapping_idc above the low 8 bits, see ELBitDepth
+	ELBitDepthMinus8              uint32
+	VDRBitDepthMinus8             uint32
+	SpatialResamplingFilterFlag   bool
+	ELSpatialResamplingFilterFlag bool
+	DisableResidualFlag           bool
+	VDRDMMetadataPresentFlag      bool
+	UsePrevVDRRPUFlag             bool
+	// PrevVDRRPUID - only valid with UsePrevVDRRPUFlag
+	PrevVDRRPUID uint32
+	// VDRRPUID to NumYPartitionsMinus1 - only valid without UsePrevVDRRPUFlag
+	VDRRPUID               uint32
+	MappingColorSpace      uint32
+	MappingChromaFormatIdc uint32
+	// PredPivotValues - pred_pivot_value per component as coded, each the
+	// difference to the previous pivot
+	PredPivotValues [3][]uint32
+	// NLQMethodIdc - only valid with a residual, see ReshapingOnly
+	NLQMethodIdc         byte
+	NumXPartitionsMinus1 uint32
+	NumYPartitionsMinus1 uint32
+}
+
+// maxRPUPivots - upper bound of num_pivots_minus2 + 2
+const maxRPUPivots = 9
+
+// ParseRPUHeader - parse the header of an RPU starting with rpu_nal_prefix,
+// with emulation prevention bytes, e.g. an RPU NAL unit without its NAL unit
+// header
+func ParseRPUHeader(rpu []byte) (*RPUHeader, error) {
+	return readRPUHeader(bits.NewAccErrEBSPReader(bytes.NewReader(rpu)))
+}
+
+// readRPUHeader - read rpu_data_header(), starting with rpu_nal_prefix
+func readRPUHeader(r *bits.AccErrEBSPReader) (*RPUHeader, error) {
+	if prefix := r.Read(8); prefix != rpuNALPrefix {
+		if err := r.AccError(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("rpu_nal_prefix %d, not %d", prefix, rpuNALPrefix)
+	}
+	h := &RPUHeader{}
+	h.RPUType = byte(r.Read(6))
+	h.RPUFormat = uint16(r.Read(11))
+	if h.RPUType != 2 {
+		return h, r.AccError()
+	}
+	h.VDRRPUProfile = byte(r.Read(4))
+	h.VDRRPULevel = byte(r.Read(4))
+	h.VDRSeqInfoPresentFlag = r.ReadFlag()
+	if !h.VDRSeqInfoPresentFlag {
+		return h, r.AccError()
+	}
+	h.ChromaResamplingExplicitFilterFlag = r.ReadFlag()
+	h.CoefficientDataType = byte(r.Read(2))
+	if h.CoefficientDataType == 0 {
+		h.CoefficientLog2Denom = uint32(r.ReadExpGolomb())
+	}
+	h.VDRRPUNormalizedIdc = byte(r.Read(2))
+	h.BLVideoFullRangeFlag = r.ReadFlag()
+	if h.RPUFormat&0x700 == 0 {
+		h.BLBitDepthMinus8 = uint32(r.ReadExpGolomb())
+		h.ELBitDepthMinus8 = uint32(r.ReadExpGolomb())
+		h.VDRBitDepthMinus8 = uint32(r.ReadExpGolomb())
+		h.SpatialResamplingFilterFlag = r.ReadFlag()
+		_ = r.Read(3) // reserved_zero_3bits
+		h.ELSpatialResamplingFilterFlag = r.ReadFlag()
+		h.DisableResidualFlag = r.ReadFlag()
+	}
+	if err := r.AccError(); err != nil {
+		return nil, err
+	}
+	if h.BLBitDepthMinus8 > 8 {
+		return nil, fmt.Errorf("bl_bit_depth_minus8 %d out of range", h.BLBitDepthMinus8)
+	}
+	h.VDRDMMetadataPresentFlag = r.ReadFlag()
+	h.UsePrevVDRRPUFlag = r.ReadFlag()
+	if h.UsePrevVDRRPUFlag {
+		h.PrevVDRRPUID = uint32(r.ReadExpGolomb())
+		return h, r.AccError()
+	}
+	h.VDRRPUID = uint32(r.ReadExpGolomb())
+	h.MappingColorSpace = uint32(r.ReadExpGolomb())
+	h.MappingChromaFormatIdc = uint32(r.ReadExpGolomb())
+	for cmp := range h.PredPivotValues {
+		numPivotsMinus2 := r.ReadExpGolomb()
+		if err := r.AccError(); err != nil {
+			return nil, err
+		}
+		if numPivotsMinus2+2 > maxRPUPivots {
+			return nil, fmt.Errorf("num_pivots_minus2 %d of component %d out of range", numPivotsMinus2, cmp)
+		}
+		h.PredPivotValues[cmp] = make([]uint32, numPivotsMinus2+2)
+		for i := range h.PredPivotValues[cmp] {
+			h.PredPivotValues[cmp][i] = uint32(r.Read(int(h.BLBitDepthMinus8) + 8))
+		}
+	}
+	if !h.ReshapingOnly() {
+		h.NLQMethodIdc = byte(r.Read(3))
+	}
+	h.NumXPartitionsMinus1 = uint32(r.ReadExpGolomb())
+	h.NumYPartitionsMinus1 = uint32(r.ReadExpGolomb())
+	return h, r.AccError()
+}
+
+// writeRPUHeader - write rpu_data_header() of a header of rpu_type 2 with
+// sequence info, starting with rpu_nal_prefix
+func writeRPUHeader(w *nalu.RBSPWriter, h *RPUHeader) {
+	w.Write(rpuNALPrefix, 8)
+	w.Write(uint(h.RPUType), 6)
+	w.Write(uint(h.RPUFormat), 11)
+	w.Write(uint(h.VDRRPUProfile), 4)
+	w.Write(uint(h.VDRRPULevel), 4)
+	w.WriteFlag(h.VDRSeqInfoPresentFlag)
+	w.WriteFlag(h.ChromaResamplingExplicitFilterFlag)
+	w.Write(uint(h.CoefficientDataType), 2)
+	if h.CoefficientDataType == 0 {
+		w.WriteExpGolomb(uint(h.CoefficientLog2Denom))
+	}
+	w.Write(uint(h.VDRRPUNormalizedIdc), 2)
+	w.WriteFlag(h.BLVideoFullRangeFlag)
+	if h.RPUFormat&0x700 == 0 {
+		w.WriteExpGolomb(uint(h.BLBitDepthMinus8))
+		w.WriteExpGolomb(uint(h.ELBitDepthMinus8))
+		w.WriteExpGolomb(uint(h.VDRBitDepthMinus8))
+		w.WriteFlag(h.SpatialResamplingFilterFlag)
+		w.Write(0, 3)
+		w.WriteFlag(h.ELSpatialResamplingFilterFlag)
+		w.WriteFlag(h.DisableResidualFlag)
+	}
+	w.WriteFlag(h.VDRDMMetadataPresentFlag)
+	w.WriteFlag(h.UsePrevVDRRPUFlag)
+	if h.UsePrevVDRRPUFlag {
+		w.WriteExpGolomb(uint(h.PrevVDRRPUID))
+		return
+	}
+	w.WriteExpGolomb(uint(h.VDRRPUID))
+	w.WriteExpGolomb(uint(h.MappingColorSpace))
+	w.WriteExpGolomb(uint(h.MappingChromaFormatIdc))
+	for _, pivots := range h.PredPivotValues {
+		w.WriteExpGolomb(uint(len(pivots) - 2))
+		for _, v := range pivots {
+			w.Write(uint(v), int(h.BLBitDepthMinus8)+8)
+		}
+	}
+	if !h.ReshapingOnly() {
+		w.Write(uint(h.NLQMethodIdc), 3)
+	}
+	w.WriteExpGolomb(uint(h.NumXPartitionsMinus1))
+	w.WriteExpGolomb(uint(h.NumYPartitionsMinus1))
+}
+
+// ELBitDepth - bit depth of the enhancement layer
+func (h *RPUHeader) ELBitDepth() int {
+	return int(h.ELBitDepthMinus8&0xff) + 8
+}
+
+// ReshapingOnly - does the RPU only reshape the base layer, as in profiles 5
+// and 8, rather than also adding an enhancement layer residual, as in
+// profiles 4 and 7
+// Full and minimal enhancement layers of profile 7 both carry a residual;
+// telling them apart needs the NLQ parameters of the mapping data.
+func (h *RPUHeader) ReshapingOnly() bool {
+	return h.RPUFormat&0x700 != 0 || h.DisableResidualFlag
+}
+
+// Profile - Dolby Vision profile the RPU is made for, 4, 5, 7 or 8, 0 if unknown
+// Profile 5 uses a full range IPT base layer and no enhancement layer.
+// Profiles 4 and 7 apply a residual from an enhancement layer, profile 7
+// with 12-bit output. Profile 8 covers the other single layer streams,
+// including AVC based profile 9, which cannot be told from the RPU.
+func (h *RPUHeader) Profile() byte {
+	if h.RPUType != 2 || !h.VDRSeqInfoPresentFlag {
+		return 0
+	}
+	switch h.VDRRPUProfile {
+	case 0:
+		if h.BLVideoFullRangeFlag {
+			return 5
+		}
+	case 1:
+		if !h.ELSpatialResamplingFilterFlag || h.ReshapingOnly() {
+			return 8
+		}
+		if h.VDRBitDepthMinus8 == 4 {
+			return 7
+		}
+		return 4
+	}
+	return 0
+}
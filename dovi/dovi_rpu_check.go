@@ -0,0 +1,112 @@
+package dovi
+
+import (
+	"fmt"
+
+	"github.com/go-webdl/media-codec/hevc"
+	"github.com/go-webdl/media-codec/nalu"
+)
+
+// RPUCheck - per access unit RPU count check of a single layer HEVC Dolby Vision stream
+// Naive concatenation, or tools not aware of NAL unit type 62, commonly drop
+// the RPU of some access units or leave two of them after a splice.
+type RPUCheck struct {
+	// Samples - number of video access units checked
+	Samples int
+	// Missing - indices of access units without RPU
+	Missing []int
+	// Duplicated - indices of access units with more than one RPU
+	Duplicated []int
+	// index - index of the next sample passed to AddSample
+	index int
+}
+
+// AddSample - check a length-prefixed HEVC sample
+// Samples without VCL NAL units are counted in the sample index but are not
+// video access units and are not checked.
+func (c *RPUCheck) AddSample(sample []byte, lengthSize int) error {
+	index := c.index
+	c.index++
+	nalus, err := nalu.SplitSample(sample, lengthSize)
+	if err != nil {
+		return fmt.Errorf("sample %d: %w", index, err)
+	}
+	c.addNALUs(index, nalus)
+	return nil
+}
+
+// AddProtectedSample - check a length-prefixed HEVC sample protected with
+// the given CENC subsample map, see AddSample
+// Only the clear NAL unit headers are read.
+func (c *RPUCheck) AddProtectedSample(sample []byte, lengthSize int, subsamples []nalu.Subsample) error {
+	index := c.index
+	c.index++
+	nalus, err := nalu.SplitProtectedSample(sample, lengthSize, subsamples)
+	if err != nil {
+		return fmt.Errorf("sample %d: %w", index, err)
+	}
+	c.addNALUs(index, nalu.ClearParts(nalus))
+	return nil
+}
+
+// addNALUs - check the NAL units of the sample with the given index
+func (c *RPUCheck) addNALUs(index int, nalus [][]byte) {
+	rpus, vcl := 0, false
+	for _, n := range nalus {
+		if len(n) < 2 {
+			continue
+		}
+		switch naluType := hevc.GetNaluType(n[0]); {
+		case naluType == NALU_RPU:
+			rpus++
+		case naluType.IsVCL():
+			vcl = true
+		}
+	}
+	if !vcl {
+		return
+	}
+	c.Samples++
+	switch {
+	case rpus == 0:
+		c.Missing = append(c.Missing, index)
+	case rpus > 1:
+		c.Duplicated = append(c.Duplicated, index)
+	}
+}
+
+// OK - every checked access unit has exactly one RPU
+func (c *RPUCheck) OK() bool {
+	return len(c.Missing) == 0 && len(c.Duplicated) == 0
+}
+
+// Issues - description of the access units failing the check
+func (c *RPUCheck) Issues() (issues []string) {
+	if len(c.Missing) > 0 {
+		issues = append(issues, fmt.Sprintf("%d of %d access units without RPU, first at sample %d",
+			len(c.Missing), c.Samples, c.Missing[0]))
+	}
+	if len(c.Duplicated) > 0 {
+		issues = append(issues, fmt.Sprintf("%d of %d access units with duplicated RPU, first at sample %d",
+			len(c.Duplicated), c.Samples, c.Duplicated[0]))
+	}
+	return issues
+}
+
+// CheckRPUs - check the RPUs of the samples of a merged track
+// The record must signal a single layer profile with RPU, e.g. profile 8.1.
+func CheckRPUs(dvcC *DOVIDecoderConfigurationRecord, samples [][]byte, lengthSize int) (*RPUCheck, error) {
+	if !dvcC.RPUPresent {
+		return nil, fmt.Errorf("profile %d: record signals no RPU", dvcC.Profile)
+	}
+	if dvcC.ELPresent {
+		return nil, fmt.Errorf("profile %d: dual layer streams are not supported", dvcC.Profile)
+	}
+	c := &RPUCheck{}
+	for _, sample := range samples {
+		if err := c.AddSample(sample, lengthSize); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
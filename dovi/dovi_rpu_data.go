@@ -0,0 +1,283 @@
+package dovi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/go-webdl/bits"
+	"github.com/go-webdl/media-codec/nalu"
+)
+
+// rpuLayout - bit positions of the parts of an RPU in its RBSP
+// rpu_data_header() is followed by rpu_data_mapping(), with NLQ parameters
+// for streams with a residual, vdr_dm_data_payload(), alignment zero bits,
+// rpu_data_crc32 and a final 0x80 byte. Rewriting an RPU only needs these
+// positions, so the payloads are walked without being decoded.
+type rpuLayout struct {
+	Header *RPUHeader
+	RBSP   []byte
+	// HeaderEnd - end of rpu_data_header()
+	HeaderEnd int
+	// NLQStart, NLQEnd - NLQ parameters of rpu_data_mapping(), empty if the
+	// RPU has no residual
+	NLQStart, NLQEnd int
+	// DM - vdr_dm_data_payload(), empty if there is none
+	DM []rpuSegment
+	// DMEnd - end of vdr_dm_data_payload(), NLQEnd if there is none
+	DMEnd int
+	// CRCStart - byte aligned position of rpu_data_crc32
+	CRCStart int
+}
+
+// maxExtBlocks - upper bound of num_ext_blocks
+const maxExtBlocks = 255
+
+// parseRPULayout - locate the parts of an RPU starting with rpu_nal_prefix,
+// with emulation prevention bytes, and verify its rpu_data_crc32
+// Only RPUs of rpu_type 2 with sequence info and a single partition are
+// supported, which covers all profiles in use.
+func parseRPULayout(rpu []byte) (*rpuLayout, error) {
+	r := bits.NewAccErrEBSPReader(bytes.NewReader(rpu))
+	h, err := readRPUHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	if h.RPUType != 2 || !h.VDRSeqInfoPresentFlag {
+		return nil, fmt.Errorf("RPU of rpu_type %d without sequence info not supported", h.RPUType)
+	}
+	l := &rpuLayout{Header: h, RBSP: nalu.UnescapeEBSP(rpu)}
+	rbspEnd := len(bytes.TrimRight(l.RBSP, "\x00"))
+	if rbspEnd < 6 || l.RBSP[rbspEnd-1] != 0x80 {
+		return nil, fmt.Errorf("RPU does not end with 0x80")
+	}
+	l.CRCStart = (rbspEnd - 5) * 8
+
+	l.HeaderEnd = nalu.RBSPBitPosition(rpu, r)
+	l.NLQStart, l.NLQEnd = l.HeaderEnd, l.HeaderEnd
+	if !h.UsePrevVDRRPUFlag {
+		if err := skipMappingCurves(r, h); err != nil {
+			return nil, err
+		}
+		l.NLQStart = nalu.RBSPBitPosition(rpu, r)
+		if !h.ReshapingOnly() {
+			if err := skipNLQParams(r, h); err != nil {
+				return nil, err
+			}
+		}
+		l.NLQEnd = nalu.RBSPBitPosition(rpu, r)
+	}
+	l.DMEnd = l.NLQEnd
+	if h.VDRDMMetadataPresentFlag {
+		if l.DM, err = skipDMData(r, rpu, l.CRCStart); err != nil {
+			return nil, err
+		}
+		l.DMEnd = nalu.RBSPBitPosition(rpu, r)
+	}
+	if (l.DMEnd+7)/8*8 != l.CRCStart {
+		return nil, fmt.Errorf("RPU data ends at bit %d, rpu_data_crc32 at bit %d", l.DMEnd, l.CRCStart)
+	}
+
+	crc := binary.BigEndian.Uint32(l.RBSP[l.CRCStart/8:])
+	if computed := crc32MPEG2(l.RBSP[1 : l.CRCStart/8]); computed != crc {
+		return nil, fmt.Errorf("rpu_data_crc32 mismatch: RPU has 0x%08X, computed 0x%08X", crc, computed)
+	}
+	return l, nil
+}
+
+// coefficientBits - length of the fractional part of mapping and NLQ coefficients
+func (h *RPUHeader) coefficientBits() (int, error) {
+	switch h.CoefficientDataType {
+	case 0:
+		if h.CoefficientLog2Denom > 32 {
+			return 0, fmt.Errorf("coefficient_log2_denom %d out of range", h.CoefficientLog2Denom)
+		}
+		return int(h.CoefficientLog2Denom), nil
+	case 1:
+		return 32, nil
+	}
+	return 0, fmt.Errorf("coefficient_data_type %d not supported", h.CoefficientDataType)
+}
+
+// readCoefficient - skip a signed or unsigned mapping or NLQ coefficient,
+// coded as integer and fractional part, or as float with coefficient_data_type 1
+func readCoefficient(r *bits.AccErrEBSPReader, h *RPUHeader, signed bool) {
+	n, _ := h.coefficientBits()
+	if h.CoefficientDataType == 0 {
+		if signed {
+			_ = r.ReadSignedGolomb()
+		} else {
+			_ = r.ReadExpGolomb()
+		}
+	}
+	_ = r.Read(n)
+}
+
+// skipMappingCurves - read the polynomial and MMR mapping curves of
+// rpu_data_mapping() for each component and pivot interval
+// The mapping parameters are never predicted, as there is only a single
+// partition.
+func skipMappingCurves(r *bits.AccErrEBSPReader, h *RPUHeader) error {
+	if h.NumXPartitionsMinus1 != 0 || h.NumYPartitionsMinus1 != 0 {
+		return fmt.Errorf("RPU with %dx%d partitions not supported", h.NumXPartitionsMinus1+1, h.NumYPartitionsMinus1+1)
+	}
+	if _, err := h.coefficientBits(); err != nil {
+		return err
+	}
+	for cmp, pivots := range h.PredPivotValues {
+		for i := 0; i < len(pivots)-1; i++ {
+			switch mappingIdc := r.ReadExpGolomb(); mappingIdc {
+			case 0: // polynomial
+				polyOrderMinus1 := r.ReadExpGolomb()
+				if polyOrderMinus1 > 1 {
+					return fmt.Errorf("component %d: poly_order_minus1 %d out of range", cmp, polyOrderMinus1)
+				}
+				if polyOrderMinus1 == 0 && r.ReadFlag() {
+					return fmt.Errorf("component %d: linear interpolation not supported", cmp)
+				}
+				for j := 0; j <= int(polyOrderMinus1)+1; j++ {
+					readCoefficient(r, h, true)
+				}
+			case 1: // MMR
+				mmrOrderMinus1 := r.Read(2)
+				if mmrOrderMinus1 > 2 {
+					return fmt.Errorf("component %d: mmr_order_minus1 %d out of range", cmp, mmrOrderMinus1)
+				}
+				readCoefficient(r, h, true)
+				for j := 0; j < 7*(int(mmrOrderMinus1)+1); j++ {
+					readCoefficient(r, h, true)
+				}
+			default:
+				if err := r.AccError(); err != nil {
+					return err
+				}
+				return fmt.Errorf("component %d: mapping_idc %d not supported", cmp, mappingIdc)
+			}
+			if err := r.AccError(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// skipNLQParams - read the NLQ parameters of rpu_data_mapping() for each
+// component, present if the RPU has a residual
+func skipNLQParams(r *bits.AccErrEBSPReader, h *RPUHeader) error {
+	if h.NLQMethodIdc != 0 {
+		return fmt.Errorf("nlq_method_idc %d not supported", h.NLQMethodIdc)
+	}
+	for cmp := 0; cmp < 3; cmp++ {
+		_ = r.Read(h.ELBitDepth())   // nlq_offset
+		readCoefficient(r, h, false) // vdr_in_max
+		readCoefficient(r, h, false) // linear_deadzone_slope
+		readCoefficient(r, h, false) // linear_deadzone_threshold
+	}
+	return r.AccError()
+}
+
+// rpuSegment - bits Start to End of an RPU to be copied verbatim, preceded
+// by zero bits up to a byte boundary if Align is set
+type rpuSegment struct {
+	Start, End int
+	Align      bool
+}
+
+// skipDMData - read vdr_dm_data_payload() with its CM v2.9 extension blocks
+// and, if more data precedes rpu_data_crc32 at crcStart, CM v4.0 extension
+// blocks
+// The extension blocks are byte aligned, so the payload is returned as the
+// segments between the alignment zero bits.
+func skipDMData(r *bits.AccErrEBSPReader, rpu []byte, crcStart int) ([]rpuSegment, error) {
+	segments := []rpuSegment{{Start: nalu.RBSPBitPosition(rpu, r)}}
+	_ = r.ReadExpGolomb() // affected_dm_metadata_id
+	_ = r.ReadExpGolomb() // current_dm_metadata_id
+	_ = r.ReadExpGolomb() // scene_refresh_flag
+	// YCbCr to RGB and RGB to LMS matrices and offsets, 9*16 + 3*32 + 9*16 bits
+	for i := 0; i < 12; i++ {
+		_ = r.Read(32)
+	}
+	// signal_eotf, signal_eotf_param0 to 2, signal_bit_depth,
+	// signal_color_space, signal_chroma_format, signal_full_range_flag,
+	// source_min_pq, source_max_pq, source_diagonal
+	for _, n := range []int{16, 16, 16, 32, 5, 2, 2, 2, 12, 12, 10} {
+		_ = r.Read(n)
+	}
+	segments, err := skipExtBlocks(r, rpu, segments)
+	if err != nil {
+		return nil, fmt.Errorf("CM v2.9 metadata: %w", err)
+	}
+	if (nalu.RBSPBitPosition(rpu, r)+7)/8*8 >= crcStart {
+		return segments, nil
+	}
+	if segments, err = skipExtBlocks(r, rpu, segments); err != nil {
+		return nil, fmt.Errorf("CM v4.0 metadata: %w", err)
+	}
+	return segments, nil
+}
+
+// skipExtBlocks - read num_ext_blocks and the byte aligned
+// ext_metadata_block() that follow, each of ext_block_length payload bytes,
+// extending segments
+func skipExtBlocks(r *bits.AccErrEBSPReader, rpu []byte, segments []rpuSegment) ([]rpuSegment, error) {
+	numExtBlocks := r.ReadExpGolomb()
+	if err := r.AccError(); err != nil {
+		return nil, err
+	}
+	segments[len(segments)-1].End = nalu.RBSPBitPosition(rpu, r)
+	if numExtBlocks == 0 {
+		return segments, nil
+	}
+	if numExtBlocks > maxExtBlocks {
+		return nil, fmt.Errorf("num_ext_blocks %d out of range", numExtBlocks)
+	}
+	for r.NrBitsReadInCurrentByte() != 8 {
+		if r.Read(1) != 0 {
+			return nil, fmt.Errorf("ext_dm_alignment_zero_bit not 0")
+		}
+	}
+	segment := rpuSegment{Start: nalu.RBSPBitPosition(rpu, r), Align: true}
+	for i := 0; i < int(numExtBlocks); i++ {
+		extBlockLength := r.ReadExpGolomb()
+		_ = r.Read(8) // ext_block_level
+		if err := r.AccError(); err != nil {
+			return nil, err
+		}
+		if extBlockLength > uint(len(rpu)) {
+			return nil, fmt.Errorf("ext_block_length %d out of range", extBlockLength)
+		}
+		for j := 0; j < int(extBlockLength); j++ {
+			_ = r.Read(8)
+		}
+	}
+	if err := r.AccError(); err != nil {
+		return nil, err
+	}
+	segment.End = nalu.RBSPBitPosition(rpu, r)
+	return append(segments, segment), nil
+}
+
+// writeRPUSegments - copy segments of rbsp to w
+func writeRPUSegments(w *nalu.RBSPWriter, rbsp []byte, segments []rpuSegment) {
+	for _, segment := range segments {
+		if segment.Align {
+			for w.NrBits()%8 != 0 {
+				w.Write(0, 1)
+			}
+		}
+		writeRBSPBits(w, rbsp, segment.Start, segment.End)
+	}
+}
+
+// writeRBSPBits - copy bits start to end of rbsp to w
+func writeRBSPBits(w *nalu.RBSPWriter, rbsp []byte, start, end int) {
+	for ; start < end && start%8 != 0; start++ {
+		w.Write(uint(rbsp[start/8]>>uint(7-start%8))&1, 1)
+	}
+	for ; start+8 <= end; start += 8 {
+		w.Write(uint(rbsp[start/8]), 8)
+	}
+	for ; start < end; start++ {
+		w.Write(uint(rbsp[start/8]>>uint(7-start%8))&1, 1)
+	}
+}
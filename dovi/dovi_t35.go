@@ -0,0 +1,138 @@
+package dovi
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/go-webdl/bits"
+	"github.com/go-webdl/media-codec/nalu"
+)
+
+// t35Header - itu_t_t35_country_code (United States),
+// itu_t_t35_terminal_provider_code (Dolby) and
+// itu_t_t35_terminal_provider_oriented_code of Dolby Vision metadata
+var t35Header = []byte{0xB5, 0x00, 0x3B, 0x00, 0x00, 0x08, 0x00}
+
+// EMDF container fields of Dolby Vision metadata
+const (
+	emdfKeyID        = 6
+	emdfPayloadID    = 256
+	emdfMaxBitsBytes = 4
+)
+
+// IsT35RPU - does ITU-T T.35 metadata, starting with itu_t_t35_country_code,
+// carry a Dolby Vision RPU
+func IsT35RPU(t35 []byte) bool {
+	return len(t35) > len(t35Header) && bytes.Equal(t35[:len(t35Header)], t35Header)
+}
+
+// UnwrapT35RPU - the RPU carried by ITU-T T.35 metadata starting with
+// itu_t_t35_country_code
+// The RPU is returned starting with rpu_nal_prefix and with emulation
+// prevention bytes, like the payload of an RPU NAL unit, so it can be passed
+// to ParseRPUHeader and the other RPU functions of this package.
+func UnwrapT35RPU(t35 []byte) ([]byte, error) {
+	if !IsT35RPU(t35) {
+		return nil, fmt.Errorf("not Dolby Vision ITU-T T.35 metadata")
+	}
+	data := t35[len(t35Header):]
+	r := bits.NewAccErrReader(bytes.NewReader(data))
+	if version := r.Read(2); version != 0 {
+		return nil, fmt.Errorf("emdf_version %d not supported", version)
+	}
+	if keyID := r.Read(3); keyID != emdfKeyID {
+		return nil, fmt.Errorf("EMDF key_id %d, not %d", keyID, emdfKeyID)
+	}
+	payloadID := r.Read(5)
+	if payloadID == 0x1f {
+		payloadID += readVariableBits(r, 5)
+	}
+	if payloadID != emdfPayloadID {
+		return nil, fmt.Errorf("emdf_payload_id %d, not %d", payloadID, emdfPayloadID)
+	}
+	// smploffste, duratione, groupide, codecdatae and discard_unknown_payload
+	if config := r.Read(5); config != 0b00001 {
+		return nil, fmt.Errorf("emdf_payload_config 0b%05b not supported", config)
+	}
+	size := readVariableBits(r, 8)
+	if err := r.AccError(); err != nil {
+		return nil, err
+	}
+	if size > uint(len(data)) {
+		return nil, fmt.Errorf("emdf_payload_size %d exceeds metadata", size)
+	}
+	rbsp := make([]byte, 1, size+1)
+	rbsp[0] = rpuNALPrefix
+	for i := uint(0); i < size; i++ {
+		rbsp = append(rbsp, byte(r.Read(8)))
+	}
+	if err := r.AccError(); err != nil {
+		return nil, err
+	}
+	return nalu.EscapeRBSP(rbsp), nil
+}
+
+// WrapT35RPU - ITU-T T.35 metadata starting with itu_t_t35_country_code
+// carrying an RPU starting with rpu_nal_prefix, with emulation prevention bytes
+// This carries RPUs where no NAL units exist, e.g. in AV1 metadata OBUs: an
+// EMDF container holds the RPU without prefix and emulation prevention.
+func WrapT35RPU(rpu []byte) ([]byte, error) {
+	rbsp := nalu.UnescapeEBSP(rpu)
+	if len(rbsp) < 2 || rbsp[0] != rpuNALPrefix {
+		return nil, fmt.Errorf("RPU does not start with rpu_nal_prefix")
+	}
+	w := nalu.NewRBSPWriter()
+	w.Write(0, 2) // emdf_version
+	w.Write(emdfKeyID, 3)
+	w.Write(0x1f, 5)
+	writeVariableBits(w, emdfPayloadID-0x1f, 5)
+	w.Write(0b00001, 5) // only discard_unknown_payload set
+	writeVariableBits(w, uint(len(rbsp)-1), 8)
+	for _, b := range rbsp[1:] {
+		w.Write(uint(b), 8)
+	}
+	w.Write(0, 5) // emdf_payload_id 0 ends the payloads
+	// emdf_protection with protection_length_primary 1 and
+	// protection_length_secondary 0, zero protection bits
+	w.Write(1, 2)
+	w.Write(0, 2)
+	w.Write(0, 8)
+	for w.NrBits()%8 != 0 {
+		w.Write(0, 1)
+	}
+	data, err := w.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return append(append(make([]byte, 0, len(t35Header)+len(data)), t35Header...), data...), nil
+}
+
+// readVariableBits - read variable_bits(n) of an EMDF container
+func readVariableBits(r *bits.AccErrReader, n int) uint {
+	value := uint(0)
+	for i := 0; i < emdfMaxBitsBytes*8/n; i++ {
+		value += r.Read(n)
+		if !r.ReadFlag() {
+			break
+		}
+		value = (value + 1) << uint(n)
+	}
+	return value
+}
+
+// writeVariableBits - write value as variable_bits(n) of an EMDF container
+func writeVariableBits(w *nalu.RBSPWriter, value uint, n int) {
+	var groups []uint
+	for {
+		groups = append(groups, value&(1<<uint(n)-1))
+		value >>= uint(n)
+		if value == 0 {
+			break
+		}
+		value--
+	}
+	for i := len(groups) - 1; i >= 0; i-- {
+		w.Write(groups[i], n)
+		w.WriteFlag(i > 0)
+	}
+}
@@ -0,0 +1,59 @@
+package dovi
+
+import "fmt"
+
+// profileConstraint - layers and dv_bl_signal_compatibility_id values of a profile
+type profileConstraint struct {
+	// dualLayer - the stream has an enhancement layer. The record of the
+	// enhancement layer track of a dual track file has no base layer.
+	dualLayer bool
+	// compatibilityIDs - allowed dv_bl_signal_compatibility_id values
+	compatibilityIDs []uint8
+}
+
+// profileConstraints - constraints of the profiles in use, by dv_profile
+var profileConstraints = map[uint8]profileConstraint{
+	4:  {dualLayer: true, compatibilityIDs: []uint8{BL_COMPATIBILITY_SDR}},
+	5:  {compatibilityIDs: []uint8{BL_COMPATIBILITY_NONE}},
+	7:  {dualLayer: true, compatibilityIDs: []uint8{BL_COMPATIBILITY_BLURAY}},
+	8:  {compatibilityIDs: []uint8{BL_COMPATIBILITY_HDR10, BL_COMPATIBILITY_SDR, BL_COMPATIBILITY_HLG}},
+	9:  {compatibilityIDs: []uint8{BL_COMPATIBILITY_SDR}},
+	10: {compatibilityIDs: []uint8{BL_COMPATIBILITY_NONE, BL_COMPATIBILITY_HDR10, BL_COMPATIBILITY_SDR, BL_COMPATIBILITY_HLG}},
+}
+
+// Validate - check the record against the constraints of its profile:
+// version, level, present flags and dv_bl_signal_compatibility_id
+// The first violation found is returned. Deprecated profiles 0 to 3 and 6
+// are not accepted.
+func (b *DOVIDecoderConfigurationRecord) Validate() error {
+	if b.VersionMajor != 1 && b.VersionMajor != 2 {
+		return fmt.Errorf("dv_version_major %d, not 1 or 2", b.VersionMajor)
+	}
+	if b.VersionMinor != 0 {
+		return fmt.Errorf("dv_version_minor %d, not 0", b.VersionMinor)
+	}
+	c, ok := profileConstraints[b.Profile]
+	if !ok {
+		return fmt.Errorf("dv_profile %d unknown or deprecated", b.Profile)
+	}
+	if b.Level == 0 || b.Level > MaxLevel {
+		return fmt.Errorf("profile %d: dv_level %d, not 1 to %d", b.Profile, b.Level, MaxLevel)
+	}
+	if !b.RPUPresent {
+		return fmt.Errorf("profile %d: rpu_present_flag not set", b.Profile)
+	}
+	switch {
+	case c.dualLayer && !b.ELPresent:
+		return fmt.Errorf("profile %d is dual layer, el_present_flag not set", b.Profile)
+	case !c.dualLayer && b.ELPresent:
+		return fmt.Errorf("profile %d is single layer, el_present_flag set", b.Profile)
+	case !c.dualLayer && !b.BLPresent:
+		return fmt.Errorf("profile %d: bl_present_flag not set", b.Profile)
+	}
+	for _, id := range c.compatibilityIDs {
+		if b.BLSignalCompatibilityID == id {
+			return nil
+		}
+	}
+	return fmt.Errorf("profile %d: dv_bl_signal_compatibility_id %d, not one of %v", b.Profile, b.BLSignalCompatibilityID, c.compatibilityIDs)
+}
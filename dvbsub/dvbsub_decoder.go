@@ -0,0 +1,303 @@
+package dvbsub
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+	"time"
+
+	"github.com/go-webdl/media-codec/subtitle"
+)
+
+// DisplaySet - the composed state of a subtitle page at a presentation time
+type DisplaySet struct {
+	PTS     time.Duration
+	TimeOut time.Duration
+	// Display size, 720x576 unless signaled by a display definition segment
+	Width, Height int
+	// Region bitmaps positioned on the display
+	Regions []*image.Paletted
+	// Text of character coded objects, if any
+	Text string
+}
+
+// Empty - true if the display set clears the screen
+func (ds *DisplaySet) Empty() bool {
+	return len(ds.Regions) == 0 && ds.Text == ""
+}
+
+// Recognizer - conversion hook turning the bitmaps of a display set into cue
+// text, typically backed by an OCR engine
+type Recognizer func(*DisplaySet) (string, error)
+
+// Decoder - compose DVB subtitle display sets and turn them into cues
+type Decoder struct {
+	// composition_page_id and ancillary_page_id from the subtitling
+	// descriptor. A zero CompositionPageID accepts the first page seen.
+	CompositionPageID uint16
+	AncillaryPageID   uint16
+	// OnDisplaySet is called for every completed display set
+	OnDisplaySet func(*DisplaySet)
+	// Recognize converts bitmap display sets to text. Without it only
+	// character coded objects produce cues. Its errors are returned by
+	// Decode and Flush.
+	Recognize Recognizer
+	// OnCue is called for every completed cue
+	OnCue func(subtitle.Cue)
+
+	page    *PageComposition
+	pagePTS time.Duration
+	display *DisplayDefinition
+	regions map[uint8]*RegionComposition
+	cluts   map[uint8]*CLUTDefinition
+	objects map[uint16]*ObjectData
+	cue     *subtitle.Cue
+}
+
+// Decode - feed the PES_data_field of one DVB subtitle PES packet presented
+// at pts
+func (d *Decoder) Decode(pesData []byte, pts time.Duration) error {
+	segments, err := ParsePESData(pesData)
+	if err != nil {
+		return err
+	}
+	if d.regions == nil {
+		d.regions = make(map[uint8]*RegionComposition)
+		d.cluts = make(map[uint8]*CLUTDefinition)
+		d.objects = make(map[uint16]*ObjectData)
+	}
+	for i := range segments {
+		s := &segments[i]
+		if d.CompositionPageID == 0 && s.Type == SEGMENT_PAGE_COMPOSITION {
+			d.CompositionPageID = s.PageID
+		}
+		if s.PageID != d.CompositionPageID && (d.AncillaryPageID == 0 || s.PageID != d.AncillaryPageID) {
+			continue
+		}
+		switch s.Type {
+		case SEGMENT_PAGE_COMPOSITION:
+			pc, err := ParsePageComposition(s)
+			if err != nil {
+				return err
+			}
+			if d.page != nil {
+				// Display set without end_of_display_set_segment
+				if err := d.completeDisplaySet(); err != nil {
+					return err
+				}
+			}
+			if pc.State == PAGE_STATE_MODE_CHANGE || pc.State == PAGE_STATE_ACQUISITION_POINT {
+				d.regions = make(map[uint8]*RegionComposition)
+				d.cluts = make(map[uint8]*CLUTDefinition)
+				d.objects = make(map[uint16]*ObjectData)
+			}
+			d.page, d.pagePTS = pc, pts
+		case SEGMENT_REGION_COMPOSITION:
+			rc, err := ParseRegionComposition(s)
+			if err != nil {
+				return err
+			}
+			d.regions[rc.RegionID] = rc
+		case SEGMENT_CLUT_DEFINITION:
+			cd, err := ParseCLUTDefinition(s)
+			if err != nil {
+				return err
+			}
+			d.cluts[cd.CLUTID] = cd
+		case SEGMENT_OBJECT_DATA:
+			od, err := ParseObjectData(s)
+			if err != nil {
+				return err
+			}
+			d.objects[od.ObjectID] = od
+		case SEGMENT_DISPLAY_DEFINITION:
+			if d.display, err = ParseDisplayDefinition(s); err != nil {
+				return err
+			}
+		case SEGMENT_END_OF_DISPLAY_SET:
+			if err := d.completeDisplaySet(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Flush - emit the cue currently displayed, ending it at pts
+// An error is returned if the pending display set cannot be composed; the
+// cue is still ended.
+func (d *Decoder) Flush(pts time.Duration) error {
+	var err error
+	if d.page != nil {
+		err = d.completeDisplaySet()
+	}
+	d.endCue(pts)
+	return err
+}
+
+func (d *Decoder) completeDisplaySet() error {
+	ds, err := d.compose()
+	d.page = nil
+	if err != nil {
+		return fmt.Errorf("page %d display set at %s: %w", d.CompositionPageID, d.pagePTS, err)
+	}
+	if d.OnDisplaySet != nil {
+		d.OnDisplaySet(ds)
+	}
+	d.endCue(ds.PTS)
+	if ds.Empty() {
+		return nil
+	}
+	text := ds.Text
+	if d.Recognize != nil && len(ds.Regions) > 0 {
+		recognized, err := d.Recognize(ds)
+		if err != nil {
+			return fmt.Errorf("page %d display set at %s: recognize: %w", d.CompositionPageID, ds.PTS, err)
+		}
+		text = strings.TrimSpace(strings.Join([]string{text, recognized}, "\n"))
+	}
+	if text != "" {
+		d.cue = &subtitle.Cue{Start: ds.PTS, End: ds.PTS + ds.TimeOut, Text: text}
+	}
+	return nil
+}
+
+func (d *Decoder) endCue(pts time.Duration) {
+	if d.cue == nil {
+		return
+	}
+	if pts < d.cue.End || d.cue.End == d.cue.Start {
+		d.cue.End = pts
+	}
+	if d.cue.End > d.cue.Start && d.OnCue != nil {
+		d.OnCue(*d.cue)
+	}
+	d.cue = nil
+}
+
+func (d *Decoder) compose() (*DisplaySet, error) {
+	ds := &DisplaySet{
+		PTS:     d.pagePTS,
+		TimeOut: time.Duration(d.page.PageTimeOut) * time.Second,
+		Width:   720,
+		Height:  576,
+	}
+	if d.display != nil {
+		ds.Width, ds.Height = int(d.display.DisplayWidthMinus1)+1, int(d.display.DisplayHeightMinus1)+1
+	}
+	var text []string
+	for _, pr := range d.page.Regions {
+		rc := d.regions[pr.RegionID]
+		if rc == nil || rc.Width == 0 || rc.Height == 0 {
+			continue
+		}
+		x, y := int(pr.HorizontalAddress), int(pr.VerticalAddress)
+		img := image.NewPaletted(image.Rect(x, y, x+int(rc.Width), y+int(rc.Height)), d.palette(rc))
+		if rc.FillFlag {
+			fill := rc.PixelCode8Bit
+			switch rc.Depth {
+			case 1:
+				fill = rc.PixelCode2Bit
+			case 2:
+				fill = rc.PixelCode4Bit
+			}
+			for i := range img.Pix {
+				img.Pix[i] = fill
+			}
+		}
+		visible := false
+		for _, ro := range rc.Objects {
+			od := d.objects[ro.ObjectID]
+			if od == nil {
+				continue
+			}
+			if od.CodingMethod == 1 {
+				var sb strings.Builder
+				for _, c := range od.CharacterCodes {
+					sb.WriteRune(rune(c))
+				}
+				text = append(text, sb.String())
+				continue
+			}
+			ox, oy := x+int(ro.HorizontalPosition), y+int(ro.VerticalPosition)
+			for field, data := range [][]byte{od.TopFieldData, od.BottomFieldData} {
+				px, py := ox, oy+field
+				err := decodePixelData(data, rc.Depth, func(code uint8, run int) {
+					for i := 0; i < run; i, px = i+1, px+1 {
+						if od.NonModifyingColourFlag && code == 1 {
+							continue
+						}
+						if (image.Point{px, py}).In(img.Rect) {
+							img.SetColorIndex(px, py, code)
+							visible = visible || img.Palette[code].(color.RGBA).A != 0
+						}
+					}
+				}, func() {
+					px, py = ox, py+2
+				})
+				if err != nil {
+					return nil, fmt.Errorf("object %d: %w", od.ObjectID, err)
+				}
+			}
+		}
+		if visible {
+			ds.Regions = append(ds.Regions, img)
+		}
+	}
+	ds.Text = strings.Join(text, "\n")
+	return ds, nil
+}
+
+// palette - CLUT of the region converted to RGBA, falling back to the default
+// CLUT for entries not defined by the stream
+func (d *Decoder) palette(rc *RegionComposition) color.Palette {
+	size := 256
+	switch rc.Depth {
+	case 1:
+		size = 4
+	case 2:
+		size = 16
+	}
+	p := make(color.Palette, size)
+	for i := range p {
+		p[i] = defaultCLUTEntry(size, uint8(i))
+	}
+	if cd := d.cluts[rc.CLUTID]; cd != nil {
+		flag := uint8(1) << (3 - rc.Depth)
+		for _, e := range cd.Entries {
+			if e.Flags&flag == 0 || int(e.EntryID) >= size {
+				continue
+			}
+			if e.Y == 0 {
+				p[e.EntryID] = color.RGBA{}
+				continue
+			}
+			r, g, b := color.YCbCrToRGB(e.Y, e.Cb, e.Cr)
+			a := 255 - e.T
+			p[e.EntryID] = color.RGBA{R: mul(r, a), G: mul(g, a), B: mul(b, a), A: a}
+		}
+	}
+	return p
+}
+
+func mul(c, a uint8) uint8 {
+	return uint8(uint16(c) * uint16(a) / 255)
+}
+
+// defaultCLUTEntry - default CLUT of EN 300 743 10.1 to 10.3. The 256-entry
+// default is approximated by the 16-entry one repeated over the high nibble.
+func defaultCLUTEntry(size int, i uint8) color.Color {
+	if size == 4 {
+		return [4]color.RGBA{{}, {255, 255, 255, 255}, {0, 0, 0, 255}, {127, 127, 127, 255}}[i]
+	}
+	i &= 0xF
+	if i == 0 {
+		return color.RGBA{}
+	}
+	level := uint8(255)
+	if i&0x8 != 0 {
+		level = 127
+	}
+	return color.RGBA{R: level * (i & 1), G: level * (i >> 1 & 1), B: level * (i >> 2 & 1), A: 255}
+}
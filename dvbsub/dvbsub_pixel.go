@@ -0,0 +1,234 @@
+package dvbsub
+
+import (
+	"fmt"
+)
+
+// Default map tables of EN 300 743 10.4 to 10.6
+var (
+	defaultMap2To4 = [4]uint8{0x0, 0x7, 0x8, 0xF}
+	defaultMap2To8 = [4]uint8{0x00, 0x77, 0x88, 0xFF}
+	defaultMap4To8 = [16]uint8{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xAA, 0xBB, 0xCC, 0xDD, 0xEE, 0xFF}
+)
+
+type bitReader struct {
+	data []byte
+	pos  int // in bits
+}
+
+func (r *bitReader) read(n int) (v uint32, err error) {
+	if r.pos+n > 8*len(r.data) {
+		return 0, fmt.Errorf("pixel data is truncated")
+	}
+	for i := 0; i < n; i++ {
+		v = v<<1 | uint32(r.data[r.pos>>3]>>(7-uint(r.pos&7))&1)
+		r.pos++
+	}
+	return
+}
+
+func (r *bitReader) align() {
+	r.pos = (r.pos + 7) &^ 7
+}
+
+// pixelSink - receives runs of pixel codes mapped to the region depth
+type pixelSink func(code uint8, run int)
+
+// decodePixelData - decode one field of pixel-data_sub-blocks, calling put for
+// every run of pixels and newLine at every end_of_object_line. depth is the
+// region depth (1: 2-bit, 2: 4-bit, 3: 8-bit).
+func decodePixelData(data []byte, depth uint8, put pixelSink, newLine func()) error {
+	map2To4, map2To8, map4To8 := defaultMap2To4, defaultMap2To8, defaultMap4To8
+	r := &bitReader{data: data}
+	for r.pos < 8*len(data) {
+		dataType, _ := r.read(8)
+		switch dataType {
+		case 0x10:
+			mapCode := func(c uint8) uint8 {
+				switch depth {
+				case 2:
+					return map2To4[c]
+				case 3:
+					return map2To8[c]
+				}
+				return c
+			}
+			if err := decode2BitString(r, func(c uint8, n int) { put(mapCode(c), n) }); err != nil {
+				return err
+			}
+		case 0x11:
+			mapCode := func(c uint8) uint8 {
+				switch depth {
+				case 1:
+					return c & 0x3
+				case 3:
+					return map4To8[c]
+				}
+				return c
+			}
+			if err := decode4BitString(r, func(c uint8, n int) { put(mapCode(c), n) }); err != nil {
+				return err
+			}
+		case 0x12:
+			mapCode := func(c uint8) uint8 {
+				switch depth {
+				case 1:
+					return c & 0x3
+				case 2:
+					return c & 0xF
+				}
+				return c
+			}
+			if err := decode8BitString(r, func(c uint8, n int) { put(mapCode(c), n) }); err != nil {
+				return err
+			}
+		case 0x20:
+			for i := range map2To4 {
+				v, err := r.read(4)
+				if err != nil {
+					return err
+				}
+				map2To4[i] = uint8(v)
+			}
+		case 0x21:
+			for i := range map2To8 {
+				v, err := r.read(8)
+				if err != nil {
+					return err
+				}
+				map2To8[i] = uint8(v)
+			}
+		case 0x22:
+			for i := range map4To8 {
+				v, err := r.read(8)
+				if err != nil {
+					return err
+				}
+				map4To8[i] = uint8(v)
+			}
+		case 0xF0:
+			newLine()
+		default:
+			return fmt.Errorf("unknown pixel data_type 0x%02X", dataType)
+		}
+	}
+	return nil
+}
+
+// 2-bit/pixel_code_string(), EN 300 743 7.2.5.2
+func decode2BitString(r *bitReader, put pixelSink) error {
+	for {
+		code, err := r.read(2)
+		if err != nil {
+			return err
+		}
+		if code != 0 {
+			put(uint8(code), 1)
+			continue
+		}
+		if s1, _ := r.read(1); s1 == 1 {
+			run, _ := r.read(3)
+			code, err = r.read(2)
+			put(uint8(code), int(run)+3)
+		} else if s2, _ := r.read(1); s2 == 1 {
+			put(0, 1)
+		} else {
+			switch s3, _ := r.read(2); s3 {
+			case 0:
+				r.align()
+				return err
+			case 1:
+				put(0, 2)
+			case 2:
+				run, _ := r.read(4)
+				code, err = r.read(2)
+				put(uint8(code), int(run)+12)
+			case 3:
+				run, _ := r.read(8)
+				code, err = r.read(2)
+				put(uint8(code), int(run)+29)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// 4-bit/pixel_code_string(), EN 300 743 7.2.5.3
+func decode4BitString(r *bitReader, put pixelSink) error {
+	for {
+		code, err := r.read(4)
+		if err != nil {
+			return err
+		}
+		if code != 0 {
+			put(uint8(code), 1)
+			continue
+		}
+		if s1, _ := r.read(1); s1 == 0 {
+			run, err := r.read(3)
+			if err != nil {
+				return err
+			}
+			if run == 0 {
+				r.align()
+				return nil
+			}
+			put(0, int(run)+2)
+		} else if s2, _ := r.read(1); s2 == 0 {
+			run, _ := r.read(2)
+			code, err = r.read(4)
+			put(uint8(code), int(run)+4)
+		} else {
+			switch s3, _ := r.read(2); s3 {
+			case 0:
+				put(0, 1)
+			case 1:
+				put(0, 2)
+			case 2:
+				run, _ := r.read(4)
+				code, err = r.read(4)
+				put(uint8(code), int(run)+9)
+			case 3:
+				run, _ := r.read(8)
+				code, err = r.read(4)
+				put(uint8(code), int(run)+25)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// 8-bit/pixel_code_string(), EN 300 743 7.2.5.4
+func decode8BitString(r *bitReader, put pixelSink) error {
+	for {
+		code, err := r.read(8)
+		if err != nil {
+			return err
+		}
+		if code != 0 {
+			put(uint8(code), 1)
+			continue
+		}
+		s1, _ := r.read(1)
+		run, err := r.read(7)
+		if err != nil {
+			return err
+		}
+		if s1 == 0 {
+			if run == 0 {
+				return nil
+			}
+			put(0, int(run))
+		} else {
+			code, err = r.read(8)
+			if err != nil {
+				return err
+			}
+			put(uint8(code), int(run))
+		}
+	}
+}
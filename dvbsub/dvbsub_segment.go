@@ -0,0 +1,296 @@
+package dvbsub
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// SegmentType - segment_type
+type SegmentType uint8
+
+const (
+	SEGMENT_PAGE_COMPOSITION    = SegmentType(0x10)
+	SEGMENT_REGION_COMPOSITION  = SegmentType(0x11)
+	SEGMENT_CLUT_DEFINITION     = SegmentType(0x12)
+	SEGMENT_OBJECT_DATA         = SegmentType(0x13)
+	SEGMENT_DISPLAY_DEFINITION  = SegmentType(0x14)
+	SEGMENT_DISPARITY_SIGNALING = SegmentType(0x15)
+	SEGMENT_ALTERNATIVE_CLUT    = SegmentType(0x16)
+	SEGMENT_END_OF_DISPLAY_SET  = SegmentType(0x80)
+	SEGMENT_STUFFING            = SegmentType(0xFF)
+)
+
+// Segment - subtitling_segment() with undecoded data
+type Segment struct {
+	Type   SegmentType
+	PageID uint16
+	Data   []byte
+}
+
+// ParsePESData - split the PES_data_field of a DVB subtitle PES packet into
+// segments
+// ETSI EN 300 743 carries subtitles in private_stream_1 PES packets whose
+// data starts with data_identifier 0x20 and subtitle_stream_id 0x00.
+func ParsePESData(data []byte) (segments []Segment, err error) {
+	if len(data) < 2 || data[0] != 0x20 || data[1] != 0x00 {
+		return nil, fmt.Errorf("PES data is not a DVB subtitle stream")
+	}
+	data = data[2:]
+	for len(data) >= 6 && data[0] == 0x0F {
+		length := int(binary.BigEndian.Uint16(data[4:6]))
+		if 6+length > len(data) {
+			return segments, fmt.Errorf("segment_length %d exceeds PES data", length)
+		}
+		segments = append(segments, Segment{
+			Type:   SegmentType(data[1]),
+			PageID: binary.BigEndian.Uint16(data[2:4]),
+			Data:   data[6 : 6+length],
+		})
+		data = data[6+length:]
+	}
+	return
+}
+
+// PageState - page_state of a page composition segment
+type PageState uint8
+
+const (
+	PAGE_STATE_NORMAL_CASE       = PageState(0)
+	PAGE_STATE_ACQUISITION_POINT = PageState(1)
+	PAGE_STATE_MODE_CHANGE       = PageState(2)
+)
+
+// PageComposition - page_composition_segment()
+type PageComposition struct {
+	// Seconds after which the page is to be erased
+	PageTimeOut   uint8
+	VersionNumber uint8
+	State         PageState
+	Regions       []PageRegion
+}
+
+type PageRegion struct {
+	RegionID          uint8
+	HorizontalAddress uint16
+	VerticalAddress   uint16
+}
+
+// ParsePageComposition - decode a page composition segment
+func ParsePageComposition(s *Segment) (*PageComposition, error) {
+	if len(s.Data) < 2 || (len(s.Data)-2)%6 != 0 {
+		return nil, fmt.Errorf("invalid page composition segment length %d", len(s.Data))
+	}
+	pc := &PageComposition{
+		PageTimeOut:   s.Data[0],
+		VersionNumber: s.Data[1] >> 4,
+		State:         PageState(s.Data[1] >> 2 & 0x3),
+	}
+	for d := s.Data[2:]; len(d) >= 6; d = d[6:] {
+		pc.Regions = append(pc.Regions, PageRegion{
+			RegionID:          d[0],
+			HorizontalAddress: binary.BigEndian.Uint16(d[2:4]),
+			VerticalAddress:   binary.BigEndian.Uint16(d[4:6]),
+		})
+	}
+	return pc, nil
+}
+
+// RegionComposition - region_composition_segment()
+type RegionComposition struct {
+	RegionID      uint8
+	VersionNumber uint8
+	FillFlag      bool
+	Width         uint16
+	Height        uint16
+	// region_level_of_compatibility, 1: 2-bit, 2: 4-bit, 3: 8-bit
+	LevelOfCompatibility uint8
+	// region_depth, 1: 2-bit, 2: 4-bit, 3: 8-bit
+	Depth         uint8
+	CLUTID        uint8
+	PixelCode8Bit uint8
+	PixelCode4Bit uint8
+	PixelCode2Bit uint8
+	Objects       []RegionObject
+}
+
+type RegionObject struct {
+	ObjectID uint16
+	// 0: basic bitmap, 1: basic character, 2: composite string of characters
+	ObjectType          uint8
+	ProviderFlag        uint8
+	HorizontalPosition  uint16
+	VerticalPosition    uint16
+	ForegroundPixelCode uint8
+	BackgroundPixelCode uint8
+}
+
+// ParseRegionComposition - decode a region composition segment
+func ParseRegionComposition(s *Segment) (*RegionComposition, error) {
+	d := s.Data
+	if len(d) < 10 {
+		return nil, fmt.Errorf("invalid region composition segment length %d", len(d))
+	}
+	rc := &RegionComposition{
+		RegionID:             d[0],
+		VersionNumber:        d[1] >> 4,
+		FillFlag:             d[1]&0x08 != 0,
+		Width:                binary.BigEndian.Uint16(d[2:4]),
+		Height:               binary.BigEndian.Uint16(d[4:6]),
+		LevelOfCompatibility: d[6] >> 5,
+		Depth:                d[6] >> 2 & 0x7,
+		CLUTID:               d[7],
+		PixelCode8Bit:        d[8],
+		PixelCode4Bit:        d[9] >> 4,
+		PixelCode2Bit:        d[9] >> 2 & 0x3,
+	}
+	for d = d[10:]; len(d) >= 6; {
+		o := RegionObject{
+			ObjectID:           binary.BigEndian.Uint16(d[0:2]),
+			ObjectType:         d[2] >> 6,
+			ProviderFlag:       d[2] >> 4 & 0x3,
+			HorizontalPosition: binary.BigEndian.Uint16(d[2:4]) & 0xFFF,
+			VerticalPosition:   binary.BigEndian.Uint16(d[4:6]) & 0xFFF,
+		}
+		d = d[6:]
+		if o.ObjectType == 1 || o.ObjectType == 2 {
+			if len(d) < 2 {
+				return rc, fmt.Errorf("truncated object %d in region %d", o.ObjectID, rc.RegionID)
+			}
+			o.ForegroundPixelCode, o.BackgroundPixelCode = d[0], d[1]
+			d = d[2:]
+		}
+		rc.Objects = append(rc.Objects, o)
+	}
+	return rc, nil
+}
+
+// CLUTDefinition - CLUT_definition_segment()
+type CLUTDefinition struct {
+	CLUTID        uint8
+	VersionNumber uint8
+	Entries       []CLUTEntry
+}
+
+type CLUTEntry struct {
+	EntryID uint8
+	// Bit 2: 2-bit CLUT, bit 1: 4-bit CLUT, bit 0: 8-bit CLUT
+	Flags uint8
+	// Y, Cr, Cb and T scaled to 8 bits
+	Y, Cr, Cb, T uint8
+}
+
+// ParseCLUTDefinition - decode a CLUT definition segment
+func ParseCLUTDefinition(s *Segment) (*CLUTDefinition, error) {
+	d := s.Data
+	if len(d) < 2 {
+		return nil, fmt.Errorf("invalid CLUT definition segment length %d", len(d))
+	}
+	cd := &CLUTDefinition{CLUTID: d[0], VersionNumber: d[1] >> 4}
+	for d = d[2:]; len(d) >= 4; {
+		e := CLUTEntry{EntryID: d[0], Flags: d[1] >> 5}
+		if d[1]&0x1 != 0 {
+			if len(d) < 6 {
+				return cd, fmt.Errorf("truncated CLUT entry %d", e.EntryID)
+			}
+			e.Y, e.Cr, e.Cb, e.T = d[2], d[3], d[4], d[5]
+			d = d[6:]
+		} else {
+			v := binary.BigEndian.Uint16(d[2:4])
+			e.Y = uint8(v>>10) << 2
+			e.Cr = uint8(v>>6&0xF) << 4
+			e.Cb = uint8(v>>2&0xF) << 4
+			e.T = uint8(v&0x3) << 6
+			d = d[4:]
+		}
+		cd.Entries = append(cd.Entries, e)
+	}
+	return cd, nil
+}
+
+// ObjectData - object_data_segment()
+type ObjectData struct {
+	ObjectID      uint16
+	VersionNumber uint8
+	// 0: pixel data, 1: string of characters
+	CodingMethod           uint8
+	NonModifyingColourFlag bool
+	TopFieldData           []byte
+	BottomFieldData        []byte
+	CharacterCodes         []uint16
+}
+
+// ParseObjectData - decode an object data segment, leaving pixel data
+// run-length coded
+func ParseObjectData(s *Segment) (*ObjectData, error) {
+	d := s.Data
+	if len(d) < 3 {
+		return nil, fmt.Errorf("invalid object data segment length %d", len(d))
+	}
+	od := &ObjectData{
+		ObjectID:               binary.BigEndian.Uint16(d[0:2]),
+		VersionNumber:          d[2] >> 4,
+		CodingMethod:           d[2] >> 2 & 0x3,
+		NonModifyingColourFlag: d[2]&0x2 != 0,
+	}
+	d = d[3:]
+	switch od.CodingMethod {
+	case 0:
+		if len(d) < 4 {
+			return nil, fmt.Errorf("truncated object %d", od.ObjectID)
+		}
+		top, bottom := int(binary.BigEndian.Uint16(d[0:2])), int(binary.BigEndian.Uint16(d[2:4]))
+		if 4+top+bottom > len(d) {
+			return nil, fmt.Errorf("object %d field data exceeds segment", od.ObjectID)
+		}
+		od.TopFieldData = d[4 : 4+top]
+		od.BottomFieldData = d[4+top : 4+top+bottom]
+		if bottom == 0 {
+			// The top field is repeated for the bottom field
+			od.BottomFieldData = od.TopFieldData
+		}
+	case 1:
+		if len(d) < 1 || 1+2*int(d[0]) > len(d) {
+			return nil, fmt.Errorf("truncated character codes of object %d", od.ObjectID)
+		}
+		for i := 0; i < int(d[0]); i++ {
+			od.CharacterCodes = append(od.CharacterCodes, binary.BigEndian.Uint16(d[1+2*i:]))
+		}
+	}
+	return od, nil
+}
+
+// DisplayDefinition - display_definition_segment()
+type DisplayDefinition struct {
+	VersionNumber       uint8
+	DisplayWindowFlag   bool
+	DisplayWidthMinus1  uint16
+	DisplayHeightMinus1 uint16
+	WindowHorizontalMin uint16
+	WindowHorizontalMax uint16
+	WindowVerticalMin   uint16
+	WindowVerticalMax   uint16
+}
+
+// ParseDisplayDefinition - decode a display definition segment
+func ParseDisplayDefinition(s *Segment) (*DisplayDefinition, error) {
+	d := s.Data
+	if len(d) < 5 {
+		return nil, fmt.Errorf("invalid display definition segment length %d", len(d))
+	}
+	dd := &DisplayDefinition{
+		VersionNumber:       d[0] >> 4,
+		DisplayWindowFlag:   d[0]&0x08 != 0,
+		DisplayWidthMinus1:  binary.BigEndian.Uint16(d[1:3]),
+		DisplayHeightMinus1: binary.BigEndian.Uint16(d[3:5]),
+	}
+	if dd.DisplayWindowFlag {
+		if len(d) < 13 {
+			return nil, fmt.Errorf("truncated display window")
+		}
+		dd.WindowHorizontalMin = binary.BigEndian.Uint16(d[5:7])
+		dd.WindowHorizontalMax = binary.BigEndian.Uint16(d[7:9])
+		dd.WindowVerticalMin = binary.BigEndian.Uint16(d[9:11])
+		dd.WindowVerticalMax = binary.BigEndian.Uint16(d[11:13])
+	}
+	return dd, nil
+}
@@ -0,0 +1,87 @@
+package hevc
+
+import (
+	"io"
+
+	"github.com/go-webdl/media-codec/nalu"
+)
+
+// AccessUnitReader - reads the access units of an Annex B byte stream one at a time
+// Access unit boundaries are detected as in Sec. 7.4.2.4.4: after the VCL NAL
+// units of a picture, an access unit delimiter, parameter set, prefix SEI or
+// reserved prefix NAL unit, or a slice segment with
+// first_slice_segment_in_pic_flag set, starts the next access unit. Dependent
+// slice segments never have the flag set and stay with their picture, see
+// ParseAccessUnitSliceHeaders. Only NAL units of the base layer start access
+// units, so the pictures of all layers of a layered stream stay together.
+// Each access unit becomes one sample when muxing.
+type AccessUnitReader struct {
+	s    *nalu.Scanner
+	d    accessUnitDetector
+	next []byte // first NAL unit of the next access unit
+	err  error
+}
+
+// NewAccessUnitReader - create an AccessUnitReader reading from r
+func NewAccessUnitReader(r io.Reader) *AccessUnitReader {
+	return &AccessUnitReader{s: nalu.NewScanner(r)}
+}
+
+// Read - NAL units of the next access unit in decode order, io.EOF at end of stream
+// The NAL units are copies and stay valid after the next call.
+func (r *AccessUnitReader) Read() (au [][]byte, err error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if r.next != nil {
+		au = append(au, r.next)
+		r.next = nil
+	}
+	for r.s.Scan() {
+		data := append([]byte(nil), r.s.NALU()...)
+		if r.d.next(data) && len(au) > 0 {
+			r.next = data
+			return au, nil
+		}
+		au = append(au, data)
+	}
+	if err := r.s.Err(); err != nil {
+		r.err = err
+		return nil, err
+	}
+	r.err = io.EOF
+	if len(au) == 0 {
+		return nil, io.EOF
+	}
+	return au, nil
+}
+
+// accessUnitDetector - finds the first NAL unit of each access unit
+type accessUnitDetector struct {
+	inPicture bool // VCL NAL units of the current access unit seen
+}
+
+// next - account for the next NAL unit in decode order, reporting if it starts a new access unit
+func (d *accessUnitDetector) next(data []byte) (first bool) {
+	if len(data) < 2 {
+		return false
+	}
+	naluType := GetNaluType(data[0])
+	if GetLayerID(data) != 0 {
+		if naluType.IsVCL() {
+			d.inPicture = true
+		}
+		return false
+	}
+	switch {
+	case naluType.IsVCL():
+		first = d.inPicture && FirstSliceSegmentInPic(data)
+		d.inPicture = true
+	case naluType == NALU_AUD, naluType.IsParameterSet(), naluType == NALU_SEI_PREFIX,
+		NALU_RSV_NVCL41 <= naluType && naluType <= NALU_RSV_NVCL44,
+		NALU_UNSPEC48 <= naluType && naluType <= NALU_UNSPEC55:
+		first = d.inPicture
+		d.inPicture = false
+	}
+	return first
+}
@@ -0,0 +1,116 @@
+package hevc
+
+import (
+	"errors"
+
+	"github.com/go-webdl/media-codec/nalu"
+)
+
+// FindAlphaLayer - nuh_layer_id of the alpha layer described by the first VPS
+// with a VPS extension in vpsNalus
+func FindAlphaLayer(vpsNalus [][]byte) (layerID byte, ok bool, err error) {
+	for _, data := range vpsNalus {
+		vps, err := ParseVPSNALUnit(data)
+		if err != nil {
+			return 0, false, err
+		}
+		if !vps.ExtensionFlag {
+			continue
+		}
+		layerID, ok = vps.AlphaLayerID()
+		return layerID, ok, nil
+	}
+	return 0, false, nil
+}
+
+// AlphaLayerID - nuh_layer_id of the alpha layer of the stream described by the record
+func (b *HEVCDecoderConfigurationRecord) AlphaLayerID() (layerID byte, ok bool, err error) {
+	var vpsNalus [][]byte
+	for _, array := range b.NaluArrays {
+		if array.NALUnitType == NALU_VPS {
+			vpsNalus = append(vpsNalus, array.NALUs...)
+		}
+	}
+	return FindAlphaLayer(vpsNalus)
+}
+
+// SplitLayerNALUnits - split nalus into the NAL units of layer layerID and all others
+// The order of NAL units within each part is kept.
+func SplitLayerNALUnits(nalus [][]byte, layerID byte) (others, layer [][]byte) {
+	for _, data := range nalus {
+		if len(data) >= 2 && GetLayerID(data) == layerID {
+			layer = append(layer, data)
+		} else {
+			others = append(others, data)
+		}
+	}
+	return others, layer
+}
+
+// SplitLayerSample - split a length-prefixed sample into a sample with the NAL
+// units of layer layerID and a sample with all others
+// Either sample may be empty, e.g. if the layer has no picture at this time.
+func SplitLayerSample(sample []byte, lengthSize int, layerID byte) (others, layer []byte, err error) {
+	nalus, err := nalu.SplitSample(sample, lengthSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	otherNalus, layerNalus := SplitLayerNALUnits(nalus, layerID)
+	if others, err = nalu.AppendSample(nil, otherNalus, lengthSize); err != nil {
+		return nil, nil, err
+	}
+	if layer, err = nalu.AppendSample(nil, layerNalus, lengthSize); err != nil {
+		return nil, nil, err
+	}
+	return others, layer, nil
+}
+
+// AlphaTrack - track description of an alpha layer split from a layered HEVC stream
+// Samples of the track are the alpha layer parts returned by SplitLayerSample.
+type AlphaTrack struct {
+	LayerID byte
+	Record  LHEVCDecoderConfigurationRecord
+}
+
+// SplitAlphaLayer - split the record of a layered HEVC stream with an alpha
+// layer into the record of the base track and the alpha track description
+// The alpha layer, marked with AuxId AUX_ALPHA in the VPS extension, becomes
+// an L-HEVC track with an lhv1 sample entry and an 'sbas' track reference to
+// the base track.
+// Parameter sets and SEI NAL units of the alpha layer move to the alpha
+// track, the VPS stays in the base track. The record itself is not modified.
+func (b *HEVCDecoderConfigurationRecord) SplitAlphaLayer() (base HEVCDecoderConfigurationRecord, alpha AlphaTrack, err error) {
+	layerID, ok, err := b.AlphaLayerID()
+	if err != nil {
+		return base, alpha, err
+	}
+	if !ok {
+		return base, alpha, errors.New("no alpha layer in VPS")
+	}
+	base = *b
+	base.NaluArrays = nil
+	alpha = AlphaTrack{
+		LayerID: layerID,
+		Record: LHEVCDecoderConfigurationRecord{
+			ConfigurationVersion:            1,
+			MinSpatialSegmentationIndicator: b.MinSpatialSegmentationIndicator,
+			ParallelismType:                 b.ParallelismType,
+			NumTemporalLayers:               b.NumTemporalLayers,
+			TemporalIDNested:                b.TemporalIDNested,
+			LengthSizeMinusOne:              b.LengthSizeMinusOne,
+		},
+	}
+	for _, array := range b.NaluArrays {
+		others, layer := array.NALUs, [][]byte(nil)
+		if array.NALUnitType != NALU_VPS {
+			others, layer = SplitLayerNALUnits(array.NALUs, layerID)
+		}
+		if len(others) > 0 || len(layer) == 0 {
+			base.NaluArrays = append(base.NaluArrays, NaluArray{array.ArrayCompleteness, array.NALUnitType, others})
+		}
+		if len(layer) > 0 {
+			alpha.Record.NaluArrays = append(alpha.Record.NaluArrays, NaluArray{array.ArrayCompleteness, array.NALUnitType, layer})
+		}
+	}
+	return base, alpha, nil
+}
@@ -0,0 +1,111 @@
+package hevc
+
+import (
+	"bytes"
+
+	"github.com/go-webdl/media-codec/nalu"
+)
+
+// AppendAnnexB - convert a length-prefixed sample described by the record to
+// Annex B and append it to dst
+// The record's VPS, SPS and PPS NAL units are inserted before IRAP pictures
+// (after a leading access unit delimiter) unless the sample already carries
+// VPS, SPS and PPS.
+func (b *HEVCDecoderConfigurationRecord) AppendAnnexB(dst, sample []byte) ([]byte, error) {
+	nalus, err := nalu.SplitSample(sample, b.LengthSize())
+	if err != nil {
+		return dst, err
+	}
+	pos, inject := parameterSetPosition(nalus)
+	if !inject {
+		return nalu.AppendAnnexB(dst, nalus), nil
+	}
+	dst = nalu.AppendAnnexB(dst, nalus[:pos])
+	dst = b.AppendParameterSetsAnnexB(dst)
+	return nalu.AppendAnnexB(dst, nalus[pos:]), nil
+}
+
+// AppendParameterSetsAnnexB - append the record's VPS, SPS and PPS NAL units to dst as Annex B
+func (b *HEVCDecoderConfigurationRecord) AppendParameterSetsAnnexB(dst []byte) []byte {
+	return nalu.AppendAnnexB(dst, b.parameterSets())
+}
+
+// parameterSets - the record's VPS, SPS and PPS NAL units in decoding order
+func (b *HEVCDecoderConfigurationRecord) parameterSets() (nalus [][]byte) {
+	for _, naluType := range []NaluType{NALU_VPS, NALU_SPS, NALU_PPS} {
+		for _, array := range b.NaluArrays {
+			if array.NALUnitType == naluType {
+				nalus = append(nalus, array.NALUs...)
+			}
+		}
+	}
+	return nalus
+}
+
+// parameterSetPosition - where to insert parameter sets into an access unit
+// inject is true for IRAP access units lacking VPS, SPS or PPS; pos skips a
+// leading access unit delimiter.
+func parameterSetPosition(nalus [][]byte) (pos int, inject bool) {
+	var hasIRAP, hasVPS, hasSPS, hasPPS bool
+	for _, n := range nalus {
+		if len(n) == 0 {
+			continue
+		}
+		switch naluType := GetNaluType(n[0]); {
+		case naluType.IsIRAP():
+			hasIRAP = true
+		case naluType == NALU_VPS:
+			hasVPS = true
+		case naluType == NALU_SPS:
+			hasSPS = true
+		case naluType == NALU_PPS:
+			hasPPS = true
+		}
+	}
+	if !hasIRAP || (hasVPS && hasSPS && hasPPS) {
+		return 0, false
+	}
+	if len(nalus) > 0 && len(nalus[0]) > 0 && GetNaluType(nalus[0][0]) == NALU_AUD {
+		pos = 1
+	}
+	return pos, true
+}
+
+// AppendSample - convert an Annex B access unit to a length-prefixed sample
+// described by the record and append it to dst
+// VPS, SPS and PPS NAL units identical to ones of the record are dropped,
+// since the sample entry carries them; differing ones are kept in-band.
+func (b *HEVCDecoderConfigurationRecord) AppendSample(dst, accessUnit []byte) ([]byte, error) {
+	lengthSize := b.LengthSize()
+	s := nalu.NewScanner(bytes.NewReader(accessUnit))
+	for s.Scan() {
+		data := s.NALU()
+		if len(data) > 0 && b.hasParameterSet(data) {
+			continue
+		}
+		var err error
+		if dst, err = nalu.AppendSample(dst, [][]byte{data}, lengthSize); err != nil {
+			return dst, err
+		}
+	}
+	return dst, s.Err()
+}
+
+// hasParameterSet - is data a VPS, SPS or PPS NAL unit of the record
+func (b *HEVCDecoderConfigurationRecord) hasParameterSet(data []byte) bool {
+	naluType := GetNaluType(data[0])
+	if !naluType.IsParameterSet() {
+		return false
+	}
+	for _, array := range b.NaluArrays {
+		if array.NALUnitType != naluType {
+			continue
+		}
+		for _, n := range array.NALUs {
+			if bytes.Equal(n, data) {
+				return true
+			}
+		}
+	}
+	return false
+}
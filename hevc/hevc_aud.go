@@ -0,0 +1,179 @@
+package hevc
+
+import (
+	"fmt"
+
+	"github.com/go-webdl/media-codec/nalu"
+)
+
+// PicType - pic_type of an access unit delimiter, which lists the slice
+// types that may occur in the access unit, Table 7-2
+type PicType byte
+
+const (
+	PIC_I     = PicType(0)
+	PIC_I_P   = PicType(1)
+	PIC_I_P_B = PicType(2)
+)
+
+// CreateAUDNALUnit - access unit delimiter NAL unit of the base layer
+// temporalID must be the TemporalId of the access unit.
+func CreateAUDNALUnit(picType PicType, temporalID byte) []byte {
+	// pic_type followed by rbsp_stop_one_bit
+	return []byte{byte(NALU_AUD) << 1, (temporalID + 1) & 0b111, byte(picType)<<5 | 0x10}
+}
+
+// GetPicType - smallest pic_type covering the slices of an access unit
+// Only slices of the base layer are considered. SPS and PPS NAL units of the
+// access unit are added to spsMap and ppsMap, which must provide the
+// parameter sets the slices refer to.
+func GetPicType(nalus [][]byte, spsMap map[byte]*SPS, ppsMap map[byte]*PPS) (PicType, error) {
+	picType := PIC_I
+	var prev *SliceSegmentHeader
+	for i, data := range nalus {
+		if len(data) < 2 || GetLayerID(data) != 0 {
+			continue
+		}
+		if err := addParameterSet(data, spsMap, ppsMap); err != nil {
+			return 0, fmt.Errorf("NAL unit %d: %w", i, err)
+		}
+		if !GetNaluType(data[0]).IsVCL() {
+			continue
+		}
+		sh, err := ParseSliceSegmentHeader(data, spsMap, ppsMap, prev)
+		if err != nil {
+			return 0, fmt.Errorf("NAL unit %d: %w", i, err)
+		}
+		prev = sh
+		switch sh.SliceType {
+		case SLICE_B:
+			picType = PIC_I_P_B
+		case SLICE_P:
+			if picType == PIC_I {
+				picType = PIC_I_P
+			}
+		}
+	}
+	return picType, nil
+}
+
+// addParameterSet - add data to spsMap or ppsMap if it is an SPS or PPS NAL unit
+func addParameterSet(data []byte, spsMap map[byte]*SPS, ppsMap map[byte]*PPS) error {
+	switch GetNaluType(data[0]) {
+	case NALU_SPS:
+		sps, err := ParseSPSNALUnit(data)
+		if err != nil {
+			return err
+		}
+		spsMap[sps.SpsID] = sps
+	case NALU_PPS:
+		pps, err := ParsePPSNALUnit(data)
+		if err != nil {
+			return err
+		}
+		ppsMap[pps.PpsID] = pps
+	}
+	return nil
+}
+
+// InsertAUD - prepend an access unit delimiter to the NAL units of an access
+// unit, unless it already starts with one
+// The TemporalId of the delimiter is that of the first VCL NAL unit. See
+// GetPicType for spsMap and ppsMap.
+func InsertAUD(nalus [][]byte, spsMap map[byte]*SPS, ppsMap map[byte]*PPS) ([][]byte, error) {
+	if len(nalus) > 0 && len(nalus[0]) > 0 && GetNaluType(nalus[0][0]) == NALU_AUD {
+		return nalus, nil
+	}
+	picType, err := GetPicType(nalus, spsMap, ppsMap)
+	if err != nil {
+		return nil, err
+	}
+	temporalID := byte(0)
+	for _, data := range nalus {
+		if len(data) >= 2 && GetNaluType(data[0]).IsVCL() {
+			temporalID = GetTemporalID(data)
+			break
+		}
+	}
+	out := make([][]byte, 0, len(nalus)+1)
+	out = append(out, CreateAUDNALUnit(picType, temporalID))
+	return append(out, nalus...), nil
+}
+
+// InsertAUDSample - prepend an access unit delimiter to a length-prefixed sample
+// The sample is returned unchanged if it already starts with one. See
+// GetPicType for spsMap and ppsMap; ParameterSetMaps provides them for
+// samples of a sample entry.
+func InsertAUDSample(sample []byte, lengthSize int, spsMap map[byte]*SPS, ppsMap map[byte]*PPS) ([]byte, error) {
+	return nalu.TransformSample(sample, lengthSize, func(units []nalu.Unit) ([]nalu.Unit, error) {
+		nalus := make([][]byte, len(units))
+		for i := range units {
+			nalus[i] = units[i].Data
+		}
+		out, err := InsertAUD(nalus, spsMap, ppsMap)
+		if err != nil || len(out) == len(nalus) {
+			return units, err
+		}
+		return append([]nalu.Unit{{Data: out[0], Modified: true}}, units...), nil
+	})
+}
+
+// RemoveAUDs - NAL units without access unit delimiters
+// Works on single access units as well as on whole streams.
+func RemoveAUDs(nalus [][]byte) [][]byte {
+	out := make([][]byte, 0, len(nalus))
+	for _, data := range nalus {
+		if len(data) > 0 && GetNaluType(data[0]) == NALU_AUD {
+			continue
+		}
+		out = append(out, data)
+	}
+	return out
+}
+
+// InsertAUDs - insert access unit delimiters at the access unit boundaries of
+// a NAL unit stream, e.g. one read from an Annex B file
+// Boundaries are detected as by AccessUnitReader. spsMap and ppsMap provide
+// parameter sets not carried in the stream and are updated with those that
+// are; they may be empty but not nil.
+func InsertAUDs(nalus [][]byte, spsMap map[byte]*SPS, ppsMap map[byte]*PPS) ([][]byte, error) {
+	starts := []int{0}
+	var d accessUnitDetector
+	for i, data := range nalus {
+		if d.next(data) {
+			starts = append(starts, i)
+		}
+	}
+	out := make([][]byte, 0, len(nalus)+len(starts))
+	for j, start := range starts {
+		end := len(nalus)
+		if j+1 < len(starts) {
+			end = starts[j+1]
+		}
+		if start == end {
+			continue
+		}
+		au, err := InsertAUD(nalus[start:end], spsMap, ppsMap)
+		if err != nil {
+			return nil, fmt.Errorf("access unit %d: %w", j, err)
+		}
+		out = append(out, au...)
+	}
+	return out, nil
+}
+
+// ParameterSetMaps - the record's SPSs and PPSs by id, e.g. for InsertAUDSample
+func (b *HEVCDecoderConfigurationRecord) ParameterSetMaps() (spsMap map[byte]*SPS, ppsMap map[byte]*PPS, err error) {
+	spsMap, ppsMap = make(map[byte]*SPS), make(map[byte]*PPS)
+	for _, array := range b.NaluArrays {
+		for _, data := range array.NALUs {
+			if len(data) < 2 {
+				continue
+			}
+			if err := addParameterSet(data, spsMap, ppsMap); err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", array.NALUnitType, err)
+			}
+		}
+	}
+	return spsMap, ppsMap, nil
+}
@@ -0,0 +1,95 @@
+package hevc
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// PROFILE_MAIN - general_profile_idc of Main profile
+	PROFILE_MAIN = byte(1)
+	// PROFILE_MAIN_10 - general_profile_idc of Main 10 profile
+	PROFILE_MAIN_10 = byte(2)
+)
+
+// ProfileCompatibilityFlag - general_profile_compatibility_flag[j] within the 32-bit field
+func ProfileCompatibilityFlag(j byte) uint32 {
+	return 1 << (31 - j)
+}
+
+// CheckBitDepthSignaling - report mismatches between the bit depths and profile
+// signalled in the record and in its first SPS
+func (b *HEVCDecoderConfigurationRecord) CheckBitDepthSignaling() (issues []string, err error) {
+	return b.bitDepthSignaling(false)
+}
+
+// FixBitDepthSignaling - correct the record from its first SPS
+// Bit depths and profile are taken from the SPS, and the Main 10 compatibility
+// flag is set for 10-bit Main/Main 10 streams and cleared for deeper ones.
+// The returned list describes the corrections made.
+func (b *HEVCDecoderConfigurationRecord) FixBitDepthSignaling() (fixes []string, err error) {
+	return b.bitDepthSignaling(true)
+}
+
+func (b *HEVCDecoderConfigurationRecord) bitDepthSignaling(fix bool) (issues []string, err error) {
+	var spsNalu []byte
+	for _, array := range b.NaluArrays {
+		if array.NALUnitType == NALU_SPS && len(array.NALUs) > 0 {
+			spsNalu = array.NALUs[0]
+			break
+		}
+	}
+	if spsNalu == nil {
+		return nil, errors.New("no SPS in record")
+	}
+	sps, err := ParseSPSNALUnit(spsNalu)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.BitDepthLumaMinus8 != sps.BitDepthLumaMinus8 {
+		issues = append(issues, fmt.Sprintf("luma bit depth %d, SPS has %d", b.BitDepthLumaMinus8+8, sps.BitDepthLumaMinus8+8))
+		if fix {
+			b.BitDepthLumaMinus8 = sps.BitDepthLumaMinus8
+		}
+	}
+	if b.BitDepthChromaMinus8 != sps.BitDepthChromaMinus8 {
+		issues = append(issues, fmt.Sprintf("chroma bit depth %d, SPS has %d", b.BitDepthChromaMinus8+8, sps.BitDepthChromaMinus8+8))
+		if fix {
+			b.BitDepthChromaMinus8 = sps.BitDepthChromaMinus8
+		}
+	}
+
+	profile := b.GenertalProfileIndicator
+	if profile != sps.ProfileTierLevel.GeneralProfileIndicator {
+		issues = append(issues, fmt.Sprintf("profile %d, SPS has %d", profile, sps.ProfileTierLevel.GeneralProfileIndicator))
+		profile = sps.ProfileTierLevel.GeneralProfileIndicator
+	}
+	maxBitDepthMinus8 := sps.BitDepthLumaMinus8
+	if sps.BitDepthChromaMinus8 > maxBitDepthMinus8 {
+		maxBitDepthMinus8 = sps.BitDepthChromaMinus8
+	}
+	if profile == PROFILE_MAIN && maxBitDepthMinus8 > 0 {
+		issues = append(issues, fmt.Sprintf("Main profile with bit depth %d", maxBitDepthMinus8+8))
+		profile = PROFILE_MAIN_10
+	}
+	compatibility := b.GeneralProfileCompatibilityFlags
+	main10 := ProfileCompatibilityFlag(PROFILE_MAIN_10)
+	switch {
+	case maxBitDepthMinus8 == 2 && (profile == PROFILE_MAIN || profile == PROFILE_MAIN_10) && compatibility&main10 == 0:
+		issues = append(issues, "10-bit stream without Main 10 compatibility flag")
+		compatibility |= main10
+	case maxBitDepthMinus8 > 2 && compatibility&main10 != 0:
+		issues = append(issues, fmt.Sprintf("%d-bit stream with Main 10 compatibility flag", maxBitDepthMinus8+8))
+		compatibility &^= main10
+	}
+	if profile != 0 && compatibility&ProfileCompatibilityFlag(profile) == 0 {
+		issues = append(issues, fmt.Sprintf("compatibility flag of profile %d not set", profile))
+		compatibility |= ProfileCompatibilityFlag(profile)
+	}
+	if fix {
+		b.GenertalProfileIndicator = profile
+		b.GeneralProfileCompatibilityFlags = compatibility
+	}
+	return issues, nil
+}
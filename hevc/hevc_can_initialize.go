@@ -0,0 +1,146 @@
+package hevc
+
+import (
+	"fmt"
+)
+
+// CanInitialize - problems a decoder would hit initializing from the record
+// All SPS and PPS are parsed, VPS and SPS references must resolve, picture
+// sizes and DPB sizes must fit the signalled level and tier, and chroma format
+// and bit depths must be supported by the signalled profile.
+func (b *HEVCDecoderConfigurationRecord) CanInitialize() (issues []string) {
+	switch b.LengthSizeMinusOne {
+	case 0, 1, 3:
+	default:
+		issues = append(issues, fmt.Sprintf("invalid lengthSizeMinusOne %d", b.LengthSizeMinusOne))
+	}
+	var vpsNalus, spsNalus, ppsNalus [][]byte
+	for _, array := range b.NaluArrays {
+		switch array.NALUnitType {
+		case NALU_VPS:
+			vpsNalus = append(vpsNalus, array.NALUs...)
+		case NALU_SPS:
+			spsNalus = append(spsNalus, array.NALUs...)
+		case NALU_PPS:
+			ppsNalus = append(ppsNalus, array.NALUs...)
+		}
+	}
+	if len(vpsNalus) == 0 {
+		issues = append(issues, "no VPS")
+	}
+	if len(spsNalus) == 0 {
+		issues = append(issues, "no SPS")
+	}
+	if len(ppsNalus) == 0 {
+		issues = append(issues, "no PPS")
+	}
+
+	vpsIDs := make(map[byte]bool)
+	for i, nalu := range vpsNalus {
+		if len(nalu) < 3 {
+			issues = append(issues, fmt.Sprintf("VPS %d: truncated", i))
+			continue
+		}
+		// vps_video_parameter_set_id follows the NAL unit header
+		vpsIDs[nalu[2]>>4] = true
+	}
+
+	spsMap := make(map[byte]*SPS)
+	for i, nalu := range spsNalus {
+		sps, err := ParseSPSNALUnit(nalu)
+		if err != nil {
+			issues = append(issues, fmt.Sprintf("SPS %d: %s", i, err))
+			continue
+		}
+		spsMap[sps.SpsID] = sps
+		if !vpsIDs[sps.VpsID] {
+			issues = append(issues, fmt.Sprintf("SPS %d: VPS %d not found", i, sps.VpsID))
+		}
+		ptl := sps.ProfileTierLevel
+		if ptl.GeneralProfileSpace != b.GeneralProfileSpace || ptl.GeneralProfileIndicator != b.GenertalProfileIndicator {
+			issues = append(issues, fmt.Sprintf("SPS %d: profile %d, record has %d", i, ptl.GeneralProfileIndicator, b.GenertalProfileIndicator))
+		}
+		if ptl.GeneralTierFlag && !b.GeneralTierFlag {
+			issues = append(issues, fmt.Sprintf("SPS %d: high tier, record has main tier", i))
+		}
+		if ptl.GeneralLevelIndicator > b.GeneralLevelIndicator {
+			issues = append(issues, fmt.Sprintf("SPS %d: level %d above record level %d", i, ptl.GeneralLevelIndicator, b.GeneralLevelIndicator))
+		}
+		if sps.ChromaFormatIndicator != b.ChromaFormatIndicator {
+			issues = append(issues, fmt.Sprintf("SPS %d: chroma format %d, record has %d", i, sps.ChromaFormatIndicator, b.ChromaFormatIndicator))
+		}
+		if sps.BitDepthLumaMinus8 != b.BitDepthLumaMinus8 || sps.BitDepthChromaMinus8 != b.BitDepthChromaMinus8 {
+			issues = append(issues, fmt.Sprintf("SPS %d: bit depths %d/%d, record has %d/%d", i,
+				sps.BitDepthLumaMinus8+8, sps.BitDepthChromaMinus8+8, b.BitDepthLumaMinus8+8, b.BitDepthChromaMinus8+8))
+		}
+		for _, issue := range sps.checkProfileSupport() {
+			issues = append(issues, fmt.Sprintf("SPS %d: %s", i, issue))
+		}
+		for _, issue := range sps.checkLevelLimits() {
+			issues = append(issues, fmt.Sprintf("SPS %d: %s", i, issue))
+		}
+	}
+
+	for i, nalu := range ppsNalus {
+		pps, err := ParsePPSNALUnit(nalu)
+		if err != nil {
+			issues = append(issues, fmt.Sprintf("PPS %d: %s", i, err))
+			continue
+		}
+		if _, ok := spsMap[pps.SpsID]; !ok {
+			issues = append(issues, fmt.Sprintf("PPS %d: SPS %d not found", i, pps.SpsID))
+		}
+	}
+	return issues
+}
+
+// checkProfileSupport - chroma format and bit depths allowed by the profile
+// Only Main, Main 10 and Main Still Picture are checked.
+func (s *SPS) checkProfileSupport() (issues []string) {
+	var maxBitDepthMinus8 byte
+	switch s.ProfileTierLevel.GeneralProfileIndicator {
+	case PROFILE_MAIN, 3:
+		maxBitDepthMinus8 = 0
+	case PROFILE_MAIN_10:
+		maxBitDepthMinus8 = 2
+	default:
+		return nil
+	}
+	if s.ChromaFormatIndicator != 1 {
+		issues = append(issues, fmt.Sprintf("chroma format %d not supported by profile %d", s.ChromaFormatIndicator, s.ProfileTierLevel.GeneralProfileIndicator))
+	}
+	if s.BitDepthLumaMinus8 > maxBitDepthMinus8 || s.BitDepthChromaMinus8 > maxBitDepthMinus8 {
+		issues = append(issues, fmt.Sprintf("bit depths %d/%d not supported by profile %d",
+			s.BitDepthLumaMinus8+8, s.BitDepthChromaMinus8+8, s.ProfileTierLevel.GeneralProfileIndicator))
+	}
+	return issues
+}
+
+// checkLevelLimits - picture size, tier and DPB limits of the level, Sec. A.4.1
+func (s *SPS) checkLevelLimits() (issues []string) {
+	ptl := s.ProfileTierLevel
+	limits, ok := LookupLevel(ptl.GeneralLevelIndicator)
+	if !ok {
+		return []string{fmt.Sprintf("unknown level %d", ptl.GeneralLevelIndicator)}
+	}
+	if ptl.GeneralTierFlag && limits.MaxCPBHigh == 0 {
+		issues = append(issues, fmt.Sprintf("high tier not allowed at level %d", ptl.GeneralLevelIndicator))
+	}
+	picSize := s.PicWidthInLumaSamples * s.PicHeightInLumaSamples
+	if picSize > limits.MaxLumaPs {
+		issues = append(issues, fmt.Sprintf("picture size %d exceeds level %d limit %d", picSize, ptl.GeneralLevelIndicator, limits.MaxLumaPs))
+	}
+	maxDim := uint64(8) * uint64(limits.MaxLumaPs)
+	if uint64(s.PicWidthInLumaSamples)*uint64(s.PicWidthInLumaSamples) > maxDim ||
+		uint64(s.PicHeightInLumaSamples)*uint64(s.PicHeightInLumaSamples) > maxDim {
+		issues = append(issues, fmt.Sprintf("%dx%d exceeds level %d dimension limits", s.PicWidthInLumaSamples, s.PicHeightInLumaSamples, ptl.GeneralLevelIndicator))
+	}
+	maxDpbSize := limits.MaxDpbSize(picSize)
+	for _, info := range s.SubLayeringOrderingInfos {
+		if uint32(info.MaxDecPicBufferingMinus1)+1 > maxDpbSize {
+			issues = append(issues, fmt.Sprintf("DPB size %d exceeds level %d limit %d", info.MaxDecPicBufferingMinus1+1, ptl.GeneralLevelIndicator, maxDpbSize))
+			break
+		}
+	}
+	return issues
+}
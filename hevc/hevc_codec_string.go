@@ -0,0 +1,120 @@
+package hevc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CodecString - RFC 6381 codecs parameter according to ISO/IEC 14496-15 Annex E.3, e.g. hvc1.1.6.L93.B0
+// sampleEntry is the four character code of the sample entry (hvc1, hev1, ...)
+func (b *HEVCDecoderConfigurationRecord) CodecString(sampleEntry string) string {
+	ptl := ProfileTierLevel{
+		GeneralProfileSpace:              b.GeneralProfileSpace,
+		GeneralTierFlag:                  b.GeneralTierFlag,
+		GeneralProfileIndicator:          b.GenertalProfileIndicator,
+		GeneralProfileCompatibilityFlags: b.GeneralProfileCompatibilityFlags,
+		GeneralConstraintIndicatorFlags:  b.GeneralConstraintIndicatorFlags,
+		GeneralLevelIndicator:            b.GeneralLevelIndicator,
+	}
+	return ptl.CodecString(sampleEntry)
+}
+
+// CodecString - codecs parameter of the general profile, tier and level, the
+// inverse of ParseCodecString
+func (b *ProfileTierLevel) CodecString(sampleEntry string) string {
+	var sb strings.Builder
+	sb.WriteString(sampleEntry)
+	sb.WriteByte('.')
+	if b.GeneralProfileSpace > 0 {
+		sb.WriteByte('A' + b.GeneralProfileSpace - 1)
+	}
+	fmt.Fprintf(&sb, "%d", b.GeneralProfileIndicator)
+
+	// general_profile_compatibility_flags in reverse bit order, leading zeroes omitted
+	var reversed uint32
+	for i := 0; i < 32; i++ {
+		if b.GeneralProfileCompatibilityFlags&(1<<i) != 0 {
+			reversed |= 1 << (31 - i)
+		}
+	}
+	fmt.Fprintf(&sb, ".%X", reversed)
+
+	tier := byte('L')
+	if b.GeneralTierFlag {
+		tier = 'H'
+	}
+	fmt.Fprintf(&sb, ".%c%d", tier, b.GeneralLevelIndicator)
+
+	// general_constraint_indicator_flags as bytes, trailing zero bytes omitted
+	var constraints [6]byte
+	last := -1
+	for i := range constraints {
+		constraints[i] = byte(b.GeneralConstraintIndicatorFlags >> (40 - 8*i))
+		if constraints[i] != 0 {
+			last = i
+		}
+	}
+	for i := 0; i <= last; i++ {
+		fmt.Fprintf(&sb, ".%X", constraints[i])
+	}
+	return sb.String()
+}
+
+// ParseCodecString - sample entry and general profile, tier and level of an
+// HEVC codecs parameter, the inverse of CodecString
+// The tier is L for the Main tier and H for the High tier.
+func ParseCodecString(codecs string) (sampleEntry string, ptl ProfileTierLevel, err error) {
+	parts := strings.Split(codecs, ".")
+	if len(parts) < 4 || len(parts) > 10 {
+		return "", ptl, fmt.Errorf("codecs %q: expected 4 to 10 elements", codecs)
+	}
+	sampleEntry = parts[0]
+
+	profile := parts[1]
+	if profile != "" && profile[0] >= 'A' && profile[0] <= 'C' {
+		ptl.GeneralProfileSpace = profile[0] - 'A' + 1
+		profile = profile[1:]
+	}
+	idc, err := strconv.ParseUint(profile, 10, 5)
+	if err != nil {
+		return "", ptl, fmt.Errorf("codecs %q: profile: %w", codecs, err)
+	}
+	ptl.GeneralProfileIndicator = byte(idc)
+
+	reversed, err := strconv.ParseUint(parts[2], 16, 32)
+	if err != nil {
+		return "", ptl, fmt.Errorf("codecs %q: compatibility flags: %w", codecs, err)
+	}
+	for i := 0; i < 32; i++ {
+		if reversed&(1<<i) != 0 {
+			ptl.GeneralProfileCompatibilityFlags |= 1 << (31 - i)
+		}
+	}
+
+	tierLevel := parts[3]
+	if tierLevel == "" {
+		return "", ptl, fmt.Errorf("codecs %q: missing tier and level", codecs)
+	}
+	switch tierLevel[0] {
+	case 'L':
+	case 'H':
+		ptl.GeneralTierFlag = true
+	default:
+		return "", ptl, fmt.Errorf("codecs %q: tier %q is neither L nor H", codecs, tierLevel[0])
+	}
+	level, err := strconv.ParseUint(tierLevel[1:], 10, 8)
+	if err != nil {
+		return "", ptl, fmt.Errorf("codecs %q: level: %w", codecs, err)
+	}
+	ptl.GeneralLevelIndicator = byte(level)
+
+	for i, c := range parts[4:] {
+		v, err := strconv.ParseUint(c, 16, 8)
+		if err != nil {
+			return "", ptl, fmt.Errorf("codecs %q: constraint byte %d: %w", codecs, i, err)
+		}
+		ptl.GeneralConstraintIndicatorFlags |= v << (40 - 8*i)
+	}
+	return sampleEntry, ptl, nil
+}
@@ -0,0 +1,24 @@
+package hevc
+
+import "github.com/go-webdl/media-codec/colr"
+
+// NCLX - colour description of the VUI, the payload of an nclx 'colr' box
+// Code points that are not signalled are unspecified.
+func (v *VUIParameters) NCLX() colr.NCLX {
+	n := colr.Unspecified()
+	if v.ColourDescriptionPresentFlag {
+		n.ColourPrimaries = uint16(v.ColourPrimaries)
+		n.TransferCharacteristics = uint16(v.TransferCharacteristics)
+		n.MatrixCoefficients = uint16(v.MatrixCoefficients)
+	}
+	n.FullRangeFlag = v.VideoFullRangeFlag
+	return n
+}
+
+// NCLX - colour description of the SPS, unspecified without VUI
+func (s *SPS) NCLX() colr.NCLX {
+	if !s.VUIParametersPresentFlag {
+		return colr.Unspecified()
+	}
+	return s.VUI.NCLX()
+}
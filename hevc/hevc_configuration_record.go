@@ -2,7 +2,11 @@ package hevc
 
 import (
 	"encoding/binary"
+	"errors"
+	"fmt"
 	"io"
+
+	"github.com/go-webdl/media-codec/debuglog"
 )
 
 // 8.3.3.1 HEVC decoder configuration record
@@ -112,7 +116,13 @@ type HEVCDecoderConfigurationRecord struct {
 	NumTemporalLayers                uint8
 	TemporalIDNested                 uint8
 	LengthSizeMinusOne               uint8
-	NaluArrays                       []NaluArray
+	// NaluArrays - all arrays in record order, including those with reserved
+	// or unpermitted NAL unit types, which readers ignore
+	NaluArrays []NaluArray
+	// ExtensionData - bytes following the arrays, defined by compatible
+	// extensions of the record and kept for lossless read-modify-write; only
+	// read by RecordReadSize and RecordReadArena, which know the record size
+	ExtensionData []byte
 }
 
 type NaluArray struct {
@@ -159,6 +169,7 @@ func (b *HEVCDecoderConfigurationRecord) RecordSize() (size uint32) {
 		}
 	}
 	size += 2 * naluCount // unsigned int(16) nalUnitLength;
+	size += uint32(len(b.ExtensionData))
 	return
 }
 
@@ -184,6 +195,7 @@ func (b *HEVCDecoderConfigurationRecord) RecordRead(r io.Reader) (err error) {
 	b.NumTemporalLayers = (tmp[21] >> 3) & 0b111
 	b.TemporalIDNested = (tmp[21] >> 2) & 0b1
 	b.LengthSizeMinusOne = tmp[21] & 0b11
+	b.debugHeader(tmp[:])
 	entryCount := tmp[22]
 	b.NaluArrays = make([]NaluArray, entryCount)
 	for i := uint8(0); i < entryCount; i++ {
@@ -192,7 +204,7 @@ func (b *HEVCDecoderConfigurationRecord) RecordRead(r io.Reader) (err error) {
 		}
 		b.NaluArrays[i].ArrayCompleteness = (tmp[0] >> 7) > 0
 		b.NaluArrays[i].NALUnitType = NaluType(tmp[0] & 0b111111)
-		naluCount := uint16(tmp[1]&0b1111)<<8 | uint16(tmp[2])
+		naluCount := uint16(tmp[1])<<8 | uint16(tmp[2])
 		b.NaluArrays[i].NALUs = make([][]byte, naluCount)
 		for j := uint16(0); j < naluCount; j++ {
 			var naluLength uint16
@@ -205,6 +217,21 @@ func (b *HEVCDecoderConfigurationRecord) RecordRead(r io.Reader) (err error) {
 			}
 		}
 	}
+	b.ExtensionData = nil
+	return
+}
+
+// RecordReadSize - RecordRead of a record of size bytes, e.g. the payload of
+// an hvcC box, keeping the bytes following the arrays as ExtensionData
+// Exactly size bytes are consumed from r.
+func (b *HEVCDecoderConfigurationRecord) RecordReadSize(r io.Reader, size int) (err error) {
+	lr := &io.LimitedReader{R: r, N: int64(size)}
+	if err = b.RecordRead(lr); err != nil {
+		return
+	}
+	if b.ExtensionData, err = io.ReadAll(lr); len(b.ExtensionData) == 0 {
+		b.ExtensionData = nil
+	}
 	return
 }
 
@@ -215,7 +242,7 @@ func (b *HEVCDecoderConfigurationRecord) RecordWrite(w io.Writer) (err error) {
 	}
 	tmp = (b.GeneralProfileSpace << 6) | (b.GenertalProfileIndicator & 0b11111)
 	if b.GeneralTierFlag {
-		tmp |= 0b10000
+		tmp |= 0b100000
 	}
 	if err = binary.Write(w, binary.BigEndian, tmp); err != nil {
 		return
@@ -235,16 +262,16 @@ func (b *HEVCDecoderConfigurationRecord) RecordWrite(w io.Writer) (err error) {
 	if err = binary.Write(w, binary.BigEndian, b.MinSpatialSegmentationIndicator|(0b1111<<12)); err != nil {
 		return
 	}
-	if err = binary.Write(w, binary.BigEndian, b.ParallelismType|0b111111); err != nil {
+	if err = binary.Write(w, binary.BigEndian, b.ParallelismType|0b11111100); err != nil {
 		return
 	}
-	if err = binary.Write(w, binary.BigEndian, b.ChromaFormatIndicator|0b111111); err != nil {
+	if err = binary.Write(w, binary.BigEndian, b.ChromaFormatIndicator|0b11111100); err != nil {
 		return
 	}
-	if err = binary.Write(w, binary.BigEndian, b.BitDepthLumaMinus8|0b11111); err != nil {
+	if err = binary.Write(w, binary.BigEndian, b.BitDepthLumaMinus8|0b11111000); err != nil {
 		return
 	}
-	if err = binary.Write(w, binary.BigEndian, b.BitDepthChromaMinus8|0b11111); err != nil {
+	if err = binary.Write(w, binary.BigEndian, b.BitDepthChromaMinus8|0b11111000); err != nil {
 		return
 	}
 	if err = binary.Write(w, binary.BigEndian, b.AvgFrameRate); err != nil {
@@ -277,11 +304,19 @@ func (b *HEVCDecoderConfigurationRecord) RecordWrite(w io.Writer) (err error) {
 			}
 		}
 	}
+	if len(b.ExtensionData) > 0 {
+		_, err = w.Write(b.ExtensionData)
+	}
 	return
 }
 
 // CreateHEVCDecoderConfigurationRecord - extract information from vps, sps, pps and fill HEVCDecoderConfigurationRecord with that
-func CreateHEVCDecoderConfigurationRecord(vpsNalus, spsNalus, ppsNalus [][]byte, vpsComplete, spsComplete, ppsComplete bool) (HEVCDecoderConfigurationRecord, error) {
+// opts add further arrays, e.g. WithPrefixSEI, or change the NAL unit length
+// size of samples with WithLengthSize.
+func CreateHEVCDecoderConfigurationRecord(vpsNalus, spsNalus, ppsNalus [][]byte, vpsComplete, spsComplete, ppsComplete bool, opts ...RecordOption) (HEVCDecoderConfigurationRecord, error) {
+	if len(spsNalus) == 0 {
+		return HEVCDecoderConfigurationRecord{}, errors.New("no SPS NAL units")
+	}
 	sps, err := ParseSPSNALUnit(spsNalus[0])
 	if err != nil {
 		return HEVCDecoderConfigurationRecord{}, err
@@ -290,8 +325,11 @@ func CreateHEVCDecoderConfigurationRecord(vpsNalus, spsNalus, ppsNalus [][]byte,
 	naluArrays = append(naluArrays, NaluArray{vpsComplete, NALU_VPS, vpsNalus})
 	naluArrays = append(naluArrays, NaluArray{spsComplete, NALU_SPS, spsNalus})
 	naluArrays = append(naluArrays, NaluArray{ppsComplete, NALU_PPS, ppsNalus})
-	ptf := sps.ProfileTierLevel
-	return HEVCDecoderConfigurationRecord{
+	ptf, err := IntersectProfileTierLevel(spsNalus)
+	if err != nil {
+		return HEVCDecoderConfigurationRecord{}, err
+	}
+	rec := HEVCDecoderConfigurationRecord{
 		ConfigurationVersion:             1,
 		GeneralProfileSpace:              ptf.GeneralProfileSpace,
 		GeneralTierFlag:                  ptf.GeneralTierFlag,
@@ -299,16 +337,65 @@ func CreateHEVCDecoderConfigurationRecord(vpsNalus, spsNalus, ppsNalus [][]byte,
 		GeneralProfileCompatibilityFlags: ptf.GeneralProfileCompatibilityFlags,
 		GeneralConstraintIndicatorFlags:  ptf.GeneralConstraintIndicatorFlags,
 		GeneralLevelIndicator:            ptf.GeneralLevelIndicator,
-		MinSpatialSegmentationIndicator:  0, // Set as default value
-		ParallelismType:                  0, // Set as default value
 		ChromaFormatIndicator:            sps.ChromaFormatIndicator,
 		BitDepthLumaMinus8:               sps.BitDepthLumaMinus8,
 		BitDepthChromaMinus8:             sps.BitDepthChromaMinus8,
-		AvgFrameRate:                     0,          // Set as default value
-		ConstantFrameRate:                0,          // Set as default value
-		NumTemporalLayers:                0,          // Set as default value
-		TemporalIDNested:                 0,          // Set as default value
-		LengthSizeMinusOne:               3,          // only support 4-byte length
+		LengthSizeMinusOne:               3,          // 4-byte length unless WithLengthSize
 		NaluArrays:                       naluArrays, // VPS, SPS, PPS nalus with complete flag
-	}, nil
+	}
+	if err := rec.setDerivedFields(vpsNalus, spsNalus, ppsNalus); err != nil {
+		return HEVCDecoderConfigurationRecord{}, err
+	}
+	for _, opt := range opts {
+		if err := opt(&rec); err != nil {
+			return HEVCDecoderConfigurationRecord{}, err
+		}
+	}
+	return rec, nil
+}
+
+// IntersectProfileTierLevel - general profile, tier and level valid for all SPS NAL units
+// Compatibility and constraint flags are only kept when all SPSs set them,
+// tier and level are the highest signalled and the profile space must be
+// identical. The profile is taken from the first SPS.
+func IntersectProfileTierLevel(spsNalus [][]byte) (ptl ProfileTierLevel, err error) {
+	if len(spsNalus) == 0 {
+		return ptl, errors.New("no SPS NAL units")
+	}
+	for i, nalu := range spsNalus {
+		sps, err := ParseSPSNALUnit(nalu)
+		if err != nil {
+			return ptl, err
+		}
+		p := sps.ProfileTierLevel
+		if i == 0 {
+			ptl = p
+			ptl.SubLayers = nil
+			continue
+		}
+		if p.GeneralProfileSpace != ptl.GeneralProfileSpace {
+			return ptl, fmt.Errorf("SPS %d has profile space %d, SPS 0 has %d", i, p.GeneralProfileSpace, ptl.GeneralProfileSpace)
+		}
+		ptl.GeneralProfileCompatibilityFlags &= p.GeneralProfileCompatibilityFlags
+		ptl.GeneralConstraintIndicatorFlags &= p.GeneralConstraintIndicatorFlags
+		if p.GeneralTierFlag && !ptl.GeneralTierFlag {
+			ptl.GeneralTierFlag = true
+		}
+		if p.GeneralLevelIndicator > ptl.GeneralLevelIndicator {
+			ptl.GeneralLevelIndicator = p.GeneralLevelIndicator
+		}
+	}
+	return ptl, nil
+}
+
+// debugHeader - report a header, the first 23 bytes of the record, that
+// deviates from the specification
+func (b *HEVCDecoderConfigurationRecord) debugHeader(header []byte) {
+	if b.ConfigurationVersion != 1 {
+		debuglog.Debug("hvcC: unknown configurationVersion", "version", b.ConfigurationVersion)
+	}
+	if header[13]>>4 != 0b1111 || header[15]>>2 != 0b111111 || header[16]>>2 != 0b111111 ||
+		header[17]>>3 != 0b11111 || header[18]>>3 != 0b11111 {
+		debuglog.Debug("hvcC: reserved bits not set", "header", fmt.Sprintf("%x", header[13:19]))
+	}
 }
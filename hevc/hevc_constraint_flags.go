@@ -0,0 +1,130 @@
+package hevc
+
+// ConstraintFlags - the 48 bits of general_constraint_indicator_flags, ISO/IEC 23008-2 Sec. 7.3.3
+//
+// The source and frame packing flags are defined for all profiles. The
+// format range extensions flags (Max12Bit to LowerBitRate) are only defined
+// for general_profile_idc 4 to 11 or compatible streams; OnePictureOnly is
+// also defined for Main 10. Max14Bit is only defined for the high
+// throughput and screen content profiles with high bit depths, and Inbld for
+// profiles supporting independent non-base layer decoding. Bits not defined
+// for the profile are 0 in conforming streams.
+type ConstraintFlags struct {
+	ProgressiveSource bool
+	InterlacedSource  bool
+	NonPacked         bool
+	FrameOnly         bool
+	Max12Bit          bool
+	Max10Bit          bool
+	Max8Bit           bool
+	Max422Chroma      bool
+	Max420Chroma      bool
+	MaxMonochrome     bool
+	Intra             bool
+	OnePictureOnly    bool
+	LowerBitRate      bool
+	Max14Bit          bool
+	Inbld             bool
+	// Reserved - all other bits, at their positions in the 48-bit value
+	Reserved uint64
+}
+
+// Bit positions in the 48-bit value, counted from the least significant bit
+const (
+	constraintProgressiveSource = 47
+	constraintInterlacedSource  = 46
+	constraintNonPacked         = 45
+	constraintFrameOnly         = 44
+	constraintMax12Bit          = 43
+	constraintMax10Bit          = 42
+	constraintMax8Bit           = 41
+	constraintMax422Chroma      = 40
+	constraintMax420Chroma      = 39
+	constraintMaxMonochrome     = 38
+	constraintIntra             = 37
+	constraintOnePictureOnly    = 36
+	constraintLowerBitRate      = 35
+	constraintMax14Bit          = 34
+	constraintInbld             = 0
+)
+
+// constraintFlagBit - a flag of ConstraintFlags and its bit position
+type constraintFlagBit struct {
+	flag *bool
+	bit  uint
+}
+
+// constraintFlagBits - the flags of c with their bit positions
+func (c *ConstraintFlags) constraintFlagBits() []constraintFlagBit {
+	return []constraintFlagBit{
+		{&c.ProgressiveSource, constraintProgressiveSource},
+		{&c.InterlacedSource, constraintInterlacedSource},
+		{&c.NonPacked, constraintNonPacked},
+		{&c.FrameOnly, constraintFrameOnly},
+		{&c.Max12Bit, constraintMax12Bit},
+		{&c.Max10Bit, constraintMax10Bit},
+		{&c.Max8Bit, constraintMax8Bit},
+		{&c.Max422Chroma, constraintMax422Chroma},
+		{&c.Max420Chroma, constraintMax420Chroma},
+		{&c.MaxMonochrome, constraintMaxMonochrome},
+		{&c.Intra, constraintIntra},
+		{&c.OnePictureOnly, constraintOnePictureOnly},
+		{&c.LowerBitRate, constraintLowerBitRate},
+		{&c.Max14Bit, constraintMax14Bit},
+		{&c.Inbld, constraintInbld},
+	}
+}
+
+// DecodeConstraintFlags - structured view of a 48-bit general_constraint_indicator_flags value
+// Bits above 48 are ignored.
+func DecodeConstraintFlags(flags uint64) (c ConstraintFlags) {
+	flags &= 1<<48 - 1
+	c.Reserved = flags
+	for _, f := range c.constraintFlagBits() {
+		*f.flag = flags&(1<<f.bit) != 0
+		c.Reserved &^= 1 << f.bit
+	}
+	return c
+}
+
+// Uint64 - the 48-bit general_constraint_indicator_flags value
+// Reserved bits at the positions of the named flags are ignored.
+func (c ConstraintFlags) Uint64() (flags uint64) {
+	flags = c.Reserved & (1<<48 - 1)
+	for _, f := range c.constraintFlagBits() {
+		flags &^= 1 << f.bit
+		if *f.flag {
+			flags |= 1 << f.bit
+		}
+	}
+	return flags
+}
+
+// GeneralConstraints - structured general_constraint_indicator_flags
+func (p *ProfileTierLevel) GeneralConstraints() ConstraintFlags {
+	return DecodeConstraintFlags(p.GeneralConstraintIndicatorFlags)
+}
+
+// SetGeneralConstraints - set general_constraint_indicator_flags and the source and packing flags
+func (p *ProfileTierLevel) SetGeneralConstraints(c ConstraintFlags) {
+	p.GeneralConstraintIndicatorFlags = c.Uint64()
+	p.GeneralProgressiveSourceFlag = c.ProgressiveSource
+	p.GeneralInterlacedSourceFlag = c.InterlacedSource
+	p.GeneralNonPackedConstraintFlag = c.NonPacked
+	p.GeneralFrameOnlyConstraintFlag = c.FrameOnly
+}
+
+// Constraints - structured sub_layer_constraint_indicator_flags, only valid with ProfilePresentFlag
+func (s *SubLayerProfileTierLevel) Constraints() ConstraintFlags {
+	return DecodeConstraintFlags(s.ConstraintIndicatorFlags)
+}
+
+// GeneralConstraints - structured general_constraint_indicator_flags of the record
+func (b *HEVCDecoderConfigurationRecord) GeneralConstraints() ConstraintFlags {
+	return DecodeConstraintFlags(b.GeneralConstraintIndicatorFlags)
+}
+
+// SetGeneralConstraints - set general_constraint_indicator_flags of the record
+func (b *HEVCDecoderConfigurationRecord) SetGeneralConstraints(c ConstraintFlags) {
+	b.GeneralConstraintIndicatorFlags = c.Uint64()
+}
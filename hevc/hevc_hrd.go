@@ -0,0 +1,120 @@
+package hevc
+
+import (
+	"fmt"
+
+	"github.com/go-webdl/bits"
+)
+
+// HRDParameters - ISO/IEC 23008-2 Sec. E.2.2
+// The common fields are only valid when read with commonInfPresentFlag set.
+type HRDParameters struct {
+	NalHrdParametersPresentFlag            bool
+	VclHrdParametersPresentFlag            bool
+	SubPicHrdParamsPresentFlag             bool
+	TickDivisorMinus2                      byte
+	DuCpbRemovalDelayIncrementLengthMinus1 byte
+	SubPicCpbParamsInPicTimingSeiFlag      bool
+	DpbOutputDelayDuLengthMinus1           byte
+	BitRateScale                           byte
+	CpbSizeScale                           byte
+	CpbSizeDuScale                         byte
+	InitialCpbRemovalDelayLengthMinus1     byte
+	AuCpbRemovalDelayLengthMinus1          byte
+	DpbOutputDelayLengthMinus1             byte
+	SubLayers                              []SubLayerHRD
+}
+
+// SubLayerHRD - HRD parameters of one temporal sub-layer
+type SubLayerHRD struct {
+	FixedPicRateGeneralFlag     bool
+	FixedPicRateWithinCvsFlag   bool
+	ElementalDurationInTcMinus1 uint32
+	LowDelayHrdFlag             bool
+	CpbCntMinus1                byte
+	NalSchedSels                []HRDSchedSel
+	VclSchedSels                []HRDSchedSel
+}
+
+// HRDSchedSel - sub_layer_hrd_parameters() of one CPB
+type HRDSchedSel struct {
+	BitRateValueMinus1   uint32
+	CpbSizeValueMinus1   uint32
+	CpbSizeDuValueMinus1 uint32
+	BitRateDuValueMinus1 uint32
+	CbrFlag              bool
+}
+
+// readHRDParameters - read hrd_parameters(commonInfPresentFlag, maxNumSubLayersMinus1)
+func readHRDParameters(r *bits.AccErrEBSPReader, commonInfPresentFlag bool, maxNumSubLayersMinus1 byte) (hrd HRDParameters, err error) {
+	if commonInfPresentFlag {
+		hrd.NalHrdParametersPresentFlag = r.ReadFlag()
+		hrd.VclHrdParametersPresentFlag = r.ReadFlag()
+		if hrd.NalHrdParametersPresentFlag || hrd.VclHrdParametersPresentFlag {
+			hrd.SubPicHrdParamsPresentFlag = r.ReadFlag()
+			if hrd.SubPicHrdParamsPresentFlag {
+				hrd.TickDivisorMinus2 = byte(r.Read(8))
+				hrd.DuCpbRemovalDelayIncrementLengthMinus1 = byte(r.Read(5))
+				hrd.SubPicCpbParamsInPicTimingSeiFlag = r.ReadFlag()
+				hrd.DpbOutputDelayDuLengthMinus1 = byte(r.Read(5))
+			}
+			hrd.BitRateScale = byte(r.Read(4))
+			hrd.CpbSizeScale = byte(r.Read(4))
+			if hrd.SubPicHrdParamsPresentFlag {
+				hrd.CpbSizeDuScale = byte(r.Read(4))
+			}
+			hrd.InitialCpbRemovalDelayLengthMinus1 = byte(r.Read(5))
+			hrd.AuCpbRemovalDelayLengthMinus1 = byte(r.Read(5))
+			hrd.DpbOutputDelayLengthMinus1 = byte(r.Read(5))
+		}
+	}
+	hrd.SubLayers = make([]SubLayerHRD, maxNumSubLayersMinus1+1)
+	for i := range hrd.SubLayers {
+		sl := &hrd.SubLayers[i]
+		sl.FixedPicRateGeneralFlag = r.ReadFlag()
+		sl.FixedPicRateWithinCvsFlag = true
+		if !sl.FixedPicRateGeneralFlag {
+			sl.FixedPicRateWithinCvsFlag = r.ReadFlag()
+		}
+		if sl.FixedPicRateWithinCvsFlag {
+			sl.ElementalDurationInTcMinus1 = uint32(r.ReadExpGolomb())
+		} else {
+			sl.LowDelayHrdFlag = r.ReadFlag()
+		}
+		if !sl.LowDelayHrdFlag {
+			cpbCntMinus1 := r.ReadExpGolomb()
+			if cpbCntMinus1 > 31 {
+				return hrd, fmt.Errorf("cpb_cnt_minus1 %d out of range", cpbCntMinus1)
+			}
+			sl.CpbCntMinus1 = byte(cpbCntMinus1)
+		}
+		if hrd.NalHrdParametersPresentFlag {
+			sl.NalSchedSels = readSubLayerHRDParameters(r, sl.CpbCntMinus1, hrd.SubPicHrdParamsPresentFlag)
+		}
+		if hrd.VclHrdParametersPresentFlag {
+			sl.VclSchedSels = readSubLayerHRDParameters(r, sl.CpbCntMinus1, hrd.SubPicHrdParamsPresentFlag)
+		}
+	}
+	return hrd, r.AccError()
+}
+
+// readSubLayerHRDParameters - read sub_layer_hrd_parameters(), Sec. E.2.3
+func readSubLayerHRDParameters(r *bits.AccErrEBSPReader, cpbCntMinus1 byte, subPicHrdParamsPresentFlag bool) (sels []HRDSchedSel) {
+	for i := 0; i <= int(cpbCntMinus1) && r.AccError() == nil; i++ {
+		var sel HRDSchedSel
+		sel.BitRateValueMinus1 = uint32(r.ReadExpGolomb())
+		sel.CpbSizeValueMinus1 = uint32(r.ReadExpGolomb())
+		if subPicHrdParamsPresentFlag {
+			sel.CpbSizeDuValueMinus1 = uint32(r.ReadExpGolomb())
+			sel.BitRateDuValueMinus1 = uint32(r.ReadExpGolomb())
+		}
+		sel.CbrFlag = r.ReadFlag()
+		sels = append(sels, sel)
+	}
+	return sels
+}
+
+// BitRate - bit rate of a CPB in bits/s, Sec. E.3.3
+func (hrd *HRDParameters) BitRate(sel *HRDSchedSel) uint64 {
+	return (uint64(sel.BitRateValueMinus1) + 1) << (6 + hrd.BitRateScale)
+}
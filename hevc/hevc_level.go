@@ -0,0 +1,82 @@
+package hevc
+
+import (
+	"fmt"
+)
+
+// LevelLimits - limits of an HEVC level for the Main and Main 10 profiles
+// ISO/IEC 23008-2 Tables A.8 and A.9
+type LevelLimits struct {
+	// general_level_idc, 30 times the level number
+	LevelIndicator byte
+	// MaxLumaPs - max luma picture size (samples)
+	MaxLumaPs uint32
+	// MaxCPBMain, MaxCPBHigh - max CPB size per tier (1000 bits), 0 if the tier is not allowed
+	MaxCPBMain uint32
+	MaxCPBHigh uint32
+	// MaxSliceSegmentsPerPicture - max slice segments per picture
+	MaxSliceSegmentsPerPicture uint32
+	// MaxTileRows, MaxTileCols - max number of tile rows and columns
+	MaxTileRows uint32
+	MaxTileCols uint32
+	// MaxLumaSr - max luma sample rate (samples/s)
+	MaxLumaSr uint32
+	// MaxBRMain, MaxBRHigh - max bit rate per tier (1000 bits/s), 0 if the tier is not allowed
+	MaxBRMain uint32
+	MaxBRHigh uint32
+}
+
+// Levels - all levels of Tables A.8 and A.9 in ascending order
+var Levels = []LevelLimits{
+	{30, 36864, 350, 0, 16, 1, 1, 552960, 128, 0},
+	{60, 122880, 1500, 0, 16, 1, 1, 3686400, 1500, 0},
+	{63, 245760, 3000, 0, 20, 1, 1, 7372800, 3000, 0},
+	{90, 552960, 6000, 0, 30, 2, 2, 16588800, 6000, 0},
+	{93, 983040, 10000, 0, 40, 3, 3, 33177600, 10000, 0},
+	{120, 2228224, 12000, 30000, 75, 5, 5, 66846720, 12000, 30000},
+	{123, 2228224, 20000, 50000, 75, 5, 5, 133693440, 20000, 50000},
+	{150, 8912896, 25000, 100000, 200, 11, 10, 267386880, 25000, 100000},
+	{153, 8912896, 40000, 160000, 200, 11, 10, 534773760, 40000, 160000},
+	{156, 8912896, 60000, 240000, 200, 11, 10, 1069547520, 60000, 240000},
+	{180, 35651584, 60000, 240000, 600, 22, 20, 1069547520, 60000, 240000},
+	{183, 35651584, 120000, 480000, 600, 22, 20, 2139095040, 120000, 480000},
+	{186, 35651584, 240000, 800000, 600, 22, 20, 4278190080, 240000, 800000},
+}
+
+// LookupLevel - limits for a general_level_idc
+func LookupLevel(levelIndicator byte) (LevelLimits, bool) {
+	for _, l := range Levels {
+		if l.LevelIndicator == levelIndicator {
+			return l, true
+		}
+	}
+	return LevelLimits{}, false
+}
+
+// LevelName - level number of a general_level_idc, e.g. "4.1"
+func LevelName(levelIndicator byte) string {
+	if major, minor := levelIndicator/30, levelIndicator%30/3; minor != 0 {
+		return fmt.Sprintf("%d.%d", major, minor)
+	}
+	return fmt.Sprintf("%d", levelIndicator/30)
+}
+
+// MaxDpbSize - max decoded picture buffer size for a picture size, Sec. A.4.2
+func (l *LevelLimits) MaxDpbSize(picSizeInSamplesY uint32) uint32 {
+	const maxDpbPicBuf = 6
+	var size uint32
+	switch {
+	case picSizeInSamplesY <= l.MaxLumaPs>>2:
+		size = 4 * maxDpbPicBuf
+	case picSizeInSamplesY <= l.MaxLumaPs>>1:
+		size = 2 * maxDpbPicBuf
+	case picSizeInSamplesY <= (3*l.MaxLumaPs)>>2:
+		size = 4 * maxDpbPicBuf / 3
+	default:
+		size = maxDpbPicBuf
+	}
+	if size > 16 {
+		size = 16
+	}
+	return size
+}
@@ -0,0 +1,129 @@
+package hevc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/go-webdl/media-codec/debuglog"
+)
+
+// 9.6.3 Layered HEVC decoder configuration record
+
+// The L-HEVC decoder configuration record, stored in the lhvC box, describes
+// the layers of a layered HEVC stream that are carried in a track of their
+// own, e.g. an auxiliary alpha layer. It has no profile, tier, level or format
+// fields, those are signalled in the parameter sets of the layer. The VPS
+// describing all layers is normally stored in the hvcC of the base layer
+// track.
+type LHEVCDecoderConfigurationRecord struct {
+	ConfigurationVersion            uint8
+	MinSpatialSegmentationIndicator uint16
+	ParallelismType                 uint8
+	NumTemporalLayers               uint8
+	TemporalIDNested                uint8
+	LengthSizeMinusOne              uint8
+	NaluArrays                      []NaluArray
+}
+
+func (b *LHEVCDecoderConfigurationRecord) RecordSize() (size uint32) {
+	// unsigned int(8) configurationVersion = 1;
+	// bit(4) reserved = '1111'b;
+	// unsigned int(12) min_spatial_segmentation_idc;
+	// bit(6) reserved = '111111'b;
+	// unsigned int(2) parallelismType;
+	// bit(2) reserved = '11'b;
+	// bit(3) numTemporalLayers;
+	// bit(1) temporalIdNested;
+	// unsigned int(2) lengthSizeMinusOne;
+	// unsigned int(8) numOfArrays;
+	size += 6
+	// unsigned int(1) array_completeness;
+	// bit(1) reserved = 0;
+	// unsigned int(6) NAL_unit_type;
+	// unsigned int(16) numNalus;
+	size += 3 * uint32(len(b.NaluArrays))
+	for _, entry := range b.NaluArrays {
+		for _, nalu := range entry.NALUs {
+			size += 2 + uint32(len(nalu)) // unsigned int(16) nalUnitLength; bit(8*nalUnitLength) nalUnit;
+		}
+	}
+	return
+}
+
+func (b *LHEVCDecoderConfigurationRecord) RecordRead(r io.Reader) (err error) {
+	var tmp [6]uint8
+	if err = binary.Read(r, binary.BigEndian, &tmp); err != nil {
+		return
+	}
+	b.ConfigurationVersion = tmp[0]
+	b.MinSpatialSegmentationIndicator = uint16(tmp[1]&0b1111)<<8 | uint16(tmp[2])
+	b.ParallelismType = tmp[3] & 0b11
+	b.NumTemporalLayers = (tmp[4] >> 3) & 0b111
+	b.TemporalIDNested = (tmp[4] >> 2) & 0b1
+	b.LengthSizeMinusOne = tmp[4] & 0b11
+	if b.ConfigurationVersion != 1 {
+		debuglog.Debug("lhvC: unknown configurationVersion", "version", b.ConfigurationVersion)
+	}
+	if tmp[1]>>4 != 0b1111 || tmp[3]>>2 != 0b111111 || tmp[4]>>6 != 0b11 {
+		debuglog.Debug("lhvC: reserved bits not set", "header", fmt.Sprintf("%x", tmp[1:5]))
+	}
+	entryCount := tmp[5]
+	b.NaluArrays = make([]NaluArray, entryCount)
+	for i := uint8(0); i < entryCount; i++ {
+		if err = binary.Read(r, binary.BigEndian, tmp[:3]); err != nil {
+			return
+		}
+		b.NaluArrays[i].ArrayCompleteness = (tmp[0] >> 7) > 0
+		b.NaluArrays[i].NALUnitType = NaluType(tmp[0] & 0b111111)
+		naluCount := uint16(tmp[1])<<8 | uint16(tmp[2])
+		b.NaluArrays[i].NALUs = make([][]byte, naluCount)
+		for j := uint16(0); j < naluCount; j++ {
+			var naluLength uint16
+			if err = binary.Read(r, binary.BigEndian, &naluLength); err != nil {
+				return
+			}
+			b.NaluArrays[i].NALUs[j] = make([]byte, naluLength)
+			if _, err = io.ReadFull(r, b.NaluArrays[i].NALUs[j]); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+func (b *LHEVCDecoderConfigurationRecord) RecordWrite(w io.Writer) (err error) {
+	header := [6]uint8{
+		b.ConfigurationVersion,
+		0b11110000 | uint8(b.MinSpatialSegmentationIndicator>>8)&0b1111,
+		uint8(b.MinSpatialSegmentationIndicator),
+		0b11111100 | b.ParallelismType&0b11,
+		0b11000000 | (b.NumTemporalLayers&0b111)<<3 | (b.TemporalIDNested&0b1)<<2 | b.LengthSizeMinusOne&0b11,
+		uint8(len(b.NaluArrays)),
+	}
+	if err = binary.Write(w, binary.BigEndian, header); err != nil {
+		return
+	}
+	for _, entry := range b.NaluArrays {
+		var tmp uint8
+		tmp |= uint8(entry.NALUnitType) & 0b00111111
+		if entry.ArrayCompleteness {
+			tmp |= 0b10000000
+		}
+		if err = binary.Write(w, binary.BigEndian, tmp); err != nil {
+			return
+		}
+		if err = binary.Write(w, binary.BigEndian, uint16(len(entry.NALUs))); err != nil {
+			return
+		}
+		for _, nalu := range entry.NALUs {
+			if err = binary.Write(w, binary.BigEndian, uint16(len(nalu))); err != nil {
+				return
+			}
+			if err = binary.Write(w, binary.BigEndian, nalu); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
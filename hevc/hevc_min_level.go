@@ -0,0 +1,144 @@
+package hevc
+
+import (
+	"fmt"
+)
+
+// LevelRequirements - stream parameters that determine the lowest allowed level and tier
+// Limits are those of the Main and Main 10 profiles.
+type LevelRequirements struct {
+	// Width, Height - picture size in luma samples
+	Width, Height uint32
+	// FrameRate - pictures per second, 0 if unknown
+	FrameRate float64
+	// DpbSize - pictures the decoded picture buffer must hold
+	DpbSize uint32
+	// BitRate - peak VCL bit rate in bits/s, 0 if unknown
+	BitRate uint64
+	// FixedTier - only consider the tier given by HighTier
+	FixedTier bool
+	HighTier  bool
+}
+
+// exceeded - why the level and tier cannot carry the requirements, empty if they can
+// Sec. A.4.1 and A.4.2
+func (req *LevelRequirements) exceeded(l *LevelLimits, highTier bool) string {
+	if highTier && l.MaxCPBHigh == 0 {
+		return fmt.Sprintf("high tier not allowed at level %s", LevelName(l.LevelIndicator))
+	}
+	picSize := req.Width * req.Height
+	if picSize > l.MaxLumaPs {
+		return fmt.Sprintf("picture size %d exceeds limit %d", picSize, l.MaxLumaPs)
+	}
+	maxDim := uint64(8) * uint64(l.MaxLumaPs)
+	if uint64(req.Width)*uint64(req.Width) > maxDim || uint64(req.Height)*uint64(req.Height) > maxDim {
+		return fmt.Sprintf("%dx%d exceeds dimension limits", req.Width, req.Height)
+	}
+	if sampleRate := float64(picSize) * req.FrameRate; sampleRate > float64(l.MaxLumaSr) {
+		return fmt.Sprintf("luma sample rate %.0f exceeds limit %d", sampleRate, l.MaxLumaSr)
+	}
+	if maxDpbSize := l.MaxDpbSize(picSize); req.DpbSize > maxDpbSize {
+		return fmt.Sprintf("DPB size %d exceeds limit %d", req.DpbSize, maxDpbSize)
+	}
+	if maxBitRate := l.MaxBitRate(highTier); req.BitRate > maxBitRate {
+		return fmt.Sprintf("bit rate %d exceeds %s tier limit %d", req.BitRate, tierName(highTier), maxBitRate)
+	}
+	return ""
+}
+
+// MinLevelAndTier - lowest level of Tables A.8 and A.9 satisfying the requirements
+// Main tier is preferred; High tier is returned if the bit rate only fits
+// High tier at the lowest sufficient level. With FixedTier only the tier
+// HighTier is considered.
+func MinLevelAndTier(req LevelRequirements) (limits LevelLimits, highTier bool, ok bool) {
+	tiers := []bool{false, true}
+	if req.FixedTier {
+		tiers = []bool{req.HighTier}
+	}
+	for i := range Levels {
+		for _, high := range tiers {
+			if req.exceeded(&Levels[i], high) == "" {
+				return Levels[i], high, true
+			}
+		}
+	}
+	return LevelLimits{}, false, false
+}
+
+// LevelRequirements - requirements of the SPS
+// The frame rate is derived from VUI timing, the DPB size from the largest
+// sps_max_dec_pic_buffering_minus1 and the bit rate from the highest CPB of
+// the highest sub-layer of the HRD parameters.
+func (s *SPS) LevelRequirements() LevelRequirements {
+	req := LevelRequirements{Width: s.PicWidthInLumaSamples, Height: s.PicHeightInLumaSamples}
+	for _, info := range s.SubLayeringOrderingInfos {
+		if uint32(info.MaxDecPicBufferingMinus1)+1 > req.DpbSize {
+			req.DpbSize = uint32(info.MaxDecPicBufferingMinus1) + 1
+		}
+	}
+	if !s.VUIParametersPresentFlag {
+		return req
+	}
+	vui := &s.VUI
+	req.FrameRate = vui.FrameRate()
+	if !vui.HrdParametersPresentFlag || len(vui.HrdParameters.SubLayers) == 0 {
+		return req
+	}
+	hrd := &vui.HrdParameters
+	highest := &hrd.SubLayers[len(hrd.SubLayers)-1]
+	for i := range highest.VclSchedSels {
+		if bitRate := hrd.BitRate(&highest.VclSchedSels[i]); bitRate > req.BitRate {
+			req.BitRate = bitRate
+		}
+	}
+	for i := range highest.NalSchedSels {
+		// NAL HRD limits are 1.1 times the VCL ones, Sec. A.4.2
+		if bitRate := hrd.BitRate(&highest.NalSchedSels[i]) * 10 / 11; bitRate > req.BitRate {
+			req.BitRate = bitRate
+		}
+	}
+	return req
+}
+
+// VerifyLevel - report if the level and tier of the record are too low for its SPSs
+// Each SPS must signal a level and tier the record covers, and the
+// parameters of the SPS must fit the level and tier of the record.
+func (b *HEVCDecoderConfigurationRecord) VerifyLevel() (issues []string) {
+	limits, ok := LookupLevel(b.GeneralLevelIndicator)
+	if !ok {
+		return []string{fmt.Sprintf("unknown level %d", b.GeneralLevelIndicator)}
+	}
+	level := LevelName(b.GeneralLevelIndicator)
+	for _, array := range b.NaluArrays {
+		if array.NALUnitType != NALU_SPS {
+			continue
+		}
+		for i, nalu := range array.NALUs {
+			sps, err := ParseSPSNALUnit(nalu)
+			if err != nil {
+				issues = append(issues, fmt.Sprintf("SPS %d: %s", i, err))
+				continue
+			}
+			ptl := &sps.ProfileTierLevel
+			if ptl.GeneralLevelIndicator > b.GeneralLevelIndicator {
+				issues = append(issues, fmt.Sprintf("SPS %d: level %s above record level %s", i, LevelName(ptl.GeneralLevelIndicator), level))
+			}
+			if ptl.GeneralTierFlag && !b.GeneralTierFlag {
+				issues = append(issues, fmt.Sprintf("SPS %d: high tier, record has main tier", i))
+			}
+			req := sps.LevelRequirements()
+			reason := req.exceeded(&limits, b.GeneralTierFlag)
+			if reason == "" {
+				continue
+			}
+			if required, high, ok := MinLevelAndTier(req); ok {
+				issues = append(issues, fmt.Sprintf("SPS %d: level %s %s tier too low, %s, level %s %s tier required",
+					i, level, tierName(b.GeneralTierFlag), reason, LevelName(required.LevelIndicator), tierName(high)))
+			} else {
+				issues = append(issues, fmt.Sprintf("SPS %d: level %s %s tier too low, %s, no level is sufficient",
+					i, level, tierName(b.GeneralTierFlag), reason))
+			}
+		}
+	}
+	return issues
+}
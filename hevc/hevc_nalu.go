@@ -19,6 +19,14 @@ const (
 	NALU_RADL_R  = NaluType(7)
 	NALU_RASL_N  = NaluType(8)
 	NALU_RASL_R  = NaluType(9)
+	// NALU_RSV_VCL_N10 to NALU_RSV_VCL_R15 - reserved non-IRAP sub-layer
+	// non-reference (N) and reference (R) VCL NAL unit types
+	NALU_RSV_VCL_N10 = NaluType(10)
+	NALU_RSV_VCL_R11 = NaluType(11)
+	NALU_RSV_VCL_N12 = NaluType(12)
+	NALU_RSV_VCL_R13 = NaluType(13)
+	NALU_RSV_VCL_N14 = NaluType(14)
+	NALU_RSV_VCL_R15 = NaluType(15)
 	// BLA_W_LP and the following types are Random Access
 	NALU_BLA_W_LP   = NaluType(16)
 	NALU_BLA_W_RADL = NaluType(17)
@@ -26,6 +34,18 @@ const (
 	NALU_IDR_W_RADL = NaluType(19)
 	NALU_IDR_N_LP   = NaluType(20)
 	NALU_CRA        = NaluType(21)
+	// NALU_RSV_IRAP_VCL22, NALU_RSV_IRAP_VCL23 - reserved IRAP VCL NAL unit types
+	NALU_RSV_IRAP_VCL22 = NaluType(22)
+	NALU_RSV_IRAP_VCL23 = NaluType(23)
+	// NALU_RSV_VCL24 to NALU_RSV_VCL31 - reserved non-IRAP VCL NAL unit types
+	NALU_RSV_VCL24 = NaluType(24)
+	NALU_RSV_VCL25 = NaluType(25)
+	NALU_RSV_VCL26 = NaluType(26)
+	NALU_RSV_VCL27 = NaluType(27)
+	NALU_RSV_VCL28 = NaluType(28)
+	NALU_RSV_VCL29 = NaluType(29)
+	NALU_RSV_VCL30 = NaluType(30)
+	NALU_RSV_VCL31 = NaluType(31)
 	// NALU_VPS - VideoParameterSet NAL Unit
 	NALU_VPS = NaluType(32)
 	// NALU_SPS - SequenceParameterSet NAL Unit
@@ -44,46 +64,141 @@ const (
 	NALU_SEI_PREFIX = NaluType(39)
 	//NALU_SEI_SUFFIX - Suffix SEI NAL Unit
 	NALU_SEI_SUFFIX = NaluType(40)
+	// NALU_RSV_NVCL41 to NALU_RSV_NVCL47 - reserved non-VCL NAL unit types
+	NALU_RSV_NVCL41 = NaluType(41)
+	NALU_RSV_NVCL42 = NaluType(42)
+	NALU_RSV_NVCL43 = NaluType(43)
+	NALU_RSV_NVCL44 = NaluType(44)
+	NALU_RSV_NVCL45 = NaluType(45)
+	NALU_RSV_NVCL46 = NaluType(46)
+	NALU_RSV_NVCL47 = NaluType(47)
+	// NALU_UNSPEC48 to NALU_UNSPEC63 - unspecified non-VCL NAL unit types,
+	// used by other specifications such as Dolby Vision
+	NALU_UNSPEC48 = NaluType(48)
+	NALU_UNSPEC49 = NaluType(49)
+	NALU_UNSPEC50 = NaluType(50)
+	NALU_UNSPEC51 = NaluType(51)
+	NALU_UNSPEC52 = NaluType(52)
+	NALU_UNSPEC53 = NaluType(53)
+	NALU_UNSPEC54 = NaluType(54)
+	NALU_UNSPEC55 = NaluType(55)
+	NALU_UNSPEC56 = NaluType(56)
+	NALU_UNSPEC57 = NaluType(57)
+	NALU_UNSPEC58 = NaluType(58)
+	NALU_UNSPEC59 = NaluType(59)
+	NALU_UNSPEC60 = NaluType(60)
+	NALU_UNSPEC61 = NaluType(61)
+	NALU_UNSPEC62 = NaluType(62)
+	NALU_UNSPEC63 = NaluType(63)
 )
 
+// naluTypeNames - names of Table 7-1 without the _NUT suffix
+var naluTypeNames = map[NaluType]string{
+	NALU_TRAIL_N:    "TRAIL_N",
+	NALU_TRAIL_R:    "TRAIL_R",
+	NALU_TSA_N:      "TSA_N",
+	NALU_TSA_R:      "TSA_R",
+	NALU_STSA_N:     "STSA_N",
+	NALU_STSA_R:     "STSA_R",
+	NALU_RADL_N:     "RADL_N",
+	NALU_RADL_R:     "RADL_R",
+	NALU_RASL_N:     "RASL_N",
+	NALU_RASL_R:     "RASL_R",
+	NALU_BLA_W_LP:   "BLA_W_LP",
+	NALU_BLA_W_RADL: "BLA_W_RADL",
+	NALU_BLA_N_LP:   "BLA_N_LP",
+	NALU_IDR_W_RADL: "IDR_W_RADL",
+	NALU_IDR_N_LP:   "IDR_N_LP",
+	NALU_CRA:        "CRA",
+	NALU_VPS:        "VPS",
+	NALU_SPS:        "SPS",
+	NALU_PPS:        "PPS",
+	NALU_AUD:        "AUD",
+	NALU_EOS:        "EOS",
+	NALU_EOB:        "EOB",
+	NALU_FD:         "FD",
+	NALU_SEI_PREFIX: "PREFIX_SEI",
+	NALU_SEI_SUFFIX: "SUFFIX_SEI",
+}
+
+// String - Table 7-1 name and value, e.g. IDR_W_RADL_19, RSV_VCL_N10 and
+// UNSPEC48 for reserved and unspecified types, Other_%d beyond 63
 func (n NaluType) String() string {
-	switch n {
-	case NALU_TRAIL_N, NALU_TRAIL_R:
-		return fmt.Sprintf("NonRAP_Trail_%d", n)
-	case NALU_TSA_N, NALU_TSA_R:
-		return fmt.Sprintf("NonRAP_TSA_%d", n)
-	case NALU_STSA_N, NALU_STSA_R:
-		return fmt.Sprintf("NonRAP_STSA_%d", n)
-	case NALU_RADL_N, NALU_RADL_R:
-		return fmt.Sprintf("NonRAP_RADL_%d", n)
-	case NALU_RASL_N, NALU_RASL_R:
-		return fmt.Sprintf("NonRAP_RASL_%d", n)
-	case NALU_BLA_N_LP, NALU_BLA_W_LP, NALU_BLA_W_RADL:
-		return fmt.Sprintf("RAP_BLA_%d", n)
-	case NALU_IDR_N_LP, NALU_IDR_W_RADL:
-		return fmt.Sprintf("RAP_IDR_%d", n)
-	case NALU_CRA:
-		return fmt.Sprintf("RAP_CRA_%d", n)
-	case NALU_VPS:
-		return fmt.Sprintf("VPS_%d", n)
-	case NALU_SPS:
-		return fmt.Sprintf("SPS_%d", n)
-	case NALU_PPS:
-		return fmt.Sprintf("PPS_%d", n)
-	case NALU_AUD:
-		return fmt.Sprintf("AUD_%d", n)
-	case NALU_SEI_PREFIX, NALU_SEI_SUFFIX:
-		return fmt.Sprintf("SEI_%d", n)
+	if name, ok := naluTypeNames[n]; ok {
+		return fmt.Sprintf("%s_%d", name, n)
+	}
+	switch {
+	case n <= NALU_RSV_VCL_R15:
+		if n.IsSubLayerNonReference() {
+			return fmt.Sprintf("RSV_VCL_N%d", n)
+		}
+		return fmt.Sprintf("RSV_VCL_R%d", n)
+	case n <= NALU_RSV_IRAP_VCL23:
+		return fmt.Sprintf("RSV_IRAP_VCL%d", n)
+	case n <= NALU_RSV_VCL31:
+		return fmt.Sprintf("RSV_VCL%d", n)
+	case n <= NALU_RSV_NVCL47:
+		return fmt.Sprintf("RSV_NVCL%d", n)
+	case n <= NALU_UNSPEC63:
+		return fmt.Sprintf("UNSPEC%d", n)
 	default:
 		return fmt.Sprintf("Other_%d", n)
 	}
 }
 
+// IsVCL - is NAL unit type a VCL (slice segment) NAL unit
+func (n NaluType) IsVCL() bool {
+	return n <= NALU_RSV_VCL31
+}
+
+// IsIRAP - is NAL unit type an IRAP picture (NALU 16-23)
+func (n NaluType) IsIRAP() bool {
+	return NALU_BLA_W_LP <= n && n <= NALU_RSV_IRAP_VCL23
+}
+
+// IsSubLayerNonReference - is NAL unit type a sub-layer non-reference picture
+// These are the even VCL NAL unit types up to RSV_VCL_N14.
+func (n NaluType) IsSubLayerNonReference() bool {
+	return n <= NALU_RSV_VCL_N14 && n%2 == 0
+}
+
+// IsParameterSet - is NAL unit type a VPS, SPS or PPS
+func (n NaluType) IsParameterSet() bool {
+	return n == NALU_VPS || n == NALU_SPS || n == NALU_PPS
+}
+
+// IsSEI - is NAL unit type a prefix or suffix SEI NAL unit
+func (n NaluType) IsSEI() bool {
+	return n == NALU_SEI_PREFIX || n == NALU_SEI_SUFFIX
+}
+
+// IsReserved - is NAL unit type reserved for future use by ISO/IEC 23008-2
+func (n NaluType) IsReserved() bool {
+	switch {
+	case NALU_RSV_VCL_N10 <= n && n <= NALU_RSV_VCL_R15:
+		return true
+	case NALU_RSV_IRAP_VCL22 <= n && n <= NALU_RSV_VCL31:
+		return true
+	default:
+		return NALU_RSV_NVCL41 <= n && n <= NALU_RSV_NVCL47
+	}
+}
+
+// IsUnspecified - is NAL unit type unspecified, i.e. left to other specifications
+func (n NaluType) IsUnspecified() bool {
+	return NALU_UNSPEC48 <= n && n <= NALU_UNSPEC63
+}
+
 // Get NaluType from first byte of NALU Header
 func GetNaluType(naluHeaderStart byte) NaluType {
 	return NaluType((naluHeaderStart >> 1) & 0x3f)
 }
 
+// GetLayerID - nuh_layer_id from the two bytes of NALU Header
+func GetLayerID(naluHeader []byte) byte {
+	return (naluHeader[0]&0b1)<<5 | naluHeader[1]>>3
+}
+
 // FindNaluTypes - find list of nalu types in sample
 func FindNaluTypes(sample []byte) []NaluType {
 	naluList := make([]NaluType, 0)
@@ -121,7 +236,7 @@ func ContainsNaluType(sample []byte, specificNalType NaluType) bool {
 // IsRAPSample - is Random Access Sample (NALU 16-23)
 func IsRAPSample(sample []byte) bool {
 	for _, naluType := range FindNaluTypes(sample) {
-		if 16 <= naluType && naluType <= 23 {
+		if naluType.IsIRAP() {
 			return true
 		}
 	}
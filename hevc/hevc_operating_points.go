@@ -0,0 +1,288 @@
+package hevc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/go-webdl/bits"
+)
+
+// 9.6.2 Operating points information sample group
+
+// OperatingPointsInformation - the 'oinf' sample group entry of an L-HEVC stream
+//
+// It lists the profiles, tiers and levels, the operating points (output layer
+// sets with their layers) and the layer dependencies of the stream, so a
+// reader can pick the layers to decode without parsing the VPS. It is carried
+// in the track holding the base layer and referenced from the other layer
+// tracks through the 'oref' track reference.
+type OperatingPointsInformation struct {
+	// ScalabilityMask - scalability types in use, each set bit adding a
+	// dimension identifier to every layer
+	ScalabilityMask uint16
+	// ProfileTierLevels - general profile, tier and level only, indexed by
+	// ptl_idx starting at 1
+	ProfileTierLevels []ProfileTierLevel
+	OperatingPoints   []OperatingPoint
+	Layers            []OperatingPointLayerInfo
+}
+
+// OperatingPoint - an output layer set of the stream up to a temporal sub-layer
+type OperatingPoint struct {
+	OutputLayerSetIdx uint16
+	MaxTemporalID     byte
+	Layers            []OperatingPointLayer
+	MinPicWidth       uint16
+	MinPicHeight      uint16
+	MaxPicWidth       uint16
+	MaxPicHeight      uint16
+	MaxChromaFormat   byte
+	MaxBitDepthMinus8 byte
+	FrameRateInfoFlag bool
+	// AvgFrameRate, ConstantFrameRate - only valid with FrameRateInfoFlag
+	AvgFrameRate      uint16
+	ConstantFrameRate byte
+	BitRateInfoFlag   bool
+	// MaxBitRate, AvgBitRate - only valid with BitRateInfoFlag
+	MaxBitRate uint32
+	AvgBitRate uint32
+}
+
+// OperatingPointLayer - a layer of an operating point
+type OperatingPointLayer struct {
+	// PTLIdx - 1-based index into ProfileTierLevels, 0 if unspecified
+	PTLIdx                 byte
+	LayerID                byte
+	IsOutputLayer          bool
+	IsAlternateOutputLayer bool
+}
+
+// OperatingPointLayerInfo - dependencies and scalability dimensions of a layer
+type OperatingPointLayerInfo struct {
+	LayerID           byte
+	DirectRefLayerIDs []byte
+	// DimensionIdentifiers - one per bit set in ScalabilityMask, lowest bit first
+	DimensionIdentifiers []byte
+}
+
+// numDimensions - number of scalability types in the mask
+func (b *OperatingPointsInformation) numDimensions() (n int) {
+	for j := 0; j < NUM_SCALABILITY_TYPES; j++ {
+		if b.ScalabilityMask&(1<<j) != 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func (b *OperatingPointsInformation) RecordSize() (size uint32) {
+	// unsigned int(16) scalability_mask;
+	// bit(2) reserved;
+	// unsigned int(6) num_profile_tier_level;
+	size += 3
+	// unsigned int(2) general_profile_space;
+	// unsigned int(1) general_tier_flag;
+	// unsigned int(5) general_profile_idc;
+	// unsigned int(32) general_profile_compatibility_flags;
+	// unsigned int(48) general_constraint_indicator_flags;
+	// unsigned int(8) general_level_idc;
+	size += 12 * uint32(len(b.ProfileTierLevels))
+	// unsigned int(16) num_operating_points;
+	size += 2
+	for _, op := range b.OperatingPoints {
+		// unsigned int(16) output_layer_set_idx;
+		// unsigned int(8) max_temporal_id;
+		// unsigned int(8) layer_count;
+		size += 4
+		// unsigned int(8) ptl_idx;
+		// unsigned int(6) layer_id;
+		// unsigned int(1) is_outputlayer;
+		// unsigned int(1) is_alternate_outputlayer;
+		size += 2 * uint32(len(op.Layers))
+		// unsigned int(16) minPicWidth, minPicHeight, maxPicWidth, maxPicHeight;
+		// unsigned int(2) maxChromaFormat;
+		// unsigned int(3) maxBitDepthMinus8;
+		// bit(1) reserved;
+		// unsigned int(1) frame_rate_info_flag;
+		// unsigned int(1) bit_rate_info_flag;
+		size += 9
+		if op.FrameRateInfoFlag {
+			// unsigned int(16) avgFrameRate;
+			// bit(6) reserved;
+			// unsigned int(2) constantFrameRate;
+			size += 3
+		}
+		if op.BitRateInfoFlag {
+			// unsigned int(32) maxBitRate;
+			// unsigned int(32) avgBitRate;
+			size += 8
+		}
+	}
+	// unsigned int(8) max_layer_count;
+	size += 1
+	for _, layer := range b.Layers {
+		// unsigned int(8) layerID;
+		// unsigned int(8) num_direct_ref_layers;
+		// unsigned int(8) direct_ref_layerID[num_direct_ref_layers];
+		// unsigned int(8) dimension_identifier[num_dimensions];
+		size += 2 + uint32(len(layer.DirectRefLayerIDs)) + uint32(b.numDimensions())
+	}
+	return
+}
+
+func (b *OperatingPointsInformation) RecordRead(r io.Reader) (err error) {
+	br := bits.NewAccErrReader(r)
+	b.ScalabilityMask = uint16(br.Read(16))
+	_ = br.Read(2) // reserved
+	b.ProfileTierLevels = make([]ProfileTierLevel, br.Read(6))
+	for i := range b.ProfileTierLevels {
+		ptl := &b.ProfileTierLevels[i]
+		ptl.GeneralProfileSpace = byte(br.Read(2))
+		ptl.GeneralTierFlag = br.ReadFlag()
+		ptl.GeneralProfileIndicator = byte(br.Read(5))
+		ptl.GeneralProfileCompatibilityFlags = uint32(br.Read(32))
+		ptl.GeneralConstraintIndicatorFlags = uint64(br.Read(48))
+		ptl.GeneralLevelIndicator = byte(br.Read(8))
+	}
+	numOperatingPoints := int(br.Read(16))
+	if err = br.AccError(); err != nil {
+		return
+	}
+	b.OperatingPoints = make([]OperatingPoint, numOperatingPoints)
+	for i := range b.OperatingPoints {
+		op := &b.OperatingPoints[i]
+		op.OutputLayerSetIdx = uint16(br.Read(16))
+		op.MaxTemporalID = byte(br.Read(8))
+		op.Layers = make([]OperatingPointLayer, br.Read(8))
+		for j := range op.Layers {
+			op.Layers[j] = OperatingPointLayer{
+				PTLIdx:                 byte(br.Read(8)),
+				LayerID:                byte(br.Read(6)),
+				IsOutputLayer:          br.ReadFlag(),
+				IsAlternateOutputLayer: br.ReadFlag(),
+			}
+		}
+		op.MinPicWidth = uint16(br.Read(16))
+		op.MinPicHeight = uint16(br.Read(16))
+		op.MaxPicWidth = uint16(br.Read(16))
+		op.MaxPicHeight = uint16(br.Read(16))
+		op.MaxChromaFormat = byte(br.Read(2))
+		op.MaxBitDepthMinus8 = byte(br.Read(3))
+		_ = br.Read(1) // reserved
+		op.FrameRateInfoFlag = br.ReadFlag()
+		op.BitRateInfoFlag = br.ReadFlag()
+		if op.FrameRateInfoFlag {
+			op.AvgFrameRate = uint16(br.Read(16))
+			_ = br.Read(6) // reserved
+			op.ConstantFrameRate = byte(br.Read(2))
+		}
+		if op.BitRateInfoFlag {
+			op.MaxBitRate = uint32(br.Read(32))
+			op.AvgBitRate = uint32(br.Read(32))
+		}
+		if err = br.AccError(); err != nil {
+			return
+		}
+	}
+	b.Layers = make([]OperatingPointLayerInfo, br.Read(8))
+	numDimensions := b.numDimensions()
+	for i := range b.Layers {
+		layer := &b.Layers[i]
+		layer.LayerID = byte(br.Read(8))
+		layer.DirectRefLayerIDs = make([]byte, br.Read(8))
+		for j := range layer.DirectRefLayerIDs {
+			layer.DirectRefLayerIDs[j] = byte(br.Read(8))
+		}
+		layer.DimensionIdentifiers = make([]byte, numDimensions)
+		for j := range layer.DimensionIdentifiers {
+			layer.DimensionIdentifiers[j] = byte(br.Read(8))
+		}
+		if err = br.AccError(); err != nil {
+			return
+		}
+	}
+	return br.AccError()
+}
+
+func (b *OperatingPointsInformation) RecordWrite(w io.Writer) (err error) {
+	if len(b.ProfileTierLevels) > 63 || len(b.OperatingPoints) > 0xffff || len(b.Layers) > 0xff {
+		return fmt.Errorf("oinf with %d profile tier levels, %d operating points and %d layers out of range",
+			len(b.ProfileTierLevels), len(b.OperatingPoints), len(b.Layers))
+	}
+	numDimensions := b.numDimensions()
+	var buf bytes.Buffer
+	bw := bits.NewWriter(&buf)
+	bw.Write(uint(b.ScalabilityMask), 16)
+	bw.Write(0b11, 2) // reserved
+	bw.Write(uint(len(b.ProfileTierLevels)), 6)
+	for _, ptl := range b.ProfileTierLevels {
+		bw.Write(uint(ptl.GeneralProfileSpace), 2)
+		bw.Write(boolBit(ptl.GeneralTierFlag), 1)
+		bw.Write(uint(ptl.GeneralProfileIndicator), 5)
+		bw.Write(uint(ptl.GeneralProfileCompatibilityFlags), 32)
+		bw.Write(uint(ptl.GeneralConstraintIndicatorFlags), 48)
+		bw.Write(uint(ptl.GeneralLevelIndicator), 8)
+	}
+	bw.Write(uint(len(b.OperatingPoints)), 16)
+	for _, op := range b.OperatingPoints {
+		if len(op.Layers) > 0xff {
+			return fmt.Errorf("oinf operating point %d with %d layers", op.OutputLayerSetIdx, len(op.Layers))
+		}
+		bw.Write(uint(op.OutputLayerSetIdx), 16)
+		bw.Write(uint(op.MaxTemporalID), 8)
+		bw.Write(uint(len(op.Layers)), 8)
+		for _, layer := range op.Layers {
+			bw.Write(uint(layer.PTLIdx), 8)
+			bw.Write(uint(layer.LayerID), 6)
+			bw.Write(boolBit(layer.IsOutputLayer), 1)
+			bw.Write(boolBit(layer.IsAlternateOutputLayer), 1)
+		}
+		bw.Write(uint(op.MinPicWidth), 16)
+		bw.Write(uint(op.MinPicHeight), 16)
+		bw.Write(uint(op.MaxPicWidth), 16)
+		bw.Write(uint(op.MaxPicHeight), 16)
+		bw.Write(uint(op.MaxChromaFormat), 2)
+		bw.Write(uint(op.MaxBitDepthMinus8), 3)
+		bw.Write(1, 1) // reserved
+		bw.Write(boolBit(op.FrameRateInfoFlag), 1)
+		bw.Write(boolBit(op.BitRateInfoFlag), 1)
+		if op.FrameRateInfoFlag {
+			bw.Write(uint(op.AvgFrameRate), 16)
+			bw.Write(0b111111, 6) // reserved
+			bw.Write(uint(op.ConstantFrameRate), 2)
+		}
+		if op.BitRateInfoFlag {
+			bw.Write(uint(op.MaxBitRate), 32)
+			bw.Write(uint(op.AvgBitRate), 32)
+		}
+	}
+	bw.Write(uint(len(b.Layers)), 8)
+	for _, layer := range b.Layers {
+		if len(layer.DirectRefLayerIDs) > 0xff || len(layer.DimensionIdentifiers) != numDimensions {
+			return fmt.Errorf("oinf layer %d with %d reference layers and %d dimension identifiers, mask has %d",
+				layer.LayerID, len(layer.DirectRefLayerIDs), len(layer.DimensionIdentifiers), numDimensions)
+		}
+		bw.Write(uint(layer.LayerID), 8)
+		bw.Write(uint(len(layer.DirectRefLayerIDs)), 8)
+		for _, id := range layer.DirectRefLayerIDs {
+			bw.Write(uint(id), 8)
+		}
+		for _, id := range layer.DimensionIdentifiers {
+			bw.Write(uint(id), 8)
+		}
+	}
+	if err = bw.Error(); err != nil {
+		return
+	}
+	_, err = w.Write(buf.Bytes())
+	return
+}
+
+// boolBit - 1 for true, 0 for false
+func boolBit(flag bool) uint {
+	if flag {
+		return 1
+	}
+	return 0
+}
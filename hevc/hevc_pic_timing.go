@@ -0,0 +1,168 @@
+package hevc
+
+import (
+	"fmt"
+
+	"github.com/go-webdl/media-codec/nalu"
+	"github.com/go-webdl/media-codec/sei"
+)
+
+// pic_struct values, ISO/IEC 23008-2 Table D.2
+const (
+	PIC_STRUCT_FRAME                  = byte(0)
+	PIC_STRUCT_TOP_FIELD              = byte(1)
+	PIC_STRUCT_BOTTOM_FIELD           = byte(2)
+	PIC_STRUCT_TOP_BOTTOM             = byte(3)
+	PIC_STRUCT_BOTTOM_TOP             = byte(4)
+	PIC_STRUCT_TOP_BOTTOM_TOP         = byte(5)
+	PIC_STRUCT_BOTTOM_TOP_BOTTOM      = byte(6)
+	PIC_STRUCT_FRAME_DOUBLING         = byte(7)
+	PIC_STRUCT_FRAME_TRIPLING         = byte(8)
+	PIC_STRUCT_TOP_PAIRED_PREV_BOTTOM = byte(9)
+	PIC_STRUCT_BOTTOM_PAIRED_PREV_TOP = byte(10)
+	PIC_STRUCT_TOP_PAIRED_NEXT_BOTTOM = byte(11)
+	PIC_STRUCT_BOTTOM_PAIRED_NEXT_TOP = byte(12)
+	maxPicStruct                      = PIC_STRUCT_BOTTOM_PAIRED_NEXT_TOP
+)
+
+// source_scan_type values, ISO/IEC 23008-2 Sec. D.3.3
+const (
+	SOURCE_SCAN_INTERLACED  = byte(0)
+	SOURCE_SCAN_PROGRESSIVE = byte(1)
+	SOURCE_SCAN_UNKNOWN     = byte(2)
+)
+
+// PicTiming - picture timing SEI message
+// ISO/IEC 23008-2 Sec. D.2.3
+type PicTiming struct {
+	// PicStruct, SourceScanType, DuplicateFlag - only valid if the SPS VUI
+	// has frame_field_info_present_flag set
+	PicStruct      byte
+	SourceScanType byte
+	DuplicateFlag  bool
+	// AuCpbRemovalDelayMinus1, PicDpbOutputDelay - only valid if the SPS VUI
+	// has NAL or VCL HRD parameters
+	AuCpbRemovalDelayMinus1 uint32
+	PicDpbOutputDelay       uint32
+	// PicDpbOutputDuDelay - only valid with sub-picture HRD parameters
+	PicDpbOutputDuDelay uint32
+	// DecodingUnits - only present with sub_pic_cpb_params_in_pic_timing_sei_flag
+	DecodingUnits []DecodingUnit
+}
+
+// DecodingUnit - NAL unit count and CPB removal delay increment of a decoding unit
+// CpbRemovalDelayIncrementMinus1 is the common one with
+// du_common_cpb_removal_delay_flag, and 0 for the last decoding unit otherwise.
+type DecodingUnit struct {
+	NumNalusMinus1                 uint32
+	CpbRemovalDelayIncrementMinus1 uint32
+}
+
+// ParsePicTiming - decode a picture timing SEI payload
+// sps must be the active SPS.
+func ParsePicTiming(payload []byte, sps *SPS) (*PicTiming, error) {
+	r := nalu.NewRBSPReader(payload)
+	pt := &PicTiming{}
+	vui := &sps.VUI
+	if !sps.VUIParametersPresentFlag {
+		vui = &VUIParameters{}
+	}
+	if vui.FrameFieldInfoPresentFlag {
+		pt.PicStruct = byte(r.Read(4))
+		pt.SourceScanType = byte(r.Read(2))
+		pt.DuplicateFlag = r.ReadFlag()
+		if pt.PicStruct > maxPicStruct {
+			return nil, fmt.Errorf("pic_struct %d reserved", pt.PicStruct)
+		}
+	}
+	hrd := &vui.HrdParameters
+	if !vui.HrdParametersPresentFlag || !(hrd.NalHrdParametersPresentFlag || hrd.VclHrdParametersPresentFlag) {
+		return pt, r.AccError()
+	}
+	pt.AuCpbRemovalDelayMinus1 = uint32(r.Read(int(hrd.AuCpbRemovalDelayLengthMinus1) + 1))
+	pt.PicDpbOutputDelay = uint32(r.Read(int(hrd.DpbOutputDelayLengthMinus1) + 1))
+	if !hrd.SubPicHrdParamsPresentFlag {
+		return pt, r.AccError()
+	}
+	pt.PicDpbOutputDuDelay = uint32(r.Read(int(hrd.DpbOutputDelayDuLengthMinus1) + 1))
+	if !hrd.SubPicCpbParamsInPicTimingSeiFlag {
+		return pt, r.AccError()
+	}
+	incrementLength := int(hrd.DuCpbRemovalDelayIncrementLengthMinus1) + 1
+	numDecodingUnitsMinus1 := r.ReadExpGolomb()
+	commonFlag := r.ReadFlag()
+	commonIncrement := uint32(0)
+	if commonFlag {
+		commonIncrement = uint32(r.Read(incrementLength))
+	}
+	if err := r.AccError(); err != nil {
+		return nil, err
+	}
+	// Each decoding unit holds at least one NAL unit
+	if numDecodingUnitsMinus1 >= uint(len(payload))*8 {
+		return nil, fmt.Errorf("pic_timing with %d decoding units", numDecodingUnitsMinus1+1)
+	}
+	pt.DecodingUnits = make([]DecodingUnit, numDecodingUnitsMinus1+1)
+	for i := range pt.DecodingUnits {
+		du := &pt.DecodingUnits[i]
+		du.NumNalusMinus1 = uint32(r.ReadExpGolomb())
+		switch {
+		case commonFlag:
+			du.CpbRemovalDelayIncrementMinus1 = commonIncrement
+		case i < len(pt.DecodingUnits)-1:
+			du.CpbRemovalDelayIncrementMinus1 = uint32(r.Read(incrementLength))
+		}
+	}
+	return pt, r.AccError()
+}
+
+// IsField - is the picture a single field
+func (pt *PicTiming) IsField() bool {
+	switch pt.PicStruct {
+	case PIC_STRUCT_TOP_FIELD, PIC_STRUCT_BOTTOM_FIELD,
+		PIC_STRUCT_TOP_PAIRED_PREV_BOTTOM, PIC_STRUCT_BOTTOM_PAIRED_PREV_TOP,
+		PIC_STRUCT_TOP_PAIRED_NEXT_BOTTOM, PIC_STRUCT_BOTTOM_PAIRED_NEXT_TOP:
+		return true
+	}
+	return false
+}
+
+// DisplayFieldPeriods - number of field periods the picture is displayed for
+// A frame lasts 2, a field 1, a frame with a repeated field 3, frame doubling
+// 4 and frame tripling 6. Dividing by 2 gives the duration in frame periods
+// for container timing.
+func (pt *PicTiming) DisplayFieldPeriods() int {
+	switch pt.PicStruct {
+	case PIC_STRUCT_TOP_BOTTOM_TOP, PIC_STRUCT_BOTTOM_TOP_BOTTOM:
+		return 3
+	case PIC_STRUCT_FRAME_DOUBLING:
+		return 4
+	case PIC_STRUCT_FRAME_TRIPLING:
+		return 6
+	}
+	if pt.IsField() {
+		return 1
+	}
+	return 2
+}
+
+// FindPicTiming - the picture timing SEI message among the prefix SEI NAL units of an access unit
+// sps must be the active SPS. ok is false if there is none.
+func FindPicTiming(nalus [][]byte, sps *SPS) (pt *PicTiming, ok bool, err error) {
+	for _, data := range nalus {
+		if len(data) < 2 || GetNaluType(data[0]) != NALU_SEI_PREFIX {
+			continue
+		}
+		msgs, err := ParseSEINALUnit(data)
+		if err != nil {
+			continue
+		}
+		for i := range msgs {
+			if msgs[i].PayloadType == sei.SEI_PIC_TIMING {
+				pt, err = ParsePicTiming(msgs[i].Payload, sps)
+				return pt, true, err
+			}
+		}
+	}
+	return nil, false, nil
+}
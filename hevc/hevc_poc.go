@@ -0,0 +1,96 @@
+package hevc
+
+import (
+	"sort"
+)
+
+// PictureOrder - picture order count of a coded picture
+// ISO/IEC 23008-2 Sec. 8.3.1
+type PictureOrder struct {
+	// PicOrderCnt - PicOrderCntVal of the picture
+	PicOrderCnt int32
+	// Reset - the picture is an IRAP picture with NoRaslOutputFlag set, i.e.
+	// an IDR or BLA picture or a CRA picture starting the stream or following
+	// an end of sequence. It starts a new POC period, so that later pictures
+	// are never presented before earlier ones of a previous period.
+	Reset bool
+}
+
+// POCCalculator - derives picture order counts of consecutive pictures
+// The zero value is ready to use. Pictures must be passed in decode order,
+// one slice segment header per picture, e.g. the first slice segment of each
+// picture. Only pass pictures of the base layer (nuh_layer_id 0).
+type POCCalculator struct {
+	prevPicOrderCntMsb int32
+	prevPicOrderCntLsb int32
+	// started - a picture has been computed; the first one after the start
+	// or an end of sequence has NoRaslOutputFlag set
+	started bool
+}
+
+// EndOfSequence - account for an end of sequence NAL unit
+// The next picture is an IRAP picture with NoRaslOutputFlag set.
+func (c *POCCalculator) EndOfSequence() {
+	c.started = false
+}
+
+// Compute - picture order count of the picture of slice segment header sh
+// sps must be the SPS the slice segment refers to.
+// RASL pictures associated with a picture reported as Reset are not output
+// by a decoder and should be dropped or never be presented.
+func (c *POCCalculator) Compute(sh *SliceSegmentHeader, sps *SPS) (po PictureOrder) {
+	naluType := sh.NaluType
+	noRaslOutputFlag := naluType.IsIDR() || naluType.IsBLA() || (naluType.IsIRAP() && !c.started)
+	c.started = true
+
+	maxPicOrderCntLsb := int32(1) << (sps.Log2MaxPicOrderCntLsbMinus4 + 4)
+	lsb := int32(sh.SlicePicOrderCntLsb)
+	msb := int32(0)
+	if !noRaslOutputFlag {
+		switch {
+		case lsb < c.prevPicOrderCntLsb && c.prevPicOrderCntLsb-lsb >= maxPicOrderCntLsb/2:
+			msb = c.prevPicOrderCntMsb + maxPicOrderCntLsb
+		case lsb > c.prevPicOrderCntLsb && lsb-c.prevPicOrderCntLsb > maxPicOrderCntLsb/2:
+			msb = c.prevPicOrderCntMsb - maxPicOrderCntLsb
+		default:
+			msb = c.prevPicOrderCntMsb
+		}
+	}
+	po.PicOrderCnt = msb + lsb
+	po.Reset = noRaslOutputFlag
+
+	// prevTid0Pic - TemporalId 0 and not a RASL, RADL or sub-layer non-reference picture
+	if sh.TemporalID == 0 && !naluType.IsLeadingPicture() && !naluType.IsSubLayerNonReference() {
+		c.prevPicOrderCntMsb = msb
+		c.prevPicOrderCntLsb = lsb
+	}
+	return po
+}
+
+// CompositionOffsets - composition time offsets of pictures in decode order
+// with a constant sample duration
+// Within each POC period pictures are presented in ascending PicOrderCnt
+// order, which puts leading pictures before their IRAP picture. Offsets may
+// be negative, as allowed by version 1 composition offset boxes; for version
+// 0 add the negated minimum and signal it with an edit.
+func CompositionOffsets(pictures []PictureOrder, duration uint32) []int64 {
+	offsets := make([]int64, len(pictures))
+	for start := 0; start < len(pictures); {
+		end := start + 1
+		for end < len(pictures) && !pictures[end].Reset {
+			end++
+		}
+		order := make([]int, end-start)
+		for i := range order {
+			order[i] = start + i
+		}
+		sort.SliceStable(order, func(i, j int) bool {
+			return pictures[order[i]].PicOrderCnt < pictures[order[j]].PicOrderCnt
+		})
+		for rank, i := range order {
+			offsets[i] = (int64(start+rank) - int64(i)) * int64(duration)
+		}
+		start = end
+	}
+	return offsets
+}
@@ -0,0 +1,175 @@
+package hevc
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/go-webdl/bits"
+)
+
+// PPS - HEVC PPS parameters
+// ISO/IEC 23008-2 Sec. 7.3.2.3
+// Syntax elements up to pps_extension_present_flag are decoded
+type PPS struct {
+	PpsID                             byte
+	SpsID                             byte
+	DependentSliceSegmentsEnabledFlag bool
+	OutputFlagPresentFlag             bool
+	NumExtraSliceHeaderBits           byte
+	SignDataHidingEnabledFlag         bool
+	CabacInitPresentFlag              bool
+	NumRefIdxL0DefaultActiveMinus1    byte
+	NumRefIdxL1DefaultActiveMinus1    byte
+	InitQpMinus26                     int32
+	ConstrainedIntraPredFlag          bool
+	TransformSkipEnabledFlag          bool
+	CuQpDeltaEnabledFlag              bool
+	DiffCuQpDeltaDepth                byte
+	CbQpOffset                        int32
+	CrQpOffset                        int32
+	SliceChromaQpOffsetsPresentFlag   bool
+	WeightedPredFlag                  bool
+	WeightedBipredFlag                bool
+	TransquantBypassEnabledFlag       bool
+	TilesEnabledFlag                  bool
+	EntropyCodingSyncEnabledFlag      bool
+	NumTileColumnsMinus1              uint32
+	NumTileRowsMinus1                 uint32
+	UniformSpacingFlag                bool
+	// ColumnWidthsMinus1, RowHeightsMinus1 - in CTBs, without the last
+	// column and row, only if not UniformSpacingFlag
+	ColumnWidthsMinus1                  []uint32
+	RowHeightsMinus1                    []uint32
+	LoopFilterAcrossTilesEnabledFlag    bool
+	LoopFilterAcrossSlicesEnabledFlag   bool
+	DeblockingFilterControlPresentFlag  bool
+	DeblockingFilterOverrideEnabledFlag bool
+	DeblockingFilterDisabledFlag        bool
+	BetaOffsetDiv2                      int32
+	TcOffsetDiv2                        int32
+	ScalingListDataPresentFlag          bool
+	// ScalingListData - only valid if ScalingListDataPresentFlag is set
+	ScalingListData                        ScalingListData
+	ListsModificationPresentFlag           bool
+	Log2ParallelMergeLevelMinus2           byte
+	SliceSegmentHeaderExtensionPresentFlag bool
+	ExtensionPresentFlag                   bool
+}
+
+// ParsePPSNALUnit - Parse HEVC PPS NAL unit starting with NAL unit header
+func ParsePPSNALUnit(data []byte) (*PPS, error) {
+	pps := &PPS{}
+
+	rd := bytes.NewReader(data)
+	r := bits.NewAccErrEBSPReader(rd)
+	// Note! First two bytes are NALU Header
+
+	naluHdrBits := r.Read(16)
+	naluType := GetNaluType(byte(naluHdrBits >> 8))
+	if naluType != NALU_PPS {
+		return nil, fmt.Errorf("NALU type is %s not PPS", naluType)
+	}
+	pps.PpsID = byte(r.ReadExpGolomb())
+	pps.SpsID = byte(r.ReadExpGolomb())
+	pps.DependentSliceSegmentsEnabledFlag = r.ReadFlag()
+	pps.OutputFlagPresentFlag = r.ReadFlag()
+	pps.NumExtraSliceHeaderBits = byte(r.Read(3))
+	pps.SignDataHidingEnabledFlag = r.ReadFlag()
+	pps.CabacInitPresentFlag = r.ReadFlag()
+	pps.NumRefIdxL0DefaultActiveMinus1 = byte(r.ReadExpGolomb())
+	pps.NumRefIdxL1DefaultActiveMinus1 = byte(r.ReadExpGolomb())
+	pps.InitQpMinus26 = int32(r.ReadSignedGolomb())
+	pps.ConstrainedIntraPredFlag = r.ReadFlag()
+	pps.TransformSkipEnabledFlag = r.ReadFlag()
+	pps.CuQpDeltaEnabledFlag = r.ReadFlag()
+	if pps.CuQpDeltaEnabledFlag {
+		pps.DiffCuQpDeltaDepth = byte(r.ReadExpGolomb())
+	}
+	pps.CbQpOffset = int32(r.ReadSignedGolomb())
+	pps.CrQpOffset = int32(r.ReadSignedGolomb())
+	pps.SliceChromaQpOffsetsPresentFlag = r.ReadFlag()
+	pps.WeightedPredFlag = r.ReadFlag()
+	pps.WeightedBipredFlag = r.ReadFlag()
+	pps.TransquantBypassEnabledFlag = r.ReadFlag()
+	pps.TilesEnabledFlag = r.ReadFlag()
+	pps.EntropyCodingSyncEnabledFlag = r.ReadFlag()
+	if pps.TilesEnabledFlag {
+		pps.NumTileColumnsMinus1 = uint32(r.ReadExpGolomb())
+		pps.NumTileRowsMinus1 = uint32(r.ReadExpGolomb())
+		if err := r.AccError(); err != nil {
+			return nil, err
+		}
+		// At most 20 tile columns and 22 tile rows, Table A.8
+		if pps.NumTileColumnsMinus1 >= 20 || pps.NumTileRowsMinus1 >= 22 {
+			return nil, fmt.Errorf("%dx%d tiles out of range", pps.NumTileColumnsMinus1+1, pps.NumTileRowsMinus1+1)
+		}
+		pps.UniformSpacingFlag = r.ReadFlag()
+		if !pps.UniformSpacingFlag {
+			pps.ColumnWidthsMinus1 = make([]uint32, pps.NumTileColumnsMinus1)
+			for i := range pps.ColumnWidthsMinus1 {
+				pps.ColumnWidthsMinus1[i] = uint32(r.ReadExpGolomb())
+			}
+			pps.RowHeightsMinus1 = make([]uint32, pps.NumTileRowsMinus1)
+			for i := range pps.RowHeightsMinus1 {
+				pps.RowHeightsMinus1[i] = uint32(r.ReadExpGolomb())
+			}
+		}
+		pps.LoopFilterAcrossTilesEnabledFlag = r.ReadFlag()
+	}
+	pps.LoopFilterAcrossSlicesEnabledFlag = r.ReadFlag()
+	pps.DeblockingFilterControlPresentFlag = r.ReadFlag()
+	if pps.DeblockingFilterControlPresentFlag {
+		pps.DeblockingFilterOverrideEnabledFlag = r.ReadFlag()
+		pps.DeblockingFilterDisabledFlag = r.ReadFlag()
+		if !pps.DeblockingFilterDisabledFlag {
+			pps.BetaOffsetDiv2 = int32(r.ReadSignedGolomb())
+			pps.TcOffsetDiv2 = int32(r.ReadSignedGolomb())
+		}
+	}
+	pps.ScalingListDataPresentFlag = r.ReadFlag()
+	if pps.ScalingListDataPresentFlag {
+		var err error
+		if pps.ScalingListData, err = readScalingListData(r); err != nil {
+			return nil, err
+		}
+	}
+	pps.ListsModificationPresentFlag = r.ReadFlag()
+	pps.Log2ParallelMergeLevelMinus2 = byte(r.ReadExpGolomb())
+	pps.SliceSegmentHeaderExtensionPresentFlag = r.ReadFlag()
+	pps.ExtensionPresentFlag = r.ReadFlag()
+
+	return pps, r.AccError()
+}
+
+// Parallelism types of the HEVCDecoderConfigurationRecord, ISO/IEC 14496-15 Sec. 8.3.3.1.2
+const (
+	PARALLELISM_MIXED     = byte(0)
+	PARALLELISM_SLICE     = byte(1)
+	PARALLELISM_TILE      = byte(2)
+	PARALLELISM_WAVEFRONT = byte(3)
+)
+
+// ParallelismType - parallelismType signalled by the PPSs
+// Tiles or wavefront parallel processing must be enabled by all PPSs, and
+// not both, for PARALLELISM_TILE or PARALLELISM_WAVEFRONT. Slice-based
+// parallelism cannot be told from the PPSs, so PARALLELISM_MIXED is
+// returned otherwise.
+func ParallelismType(ppsNalus [][]byte) (byte, error) {
+	tiles, wavefront := len(ppsNalus) > 0, len(ppsNalus) > 0
+	for i, nalu := range ppsNalus {
+		pps, err := ParsePPSNALUnit(nalu)
+		if err != nil {
+			return 0, fmt.Errorf("PPS %d: %w", i, err)
+		}
+		tiles = tiles && pps.TilesEnabledFlag && !pps.EntropyCodingSyncEnabledFlag
+		wavefront = wavefront && pps.EntropyCodingSyncEnabledFlag && !pps.TilesEnabledFlag
+	}
+	switch {
+	case tiles:
+		return PARALLELISM_TILE, nil
+	case wavefront:
+		return PARALLELISM_WAVEFRONT, nil
+	default:
+		return PARALLELISM_MIXED, nil
+	}
+}
@@ -0,0 +1,144 @@
+package hevc
+
+import (
+	"errors"
+	"io"
+
+	"github.com/go-webdl/media-codec/colr"
+	"github.com/go-webdl/media-codec/nalu"
+	"github.com/go-webdl/media-codec/sei"
+)
+
+const (
+	// DefaultProbeSize - bytes of a stream read by Probe at most
+	DefaultProbeSize = 8 << 20
+	// probeAccessUnits - access units searched for HDR metadata
+	probeAccessUnits = 30
+)
+
+// ProbeResult - properties of an Annex B stream found by Probe
+type ProbeResult struct {
+	// ProfileTierLevel - general profile, tier and level of the first SPS
+	ProfileTierLevel ProfileTierLevel
+	// Width, Height - displayed size in luma samples, after cropping
+	Width, Height uint32
+	// FrameRate - pictures per second from SPS or VPS timing info, 0 if not signalled
+	FrameRate float64
+	// ChromaFormat - 0 monochrome, 1 4:2:0, 2 4:2:2, 3 4:4:4
+	ChromaFormat   byte
+	BitDepthLuma   byte
+	BitDepthChroma byte
+	// Colour - colour description of the VUI, unspecified without one. The
+	// transfer characteristics are those preferred by an
+	// alternative_transfer_characteristics SEI message, e.g. HLG for streams
+	// signalling BT.2020 10-bit for backwards compatibility.
+	Colour colr.NCLX
+	// MasteringDisplay, ContentLightLevel - static HDR10 metadata, nil if absent
+	MasteringDisplay  *sei.MasteringDisplayColourVolume
+	ContentLightLevel *sei.ContentLightLevelInfo
+	// HDR10Plus - HDR10+ dynamic metadata is present
+	HDR10Plus bool
+	// DolbyVision - Dolby Vision RPU NAL units are present
+	DolbyVision bool
+	// SPS - the first SPS of the stream
+	SPS *SPS
+}
+
+// IsHDR - does the stream use the PQ or HLG transfer function or carry
+// HDR metadata
+func (p *ProbeResult) IsHDR() bool {
+	return p.Colour.IsHDR() || p.MasteringDisplay != nil || p.HDR10Plus || p.DolbyVision
+}
+
+// Probe - properties of an HEVC Annex B stream from its first
+// DefaultProbeSize bytes
+// NAL units before the first SPS are skipped, so the stream may start
+// anywhere. The SEI messages of up to 30 access units from there are searched
+// for HDR metadata.
+func Probe(r io.Reader) (*ProbeResult, error) {
+	return ProbeN(r, DefaultProbeSize)
+}
+
+// ProbeN - Probe reading at most maxBytes bytes, DefaultProbeSize if maxBytes is 0
+func ProbeN(r io.Reader, maxBytes int64) (*ProbeResult, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultProbeSize
+	}
+	s := nalu.NewScanner(io.LimitReader(r, maxBytes))
+	var d accessUnitDetector
+	vpss := make(map[byte]*VPS)
+	p := &ProbeResult{}
+	var atc *sei.AlternativeTransferCharacteristics
+	accessUnits := 0
+	for accessUnits < probeAccessUnits && s.Scan() {
+		data := s.NALU()
+		if len(data) < 2 {
+			continue
+		}
+		naluType := GetNaluType(data[0])
+		if naluType == NALU_VPS {
+			// A VPS may precede the first SPS and provide its timing
+			if vps, err := ParseVPSNALUnit(data); err == nil {
+				vpss[vps.VpsID] = vps
+			}
+		}
+		if p.SPS == nil {
+			if naluType != NALU_SPS {
+				continue
+			}
+			sps, err := ParseSPSNALUnit(data)
+			if err != nil {
+				return nil, err
+			}
+			p.SPS = sps
+		}
+		if d.next(data) {
+			accessUnits++
+		}
+		switch naluType {
+		case NALU_SEI_PREFIX:
+			msgs, err := ParseSEINALUnit(data)
+			if err != nil {
+				continue
+			}
+			if m, ok := sei.FindMasteringDisplayColourVolume(msgs); ok && p.MasteringDisplay == nil {
+				p.MasteringDisplay = m
+			}
+			if c, ok := sei.FindContentLightLevelInfo(msgs); ok && p.ContentLightLevel == nil {
+				p.ContentLightLevel = c
+			}
+			if a, ok := sei.FindAlternativeTransferCharacteristics(msgs); ok && atc == nil {
+				atc = a
+			}
+			if _, ok, _ := sei.FindHDR10Plus(msgs); ok {
+				p.HDR10Plus = true
+			}
+		case NALU_UNSPEC62:
+			// Dolby Vision RPU, ETSI GS CCM 001
+			p.DolbyVision = true
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if p.SPS == nil {
+		return nil, errors.New("no SPS in stream")
+	}
+
+	sps := p.SPS
+	p.ProfileTierLevel = sps.ProfileTierLevel
+	p.ProfileTierLevel.SubLayers = nil
+	p.Width, p.Height = sps.ImageSize()
+	if sps.VUIParametersPresentFlag {
+		p.FrameRate = sps.VUI.FrameRate()
+	}
+	if vps, ok := vpss[sps.VpsID]; ok && p.FrameRate == 0 {
+		p.FrameRate = vps.FrameRate()
+	}
+	p.ChromaFormat = sps.ChromaFormatIndicator
+	p.BitDepthLuma = sps.BitDepthLumaMinus8 + 8
+	p.BitDepthChroma = sps.BitDepthChromaMinus8 + 8
+	p.Colour = sps.NCLX()
+	p.Colour.TransferCharacteristics = uint16(sei.EffectiveTransferCharacteristics(byte(p.Colour.TransferCharacteristics), atc))
+	return p, nil
+}
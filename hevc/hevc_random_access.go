@@ -0,0 +1,140 @@
+package hevc
+
+// IsIDR - is NAL unit type an IDR picture, IDR_W_RADL or IDR_N_LP
+func (n NaluType) IsIDR() bool {
+	return n == NALU_IDR_W_RADL || n == NALU_IDR_N_LP
+}
+
+// IsCRA - is NAL unit type a CRA picture
+func (n NaluType) IsCRA() bool {
+	return n == NALU_CRA
+}
+
+// IsBLA - is NAL unit type a BLA picture, BLA_W_LP, BLA_W_RADL or BLA_N_LP
+func (n NaluType) IsBLA() bool {
+	return NALU_BLA_W_LP <= n && n <= NALU_BLA_N_LP
+}
+
+// IsRADL - is NAL unit type a random access decodable leading picture
+func (n NaluType) IsRADL() bool {
+	return n == NALU_RADL_N || n == NALU_RADL_R
+}
+
+// IsRASL - is NAL unit type a random access skipped leading picture
+func (n NaluType) IsRASL() bool {
+	return n == NALU_RASL_N || n == NALU_RASL_R
+}
+
+// IsLeadingPicture - is NAL unit type a leading picture, RADL or RASL
+// Leading pictures follow an IRAP picture in decode order but precede it in
+// output order. RASL pictures reference pictures before the IRAP picture
+// and are discarded when decoding starts there; RADL pictures are not.
+func (n NaluType) IsLeadingPicture() bool {
+	return n.IsRADL() || n.IsRASL()
+}
+
+// AllowedLeadingPictures - kinds of leading pictures an IRAP picture of the NAL unit type may have
+// IDR_N_LP and BLA_N_LP have none, IDR_W_RADL and BLA_W_RADL only RADL
+// pictures, CRA and BLA_W_LP both. Other types have none.
+func (n NaluType) AllowedLeadingPictures() (radl, rasl bool) {
+	switch n {
+	case NALU_IDR_W_RADL, NALU_BLA_W_RADL:
+		return true, false
+	case NALU_CRA, NALU_BLA_W_LP:
+		return true, true
+	default:
+		return false, false
+	}
+}
+
+// PictureType - NAL unit type of the first VCL NAL unit of an access unit
+// ok is false for access units without VCL NAL units.
+func PictureType(nalus [][]byte) (naluType NaluType, ok bool) {
+	for _, nalu := range nalus {
+		if len(nalu) == 0 {
+			continue
+		}
+		if naluType = GetNaluType(nalu[0]); naluType.IsVCL() {
+			return naluType, true
+		}
+	}
+	return 0, false
+}
+
+// IsSyncAccessUnit - is the access unit an IRAP picture
+// ISO/IEC 14496-15 makes IRAP pictures sync samples. For CRA and BLA_W_LP
+// pictures RASL pictures may follow, which are not decodable when starting
+// there; use RandomAccessAnalysis to find out.
+func IsSyncAccessUnit(nalus [][]byte) bool {
+	naluType, ok := PictureType(nalus)
+	return ok && naluType.IsIRAP()
+}
+
+// IRAPPicture - an IRAP access unit and the leading pictures following it
+type IRAPPicture struct {
+	// Index - access unit index in decode order
+	Index    int
+	NaluType NaluType
+	// RADL, RASL - leading access units of each kind following the IRAP picture
+	RADL int
+	RASL int
+}
+
+// IsClean - does decoding from the IRAP picture output all following pictures
+// This is the case without RASL pictures.
+func (p *IRAPPicture) IsClean() bool {
+	return p.RASL == 0
+}
+
+// SAPType - stream access point type of ISO/IEC 14496-12 Annex I
+// 1 without leading pictures, 2 with RADL pictures only and 3 with RASL pictures.
+func (p *IRAPPicture) SAPType() int {
+	switch {
+	case p.RASL > 0:
+		return 3
+	case p.RADL > 0:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// RandomAccessAnalysis - IRAP pictures of a stream, built access unit by access unit in decode order
+// Leading pictures before the first IRAP picture are not counted.
+type RandomAccessAnalysis struct {
+	AccessUnits int
+	IRAPs       []IRAPPicture
+	// leading - leading pictures may still follow the last IRAP picture
+	leading bool
+}
+
+// AddAccessUnit - account for the NAL units of the next access unit in decode order
+func (a *RandomAccessAnalysis) AddAccessUnit(nalus [][]byte) {
+	naluType, ok := PictureType(nalus)
+	switch {
+	case !ok:
+	case naluType.IsIRAP():
+		a.IRAPs = append(a.IRAPs, IRAPPicture{Index: a.AccessUnits, NaluType: naluType})
+		a.leading = true
+	case naluType.IsLeadingPicture() && a.leading:
+		last := &a.IRAPs[len(a.IRAPs)-1]
+		if naluType.IsRASL() {
+			last.RASL++
+		} else {
+			last.RADL++
+		}
+	default:
+		a.leading = false
+	}
+	a.AccessUnits++
+}
+
+// CleanIRAPs - access unit indices of the IRAP pictures without RASL pictures
+func (a *RandomAccessAnalysis) CleanIRAPs() (indices []int) {
+	for i := range a.IRAPs {
+		if a.IRAPs[i].IsClean() {
+			indices = append(indices, a.IRAPs[i].Index)
+		}
+	}
+	return indices
+}
@@ -0,0 +1,125 @@
+package hevc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrRecordArenaFull - a record has more arrays or NAL units than the arena can hold
+var ErrRecordArenaFull = errors.New("record arena full")
+
+// RecordArena - caller-owned storage for the arrays and NAL unit slices of records
+// A record of size bytes needs at most MaxRecordArrays(size) arrays and
+// MaxRecordNALUs(size) NAL units, taking 32 and 24 bytes each on 64-bit
+// platforms. Smaller limits make RecordReadArena fail with
+// ErrRecordArenaFull instead of growing.
+type RecordArena struct {
+	arrays []NaluArray
+	nalus  [][]byte
+}
+
+// NewRecordArena - arena for at most maxArrays arrays and maxNALUs NAL units
+func NewRecordArena(maxArrays, maxNALUs int) *RecordArena {
+	return &RecordArena{
+		arrays: make([]NaluArray, 0, maxArrays),
+		nalus:  make([][]byte, 0, maxNALUs),
+	}
+}
+
+// MaxRecordArrays - upper bound of the number of arrays in a record of size bytes
+func MaxRecordArrays(size int) int {
+	n := (size - 23) / 3
+	if n < 0 {
+		return 0
+	}
+	if n > 255 {
+		return 255
+	}
+	return n
+}
+
+// MaxRecordNALUs - upper bound of the number of NAL units in a record of size bytes
+func MaxRecordNALUs(size int) int {
+	n := (size - 23 - 3) / 2
+	if n < 0 {
+		return 0
+	}
+	return n
+}
+
+// Reset - make all entries available again
+// Records previously read into the arena must not be used afterwards.
+func (a *RecordArena) Reset() {
+	a.arrays = a.arrays[:0]
+	a.nalus = a.nalus[:0]
+}
+
+// RecordReadArena - decode the record in data without allocating, taking its arrays from arena
+// NAL units and extension data alias data, which must not be modified while the record is in use.
+func (b *HEVCDecoderConfigurationRecord) RecordReadArena(data []byte, arena *RecordArena) (err error) {
+	if len(data) < 23 {
+		return io.ErrUnexpectedEOF
+	}
+	b.ConfigurationVersion = data[0]
+	b.GeneralProfileSpace = data[1] >> 6
+	b.GeneralTierFlag = ((data[1] >> 5) & 0b1) > 0
+	b.GenertalProfileIndicator = data[1] & 0b11111
+	b.GeneralProfileCompatibilityFlags = binary.BigEndian.Uint32(data[2:])
+	b.GeneralConstraintIndicatorFlags = uint64(binary.BigEndian.Uint16(data[6:]))<<32 | uint64(binary.BigEndian.Uint32(data[8:]))
+	b.GeneralLevelIndicator = data[12]
+	b.MinSpatialSegmentationIndicator = binary.BigEndian.Uint16(data[13:]) & 0x0fff
+	b.ParallelismType = data[15] & 0b11
+	b.ChromaFormatIndicator = data[16] & 0b11
+	b.BitDepthLumaMinus8 = data[17] & 0b111
+	b.BitDepthChromaMinus8 = data[18] & 0b111
+	b.AvgFrameRate = binary.BigEndian.Uint16(data[19:])
+	b.ConstantFrameRate = data[21] >> 6
+	b.NumTemporalLayers = (data[21] >> 3) & 0b111
+	b.TemporalIDNested = (data[21] >> 2) & 0b1
+	b.LengthSizeMinusOne = data[21] & 0b11
+	b.debugHeader(data[:23])
+	numOfArrays := int(data[22])
+	pos := 23
+
+	if cap(arena.arrays)-len(arena.arrays) < numOfArrays {
+		return fmt.Errorf("%w: %d arrays", ErrRecordArenaFull, numOfArrays)
+	}
+	arraysStart := len(arena.arrays)
+	for i := 0; i < numOfArrays; i++ {
+		if len(data)-pos < 3 {
+			return io.ErrUnexpectedEOF
+		}
+		array := NaluArray{
+			ArrayCompleteness: data[pos]>>7 > 0,
+			NALUnitType:       NaluType(data[pos] & 0b111111),
+		}
+		numNalus := int(binary.BigEndian.Uint16(data[pos+1:]))
+		pos += 3
+		if cap(arena.nalus)-len(arena.nalus) < numNalus {
+			return fmt.Errorf("%w: %d NAL units", ErrRecordArenaFull, numNalus)
+		}
+		nalusStart := len(arena.nalus)
+		for j := 0; j < numNalus; j++ {
+			if len(data)-pos < 2 {
+				return io.ErrUnexpectedEOF
+			}
+			length := int(binary.BigEndian.Uint16(data[pos:]))
+			pos += 2
+			if len(data)-pos < length {
+				return io.ErrUnexpectedEOF
+			}
+			arena.nalus = append(arena.nalus, data[pos:pos+length:pos+length])
+			pos += length
+		}
+		array.NALUs = arena.nalus[nalusStart:len(arena.nalus):len(arena.nalus)]
+		arena.arrays = append(arena.arrays, array)
+	}
+	b.NaluArrays = arena.arrays[arraysStart:len(arena.arrays):len(arena.arrays)]
+	b.ExtensionData = nil
+	if pos < len(data) {
+		b.ExtensionData = data[pos:len(data):len(data)]
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package hevc
+
+import (
+	"fmt"
+	"math"
+)
+
+// Constant frame rate values of the HEVCDecoderConfigurationRecord, ISO/IEC 14496-15 Sec. 8.3.3.1.2
+const (
+	CONSTANT_FRAME_RATE_UNKNOWN   = uint8(0)
+	CONSTANT_FRAME_RATE           = uint8(1)
+	CONSTANT_FRAME_RATE_PER_LAYER = uint8(2)
+)
+
+// FrameRate - frames per second from VUI timing info, 0 if not signalled
+// For field sequences, with field_seq_flag, this is the field rate.
+func (v *VUIParameters) FrameRate() float64 {
+	if !v.TimingInfoPresentFlag || v.NumUnitsInTick == 0 {
+		return 0
+	}
+	return float64(v.TimeScale) / float64(v.NumUnitsInTick)
+}
+
+// FrameRate - frames per second from VPS timing info, 0 if not signalled
+func (v *VPS) FrameRate() float64 {
+	if !v.TimingInfoPresentFlag || v.NumUnitsInTick == 0 {
+		return 0
+	}
+	return float64(v.TimeScale) / float64(v.NumUnitsInTick)
+}
+
+// setDerivedFields - set the record fields derived from the parameter sets
+// min_spatial_segmentation_idc is the lowest of all SPSs, 0 if one has no
+// bitstream restriction. The frame rate comes from the VUI of the first SPS
+// or else the VPS of the first SPS, constancy from the HRD parameters of the
+// highest sub-layer. Temporal layers and nesting must hold for all SPSs.
+func (b *HEVCDecoderConfigurationRecord) setDerivedFields(vpsNalus, spsNalus, ppsNalus [][]byte) error {
+	vpss := make(map[byte]*VPS)
+	for _, nalu := range vpsNalus {
+		// The VPS only provides fallback timing, so unparsable ones are skipped
+		if vps, err := ParseVPSNALUnit(nalu); err == nil {
+			vpss[vps.VpsID] = vps
+		}
+	}
+	var err error
+	if b.ParallelismType, err = ParallelismType(ppsNalus); err != nil {
+		return err
+	}
+	b.MinSpatialSegmentationIndicator = 0
+	b.NumTemporalLayers = 0
+	b.TemporalIDNested = 1
+	for i, nalu := range spsNalus {
+		sps, err := ParseSPSNALUnit(nalu)
+		if err != nil {
+			return fmt.Errorf("SPS %d: %w", i, err)
+		}
+		minSpatialSegmentation := uint16(0)
+		if sps.VUIParametersPresentFlag && sps.VUI.BitstreamRestrictionFlag {
+			// 12 bits in the record, larger values are not allowed
+			minSpatialSegmentation = sps.VUI.MinSpatialSegmentationIdc & 0xfff
+		}
+		if i == 0 || minSpatialSegmentation < b.MinSpatialSegmentationIndicator {
+			b.MinSpatialSegmentationIndicator = minSpatialSegmentation
+		}
+		if sps.MaxSubLayersMinus1+1 > b.NumTemporalLayers {
+			b.NumTemporalLayers = sps.MaxSubLayersMinus1 + 1
+		}
+		if !sps.TemporalIdNestingFlag {
+			b.TemporalIDNested = 0
+		}
+		if i == 0 {
+			b.setFrameRate(sps, vpss[sps.VpsID])
+		}
+	}
+	return nil
+}
+
+// setFrameRate - avgFrameRate and constantFrameRate from SPS or VPS timing
+// With a fixed picture rate, pictures last elemental_duration_in_tc_minus1+1
+// clock ticks. numTemporalLayers must be set before.
+func (b *HEVCDecoderConfigurationRecord) setFrameRate(sps *SPS, vps *VPS) {
+	b.AvgFrameRate = 0
+	b.ConstantFrameRate = CONSTANT_FRAME_RATE_UNKNOWN
+	frameRate := 0.0
+	var subLayers []SubLayerHRD
+	if sps.VUIParametersPresentFlag {
+		frameRate = sps.VUI.FrameRate()
+		if sps.VUI.HrdParametersPresentFlag {
+			subLayers = sps.VUI.HrdParameters.SubLayers
+		}
+	}
+	// Sub-layers above numTemporalLayers are not in the stream
+	if n := int(b.NumTemporalLayers); n > 0 && len(subLayers) > n {
+		subLayers = subLayers[:n]
+	}
+	if frameRate == 0 && vps != nil {
+		frameRate = vps.FrameRate()
+	}
+	if len(subLayers) > 0 && subLayers[len(subLayers)-1].FixedPicRateWithinCvsFlag {
+		highest := subLayers[len(subLayers)-1]
+		frameRate /= float64(highest.ElementalDurationInTcMinus1) + 1
+		b.ConstantFrameRate = CONSTANT_FRAME_RATE
+		perLayer := len(subLayers) > 1
+		for _, sl := range subLayers {
+			perLayer = perLayer && sl.FixedPicRateWithinCvsFlag
+		}
+		if perLayer {
+			b.ConstantFrameRate = CONSTANT_FRAME_RATE_PER_LAYER
+		}
+	}
+	// avgFrameRate is in frames per 256 seconds
+	if avg := math.Round(frameRate * 256); avg <= math.MaxUint16 {
+		b.AvgFrameRate = uint16(avg)
+	}
+}
+
+// LengthSize - bytes of the NAL unit length fields of samples, 1, 2 or 4
+func (b *HEVCDecoderConfigurationRecord) LengthSize() int {
+	return int(b.LengthSizeMinusOne&0b11) + 1
+}
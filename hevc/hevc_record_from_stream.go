@@ -0,0 +1,139 @@
+package hevc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/go-webdl/media-codec/nalu"
+)
+
+// CreateHEVCDecoderConfigurationRecordFromStream - build a record covering
+// all parameter sets of an Annex B elementary stream
+// The whole stream is scanned first and every distinct VPS, SPS and PPS is
+// collected. A parameter set id reused with different content, SPSs
+// disagreeing on chroma format or bit depths, and references to missing
+// parameter sets are errors since no single record can describe them. Profile
+// compatibility and constraint flags are the intersection over all SPSs, the
+// tier is the highest signalled and the level is raised to the highest
+// signalled or required by the picture size and DPB size of an SPS. The
+// temporal layer fields are set from the SPSs and all arrays are complete.
+func CreateHEVCDecoderConfigurationRecordFromStream(r io.Reader) (HEVCDecoderConfigurationRecord, error) {
+	sets := map[NaluType]map[byte][]byte{
+		NALU_VPS: make(map[byte][]byte),
+		NALU_SPS: make(map[byte][]byte),
+		NALU_PPS: make(map[byte][]byte),
+	}
+	s := nalu.NewScanner(r)
+	for s.Scan() {
+		data := s.NALU()
+		if len(data) < 3 {
+			continue
+		}
+		naluType := GetNaluType(data[0])
+		var id byte
+		switch naluType {
+		case NALU_VPS:
+			// vps_video_parameter_set_id follows the NAL unit header
+			id = data[2] >> 4
+		case NALU_SPS:
+			sps, err := ParseSPSNALUnit(data)
+			if err != nil {
+				return HEVCDecoderConfigurationRecord{}, err
+			}
+			id = sps.SpsID
+		case NALU_PPS:
+			pps, err := ParsePPSNALUnit(data)
+			if err != nil {
+				return HEVCDecoderConfigurationRecord{}, err
+			}
+			id = pps.PpsID
+		default:
+			continue
+		}
+		if existing, ok := sets[naluType][id]; ok {
+			if !bytes.Equal(existing, data) {
+				return HEVCDecoderConfigurationRecord{}, fmt.Errorf("%s %d changes within the stream", naluType, id)
+			}
+			continue
+		}
+		sets[naluType][id] = append([]byte(nil), data...)
+	}
+	if err := s.Err(); err != nil {
+		return HEVCDecoderConfigurationRecord{}, err
+	}
+	for _, naluType := range []NaluType{NALU_VPS, NALU_SPS, NALU_PPS} {
+		if len(sets[naluType]) == 0 {
+			return HEVCDecoderConfigurationRecord{}, fmt.Errorf("no %s NAL units", naluType)
+		}
+	}
+
+	vpss := sortedParameterSets(sets[NALU_VPS])
+	spss := sortedParameterSets(sets[NALU_SPS])
+	ppss := sortedParameterSets(sets[NALU_PPS])
+	rec, err := CreateHEVCDecoderConfigurationRecord(vpss, spss, ppss, true, true, true)
+	if err != nil {
+		return HEVCDecoderConfigurationRecord{}, err
+	}
+
+	for _, data := range spss {
+		sps, err := ParseSPSNALUnit(data)
+		if err != nil {
+			return HEVCDecoderConfigurationRecord{}, err
+		}
+		if _, ok := sets[NALU_VPS][sps.VpsID]; !ok {
+			return HEVCDecoderConfigurationRecord{}, fmt.Errorf("SPS %d: VPS %d not found", sps.SpsID, sps.VpsID)
+		}
+		if sps.ChromaFormatIndicator != rec.ChromaFormatIndicator ||
+			sps.BitDepthLumaMinus8 != rec.BitDepthLumaMinus8 || sps.BitDepthChromaMinus8 != rec.BitDepthChromaMinus8 {
+			return HEVCDecoderConfigurationRecord{}, fmt.Errorf("SPS %d has chroma format %d and bit depths %d/%d, record has %d and %d/%d",
+				sps.SpsID, sps.ChromaFormatIndicator, sps.BitDepthLumaMinus8+8, sps.BitDepthChromaMinus8+8,
+				rec.ChromaFormatIndicator, rec.BitDepthLumaMinus8+8, rec.BitDepthChromaMinus8+8)
+		}
+		level, err := sps.requiredLevel(rec.GeneralTierFlag)
+		if err != nil {
+			return HEVCDecoderConfigurationRecord{}, fmt.Errorf("SPS %d: %w", sps.SpsID, err)
+		}
+		if level > rec.GeneralLevelIndicator {
+			rec.GeneralLevelIndicator = level
+		}
+	}
+	for _, data := range ppss {
+		pps, err := ParsePPSNALUnit(data)
+		if err != nil {
+			return HEVCDecoderConfigurationRecord{}, err
+		}
+		if _, ok := sets[NALU_SPS][pps.SpsID]; !ok {
+			return HEVCDecoderConfigurationRecord{}, fmt.Errorf("PPS %d: SPS %d not found", pps.PpsID, pps.SpsID)
+		}
+	}
+	return rec, nil
+}
+
+// requiredLevel - lowest level of the tier satisfying the requirements of
+// the SPS, see MinLevelAndTier
+func (s *SPS) requiredLevel(highTier bool) (byte, error) {
+	req := s.LevelRequirements()
+	req.FixedTier, req.HighTier = true, highTier
+	limits, _, ok := MinLevelAndTier(req)
+	if !ok {
+		return 0, errors.New("parameters exceed all levels")
+	}
+	return limits.LevelIndicator, nil
+}
+
+// sortedParameterSets - NAL units in ascending parameter set id order
+func sortedParameterSets(sets map[byte][]byte) [][]byte {
+	ids := make([]int, 0, len(sets))
+	for id := range sets {
+		ids = append(ids, int(id))
+	}
+	sort.Ints(ids)
+	nalus := make([][]byte, 0, len(ids))
+	for _, id := range ids {
+		nalus = append(nalus, sets[byte(id)])
+	}
+	return nalus
+}
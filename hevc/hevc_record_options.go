@@ -0,0 +1,73 @@
+package hevc
+
+import (
+	"fmt"
+)
+
+// RecordOption - optional content of a record created by CreateHEVCDecoderConfigurationRecord
+type RecordOption func(b *HEVCDecoderConfigurationRecord) error
+
+// WithLengthSize - use NAL unit length fields of lengthSize bytes, 1, 2 or 4
+// instead of 4
+// Samples must then be written with the record's AppendSample or be
+// rewritten with nalu.ConvertLengthSize; NAL units longer than the length
+// fields allow, 255 or 65535 bytes, make that fail.
+func WithLengthSize(lengthSize int) RecordOption {
+	return func(b *HEVCDecoderConfigurationRecord) error {
+		if lengthSize != 1 && lengthSize != 2 && lengthSize != 4 {
+			return fmt.Errorf("invalid NAL unit length size %d", lengthSize)
+		}
+		b.LengthSizeMinusOne = byte(lengthSize - 1)
+		return nil
+	}
+}
+
+// WithPrefixSEI - add a prefix SEI array with nalus, e.g. declarative SEI
+// such as mastering display colour volume or content light level that apply
+// to the whole stream
+// The array is not complete, so the SEI messages may also occur in samples.
+func WithPrefixSEI(nalus [][]byte) RecordOption {
+	return withSEIArray(NALU_SEI_PREFIX, nalus)
+}
+
+// WithSuffixSEI - add a suffix SEI array with nalus
+// The array is not complete, so the SEI messages may also occur in samples.
+func WithSuffixSEI(nalus [][]byte) RecordOption {
+	return withSEIArray(NALU_SEI_SUFFIX, nalus)
+}
+
+// withSEIArray - add an array of SEI NAL units of naluType after the existing
+// arrays, keeping the recommended order VPS, SPS, PPS, prefix SEI, suffix SEI
+// No array is added without NAL units.
+func withSEIArray(naluType NaluType, nalus [][]byte) RecordOption {
+	return func(b *HEVCDecoderConfigurationRecord) error {
+		if len(nalus) == 0 {
+			return nil
+		}
+		for i, data := range nalus {
+			if len(data) < 2 {
+				return fmt.Errorf("%s NAL unit %d truncated", naluType, i)
+			}
+			if t := GetNaluType(data[0]); t != naluType {
+				return fmt.Errorf("NAL unit %d is %s, not %s", i, t, naluType)
+			}
+			if len(data) > 0xffff {
+				return fmt.Errorf("%s NAL unit %d of %d bytes too long for record", naluType, i, len(data))
+			}
+		}
+		array := NaluArray{NALUnitType: naluType, NALUs: nalus}
+		pos := len(b.NaluArrays)
+		if naluType == NALU_SEI_PREFIX {
+			for i := range b.NaluArrays {
+				if b.NaluArrays[i].NALUnitType == NALU_SEI_SUFFIX {
+					pos = i
+					break
+				}
+			}
+		}
+		b.NaluArrays = append(b.NaluArrays, NaluArray{})
+		copy(b.NaluArrays[pos+1:], b.NaluArrays[pos:])
+		b.NaluArrays[pos] = array
+		return nil
+	}
+}
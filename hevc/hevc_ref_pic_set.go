@@ -0,0 +1,129 @@
+package hevc
+
+import (
+	"fmt"
+
+	"github.com/go-webdl/bits"
+)
+
+// maxShortTermRefPicSets - upper bound of num_short_term_ref_pic_sets
+const maxShortTermRefPicSets = 64
+
+// ShortTermRefPicSet - st_ref_pic_set() with the derived picture order count deltas
+// ISO/IEC 23008-2 Sec. 7.3.7 and 7.4.8
+type ShortTermRefPicSet struct {
+	InterRefPicSetPredictionFlag bool
+	// DeltaPocS0 - negative POC deltas of pictures before the current one, closest first
+	DeltaPocS0      []int32
+	UsedByCurrPicS0 []bool
+	// DeltaPocS1 - positive POC deltas of pictures after the current one, closest first
+	DeltaPocS1      []int32
+	UsedByCurrPicS1 []bool
+	// DeltaIdxMinus1, DeltaRps, UsedByCurrPicFlags, UseDeltaFlags - the
+	// prediction from another set as coded, only valid with
+	// InterRefPicSetPredictionFlag. DeltaIdxMinus1 is only coded in slice
+	// headers.
+	DeltaIdxMinus1     uint32
+	DeltaRps           int32
+	UsedByCurrPicFlags []bool
+	UseDeltaFlags      []bool
+}
+
+// NumDeltaPocs - number of pictures in the set
+func (s *ShortTermRefPicSet) NumDeltaPocs() int {
+	return len(s.DeltaPocS0) + len(s.DeltaPocS1)
+}
+
+// readShortTermRefPicSet - read st_ref_pic_set(stRpsIdx)
+// sets are the sets of the SPS decoded so far. stRpsIdx equal to
+// numShortTermRefPicSets, as in a slice header, may predict from any of them.
+func readShortTermRefPicSet(r *bits.AccErrEBSPReader, stRpsIdx, numShortTermRefPicSets int, sets []ShortTermRefPicSet) (rps ShortTermRefPicSet, err error) {
+	if stRpsIdx != 0 {
+		rps.InterRefPicSetPredictionFlag = r.ReadFlag()
+	}
+	if !rps.InterRefPicSetPredictionFlag {
+		numNegativePics := r.ReadExpGolomb()
+		numPositivePics := r.ReadExpGolomb()
+		if err := r.AccError(); err != nil {
+			return rps, err
+		}
+		if numNegativePics > 16 || numPositivePics > 16-numNegativePics {
+			return rps, fmt.Errorf("st_ref_pic_set with %d+%d pictures out of range", numNegativePics, numPositivePics)
+		}
+		poc := int32(0)
+		for i := 0; i < int(numNegativePics); i++ {
+			poc -= int32(r.ReadExpGolomb()) + 1
+			rps.DeltaPocS0 = append(rps.DeltaPocS0, poc)
+			rps.UsedByCurrPicS0 = append(rps.UsedByCurrPicS0, r.ReadFlag())
+		}
+		poc = 0
+		for i := 0; i < int(numPositivePics); i++ {
+			poc += int32(r.ReadExpGolomb()) + 1
+			rps.DeltaPocS1 = append(rps.DeltaPocS1, poc)
+			rps.UsedByCurrPicS1 = append(rps.UsedByCurrPicS1, r.ReadFlag())
+		}
+		return rps, r.AccError()
+	}
+
+	if stRpsIdx == numShortTermRefPicSets {
+		rps.DeltaIdxMinus1 = uint32(r.ReadExpGolomb())
+	}
+	refRpsIdx := stRpsIdx - (int(rps.DeltaIdxMinus1) + 1)
+	if refRpsIdx < 0 || refRpsIdx >= len(sets) {
+		return rps, fmt.Errorf("st_ref_pic_set %d predicted from missing set %d", stRpsIdx, refRpsIdx)
+	}
+	ref := &sets[refRpsIdx]
+	deltaRpsSign := r.ReadFlag()
+	deltaRps := int32(r.ReadExpGolomb()) + 1
+	if deltaRpsSign {
+		deltaRps = -deltaRps
+	}
+	rps.DeltaRps = deltaRps
+	numRefDeltaPocs := ref.NumDeltaPocs()
+	usedByCurrPic := make([]bool, numRefDeltaPocs+1)
+	useDelta := make([]bool, numRefDeltaPocs+1)
+	for j := range usedByCurrPic {
+		usedByCurrPic[j] = r.ReadFlag()
+		useDelta[j] = true
+		if !usedByCurrPic[j] {
+			useDelta[j] = r.ReadFlag()
+		}
+	}
+	if err := r.AccError(); err != nil {
+		return rps, err
+	}
+	rps.UsedByCurrPicFlags, rps.UseDeltaFlags = usedByCurrPic, useDelta
+
+	// Eq. 7-61 and 7-62, entries j of the reference set are ordered S0 then S1
+	numNeg := len(ref.DeltaPocS0)
+	addS0 := func(dPoc int32, j int) {
+		if dPoc < 0 && useDelta[j] {
+			rps.DeltaPocS0 = append(rps.DeltaPocS0, dPoc)
+			rps.UsedByCurrPicS0 = append(rps.UsedByCurrPicS0, usedByCurrPic[j])
+		}
+	}
+	addS1 := func(dPoc int32, j int) {
+		if dPoc > 0 && useDelta[j] {
+			rps.DeltaPocS1 = append(rps.DeltaPocS1, dPoc)
+			rps.UsedByCurrPicS1 = append(rps.UsedByCurrPicS1, usedByCurrPic[j])
+		}
+	}
+	for j := len(ref.DeltaPocS1) - 1; j >= 0; j-- {
+		addS0(ref.DeltaPocS1[j]+deltaRps, numNeg+j)
+	}
+	addS0(deltaRps, numRefDeltaPocs)
+	for j := 0; j < numNeg; j++ {
+		addS0(ref.DeltaPocS0[j]+deltaRps, j)
+	}
+	for j := numNeg - 1; j >= 0; j-- {
+		addS1(ref.DeltaPocS0[j]+deltaRps, j)
+	}
+	addS1(deltaRps, numRefDeltaPocs)
+	for j := 0; j < len(ref.DeltaPocS1); j++ {
+		addS1(ref.DeltaPocS1[j]+deltaRps, numNeg+j)
+	}
+	if rps.NumDeltaPocs() > 16 {
+		return rps, fmt.Errorf("st_ref_pic_set with %d pictures out of range", rps.NumDeltaPocs())
+	}
+	return rps, nil
+}
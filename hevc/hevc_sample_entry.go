@@ -0,0 +1,99 @@
+package hevc
+
+import (
+	"fmt"
+
+	"github.com/go-webdl/media-codec/nalu"
+)
+
+// inBandParameterSets - does the sample entry allow parameter sets in samples
+// ISO/IEC 14496-15 Sec. 8.4.1: hev1 and hev2 do, so streams can be spliced or
+// joined mid-stream; with hvc1 and hvc2 all of them are in the record.
+func inBandParameterSets(sampleEntry string) (bool, error) {
+	switch sampleEntry {
+	case "hvc1", "hvc2":
+		return false, nil
+	case "hev1", "hev2":
+		return true, nil
+	default:
+		return false, fmt.Errorf("sample entry %q is not hvc1, hvc2, hev1 or hev2", sampleEntry)
+	}
+}
+
+// SetArrayCompleteness - set array_completeness of the VPS, SPS and PPS arrays for sampleEntry
+// They are complete for hvc1 and hvc2, which requires all three to be in the
+// record, and not complete for hev1 and hev2.
+func (b *HEVCDecoderConfigurationRecord) SetArrayCompleteness(sampleEntry string) error {
+	inBand, err := inBandParameterSets(sampleEntry)
+	if err != nil {
+		return err
+	}
+	for _, naluType := range []NaluType{NALU_VPS, NALU_SPS, NALU_PPS} {
+		found := false
+		for i := range b.NaluArrays {
+			array := &b.NaluArrays[i]
+			if array.NALUnitType != naluType {
+				continue
+			}
+			array.ArrayCompleteness = !inBand
+			found = found || len(array.NALUs) > 0
+		}
+		if !found && !inBand {
+			return fmt.Errorf("%s needs %s in record", sampleEntry, naluType)
+		}
+	}
+	return nil
+}
+
+// ConvertSample - rewrite a length-prefixed sample for sampleEntry
+// For hvc1 and hvc2 in-band VPS, SPS and PPS NAL units are stripped; it is an
+// error if one differs from those of the record, since it could then only be
+// carried by a new sample entry. For hev1 and hev2 the record's parameter
+// sets are injected into IRAP samples lacking them, after a leading access
+// unit delimiter. The sample is returned unchanged if nothing needs doing.
+func (b *HEVCDecoderConfigurationRecord) ConvertSample(sample []byte, sampleEntry string) ([]byte, error) {
+	inBand, err := inBandParameterSets(sampleEntry)
+	if err != nil {
+		return nil, err
+	}
+	lengthSize := b.LengthSize()
+	nalus, err := nalu.SplitSample(sample, lengthSize)
+	if err != nil {
+		return nil, err
+	}
+	if inBand {
+		return b.injectParameterSets(sample, nalus, lengthSize)
+	}
+	kept := nalus[:0:0]
+	for _, data := range nalus {
+		if len(data) == 0 {
+			continue
+		}
+		naluType := GetNaluType(data[0])
+		if !naluType.IsParameterSet() {
+			kept = append(kept, data)
+			continue
+		}
+		if !b.hasParameterSet(data) {
+			return nil, fmt.Errorf("sample carries a %s not in the record", naluType)
+		}
+	}
+	if len(kept) == len(nalus) {
+		return sample, nil
+	}
+	return nalu.AppendSample(nil, kept, lengthSize)
+}
+
+// injectParameterSets - insert the record's parameter sets into an IRAP sample without them
+func (b *HEVCDecoderConfigurationRecord) injectParameterSets(sample []byte, nalus [][]byte, lengthSize int) ([]byte, error) {
+	pos, inject := parameterSetPosition(nalus)
+	if !inject {
+		return sample, nil
+	}
+	parameterSets := b.parameterSets()
+	out := make([][]byte, 0, len(nalus)+len(parameterSets))
+	out = append(out, nalus[:pos]...)
+	out = append(out, parameterSets...)
+	out = append(out, nalus[pos:]...)
+	return nalu.AppendSample(nil, out, lengthSize)
+}
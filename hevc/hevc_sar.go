@@ -0,0 +1,41 @@
+package hevc
+
+import "github.com/go-webdl/media-codec/sar"
+
+// EXTENDED_SAR - aspect_ratio_idc signalling sar_width and sar_height explicitly
+const EXTENDED_SAR = sar.EXTENDED_SAR
+
+// SAR - sample aspect ratio signalled in the VUI
+// 0:0 is returned if the aspect ratio is unspecified or uses a reserved aspect_ratio_idc.
+func (v *VUIParameters) SAR() (width, height uint16) {
+	if !v.AspectRatioInfoPresentFlag {
+		return 0, 0
+	}
+	return sar.FromIdc(v.AspectRatioIndicator, v.SarWidth, v.SarHeight)
+}
+
+// SAR - sample aspect ratio, 1:1 if not signalled
+func (s *SPS) SAR() (width, height uint16) {
+	if s.VUIParametersPresentFlag {
+		if width, height = s.VUI.SAR(); width != 0 {
+			return width, height
+		}
+	}
+	return 1, 1
+}
+
+// DisplayAspectRatio - aspect ratio of the cropped picture scaled by the SAR,
+// reduced to lowest terms, e.g. 16:9
+func (s *SPS) DisplayAspectRatio() (width, height uint32) {
+	sarWidth, sarHeight := s.SAR()
+	imageWidth, imageHeight := s.ImageSize()
+	return sar.DisplayAspectRatio(imageWidth, imageHeight, sarWidth, sarHeight)
+}
+
+// DisplaySize - picture size after scaling the width by the SAR, as used for
+// the track header of an MP4 track
+func (s *SPS) DisplaySize() (width, height uint32) {
+	sarWidth, sarHeight := s.SAR()
+	imageWidth, imageHeight := s.ImageSize()
+	return sar.DisplaySize(imageWidth, imageHeight, sarWidth, sarHeight)
+}
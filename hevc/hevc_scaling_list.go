@@ -0,0 +1,138 @@
+package hevc
+
+import (
+	"fmt"
+
+	"github.com/go-webdl/bits"
+)
+
+// ScalingListData - scaling_list_data() with predicted lists resolved
+// ISO/IEC 23008-2 Sec. 7.3.4 and 7.4.5
+type ScalingListData struct {
+	// Lists - ScalingList[sizeId][matrixId] in up-right diagonal scan order,
+	// 16 coefficients for sizeId 0 (4x4) and 64 for the larger sizes. Only
+	// matrixId 0 and 3 are coded for sizeId 3 (32x32); the chroma lists used
+	// with ChromaArrayType 3 are those of sizeId 2 (Eq. 7-44).
+	Lists [4][6][]byte
+	// DCCoefs - scaling_list_dc_coef_minus8 + 8 of sizeId 2 and 3
+	DCCoefs [2][6]byte
+	// PredModeFlags, PredMatrixIDDeltas - scaling_list_pred_mode_flag and
+	// scaling_list_pred_matrix_id_delta as coded. Lists without the flag are
+	// copies of the list PredMatrixIDDeltas before, or default lists for 0.
+	PredModeFlags      [4][6]bool
+	PredMatrixIDDeltas [4][6]byte
+}
+
+// Default scaling lists of sizeId 1 to 3, Table 7-6
+var (
+	defaultScalingListIntra = []byte{
+		16, 16, 16, 16, 16, 16, 16, 16, 16, 16, 17, 16, 17, 16, 17, 18,
+		17, 18, 18, 17, 18, 21, 19, 20, 21, 20, 19, 21, 24, 22, 22, 24,
+		24, 22, 22, 24, 25, 25, 27, 30, 27, 25, 25, 29, 31, 35, 35, 31,
+		29, 36, 41, 44, 41, 36, 47, 54, 54, 47, 65, 70, 65, 88, 88, 115,
+	}
+	defaultScalingListInter = []byte{
+		16, 16, 16, 16, 16, 16, 16, 16, 16, 16, 17, 17, 17, 17, 17, 18,
+		18, 18, 18, 18, 18, 20, 20, 20, 20, 20, 20, 20, 24, 24, 24, 24,
+		24, 24, 24, 24, 25, 25, 25, 25, 25, 25, 25, 28, 28, 28, 28, 28,
+		28, 33, 33, 33, 33, 33, 41, 41, 41, 41, 54, 54, 54, 71, 71, 91,
+	}
+)
+
+// defaultScalingList - Table 7-5 and 7-6 list of sizeId and matrixId
+func defaultScalingList(sizeID, matrixID int) []byte {
+	switch {
+	case sizeID == 0:
+		list := make([]byte, 16)
+		for i := range list {
+			list[i] = 16
+		}
+		return list
+	case matrixID < 3:
+		return append([]byte(nil), defaultScalingListIntra...)
+	default:
+		return append([]byte(nil), defaultScalingListInter...)
+	}
+}
+
+// DefaultScalingListData - the lists used with scaling_list_enabled_flag
+// but without scaling list data in SPS or PPS
+func DefaultScalingListData() (d ScalingListData) {
+	for sizeID := 0; sizeID < 4; sizeID++ {
+		for matrixID := 0; matrixID < 6; matrixID++ {
+			d.Lists[sizeID][matrixID] = defaultScalingList(sizeID, matrixID)
+			if sizeID > 1 {
+				d.DCCoefs[sizeID-2][matrixID] = 16
+			}
+		}
+	}
+	return d
+}
+
+// readScalingListData - read scaling_list_data(), Sec. 7.3.4
+func readScalingListData(r *bits.AccErrEBSPReader) (d ScalingListData, err error) {
+	for sizeID := 0; sizeID < 4; sizeID++ {
+		matrixStep := 1
+		if sizeID == 3 {
+			matrixStep = 3
+		}
+		coefNum := 1 << (4 + sizeID<<1)
+		if coefNum > 64 {
+			coefNum = 64
+		}
+		for matrixID := 0; matrixID < 6; matrixID += matrixStep {
+			d.PredModeFlags[sizeID][matrixID] = r.ReadFlag()
+			if !d.PredModeFlags[sizeID][matrixID] {
+				delta := r.ReadExpGolomb()
+				if err := r.AccError(); err != nil {
+					return d, err
+				}
+				if delta > uint(matrixID/matrixStep) {
+					return d, fmt.Errorf("scaling_list_pred_matrix_id_delta %d out of range for sizeId %d matrixId %d", delta, sizeID, matrixID)
+				}
+				d.PredMatrixIDDeltas[sizeID][matrixID] = byte(delta)
+				if delta == 0 {
+					d.Lists[sizeID][matrixID] = defaultScalingList(sizeID, matrixID)
+					if sizeID > 1 {
+						d.DCCoefs[sizeID-2][matrixID] = 16
+					}
+					continue
+				}
+				refMatrixID := matrixID - int(delta)*matrixStep
+				d.Lists[sizeID][matrixID] = append([]byte(nil), d.Lists[sizeID][refMatrixID]...)
+				if sizeID > 1 {
+					d.DCCoefs[sizeID-2][matrixID] = d.DCCoefs[sizeID-2][refMatrixID]
+				}
+				continue
+			}
+			nextCoef := 8
+			if sizeID > 1 {
+				dcCoefMinus8 := r.ReadSignedGolomb()
+				if dcCoefMinus8 < -7 || dcCoefMinus8 > 247 {
+					return d, fmt.Errorf("scaling_list_dc_coef_minus8 %d out of range", dcCoefMinus8)
+				}
+				nextCoef = dcCoefMinus8 + 8
+				d.DCCoefs[sizeID-2][matrixID] = byte(nextCoef)
+			}
+			list := make([]byte, coefNum)
+			for i := range list {
+				deltaCoef := r.ReadSignedGolomb()
+				if deltaCoef < -128 || deltaCoef > 127 {
+					return d, fmt.Errorf("scaling_list_delta_coef %d out of range", deltaCoef)
+				}
+				nextCoef = (nextCoef + deltaCoef + 256) % 256
+				list[i] = byte(nextCoef)
+			}
+			if err := r.AccError(); err != nil {
+				return d, err
+			}
+			d.Lists[sizeID][matrixID] = list
+		}
+	}
+	// Chroma 32x32 lists for ChromaArrayType 3, Eq. 7-44
+	for _, matrixID := range []int{1, 2, 4, 5} {
+		d.Lists[3][matrixID] = append([]byte(nil), d.Lists[2][matrixID]...)
+		d.DCCoefs[1][matrixID] = d.DCCoefs[0][matrixID]
+	}
+	return d, r.AccError()
+}
@@ -0,0 +1,132 @@
+package hevc
+
+import (
+	"fmt"
+
+	"github.com/go-webdl/media-codec/nalu"
+	"github.com/go-webdl/media-codec/sei"
+)
+
+// CreateSEINALUnit - build a prefix or suffix SEI NAL unit carrying msgs in
+// the base layer with TemporalId 0
+func CreateSEINALUnit(naluType NaluType, msgs []sei.Message) ([]byte, error) {
+	if naluType != NALU_SEI_PREFIX && naluType != NALU_SEI_SUFFIX {
+		return nil, fmt.Errorf("NALU type %s is not SEI", naluType)
+	}
+	// forbidden_zero_bit, nal_unit_type, nuh_layer_id = 0, nuh_temporal_id_plus1 = 1
+	return sei.CreateNALUnit([]byte{byte(naluType) << 1, 1}, msgs)
+}
+
+// ParseSEINALUnit - split a prefix or suffix SEI NAL unit into its messages
+func ParseSEINALUnit(data []byte) ([]sei.Message, error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("NALU is not SEI")
+	}
+	if naluType := GetNaluType(data[0]); naluType != NALU_SEI_PREFIX && naluType != NALU_SEI_SUFFIX {
+		return nil, fmt.Errorf("NALU type %s is not SEI", naluType)
+	}
+	return sei.ParseMessages(nalu.UnescapeEBSP(data[2:]))
+}
+
+// FindEncoderSettings - x265 settings, or those of another encoder using the
+// same format, from the prefix SEI NAL units among nalus
+// Encoders write them into the first access unit.
+func FindEncoderSettings(nalus [][]byte) (*sei.EncoderSettings, bool) {
+	for _, data := range nalus {
+		if len(data) < 2 || GetNaluType(data[0]) != NALU_SEI_PREFIX {
+			continue
+		}
+		msgs, err := ParseSEINALUnit(data)
+		if err != nil {
+			continue
+		}
+		if s, ok := sei.FindEncoderSettings(msgs); ok {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// FindMasteringDisplayColourVolume - HDR10 mastering display metadata from
+// the prefix SEI NAL units among nalus
+// Encoders repeat it in every IRAP access unit.
+func FindMasteringDisplayColourVolume(nalus [][]byte) (*sei.MasteringDisplayColourVolume, bool) {
+	for _, data := range nalus {
+		if len(data) < 2 || GetNaluType(data[0]) != NALU_SEI_PREFIX {
+			continue
+		}
+		msgs, err := ParseSEINALUnit(data)
+		if err != nil {
+			continue
+		}
+		if m, ok := sei.FindMasteringDisplayColourVolume(msgs); ok {
+			return m, true
+		}
+	}
+	return nil, false
+}
+
+// FindContentLightLevelInfo - HDR10 MaxCLL and MaxFALL from the prefix SEI NAL units among nalus
+func FindContentLightLevelInfo(nalus [][]byte) (*sei.ContentLightLevelInfo, bool) {
+	for _, data := range nalus {
+		if len(data) < 2 || GetNaluType(data[0]) != NALU_SEI_PREFIX {
+			continue
+		}
+		msgs, err := ParseSEINALUnit(data)
+		if err != nil {
+			continue
+		}
+		if c, ok := sei.FindContentLightLevelInfo(msgs); ok {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+// FindHDR10Plus - HDR10+ dynamic metadata from the prefix SEI NAL units of an access unit
+// ok is true if the access unit carries HDR10+, err reports it malformed.
+func FindHDR10Plus(nalus [][]byte) (h *sei.HDR10PlusMetadata, ok bool, err error) {
+	for _, data := range nalus {
+		if len(data) < 2 || GetNaluType(data[0]) != NALU_SEI_PREFIX {
+			continue
+		}
+		msgs, err := ParseSEINALUnit(data)
+		if err != nil {
+			continue
+		}
+		if h, ok, err = sei.FindHDR10Plus(msgs); ok {
+			return h, ok, err
+		}
+	}
+	return nil, false, nil
+}
+
+// FindAlternativeTransferCharacteristics - alternative_transfer_characteristics
+// from the prefix SEI NAL units among nalus
+func FindAlternativeTransferCharacteristics(nalus [][]byte) (*sei.AlternativeTransferCharacteristics, bool) {
+	for _, data := range nalus {
+		if len(data) < 2 || GetNaluType(data[0]) != NALU_SEI_PREFIX {
+			continue
+		}
+		msgs, err := ParseSEINALUnit(data)
+		if err != nil {
+			continue
+		}
+		if a, ok := sei.FindAlternativeTransferCharacteristics(msgs); ok {
+			return a, true
+		}
+	}
+	return nil, false
+}
+
+// EffectiveTransferCharacteristics - transfer function of the content, from
+// the VUI of sps and an alternative_transfer_characteristics SEI among nalus
+// Without colour description the VUI transfer characteristics are unspecified (2).
+func EffectiveTransferCharacteristics(sps *SPS, nalus [][]byte) byte {
+	vuiTransferCharacteristics := byte(2)
+	if sps.VUIParametersPresentFlag && sps.VUI.ColourDescriptionPresentFlag {
+		vuiTransferCharacteristics = sps.VUI.TransferCharacteristics
+	}
+	a, _ := FindAlternativeTransferCharacteristics(nalus)
+	return sei.EffectiveTransferCharacteristics(vuiTransferCharacteristics, a)
+}
@@ -0,0 +1,191 @@
+package hevc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/go-webdl/bits"
+)
+
+// SliceType - HEVC slice_type according to ISO/IEC 23008-2 Table 7-7
+type SliceType uint
+
+const (
+	SLICE_B = SliceType(0)
+	SLICE_P = SliceType(1)
+	SLICE_I = SliceType(2)
+)
+
+func (s SliceType) String() string {
+	switch s {
+	case SLICE_B:
+		return "B"
+	case SLICE_P:
+		return "P"
+	case SLICE_I:
+		return "I"
+	default:
+		return fmt.Sprintf("Other_%d", s)
+	}
+}
+
+// ErrNoIndependentSliceSegment - dependent slice segment without a preceding independent slice segment
+var ErrNoIndependentSliceSegment = errors.New("dependent slice segment without preceding independent slice segment")
+
+// SliceSegmentHeader - HEVC slice segment header
+// ISO/IEC 23008-2 Sec. 7.3.6.1
+// Fields after SliceSegmentAddress are not coded in dependent slice segments.
+// For those they are inherited from the preceding independent slice segment.
+type SliceSegmentHeader struct {
+	NaluType NaluType
+	// TemporalID - nuh_temporal_id_plus1 - 1 of the NAL unit header
+	TemporalID                 byte
+	FirstSliceSegmentInPicFlag bool
+	NoOutputOfPriorPicsFlag    bool
+	PpsID                      byte
+	DependentSliceSegmentFlag  bool
+	SliceSegmentAddress        uint32
+	SliceType                  SliceType
+	PicOutputFlag              bool
+	ColourPlaneID              byte
+	// SlicePicOrderCntLsb - 0 for IDR pictures
+	SlicePicOrderCntLsb uint32
+}
+
+// FirstSliceSegmentInPic - read first_slice_segment_in_pic_flag of a VCL NAL unit
+// This is what starts a new picture. Dependent slice segments never have it set.
+func FirstSliceSegmentInPic(nalu []byte) bool {
+	if len(nalu) < 3 || !GetNaluType(nalu[0]).IsVCL() {
+		return false
+	}
+	return nalu[2]&0x80 != 0
+}
+
+// PicSizeInCtbsY - number of coding tree blocks in a picture
+func (s *SPS) PicSizeInCtbsY() uint32 {
+	ctbLog2SizeY := uint32(s.Log2MinLumaCodingBlockSizeMinus3) + 3 + uint32(s.Log2DiffMaxMinLumaCodingBlockSize)
+	ctbSizeY := uint32(1) << ctbLog2SizeY
+	picWidthInCtbsY := (s.PicWidthInLumaSamples + ctbSizeY - 1) / ctbSizeY
+	picHeightInCtbsY := (s.PicHeightInLumaSamples + ctbSizeY - 1) / ctbSizeY
+	return picWidthInCtbsY * picHeightInCtbsY
+}
+
+// ParseSliceSegmentHeader - Parse HEVC slice segment header of a NAL unit starting with NAL unit header
+// spsMap and ppsMap are indexed by parameter set id.
+// For dependent slice segments, prev must be the header of the preceding slice segment in the same picture
+// (either independent or itself dependent with inherited fields); ErrNoIndependentSliceSegment is returned otherwise.
+func ParseSliceSegmentHeader(data []byte, spsMap map[byte]*SPS, ppsMap map[byte]*PPS, prev *SliceSegmentHeader) (*SliceSegmentHeader, error) {
+	sh := &SliceSegmentHeader{PicOutputFlag: true}
+
+	rd := bytes.NewReader(data)
+	r := bits.NewAccErrEBSPReader(rd)
+	// Note! First two bytes are NALU Header
+
+	naluHdrBits := r.Read(16)
+	sh.NaluType = GetNaluType(byte(naluHdrBits >> 8))
+	if !sh.NaluType.IsVCL() {
+		return nil, fmt.Errorf("NALU type is %s not a slice segment", sh.NaluType)
+	}
+	if naluHdrBits&0b111 == 0 {
+		return nil, fmt.Errorf("nuh_temporal_id_plus1 is 0")
+	}
+	sh.TemporalID = byte(naluHdrBits&0b111) - 1
+	sh.FirstSliceSegmentInPicFlag = r.ReadFlag()
+	if sh.NaluType.IsIRAP() {
+		sh.NoOutputOfPriorPicsFlag = r.ReadFlag()
+	}
+	sh.PpsID = byte(r.ReadExpGolomb())
+	if err := r.AccError(); err != nil {
+		return nil, err
+	}
+	pps, ok := ppsMap[sh.PpsID]
+	if !ok {
+		return nil, fmt.Errorf("PPS %d not found", sh.PpsID)
+	}
+	sps, ok := spsMap[pps.SpsID]
+	if !ok {
+		return nil, fmt.Errorf("SPS %d not found", pps.SpsID)
+	}
+	if !sh.FirstSliceSegmentInPicFlag {
+		if pps.DependentSliceSegmentsEnabledFlag {
+			sh.DependentSliceSegmentFlag = r.ReadFlag()
+		}
+		sh.SliceSegmentAddress = uint32(r.Read(ceilLog2(sps.PicSizeInCtbsY())))
+	}
+	if sh.DependentSliceSegmentFlag {
+		if prev == nil {
+			return nil, ErrNoIndependentSliceSegment
+		}
+		if prev.PpsID != sh.PpsID {
+			return nil, fmt.Errorf("dependent slice segment refers to PPS %d, independent slice segment to PPS %d", sh.PpsID, prev.PpsID)
+		}
+		sh.SliceType = prev.SliceType
+		sh.PicOutputFlag = prev.PicOutputFlag
+		sh.ColourPlaneID = prev.ColourPlaneID
+		sh.SlicePicOrderCntLsb = prev.SlicePicOrderCntLsb
+		return sh, r.AccError()
+	}
+	for i := byte(0); i < pps.NumExtraSliceHeaderBits; i++ {
+		_ = r.ReadFlag() // slice_reserved_flag
+	}
+	sh.SliceType = SliceType(r.ReadExpGolomb())
+	if pps.OutputFlagPresentFlag {
+		sh.PicOutputFlag = r.ReadFlag()
+	}
+	if sps.SeparateColourPlaneFlag {
+		sh.ColourPlaneID = byte(r.Read(2))
+	}
+	if !sh.NaluType.IsIDR() {
+		sh.SlicePicOrderCntLsb = uint32(r.Read(int(sps.Log2MaxPicOrderCntLsbMinus4) + 4))
+	}
+
+	return sh, r.AccError()
+}
+
+// ParseAccessUnitSliceHeaders - parse the slice segment headers of the base
+// layer in an access unit, e.g. one read by AccessUnitReader, in decode order
+// Each dependent slice segment inherits from the independent slice segment
+// preceding it in the access unit. SPS and PPS NAL units of the access unit
+// are added to spsMap and ppsMap before the slice segments following them
+// are parsed.
+func ParseAccessUnitSliceHeaders(au [][]byte, spsMap map[byte]*SPS, ppsMap map[byte]*PPS) ([]*SliceSegmentHeader, error) {
+	var headers []*SliceSegmentHeader
+	var prev *SliceSegmentHeader
+	for i, data := range au {
+		if len(data) < 2 || GetLayerID(data) != 0 {
+			continue
+		}
+		switch naluType := GetNaluType(data[0]); {
+		case naluType == NALU_SPS:
+			sps, err := ParseSPSNALUnit(data)
+			if err != nil {
+				return nil, fmt.Errorf("NAL unit %d: %w", i, err)
+			}
+			spsMap[sps.SpsID] = sps
+		case naluType == NALU_PPS:
+			pps, err := ParsePPSNALUnit(data)
+			if err != nil {
+				return nil, fmt.Errorf("NAL unit %d: %w", i, err)
+			}
+			ppsMap[pps.PpsID] = pps
+		case naluType.IsVCL():
+			sh, err := ParseSliceSegmentHeader(data, spsMap, ppsMap, prev)
+			if err != nil {
+				return nil, fmt.Errorf("NAL unit %d: %w", i, err)
+			}
+			headers = append(headers, sh)
+			prev = sh
+		}
+	}
+	return headers, nil
+}
+
+// ceilLog2 - Ceil(Log2(n)) as used for u(v) lengths
+func ceilLog2(n uint32) int {
+	l := 0
+	for (uint32(1) << l) < n {
+		l++
+	}
+	return l
+}
@@ -0,0 +1,75 @@
+package hevc
+
+import (
+	"fmt"
+
+	"github.com/go-webdl/media-codec/nalu"
+)
+
+// SpliceRewriter - rewrites CRA pictures at splice points to BLA pictures and drops their RASL pictures
+// After a splice the RASL pictures of a CRA picture reference pictures of the
+// other stream; a BLA picture tells decoders to reset and skip them.
+// The zero value passes all samples through. Samples must be passed in decode order.
+type SpliceRewriter struct {
+	// Rewritten, DroppedRASL - counts of rewritten CRA pictures and dropped RASL pictures
+	Rewritten   int
+	DroppedRASL int
+	// splice - the next sample is a splice point
+	splice bool
+	// dropRASL - RASL pictures follow a rewritten CRA picture
+	dropRASL bool
+}
+
+// Splice - mark the next sample as the start of a spliced-in stream
+// It must be an IRAP picture.
+func (s *SpliceRewriter) Splice() {
+	s.splice = true
+}
+
+// RewriteSample - rewrite the next length-prefixed sample in decode order
+// ok is false for RASL pictures of a rewritten CRA picture, which are to be
+// removed from the track. Only the NAL unit headers of the VCL NAL units of
+// a CRA picture at a splice point are changed, to BLA_W_LP; all other NAL
+// units are passed through verbatim and samples without changes are
+// returned as is.
+func (s *SpliceRewriter) RewriteSample(sample []byte, lengthSize int) (out []byte, ok bool, err error) {
+	nalus, err := nalu.SplitSample(sample, lengthSize)
+	if err != nil {
+		return nil, false, err
+	}
+	naluType, isPicture := PictureType(nalus)
+	if !isPicture {
+		return sample, true, nil
+	}
+	splice := s.splice
+	s.splice = false
+	switch {
+	case naluType.IsIRAP():
+		s.dropRASL = false
+	case splice:
+		return nil, false, fmt.Errorf("splice point at %s picture, not IRAP", naluType)
+	case naluType.IsRASL() && s.dropRASL:
+		s.DroppedRASL++
+		return nil, false, nil
+	}
+	if !splice || !naluType.IsCRA() {
+		return sample, true, nil
+	}
+	out, err = nalu.TransformSample(sample, lengthSize, func(units []nalu.Unit) ([]nalu.Unit, error) {
+		for i := range units {
+			if len(units[i].Data) < 2 || !GetNaluType(units[i].Data[0]).IsCRA() {
+				continue
+			}
+			data := append([]byte(nil), units[i].Data...)
+			data[0] = data[0]&0b10000001 | byte(NALU_BLA_W_LP)<<1
+			units[i] = nalu.Unit{Data: data, Modified: true}
+		}
+		return units, nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	s.dropRASL = true
+	s.Rewritten++
+	return out, true, nil
+}
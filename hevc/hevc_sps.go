@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/go-webdl/bits"
+	"github.com/go-webdl/media-codec/nalu"
 )
 
 // SPS - HEVC SPS parameters
@@ -34,14 +35,74 @@ type SPS struct {
 	MaxTransformHierarchyDepthIntra      byte
 	ScalingListEnabledFlag               bool
 	ScalingListDataPresentFlag           bool
-	AmpEnabledFlag                       bool
-	SampleAdaptiveOffsetEnabledFlag      bool
-	PCMEnabledFlag                       bool
+	// ScalingListData - only valid if ScalingListDataPresentFlag is set
+	ScalingListData                 ScalingListData
+	AmpEnabledFlag                  bool
+	SampleAdaptiveOffsetEnabledFlag bool
+	PCMEnabledFlag                  bool
+	// PCM parameters - only valid if PCMEnabledFlag is set
+	PCMSampleBitDepthLumaMinus1          byte
+	PCMSampleBitDepthChromaMinus1        byte
+	Log2MinPCMLumaCodingBlockSizeMinus3  byte
+	Log2DiffMaxMinPCMLumaCodingBlockSize byte
+	PCMLoopFilterDisabledFlag            bool
 	NumShortTermRefPicSets               byte
+	ShortTermRefPicSets                  []ShortTermRefPicSet
 	LongTermRefPicsPresentFlag           bool
+	LtRefPicPocLsbSps                    []uint32
+	UsedByCurrPicLtSpsFlags              []bool
 	SpsTemporalMvpEnabledFlag            bool
 	StrongIntraSmoothingEnabledFlag      bool
 	VUIParametersPresentFlag             bool
+	// VUI - only valid if VUIParametersPresentFlag is set
+	VUI                  VUIParameters
+	ExtensionPresentFlag bool
+	// ExtensionData - sps_range_extension_flag and all following syntax
+	// elements up to rbsp_trailing_bits as coded, ExtensionDataBits bits
+	// left-aligned, only valid if ExtensionPresentFlag is set
+	ExtensionData     []byte
+	ExtensionDataBits int
+}
+
+// VUIParameters - ISO/IEC 23008-2 Sec. E.2.1
+type VUIParameters struct {
+	AspectRatioInfoPresentFlag         bool
+	AspectRatioIndicator               byte
+	SarWidth                           uint16
+	SarHeight                          uint16
+	OverscanInfoPresentFlag            bool
+	OverscanAppropriateFlag            bool
+	VideoSignalTypePresentFlag         bool
+	VideoFormat                        byte
+	VideoFullRangeFlag                 bool
+	ColourDescriptionPresentFlag       bool
+	ColourPrimaries                    byte
+	TransferCharacteristics            byte
+	MatrixCoefficients                 byte
+	ChromaLocInfoPresentFlag           bool
+	ChromaSampleLocTypeTopField        byte
+	ChromaSampleLocTypeBottomField     byte
+	NeutralChromaIndicationFlag        bool
+	FieldSeqFlag                       bool
+	FrameFieldInfoPresentFlag          bool
+	DefaultDisplayWindowFlag           bool
+	DefaultDisplayWindow               ConformanceWindow
+	TimingInfoPresentFlag              bool
+	NumUnitsInTick                     uint32
+	TimeScale                          uint32
+	PocProportionalToTimingFlag        bool
+	NumTicksPocDiffOneMinus1           uint32
+	HrdParametersPresentFlag           bool
+	HrdParameters                      HRDParameters
+	BitstreamRestrictionFlag           bool
+	TilesFixedStructureFlag            bool
+	MotionVectorsOverPicBoundariesFlag bool
+	RestrictedRefPicListsFlag          bool
+	MinSpatialSegmentationIdc          uint16
+	MaxBytesPerPicDenom                uint32
+	MaxBitsPerMinCuDenom               uint32
+	Log2MaxMvLengthHorizontal          uint32
+	Log2MaxMvLengthVertical            uint32
 }
 
 // ISO/IEC 23008-2 Section 7.3.3
@@ -50,15 +111,30 @@ type ProfileTierLevel struct {
 	GeneralTierFlag                  bool
 	GeneralProfileIndicator          byte
 	GeneralProfileCompatibilityFlags uint32
-	GeneralConstraintIndicatorFlags  uint64 // 48 bits
-	GeneralProgressiveSourceFlag     bool
-	GeneralInterlacedSourceFlag      bool
-	GeneralNonPackedConstraintFlag   bool
-	GeneralFrameOnlyConstraintFlag   bool
+	// GeneralConstraintIndicatorFlags - 48 bits, see GeneralConstraints for a structured view
+	GeneralConstraintIndicatorFlags uint64
+	// GeneralProgressiveSourceFlag to GeneralFrameOnlyConstraintFlag - the
+	// top 4 bits of GeneralConstraintIndicatorFlags as read from an SPS or VPS
+	GeneralProgressiveSourceFlag   bool
+	GeneralInterlacedSourceFlag    bool
+	GeneralNonPackedConstraintFlag bool
+	GeneralFrameOnlyConstraintFlag bool
 	// 43 + 1 bits of info
 	GeneralLevelIndicator byte
-	// Sublayer stuff
+	SubLayers             []SubLayerProfileTierLevel
+}
 
+// SubLayerProfileTierLevel - profile, tier and level of one temporal sub-layer
+// Fields other than the present flags are only valid when the corresponding flag is set
+type SubLayerProfileTierLevel struct {
+	ProfilePresentFlag        bool
+	LevelPresentFlag          bool
+	ProfileSpace              byte
+	TierFlag                  bool
+	ProfileIndicator          byte
+	ProfileCompatibilityFlags uint32
+	ConstraintIndicatorFlags  uint64 // 48 bits
+	LevelIndicator            byte
 }
 
 type ConformanceWindow struct {
@@ -91,15 +167,7 @@ func ParseSPSNALUnit(data []byte) (*SPS, error) {
 	sps.VpsID = byte(r.Read(4))
 	sps.MaxSubLayersMinus1 = byte(r.Read(3))
 	sps.TemporalIdNestingFlag = r.ReadFlag()
-	sps.ProfileTierLevel.GeneralProfileSpace = byte(r.Read(2))
-	sps.ProfileTierLevel.GeneralTierFlag = r.ReadFlag()
-	sps.ProfileTierLevel.GeneralProfileIndicator = byte(r.Read(5))
-	sps.ProfileTierLevel.GeneralProfileCompatibilityFlags = uint32(r.Read(32))
-	sps.ProfileTierLevel.GeneralConstraintIndicatorFlags = uint64(r.Read(48))
-	sps.ProfileTierLevel.GeneralLevelIndicator = byte(r.Read(8))
-	if sps.MaxSubLayersMinus1 != 0 {
-		return sps, nil // Cannot parse any further
-	}
+	sps.ProfileTierLevel = readProfileTierLevel(r, true, sps.MaxSubLayersMinus1)
 	sps.SpsID = byte(r.ReadExpGolomb())
 	sps.ChromaFormatIndicator = byte(r.ReadExpGolomb())
 	if sps.ChromaFormatIndicator == 3 {
@@ -120,9 +188,9 @@ func ParseSPSNALUnit(data []byte) (*SPS, error) {
 	sps.BitDepthChromaMinus8 = byte(r.ReadExpGolomb())
 	sps.Log2MaxPicOrderCntLsbMinus4 = byte(r.ReadExpGolomb())
 	sps.SubLayerOrderingInfoPresentFlag = r.ReadFlag()
-	startValue := byte(0)
+	startValue := sps.MaxSubLayersMinus1
 	if sps.SubLayerOrderingInfoPresentFlag {
-		startValue = sps.MaxSubLayersMinus1
+		startValue = 0
 	}
 	for i := startValue; i <= sps.MaxSubLayersMinus1; i++ {
 		sps.SubLayeringOrderingInfos = append(
@@ -143,41 +211,256 @@ func ParseSPSNALUnit(data []byte) (*SPS, error) {
 	if sps.ScalingListEnabledFlag {
 		sps.ScalingListDataPresentFlag = r.ReadFlag()
 		if sps.ScalingListDataPresentFlag {
-			return sps, r.AccError() // Doesn't get any further now
+			var err error
+			if sps.ScalingListData, err = readScalingListData(r); err != nil {
+				return nil, err
+			}
 		}
 	}
 	sps.AmpEnabledFlag = r.ReadFlag()
 	sps.SampleAdaptiveOffsetEnabledFlag = r.ReadFlag()
 	sps.PCMEnabledFlag = r.ReadFlag()
 	if sps.PCMEnabledFlag {
-		return sps, r.AccError() // Doesn't get any further now
+		sps.PCMSampleBitDepthLumaMinus1 = byte(r.Read(4))
+		sps.PCMSampleBitDepthChromaMinus1 = byte(r.Read(4))
+		sps.Log2MinPCMLumaCodingBlockSizeMinus3 = byte(r.ReadExpGolomb())
+		sps.Log2DiffMaxMinPCMLumaCodingBlockSize = byte(r.ReadExpGolomb())
+		sps.PCMLoopFilterDisabledFlag = r.ReadFlag()
 	}
-	sps.NumShortTermRefPicSets = byte(r.ReadExpGolomb())
-	if sps.NumShortTermRefPicSets != 0 {
-		return sps, r.AccError() // Doesn't get any further for now
+	numShortTermRefPicSets := r.ReadExpGolomb()
+	if err := r.AccError(); err != nil {
+		return nil, err
+	}
+	if numShortTermRefPicSets > maxShortTermRefPicSets {
+		return nil, fmt.Errorf("num_short_term_ref_pic_sets %d out of range", numShortTermRefPicSets)
+	}
+	sps.NumShortTermRefPicSets = byte(numShortTermRefPicSets)
+	for i := 0; i < int(sps.NumShortTermRefPicSets); i++ {
+		rps, err := readShortTermRefPicSet(r, i, int(sps.NumShortTermRefPicSets), sps.ShortTermRefPicSets)
+		if err != nil {
+			return nil, err
+		}
+		sps.ShortTermRefPicSets = append(sps.ShortTermRefPicSets, rps)
 	}
 	sps.LongTermRefPicsPresentFlag = r.ReadFlag()
 	if sps.LongTermRefPicsPresentFlag {
-		return sps, r.AccError() // Does't get any further for now
+		numLongTermRefPicsSps := r.ReadExpGolomb()
+		if err := r.AccError(); err != nil {
+			return nil, err
+		}
+		if numLongTermRefPicsSps > 32 {
+			return nil, fmt.Errorf("num_long_term_ref_pics_sps %d out of range", numLongTermRefPicsSps)
+		}
+		for i := 0; i < int(numLongTermRefPicsSps); i++ {
+			sps.LtRefPicPocLsbSps = append(sps.LtRefPicPocLsbSps, uint32(r.Read(int(sps.Log2MaxPicOrderCntLsbMinus4)+4)))
+			sps.UsedByCurrPicLtSpsFlags = append(sps.UsedByCurrPicLtSpsFlags, r.ReadFlag())
+		}
 	}
 	sps.SpsTemporalMvpEnabledFlag = r.ReadFlag()
 	sps.StrongIntraSmoothingEnabledFlag = r.ReadFlag()
 	sps.VUIParametersPresentFlag = r.ReadFlag()
+	if sps.VUIParametersPresentFlag {
+		var err error
+		if sps.VUI, err = readVUIParameters(r, sps.MaxSubLayersMinus1); err != nil {
+			return nil, err
+		}
+	}
+	if err := r.AccError(); err != nil {
+		return nil, err
+	}
+	// The extensions are kept as coded, so that CreateSPSNALUnit can write
+	// them back
+	rbsp, pos, end := rbspPosition(data, r)
+	if pos < end {
+		sps.ExtensionPresentFlag = rbsp[pos/8]&(0x80>>uint(pos%8)) != 0
+		if sps.ExtensionPresentFlag {
+			sps.ExtensionData, sps.ExtensionDataBits = copyBits(rbsp, pos+1, end)
+		}
+	}
+
+	return sps, nil
+}
+
+// rbspPosition - RBSP of NAL unit data, the bit position of r within it and
+// the position of the rbsp_stop_one_bit
+func rbspPosition(data []byte, r *bits.AccErrEBSPReader) (rbsp []byte, pos, end int) {
+	rbsp = nalu.UnescapeEBSP(data)
+	pos = nalu.RBSPBitPosition(data, r)
+	for end = len(rbsp)*8 - 1; end >= 0; end-- {
+		if rbsp[end/8]&(0x80>>uint(end%8)) != 0 {
+			break
+		}
+	}
+	return rbsp, pos, end
+}
+
+// copyBits - bits start to end of data, left-aligned
+func copyBits(data []byte, start, end int) (out []byte, n int) {
+	n = end - start
+	out = make([]byte, (n+7)/8)
+	for i := 0; i < n; i++ {
+		bit := start + i
+		if data[bit/8]&(0x80>>uint(bit%8)) != 0 {
+			out[i/8] |= 0x80 >> uint(i%8)
+		}
+	}
+	return out, n
+}
+
+// readVUIParameters - read vui_parameters(), Sec. E.2.1
+func readVUIParameters(r *bits.AccErrEBSPReader, maxSubLayersMinus1 byte) (vui VUIParameters, err error) {
+	vui.AspectRatioInfoPresentFlag = r.ReadFlag()
+	if vui.AspectRatioInfoPresentFlag {
+		vui.AspectRatioIndicator = byte(r.Read(8))
+		if vui.AspectRatioIndicator == 255 { // EXTENDED_SAR
+			vui.SarWidth = uint16(r.Read(16))
+			vui.SarHeight = uint16(r.Read(16))
+		}
+	}
+	vui.OverscanInfoPresentFlag = r.ReadFlag()
+	if vui.OverscanInfoPresentFlag {
+		vui.OverscanAppropriateFlag = r.ReadFlag()
+	}
+	vui.VideoSignalTypePresentFlag = r.ReadFlag()
+	if vui.VideoSignalTypePresentFlag {
+		vui.VideoFormat = byte(r.Read(3))
+		vui.VideoFullRangeFlag = r.ReadFlag()
+		vui.ColourDescriptionPresentFlag = r.ReadFlag()
+		if vui.ColourDescriptionPresentFlag {
+			vui.ColourPrimaries = byte(r.Read(8))
+			vui.TransferCharacteristics = byte(r.Read(8))
+			vui.MatrixCoefficients = byte(r.Read(8))
+		}
+	}
+	vui.ChromaLocInfoPresentFlag = r.ReadFlag()
+	if vui.ChromaLocInfoPresentFlag {
+		vui.ChromaSampleLocTypeTopField = byte(r.ReadExpGolomb())
+		vui.ChromaSampleLocTypeBottomField = byte(r.ReadExpGolomb())
+	}
+	vui.NeutralChromaIndicationFlag = r.ReadFlag()
+	vui.FieldSeqFlag = r.ReadFlag()
+	vui.FrameFieldInfoPresentFlag = r.ReadFlag()
+	vui.DefaultDisplayWindowFlag = r.ReadFlag()
+	if vui.DefaultDisplayWindowFlag {
+		vui.DefaultDisplayWindow = ConformanceWindow{
+			LeftOffset:   uint32(r.ReadExpGolomb()),
+			RightOffset:  uint32(r.ReadExpGolomb()),
+			TopOffset:    uint32(r.ReadExpGolomb()),
+			BottomOffset: uint32(r.ReadExpGolomb()),
+		}
+	}
+	vui.TimingInfoPresentFlag = r.ReadFlag()
+	if vui.TimingInfoPresentFlag {
+		vui.NumUnitsInTick = uint32(r.Read(32))
+		vui.TimeScale = uint32(r.Read(32))
+		vui.PocProportionalToTimingFlag = r.ReadFlag()
+		if vui.PocProportionalToTimingFlag {
+			vui.NumTicksPocDiffOneMinus1 = uint32(r.ReadExpGolomb())
+		}
+		vui.HrdParametersPresentFlag = r.ReadFlag()
+		if vui.HrdParametersPresentFlag {
+			if vui.HrdParameters, err = readHRDParameters(r, true, maxSubLayersMinus1); err != nil {
+				return vui, err
+			}
+		}
+	}
+	vui.BitstreamRestrictionFlag = r.ReadFlag()
+	if vui.BitstreamRestrictionFlag {
+		vui.TilesFixedStructureFlag = r.ReadFlag()
+		vui.MotionVectorsOverPicBoundariesFlag = r.ReadFlag()
+		vui.RestrictedRefPicListsFlag = r.ReadFlag()
+		vui.MinSpatialSegmentationIdc = uint16(r.ReadExpGolomb())
+		vui.MaxBytesPerPicDenom = uint32(r.ReadExpGolomb())
+		vui.MaxBitsPerMinCuDenom = uint32(r.ReadExpGolomb())
+		vui.Log2MaxMvLengthHorizontal = uint32(r.ReadExpGolomb())
+		vui.Log2MaxMvLengthVertical = uint32(r.ReadExpGolomb())
+	}
+	return vui, r.AccError()
+}
 
-	return sps, r.AccError()
+// readProfileTierLevel - read profile_tier_level(profilePresentFlag, maxNumSubLayersMinus1)
+// Without profilePresentFlag only the level is read, as in the VPS extension.
+// ISO/IEC 23008-2 Section 7.3.3
+func readProfileTierLevel(r *bits.AccErrEBSPReader, profilePresentFlag bool, maxNumSubLayersMinus1 byte) (ptl ProfileTierLevel) {
+	if profilePresentFlag {
+		ptl.GeneralProfileSpace = byte(r.Read(2))
+		ptl.GeneralTierFlag = r.ReadFlag()
+		ptl.GeneralProfileIndicator = byte(r.Read(5))
+		ptl.GeneralProfileCompatibilityFlags = uint32(r.Read(32))
+		ptl.GeneralConstraintIndicatorFlags = uint64(r.Read(48))
+		c := ptl.GeneralConstraints()
+		ptl.GeneralProgressiveSourceFlag = c.ProgressiveSource
+		ptl.GeneralInterlacedSourceFlag = c.InterlacedSource
+		ptl.GeneralNonPackedConstraintFlag = c.NonPacked
+		ptl.GeneralFrameOnlyConstraintFlag = c.FrameOnly
+	}
+	ptl.GeneralLevelIndicator = byte(r.Read(8))
+	if maxNumSubLayersMinus1 == 0 {
+		return ptl
+	}
+	ptl.SubLayers = make([]SubLayerProfileTierLevel, maxNumSubLayersMinus1)
+	for i := range ptl.SubLayers {
+		ptl.SubLayers[i].ProfilePresentFlag = r.ReadFlag()
+		ptl.SubLayers[i].LevelPresentFlag = r.ReadFlag()
+	}
+	for i := maxNumSubLayersMinus1; i < 8; i++ {
+		_ = r.Read(2) // reserved_zero_2bits
+	}
+	for i := range ptl.SubLayers {
+		sl := &ptl.SubLayers[i]
+		if sl.ProfilePresentFlag {
+			sl.ProfileSpace = byte(r.Read(2))
+			sl.TierFlag = r.ReadFlag()
+			sl.ProfileIndicator = byte(r.Read(5))
+			sl.ProfileCompatibilityFlags = uint32(r.Read(32))
+			sl.ConstraintIndicatorFlags = uint64(r.Read(48))
+		}
+		if sl.LevelPresentFlag {
+			sl.LevelIndicator = byte(r.Read(8))
+		}
+	}
+	return ptl
+}
+
+// CodedSize - decoded picture width and height in luma samples, before cropping
+func (s *SPS) CodedSize() (width, height uint32) {
+	return s.PicWidthInLumaSamples, s.PicHeightInLumaSamples
 }
 
 // ImageSize - calculated width and height using ConformanceWindow
+// The offsets are in chroma sample units, Sec. 7.4.3.2.1, which equal luma
+// samples for 4:0:0, 4:4:4 and separate colour planes. A malformed window
+// larger than the coded picture gives a size of 0.
 func (s *SPS) ImageSize() (width, height uint32) {
-	encWidth, encHeight := s.PicWidthInLumaSamples, s.PicHeightInLumaSamples
-	var subWidthC, subHeightC uint32 = 1, 1
+	width, height = s.CodedSize()
+	if !s.ConformanceWindowFlag {
+		return width, height
+	}
+	subWidthC, subHeightC := s.chromaSubsampling()
+	w := s.ConformanceWindow
+	width = cropSize(width, uint64(w.LeftOffset)+uint64(w.RightOffset), subWidthC)
+	height = cropSize(height, uint64(w.TopOffset)+uint64(w.BottomOffset), subHeightC)
+	return width, height
+}
+
+// cropSize - size less offsets in units of sub samples, 0 if they exceed it
+func cropSize(size uint32, offsets uint64, sub uint32) uint32 {
+	if crop := offsets * uint64(sub); crop < uint64(size) {
+		return size - uint32(crop)
+	}
+	return 0
+}
+
+// chromaSubsampling - SubWidthC and SubHeightC, Table 6-1
+func (s *SPS) chromaSubsampling() (subWidthC, subHeightC uint32) {
+	if s.SeparateColourPlaneFlag {
+		return 1, 1
+	}
 	switch s.ChromaFormatIndicator {
 	case 1: // 4:2:0
-		subWidthC, subHeightC = 2, 2
+		return 2, 2
 	case 2: // 4:2:2
-		subWidthC = 2
+		return 2, 1
 	}
-	width = encWidth - (s.ConformanceWindow.LeftOffset+s.ConformanceWindow.RightOffset)*subWidthC
-	height = encHeight - (s.ConformanceWindow.TopOffset+s.ConformanceWindow.BottomOffset)*subHeightC
-	return width, height
+	return 1, 1
 }
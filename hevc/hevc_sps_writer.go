@@ -0,0 +1,385 @@
+package hevc
+
+import (
+	"fmt"
+
+	"github.com/go-webdl/media-codec/nalu"
+)
+
+// CreateSPSNALUnit - encode sps as an SPS NAL unit starting with NAL unit header
+// Parsing an SPS NAL unit and encoding the result gives back the same bytes,
+// so an SPS can be modified, e.g. to add VUI colour description or timing
+// info missing from an HDR stream, and written out again. Emulation
+// prevention bytes are recomputed. Presence flags decide which fields are
+// written, e.g. VUI timing info is only written if VUI.TimingInfoPresentFlag
+// is set. The profile constraint flags are written from
+// GeneralConstraintIndicatorFlags, scaling lists and predicted reference
+// picture sets from their coded fields, and extensions from ExtensionData.
+func CreateSPSNALUnit(sps *SPS) ([]byte, error) {
+	if err := sps.checkWritable(); err != nil {
+		return nil, err
+	}
+	w := nalu.NewRBSPWriter()
+	w.Write(uint(sps.VpsID), 4)
+	w.Write(uint(sps.MaxSubLayersMinus1), 3)
+	w.WriteFlag(sps.TemporalIdNestingFlag)
+	writeProfileTierLevel(w, &sps.ProfileTierLevel, true)
+	w.WriteExpGolomb(uint(sps.SpsID))
+	w.WriteExpGolomb(uint(sps.ChromaFormatIndicator))
+	if sps.ChromaFormatIndicator == 3 {
+		w.WriteFlag(sps.SeparateColourPlaneFlag)
+	}
+	w.WriteExpGolomb(uint(sps.PicWidthInLumaSamples))
+	w.WriteExpGolomb(uint(sps.PicHeightInLumaSamples))
+	w.WriteFlag(sps.ConformanceWindowFlag)
+	if sps.ConformanceWindowFlag {
+		writeConformanceWindow(w, &sps.ConformanceWindow)
+	}
+	w.WriteExpGolomb(uint(sps.BitDepthLumaMinus8))
+	w.WriteExpGolomb(uint(sps.BitDepthChromaMinus8))
+	w.WriteExpGolomb(uint(sps.Log2MaxPicOrderCntLsbMinus4))
+	w.WriteFlag(sps.SubLayerOrderingInfoPresentFlag)
+	for _, info := range sps.SubLayeringOrderingInfos {
+		w.WriteExpGolomb(uint(info.MaxDecPicBufferingMinus1))
+		w.WriteExpGolomb(uint(info.MaxNumReorderPics))
+		w.WriteExpGolomb(uint(info.MaxLatencyIncreasePlus1))
+	}
+	w.WriteExpGolomb(uint(sps.Log2MinLumaCodingBlockSizeMinus3))
+	w.WriteExpGolomb(uint(sps.Log2DiffMaxMinLumaCodingBlockSize))
+	w.WriteExpGolomb(uint(sps.Log2MinLumaTransformBlockSizeMinus2))
+	w.WriteExpGolomb(uint(sps.Log2DiffMaxMinLumaTransformBlockSize))
+	w.WriteExpGolomb(uint(sps.MaxTransformHierarchyDepthInter))
+	w.WriteExpGolomb(uint(sps.MaxTransformHierarchyDepthIntra))
+	w.WriteFlag(sps.ScalingListEnabledFlag)
+	if sps.ScalingListEnabledFlag {
+		w.WriteFlag(sps.ScalingListDataPresentFlag)
+		if sps.ScalingListDataPresentFlag {
+			writeScalingListData(w, &sps.ScalingListData)
+		}
+	}
+	w.WriteFlag(sps.AmpEnabledFlag)
+	w.WriteFlag(sps.SampleAdaptiveOffsetEnabledFlag)
+	w.WriteFlag(sps.PCMEnabledFlag)
+	if sps.PCMEnabledFlag {
+		w.Write(uint(sps.PCMSampleBitDepthLumaMinus1), 4)
+		w.Write(uint(sps.PCMSampleBitDepthChromaMinus1), 4)
+		w.WriteExpGolomb(uint(sps.Log2MinPCMLumaCodingBlockSizeMinus3))
+		w.WriteExpGolomb(uint(sps.Log2DiffMaxMinPCMLumaCodingBlockSize))
+		w.WriteFlag(sps.PCMLoopFilterDisabledFlag)
+	}
+	w.WriteExpGolomb(uint(sps.NumShortTermRefPicSets))
+	for i := range sps.ShortTermRefPicSets {
+		if err := writeShortTermRefPicSet(w, &sps.ShortTermRefPicSets[i], i, int(sps.NumShortTermRefPicSets)); err != nil {
+			return nil, err
+		}
+	}
+	w.WriteFlag(sps.LongTermRefPicsPresentFlag)
+	if sps.LongTermRefPicsPresentFlag {
+		w.WriteExpGolomb(uint(len(sps.LtRefPicPocLsbSps)))
+		for i, lsb := range sps.LtRefPicPocLsbSps {
+			w.Write(uint(lsb), int(sps.Log2MaxPicOrderCntLsbMinus4)+4)
+			w.WriteFlag(sps.UsedByCurrPicLtSpsFlags[i])
+		}
+	}
+	w.WriteFlag(sps.SpsTemporalMvpEnabledFlag)
+	w.WriteFlag(sps.StrongIntraSmoothingEnabledFlag)
+	w.WriteFlag(sps.VUIParametersPresentFlag)
+	if sps.VUIParametersPresentFlag {
+		writeVUIParameters(w, &sps.VUI)
+	}
+	w.WriteFlag(sps.ExtensionPresentFlag)
+	if sps.ExtensionPresentFlag {
+		for i := 0; i < sps.ExtensionDataBits; i++ {
+			w.Write(uint(sps.ExtensionData[i/8]>>uint(7-i%8)), 1)
+		}
+	}
+	w.WriteTrailingBits()
+	return w.NALUnit([]byte{byte(NALU_SPS) << 1, 1})
+}
+
+// checkWritable - do the lengths of the lists of sps match their counts
+func (sps *SPS) checkWritable() error {
+	if len(sps.ProfileTierLevel.SubLayers) != int(sps.MaxSubLayersMinus1) {
+		return fmt.Errorf("%d sub-layer profile tier levels, need %d", len(sps.ProfileTierLevel.SubLayers), sps.MaxSubLayersMinus1)
+	}
+	orderingInfos := 1
+	if sps.SubLayerOrderingInfoPresentFlag {
+		orderingInfos = int(sps.MaxSubLayersMinus1) + 1
+	}
+	if len(sps.SubLayeringOrderingInfos) != orderingInfos {
+		return fmt.Errorf("%d sub-layer ordering infos, need %d", len(sps.SubLayeringOrderingInfos), orderingInfos)
+	}
+	if len(sps.ShortTermRefPicSets) != int(sps.NumShortTermRefPicSets) || sps.NumShortTermRefPicSets > maxShortTermRefPicSets {
+		return fmt.Errorf("%d short-term reference picture sets, need %d", len(sps.ShortTermRefPicSets), sps.NumShortTermRefPicSets)
+	}
+	if sps.LongTermRefPicsPresentFlag {
+		if len(sps.LtRefPicPocLsbSps) > 32 || len(sps.UsedByCurrPicLtSpsFlags) != len(sps.LtRefPicPocLsbSps) {
+			return fmt.Errorf("%d long-term reference pictures with %d used flags", len(sps.LtRefPicPocLsbSps), len(sps.UsedByCurrPicLtSpsFlags))
+		}
+	}
+	if sps.ExtensionPresentFlag && (sps.ExtensionDataBits < 0 || len(sps.ExtensionData)*8 < sps.ExtensionDataBits) {
+		return fmt.Errorf("%d bytes of extension data shorter than %d bits", len(sps.ExtensionData), sps.ExtensionDataBits)
+	}
+	if sps.VUIParametersPresentFlag && sps.VUI.TimingInfoPresentFlag && sps.VUI.HrdParametersPresentFlag {
+		return sps.VUI.HrdParameters.checkWritable(sps.MaxSubLayersMinus1)
+	}
+	return nil
+}
+
+// writeProfileTierLevel - write profile_tier_level(profilePresentFlag, len(ptl.SubLayers))
+func writeProfileTierLevel(w *nalu.RBSPWriter, ptl *ProfileTierLevel, profilePresentFlag bool) {
+	if profilePresentFlag {
+		w.Write(uint(ptl.GeneralProfileSpace), 2)
+		w.WriteFlag(ptl.GeneralTierFlag)
+		w.Write(uint(ptl.GeneralProfileIndicator), 5)
+		w.Write(uint(ptl.GeneralProfileCompatibilityFlags), 32)
+		w.Write(uint(ptl.GeneralConstraintIndicatorFlags), 48)
+	}
+	w.Write(uint(ptl.GeneralLevelIndicator), 8)
+	if len(ptl.SubLayers) == 0 {
+		return
+	}
+	for _, sl := range ptl.SubLayers {
+		w.WriteFlag(sl.ProfilePresentFlag)
+		w.WriteFlag(sl.LevelPresentFlag)
+	}
+	for i := len(ptl.SubLayers); i < 8; i++ {
+		w.Write(0, 2) // reserved_zero_2bits
+	}
+	for _, sl := range ptl.SubLayers {
+		if sl.ProfilePresentFlag {
+			w.Write(uint(sl.ProfileSpace), 2)
+			w.WriteFlag(sl.TierFlag)
+			w.Write(uint(sl.ProfileIndicator), 5)
+			w.Write(uint(sl.ProfileCompatibilityFlags), 32)
+			w.Write(uint(sl.ConstraintIndicatorFlags), 48)
+		}
+		if sl.LevelPresentFlag {
+			w.Write(uint(sl.LevelIndicator), 8)
+		}
+	}
+}
+
+func writeConformanceWindow(w *nalu.RBSPWriter, win *ConformanceWindow) {
+	w.WriteExpGolomb(uint(win.LeftOffset))
+	w.WriteExpGolomb(uint(win.RightOffset))
+	w.WriteExpGolomb(uint(win.TopOffset))
+	w.WriteExpGolomb(uint(win.BottomOffset))
+}
+
+// writeScalingListData - write scaling_list_data(), explicit lists as deltas
+// of Lists and DCCoefs
+func writeScalingListData(w *nalu.RBSPWriter, d *ScalingListData) {
+	for sizeID := 0; sizeID < 4; sizeID++ {
+		matrixStep := 1
+		if sizeID == 3 {
+			matrixStep = 3
+		}
+		for matrixID := 0; matrixID < 6; matrixID += matrixStep {
+			w.WriteFlag(d.PredModeFlags[sizeID][matrixID])
+			if !d.PredModeFlags[sizeID][matrixID] {
+				w.WriteExpGolomb(uint(d.PredMatrixIDDeltas[sizeID][matrixID]))
+				continue
+			}
+			nextCoef := 8
+			if sizeID > 1 {
+				nextCoef = int(d.DCCoefs[sizeID-2][matrixID])
+				w.WriteSignedGolomb(nextCoef - 8)
+			}
+			for _, coef := range d.Lists[sizeID][matrixID] {
+				// scaling_list_delta_coef in -128..127, modulo 256
+				delta := (int(coef)-nextCoef+128+256)%256 - 128
+				w.WriteSignedGolomb(delta)
+				nextCoef = int(coef)
+			}
+		}
+	}
+}
+
+// writeShortTermRefPicSet - write st_ref_pic_set(stRpsIdx)
+func writeShortTermRefPicSet(w *nalu.RBSPWriter, rps *ShortTermRefPicSet, stRpsIdx, numShortTermRefPicSets int) error {
+	if stRpsIdx != 0 {
+		w.WriteFlag(rps.InterRefPicSetPredictionFlag)
+	}
+	if rps.InterRefPicSetPredictionFlag && stRpsIdx != 0 {
+		if stRpsIdx == numShortTermRefPicSets {
+			w.WriteExpGolomb(uint(rps.DeltaIdxMinus1))
+		}
+		if rps.DeltaRps == 0 || len(rps.UseDeltaFlags) != len(rps.UsedByCurrPicFlags) {
+			return fmt.Errorf("st_ref_pic_set %d: invalid prediction", stRpsIdx)
+		}
+		absDeltaRps := rps.DeltaRps
+		w.WriteFlag(absDeltaRps < 0)
+		if absDeltaRps < 0 {
+			absDeltaRps = -absDeltaRps
+		}
+		w.WriteExpGolomb(uint(absDeltaRps - 1))
+		for j, used := range rps.UsedByCurrPicFlags {
+			w.WriteFlag(used)
+			if !used {
+				w.WriteFlag(rps.UseDeltaFlags[j])
+			}
+		}
+		return nil
+	}
+	if len(rps.UsedByCurrPicS0) != len(rps.DeltaPocS0) || len(rps.UsedByCurrPicS1) != len(rps.DeltaPocS1) {
+		return fmt.Errorf("st_ref_pic_set %d: used flags do not match POC deltas", stRpsIdx)
+	}
+	w.WriteExpGolomb(uint(len(rps.DeltaPocS0)))
+	w.WriteExpGolomb(uint(len(rps.DeltaPocS1)))
+	prev := int32(0)
+	for i, poc := range rps.DeltaPocS0 {
+		if poc >= prev {
+			return fmt.Errorf("st_ref_pic_set %d: DeltaPocS0 not descending", stRpsIdx)
+		}
+		w.WriteExpGolomb(uint(prev - poc - 1))
+		w.WriteFlag(rps.UsedByCurrPicS0[i])
+		prev = poc
+	}
+	prev = 0
+	for i, poc := range rps.DeltaPocS1 {
+		if poc <= prev {
+			return fmt.Errorf("st_ref_pic_set %d: DeltaPocS1 not ascending", stRpsIdx)
+		}
+		w.WriteExpGolomb(uint(poc - prev - 1))
+		w.WriteFlag(rps.UsedByCurrPicS1[i])
+		prev = poc
+	}
+	return nil
+}
+
+func writeVUIParameters(w *nalu.RBSPWriter, vui *VUIParameters) {
+	w.WriteFlag(vui.AspectRatioInfoPresentFlag)
+	if vui.AspectRatioInfoPresentFlag {
+		w.Write(uint(vui.AspectRatioIndicator), 8)
+		if vui.AspectRatioIndicator == 255 { // EXTENDED_SAR
+			w.Write(uint(vui.SarWidth), 16)
+			w.Write(uint(vui.SarHeight), 16)
+		}
+	}
+	w.WriteFlag(vui.OverscanInfoPresentFlag)
+	if vui.OverscanInfoPresentFlag {
+		w.WriteFlag(vui.OverscanAppropriateFlag)
+	}
+	w.WriteFlag(vui.VideoSignalTypePresentFlag)
+	if vui.VideoSignalTypePresentFlag {
+		w.Write(uint(vui.VideoFormat), 3)
+		w.WriteFlag(vui.VideoFullRangeFlag)
+		w.WriteFlag(vui.ColourDescriptionPresentFlag)
+		if vui.ColourDescriptionPresentFlag {
+			w.Write(uint(vui.ColourPrimaries), 8)
+			w.Write(uint(vui.TransferCharacteristics), 8)
+			w.Write(uint(vui.MatrixCoefficients), 8)
+		}
+	}
+	w.WriteFlag(vui.ChromaLocInfoPresentFlag)
+	if vui.ChromaLocInfoPresentFlag {
+		w.WriteExpGolomb(uint(vui.ChromaSampleLocTypeTopField))
+		w.WriteExpGolomb(uint(vui.ChromaSampleLocTypeBottomField))
+	}
+	w.WriteFlag(vui.NeutralChromaIndicationFlag)
+	w.WriteFlag(vui.FieldSeqFlag)
+	w.WriteFlag(vui.FrameFieldInfoPresentFlag)
+	w.WriteFlag(vui.DefaultDisplayWindowFlag)
+	if vui.DefaultDisplayWindowFlag {
+		writeConformanceWindow(w, &vui.DefaultDisplayWindow)
+	}
+	w.WriteFlag(vui.TimingInfoPresentFlag)
+	if vui.TimingInfoPresentFlag {
+		w.Write(uint(vui.NumUnitsInTick), 32)
+		w.Write(uint(vui.TimeScale), 32)
+		w.WriteFlag(vui.PocProportionalToTimingFlag)
+		if vui.PocProportionalToTimingFlag {
+			w.WriteExpGolomb(uint(vui.NumTicksPocDiffOneMinus1))
+		}
+		w.WriteFlag(vui.HrdParametersPresentFlag)
+		if vui.HrdParametersPresentFlag {
+			writeHRDParameters(w, &vui.HrdParameters, true)
+		}
+	}
+	w.WriteFlag(vui.BitstreamRestrictionFlag)
+	if vui.BitstreamRestrictionFlag {
+		w.WriteFlag(vui.TilesFixedStructureFlag)
+		w.WriteFlag(vui.MotionVectorsOverPicBoundariesFlag)
+		w.WriteFlag(vui.RestrictedRefPicListsFlag)
+		w.WriteExpGolomb(uint(vui.MinSpatialSegmentationIdc))
+		w.WriteExpGolomb(uint(vui.MaxBytesPerPicDenom))
+		w.WriteExpGolomb(uint(vui.MaxBitsPerMinCuDenom))
+		w.WriteExpGolomb(uint(vui.Log2MaxMvLengthHorizontal))
+		w.WriteExpGolomb(uint(vui.Log2MaxMvLengthVertical))
+	}
+}
+
+// checkWritable - one sub-layer per temporal sub-layer and one SchedSel per CPB
+func (hrd *HRDParameters) checkWritable(maxNumSubLayersMinus1 byte) error {
+	if len(hrd.SubLayers) != int(maxNumSubLayersMinus1)+1 {
+		return fmt.Errorf("HRD parameters of %d sub-layers, need %d", len(hrd.SubLayers), maxNumSubLayersMinus1+1)
+	}
+	for i := range hrd.SubLayers {
+		sl := &hrd.SubLayers[i]
+		if hrd.NalHrdParametersPresentFlag && len(sl.NalSchedSels) != int(sl.CpbCntMinus1)+1 ||
+			hrd.VclHrdParametersPresentFlag && len(sl.VclSchedSels) != int(sl.CpbCntMinus1)+1 {
+			return fmt.Errorf("HRD parameters of sub-layer %d need cpb_cnt_minus1 + 1 SchedSels", i)
+		}
+	}
+	return nil
+}
+
+// writeHRDParameters - write hrd_parameters(commonInfPresentFlag, len(hrd.SubLayers)-1)
+func writeHRDParameters(w *nalu.RBSPWriter, hrd *HRDParameters, commonInfPresentFlag bool) {
+	if commonInfPresentFlag {
+		w.WriteFlag(hrd.NalHrdParametersPresentFlag)
+		w.WriteFlag(hrd.VclHrdParametersPresentFlag)
+		if hrd.NalHrdParametersPresentFlag || hrd.VclHrdParametersPresentFlag {
+			w.WriteFlag(hrd.SubPicHrdParamsPresentFlag)
+			if hrd.SubPicHrdParamsPresentFlag {
+				w.Write(uint(hrd.TickDivisorMinus2), 8)
+				w.Write(uint(hrd.DuCpbRemovalDelayIncrementLengthMinus1), 5)
+				w.WriteFlag(hrd.SubPicCpbParamsInPicTimingSeiFlag)
+				w.Write(uint(hrd.DpbOutputDelayDuLengthMinus1), 5)
+			}
+			w.Write(uint(hrd.BitRateScale), 4)
+			w.Write(uint(hrd.CpbSizeScale), 4)
+			if hrd.SubPicHrdParamsPresentFlag {
+				w.Write(uint(hrd.CpbSizeDuScale), 4)
+			}
+			w.Write(uint(hrd.InitialCpbRemovalDelayLengthMinus1), 5)
+			w.Write(uint(hrd.AuCpbRemovalDelayLengthMinus1), 5)
+			w.Write(uint(hrd.DpbOutputDelayLengthMinus1), 5)
+		}
+	}
+	for i := range hrd.SubLayers {
+		sl := &hrd.SubLayers[i]
+		w.WriteFlag(sl.FixedPicRateGeneralFlag)
+		if !sl.FixedPicRateGeneralFlag {
+			w.WriteFlag(sl.FixedPicRateWithinCvsFlag)
+		}
+		if sl.FixedPicRateWithinCvsFlag {
+			w.WriteExpGolomb(uint(sl.ElementalDurationInTcMinus1))
+		} else {
+			w.WriteFlag(sl.LowDelayHrdFlag)
+		}
+		if !sl.LowDelayHrdFlag {
+			w.WriteExpGolomb(uint(sl.CpbCntMinus1))
+		}
+		if hrd.NalHrdParametersPresentFlag {
+			writeSubLayerHRDParameters(w, sl.NalSchedSels, hrd.SubPicHrdParamsPresentFlag)
+		}
+		if hrd.VclHrdParametersPresentFlag {
+			writeSubLayerHRDParameters(w, sl.VclSchedSels, hrd.SubPicHrdParamsPresentFlag)
+		}
+	}
+}
+
+// writeSubLayerHRDParameters - write sub_layer_hrd_parameters(), Sec. E.2.3
+func writeSubLayerHRDParameters(w *nalu.RBSPWriter, sels []HRDSchedSel, subPicHrdParamsPresentFlag bool) {
+	for _, sel := range sels {
+		w.WriteExpGolomb(uint(sel.BitRateValueMinus1))
+		w.WriteExpGolomb(uint(sel.CpbSizeValueMinus1))
+		if subPicHrdParamsPresentFlag {
+			w.WriteExpGolomb(uint(sel.CpbSizeDuValueMinus1))
+			w.WriteExpGolomb(uint(sel.BitRateDuValueMinus1))
+		}
+		w.WriteFlag(sel.CbrFlag)
+	}
+}
@@ -0,0 +1,116 @@
+package hevc
+
+import (
+	"github.com/go-webdl/media-codec/nalu"
+)
+
+// GetTemporalID - TemporalId (nuh_temporal_id_plus1 - 1) from the two bytes of NALU Header
+// nuh_temporal_id_plus1 is never 0 in a conforming stream; 0 is returned then.
+func GetTemporalID(naluHeader []byte) byte {
+	tid := naluHeader[1] & 0b111
+	if tid == 0 {
+		return 0
+	}
+	return tid - 1
+}
+
+// FilterTemporalLayerNALUnits - the NAL units of nalus with TemporalId up to maxTemporalID
+// Pictures only reference pictures of the same or a lower TemporalId, so the
+// result decodes at a lower frame rate (sub-bitstream extraction, ISO/IEC
+// 23008-2 Sec. 10). The order of NAL units is kept.
+func FilterTemporalLayerNALUnits(nalus [][]byte, maxTemporalID byte) (kept [][]byte) {
+	for _, data := range nalus {
+		if len(data) < 2 || GetTemporalID(data) <= maxTemporalID {
+			kept = append(kept, data)
+		}
+	}
+	return kept
+}
+
+// FilterTemporalLayerSample - drop the NAL units above maxTemporalID from a length-prefixed sample
+// ok is false if no VCL NAL unit is left, i.e. the whole access unit belongs
+// to a dropped sub-layer and the sample should be removed from the track,
+// with its duration added to the preceding sample. The sample is returned
+// unchanged if nothing is dropped.
+func FilterTemporalLayerSample(sample []byte, lengthSize int, maxTemporalID byte) (filtered []byte, ok bool, err error) {
+	nalus, err := nalu.SplitSample(sample, lengthSize)
+	if err != nil {
+		return nil, false, err
+	}
+	kept := FilterTemporalLayerNALUnits(nalus, maxTemporalID)
+	if _, ok = PictureType(kept); !ok {
+		return nil, false, nil
+	}
+	if len(kept) == len(nalus) {
+		return sample, true, nil
+	}
+	filtered, err = nalu.AppendSample(nil, kept, lengthSize)
+	return filtered, err == nil, err
+}
+
+// FilterTemporalLayers - record of the stream with the sub-layers above maxTemporalID dropped
+// numTemporalLayers is capped at maxTemporalID+1 and the level is lowered to
+// the sub-layer level of the SPSs when they all signal it. The frame rate is
+// that of the highest kept sub-layer if it is fixed in the HRD parameters of
+// the first SPS, and unknown (0) otherwise. The record itself is not modified.
+func (b *HEVCDecoderConfigurationRecord) FilterTemporalLayers(maxTemporalID byte) (HEVCDecoderConfigurationRecord, error) {
+	rec := *b
+	// TemporalId is at most 6
+	if maxTemporalID >= 6 || (rec.NumTemporalLayers != 0 && rec.NumTemporalLayers <= maxTemporalID+1) {
+		return rec, nil
+	}
+	rec.NumTemporalLayers = maxTemporalID + 1
+
+	var vpsNalus, spsNalus [][]byte
+	for _, array := range b.NaluArrays {
+		switch array.NALUnitType {
+		case NALU_VPS:
+			vpsNalus = append(vpsNalus, array.NALUs...)
+		case NALU_SPS:
+			spsNalus = append(spsNalus, array.NALUs...)
+		}
+	}
+	rec.AvgFrameRate = 0
+	rec.ConstantFrameRate = CONSTANT_FRAME_RATE_UNKNOWN
+	level, levelKnown := byte(0), true
+	for i, data := range spsNalus {
+		sps, err := ParseSPSNALUnit(data)
+		if err != nil {
+			return rec, err
+		}
+		subLayerLevel, ok := sps.subLayerLevel(maxTemporalID)
+		levelKnown = levelKnown && ok
+		if subLayerLevel > level {
+			level = subLayerLevel
+		}
+		if i == 0 {
+			var vps *VPS
+			for _, data := range vpsNalus {
+				if v, err := ParseVPSNALUnit(data); err == nil && v.VpsID == sps.VpsID {
+					vps = v
+				}
+			}
+			rec.setFrameRate(sps, vps)
+			if rec.ConstantFrameRate == CONSTANT_FRAME_RATE_UNKNOWN {
+				rec.AvgFrameRate = 0
+			}
+		}
+	}
+	if levelKnown && level != 0 && level < rec.GeneralLevelIndicator {
+		rec.GeneralLevelIndicator = level
+	}
+	return rec, nil
+}
+
+// subLayerLevel - level of the sub-bitstream up to TemporalId tid
+// ok is false if the SPS does not signal it.
+func (s *SPS) subLayerLevel(tid byte) (level byte, ok bool) {
+	if tid >= s.MaxSubLayersMinus1 {
+		return s.ProfileTierLevel.GeneralLevelIndicator, true
+	}
+	if int(tid) >= len(s.ProfileTierLevel.SubLayers) {
+		return 0, false
+	}
+	sl := &s.ProfileTierLevel.SubLayers[tid]
+	return sl.LevelIndicator, sl.LevelPresentFlag
+}
@@ -0,0 +1,98 @@
+package hevc
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MaxBitRate - max bit rate of the level in a tier in bits/s, 0 if the tier is not allowed
+func (l *LevelLimits) MaxBitRate(highTier bool) uint64 {
+	if highTier {
+		return 1000 * uint64(l.MaxBRHigh)
+	}
+	return 1000 * uint64(l.MaxBRMain)
+}
+
+// SetTier - change the tier of the record, raising the level if needed
+// The level is revalidated against the new tier with MinLevelAndTier and the
+// requirements of each SPS: High tier is only defined from level 4, and a bit
+// rate above the limit of the level in the new tier, e.g. when moving
+// high-tier content to Main tier, requires a higher level. bitRate is the
+// peak bit rate of the stream in bits/s, 0 if unknown; the HRD bit rate of
+// the SPS is used if higher. The level is never lowered. Moving to Main tier fails if an SPS signals High
+// tier, since the record must cover all parameter sets. changes describes
+// the modified fields.
+func (b *HEVCDecoderConfigurationRecord) SetTier(highTier bool, bitRate uint64) (changes []string, err error) {
+	var limits LevelLimits
+	found := false
+	for _, array := range b.NaluArrays {
+		if array.NALUnitType != NALU_SPS {
+			continue
+		}
+		for i, nalu := range array.NALUs {
+			sps, err := ParseSPSNALUnit(nalu)
+			if err != nil {
+				return nil, fmt.Errorf("SPS %d: %w", i, err)
+			}
+			if sps.ProfileTierLevel.GeneralTierFlag && !highTier {
+				return nil, fmt.Errorf("SPS %d signals high tier", i)
+			}
+			req := sps.LevelRequirements()
+			if bitRate > req.BitRate {
+				req.BitRate = bitRate
+			}
+			req.FixedTier, req.HighTier = true, highTier
+			l, _, ok := MinLevelAndTier(req)
+			if !ok {
+				return nil, fmt.Errorf("SPS %d: no %s tier level supports the parameters at %d bits/s", i, tierName(highTier), req.BitRate)
+			}
+			if !found || l.LevelIndicator > limits.LevelIndicator {
+				limits, found = l, true
+			}
+		}
+	}
+	if !found {
+		return nil, errors.New("no SPS")
+	}
+	if b.GeneralTierFlag != highTier {
+		changes = append(changes, fmt.Sprintf("tier %s to %s", tierName(b.GeneralTierFlag), tierName(highTier)))
+		b.GeneralTierFlag = highTier
+	}
+	if limits.LevelIndicator > b.GeneralLevelIndicator {
+		changes = append(changes, fmt.Sprintf("level %d to %d", b.GeneralLevelIndicator, limits.LevelIndicator))
+		b.GeneralLevelIndicator = limits.LevelIndicator
+	}
+	return changes, nil
+}
+
+// RaiseLevel - raise general_level_idc of the SPS to the lowest level of its
+// tier its parameters allow
+// bitRate is the peak bit rate of the stream in bits/s, 0 if unknown; the
+// HRD bit rate is used if higher. The level is never lowered. changes
+// describes the modified fields.
+func (s *SPS) RaiseLevel(bitRate uint64) (changes []string, err error) {
+	ptl := &s.ProfileTierLevel
+	req := s.LevelRequirements()
+	if bitRate > req.BitRate {
+		req.BitRate = bitRate
+	}
+	req.FixedTier, req.HighTier = true, ptl.GeneralTierFlag
+	required, _, ok := MinLevelAndTier(req)
+	if !ok {
+		return nil, fmt.Errorf("no %s tier level supports %dx%d at %d bits/s", tierName(ptl.GeneralTierFlag), req.Width, req.Height, req.BitRate)
+	}
+	if required.LevelIndicator <= ptl.GeneralLevelIndicator {
+		return nil, nil
+	}
+	changes = append(changes, fmt.Sprintf("level %s to %s", LevelName(ptl.GeneralLevelIndicator), LevelName(required.LevelIndicator)))
+	ptl.GeneralLevelIndicator = required.LevelIndicator
+	return changes, nil
+}
+
+// tierName - tier name for messages
+func tierName(highTier bool) string {
+	if highTier {
+		return "High"
+	}
+	return "Main"
+}
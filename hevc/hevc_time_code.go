@@ -0,0 +1,194 @@
+package hevc
+
+import (
+	"github.com/go-webdl/media-codec/nalu"
+	"github.com/go-webdl/media-codec/sei"
+)
+
+// TimeCode - time code SEI message
+// ISO/IEC 23008-2 Sec. D.2.26
+type TimeCode struct {
+	// ClockTimestamps - num_clock_ts entries, 1 to 3, one per field or frame
+	// of the picture
+	ClockTimestamps []ClockTimestamp
+}
+
+// ClockTimestamp - clock timestamp of a time code SEI message
+// The other fields are only valid with ClockTimestampFlag set.
+type ClockTimestamp struct {
+	ClockTimestampFlag  bool
+	UnitsFieldBasedFlag bool
+	CountingType        byte
+	FullTimestampFlag   bool
+	DiscontinuityFlag   bool
+	CntDroppedFlag      bool
+	NFrames             uint16
+	SecondsValue        byte
+	MinutesValue        byte
+	HoursValue          byte
+	// SecondsFlag, MinutesFlag, HoursFlag - which values are present when
+	// FullTimestampFlag is not set
+	SecondsFlag      bool
+	MinutesFlag      bool
+	HoursFlag        bool
+	TimeOffsetLength byte
+	TimeOffsetValue  int32
+}
+
+// ParseTimeCode - decode a time code SEI payload
+func ParseTimeCode(payload []byte) (*TimeCode, error) {
+	r := nalu.NewRBSPReader(payload)
+	tc := &TimeCode{}
+	tc.ClockTimestamps = make([]ClockTimestamp, r.Read(2))
+	for i := range tc.ClockTimestamps {
+		ts := &tc.ClockTimestamps[i]
+		ts.ClockTimestampFlag = r.ReadFlag()
+		if !ts.ClockTimestampFlag {
+			continue
+		}
+		ts.UnitsFieldBasedFlag = r.ReadFlag()
+		ts.CountingType = byte(r.Read(5))
+		ts.FullTimestampFlag = r.ReadFlag()
+		ts.DiscontinuityFlag = r.ReadFlag()
+		ts.CntDroppedFlag = r.ReadFlag()
+		ts.NFrames = uint16(r.Read(9))
+		if ts.FullTimestampFlag {
+			ts.SecondsValue = byte(r.Read(6))
+			ts.MinutesValue = byte(r.Read(6))
+			ts.HoursValue = byte(r.Read(5))
+		} else {
+			ts.SecondsFlag = r.ReadFlag()
+			if ts.SecondsFlag {
+				ts.SecondsValue = byte(r.Read(6))
+				ts.MinutesFlag = r.ReadFlag()
+				if ts.MinutesFlag {
+					ts.MinutesValue = byte(r.Read(6))
+					ts.HoursFlag = r.ReadFlag()
+					if ts.HoursFlag {
+						ts.HoursValue = byte(r.Read(5))
+					}
+				}
+			}
+		}
+		ts.TimeOffsetLength = byte(r.Read(5))
+		if n := int(ts.TimeOffsetLength); n > 0 {
+			v := int64(r.Read(n))
+			if v&(1<<(n-1)) != 0 {
+				v -= 1 << n
+			}
+			ts.TimeOffsetValue = int32(v)
+		}
+	}
+	return tc, r.AccError()
+}
+
+// NewTimeCode - time code SEI message with a single full timestamp of t
+// Drop-frame time code sets counting_type 4 and cnt_dropped_flag.
+func NewTimeCode(t sei.Timecode) *TimeCode {
+	ts := ClockTimestamp{
+		ClockTimestampFlag: true,
+		FullTimestampFlag:  true,
+		NFrames:            t.Frames,
+		SecondsValue:       t.Seconds,
+		MinutesValue:       t.Minutes,
+		HoursValue:         t.Hours,
+	}
+	if t.DropFrame {
+		ts.CountingType = sei.COUNTING_TYPE_DROP_FRAME
+		ts.CntDroppedFlag = true
+	}
+	return &TimeCode{ClockTimestamps: []ClockTimestamp{ts}}
+}
+
+// Message - encode the time code into an SEI message
+// At most 3 clock timestamps are written.
+func (tc *TimeCode) Message() (sei.Message, error) {
+	w := nalu.NewRBSPWriter()
+	timestamps := tc.ClockTimestamps
+	if len(timestamps) > 3 {
+		timestamps = timestamps[:3]
+	}
+	w.Write(uint(len(timestamps)), 2)
+	for i := range timestamps {
+		ts := &timestamps[i]
+		w.WriteFlag(ts.ClockTimestampFlag)
+		if !ts.ClockTimestampFlag {
+			continue
+		}
+		w.WriteFlag(ts.UnitsFieldBasedFlag)
+		w.Write(uint(ts.CountingType), 5)
+		w.WriteFlag(ts.FullTimestampFlag)
+		w.WriteFlag(ts.DiscontinuityFlag)
+		w.WriteFlag(ts.CntDroppedFlag)
+		w.Write(uint(ts.NFrames), 9)
+		if ts.FullTimestampFlag {
+			w.Write(uint(ts.SecondsValue), 6)
+			w.Write(uint(ts.MinutesValue), 6)
+			w.Write(uint(ts.HoursValue), 5)
+		} else {
+			w.WriteFlag(ts.SecondsFlag)
+			if ts.SecondsFlag {
+				w.Write(uint(ts.SecondsValue), 6)
+				w.WriteFlag(ts.MinutesFlag)
+				if ts.MinutesFlag {
+					w.Write(uint(ts.MinutesValue), 6)
+					w.WriteFlag(ts.HoursFlag)
+					if ts.HoursFlag {
+						w.Write(uint(ts.HoursValue), 5)
+					}
+				}
+			}
+		}
+		w.Write(uint(ts.TimeOffsetLength), 5)
+		if ts.TimeOffsetLength > 0 {
+			w.Write(uint(ts.TimeOffsetValue), int(ts.TimeOffsetLength))
+		}
+	}
+	payload, err := w.SEIPayload()
+	if err != nil {
+		return sei.Message{}, err
+	}
+	return sei.Message{PayloadType: sei.SEI_TIME_CODE, Payload: payload}, nil
+}
+
+// Timecode - hours, minutes, seconds and frames of the timestamp
+// Values that are not present, which the decoder infers from the previous
+// timestamp, are 0. Drop-frame is counting_type 4 or cnt_dropped_flag.
+func (ts *ClockTimestamp) Timecode() sei.Timecode {
+	return sei.Timecode{
+		Hours:     ts.HoursValue,
+		Minutes:   ts.MinutesValue,
+		Seconds:   ts.SecondsValue,
+		Frames:    ts.NFrames,
+		DropFrame: ts.CountingType == sei.COUNTING_TYPE_DROP_FRAME || ts.CntDroppedFlag,
+	}
+}
+
+// FindTimeCode - the time code of the first clock timestamp of a time code
+// SEI message among the prefix SEI NAL units of an access unit
+func FindTimeCode(nalus [][]byte) (sei.Timecode, bool) {
+	for _, data := range nalus {
+		if len(data) < 2 || GetNaluType(data[0]) != NALU_SEI_PREFIX {
+			continue
+		}
+		msgs, err := ParseSEINALUnit(data)
+		if err != nil {
+			continue
+		}
+		for i := range msgs {
+			if msgs[i].PayloadType != sei.SEI_TIME_CODE {
+				continue
+			}
+			tc, err := ParseTimeCode(msgs[i].Payload)
+			if err != nil {
+				continue
+			}
+			for j := range tc.ClockTimestamps {
+				if tc.ClockTimestamps[j].ClockTimestampFlag {
+					return tc.ClockTimestamps[j].Timecode(), true
+				}
+			}
+		}
+	}
+	return sei.Timecode{}, false
+}
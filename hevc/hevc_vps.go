@@ -0,0 +1,276 @@
+package hevc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/go-webdl/bits"
+)
+
+// ScalabilityType - index into scalability_mask_flag, ISO/IEC 23008-2 Table F.1
+const (
+	SCALABILITY_DEPTH     = 0 // DepthLayerFlag
+	SCALABILITY_MULTIVIEW = 1 // ViewOrderIdx
+	SCALABILITY_SPATIAL   = 2 // DependencyId, spatial or quality
+	SCALABILITY_AUXILIARY = 3 // AuxId
+	NUM_SCALABILITY_TYPES = 16
+)
+
+// AuxId - type of auxiliary picture, ISO/IEC 23008-2 Table F.2
+const (
+	AUX_NONE  = byte(0)
+	AUX_ALPHA = byte(1)
+	AUX_DEPTH = byte(2)
+)
+
+// VPS - HEVC VPS parameters
+// ISO/IEC 23008-2 Sec. 7.3.2.1
+type VPS struct {
+	VpsID                           byte
+	BaseLayerInternalFlag           bool
+	BaseLayerAvailableFlag          bool
+	MaxLayersMinus1                 byte
+	MaxSubLayersMinus1              byte
+	TemporalIdNestingFlag           bool
+	ProfileTierLevel                ProfileTierLevel
+	SubLayerOrderingInfoPresentFlag bool
+	SubLayeringOrderingInfos        []SubLayerOrderingInfo
+	MaxLayerID                      byte
+	NumLayerSetsMinus1              uint16
+	// LayerIDIncludedFlags - layer IDs included in layer set 1 to NumLayerSetsMinus1,
+	// bit n set for nuh_layer_id n
+	LayerIDIncludedFlags        []uint64
+	TimingInfoPresentFlag       bool
+	NumUnitsInTick              uint32
+	TimeScale                   uint32
+	PocProportionalToTimingFlag bool
+	NumTicksPocDiffOneMinus1    uint32
+	HRDParameters               []VPSHRDParameters
+	ExtensionFlag               bool
+	// Extension - only valid if ExtensionFlag is set
+	Extension VPSExtension
+}
+
+// VPSHRDParameters - HRD parameters applying to a layer set
+type VPSHRDParameters struct {
+	LayerSetIdx      uint16
+	CprmsPresentFlag bool
+	HRDParameters    HRDParameters
+}
+
+// VPSExtension - start of vps_extension() up to the layer dimension IDs
+// ISO/IEC 23008-2 Sec. F.7.3.2.1.1
+// This is enough to tell what each layer of a layered stream carries, e.g.
+// an alpha plane. Layer dependencies and the later fields are not parsed.
+type VPSExtension struct {
+	// ProfileTierLevel - level of the base layer, only present with more than
+	// one layer and an internal base layer
+	ProfileTierLevel ProfileTierLevel
+	SplittingFlag    bool
+	// ScalabilityMaskFlags - scalability_mask_flag[0] in the most significant bit
+	ScalabilityMaskFlags  uint16
+	DimensionIDLenMinus1  []byte
+	NuhLayerIDPresentFlag bool
+	// Layers - layers 1 to MaxLayersMinus1, layer 0 being the base layer
+	Layers []VPSLayer
+}
+
+// VPSLayer - nuh_layer_id and scalability IDs of a layer
+type VPSLayer struct {
+	LayerIDInNuh byte
+	// ScalabilityIDs - ScalabilityId, indexed by scalability type
+	ScalabilityIDs [NUM_SCALABILITY_TYPES]byte
+}
+
+// AuxID - type of auxiliary picture carried in the layer, AUX_NONE for a primary layer
+func (l *VPSLayer) AuxID() byte {
+	return l.ScalabilityIDs[SCALABILITY_AUXILIARY]
+}
+
+// ScalabilityMaskFlag - is scalability of type scalabilityType used
+func (e *VPSExtension) ScalabilityMaskFlag(scalabilityType int) bool {
+	return e.ScalabilityMaskFlags>>(NUM_SCALABILITY_TYPES-1-scalabilityType)&1 != 0
+}
+
+// ParseVPSNALUnit - Parse HEVC VPS NAL unit starting with NAL unit header
+func ParseVPSNALUnit(data []byte) (*VPS, error) {
+	vps := &VPS{}
+
+	rd := bytes.NewReader(data)
+	r := bits.NewAccErrEBSPReader(rd)
+	// Note! First two bytes are NALU Header
+
+	naluHdrBits := r.Read(16)
+	naluType := GetNaluType(byte(naluHdrBits >> 8))
+	if naluType != NALU_VPS {
+		return nil, fmt.Errorf("NALU type is %s not VPS", naluType)
+	}
+	vps.VpsID = byte(r.Read(4))
+	vps.BaseLayerInternalFlag = r.ReadFlag()
+	vps.BaseLayerAvailableFlag = r.ReadFlag()
+	vps.MaxLayersMinus1 = byte(r.Read(6))
+	vps.MaxSubLayersMinus1 = byte(r.Read(3))
+	vps.TemporalIdNestingFlag = r.ReadFlag()
+	_ = r.Read(16) // vps_reserved_0xffff_16bits
+	vps.ProfileTierLevel = readProfileTierLevel(r, true, vps.MaxSubLayersMinus1)
+	vps.SubLayerOrderingInfoPresentFlag = r.ReadFlag()
+	startValue := vps.MaxSubLayersMinus1
+	if vps.SubLayerOrderingInfoPresentFlag {
+		startValue = 0
+	}
+	for i := startValue; i <= vps.MaxSubLayersMinus1; i++ {
+		vps.SubLayeringOrderingInfos = append(
+			vps.SubLayeringOrderingInfos,
+			SubLayerOrderingInfo{
+				MaxDecPicBufferingMinus1: byte(r.ReadExpGolomb()),
+				MaxNumReorderPics:        byte(r.ReadExpGolomb()),
+				MaxLatencyIncreasePlus1:  byte(r.ReadExpGolomb()),
+			})
+	}
+	vps.MaxLayerID = byte(r.Read(6))
+	numLayerSetsMinus1 := r.ReadExpGolomb()
+	if numLayerSetsMinus1 > 1023 {
+		return nil, fmt.Errorf("vps_num_layer_sets_minus1 %d out of range", numLayerSetsMinus1)
+	}
+	vps.NumLayerSetsMinus1 = uint16(numLayerSetsMinus1)
+	for i := 1; i <= int(vps.NumLayerSetsMinus1); i++ {
+		var included uint64
+		for j := 0; j <= int(vps.MaxLayerID); j++ {
+			if r.ReadFlag() {
+				included |= 1 << uint(j)
+			}
+		}
+		vps.LayerIDIncludedFlags = append(vps.LayerIDIncludedFlags, included)
+	}
+	vps.TimingInfoPresentFlag = r.ReadFlag()
+	if vps.TimingInfoPresentFlag {
+		vps.NumUnitsInTick = uint32(r.Read(32))
+		vps.TimeScale = uint32(r.Read(32))
+		vps.PocProportionalToTimingFlag = r.ReadFlag()
+		if vps.PocProportionalToTimingFlag {
+			vps.NumTicksPocDiffOneMinus1 = uint32(r.ReadExpGolomb())
+		}
+		numHrdParameters := r.ReadExpGolomb()
+		if numHrdParameters > uint(vps.NumLayerSetsMinus1)+1 {
+			return nil, fmt.Errorf("vps_num_hrd_parameters %d out of range", numHrdParameters)
+		}
+		for i := 0; i < int(numHrdParameters); i++ {
+			hrd := VPSHRDParameters{
+				LayerSetIdx:      uint16(r.ReadExpGolomb()),
+				CprmsPresentFlag: true,
+			}
+			if i > 0 {
+				hrd.CprmsPresentFlag = r.ReadFlag()
+			}
+			var err error
+			if hrd.HRDParameters, err = readHRDParameters(r, hrd.CprmsPresentFlag, vps.MaxSubLayersMinus1); err != nil {
+				return nil, err
+			}
+			vps.HRDParameters = append(vps.HRDParameters, hrd)
+		}
+	}
+	vps.ExtensionFlag = r.ReadFlag()
+	if !vps.ExtensionFlag {
+		return vps, r.AccError()
+	}
+	for r.NrBitsReadInCurrentByte() != 8 && r.AccError() == nil {
+		_ = r.Read(1) // vps_extension_alignment_bit_equal_to_one
+	}
+	var err error
+	if vps.Extension, err = readVPSExtension(r, vps); err != nil {
+		return nil, err
+	}
+	return vps, nil
+}
+
+// readVPSExtension - read vps_extension() up to the dimension IDs of all layers
+func readVPSExtension(r *bits.AccErrEBSPReader, vps *VPS) (ext VPSExtension, err error) {
+	if vps.MaxLayersMinus1 > 0 && vps.BaseLayerInternalFlag {
+		ext.ProfileTierLevel = readProfileTierLevel(r, false, vps.MaxSubLayersMinus1)
+	}
+	ext.SplittingFlag = r.ReadFlag()
+	ext.ScalabilityMaskFlags = uint16(r.Read(NUM_SCALABILITY_TYPES))
+	numScalabilityTypes := 0
+	for i := 0; i < NUM_SCALABILITY_TYPES; i++ {
+		if ext.ScalabilityMaskFlag(i) {
+			numScalabilityTypes++
+		}
+	}
+	numLens := numScalabilityTypes
+	if ext.SplittingFlag && numLens > 0 {
+		numLens--
+	}
+	for j := 0; j < numLens; j++ {
+		ext.DimensionIDLenMinus1 = append(ext.DimensionIDLenMinus1, byte(r.Read(3)))
+	}
+	// dimBitOffset, Eq. F-2; with splitting_flag the last length is inferred
+	dimBitOffset := make([]int, numScalabilityTypes+1)
+	for j := 0; j < numScalabilityTypes; j++ {
+		if j == len(ext.DimensionIDLenMinus1) {
+			if dimBitOffset[j] > 5 {
+				return ext, errors.New("dimension ID lengths exceed the 6 bits of nuh_layer_id")
+			}
+			ext.DimensionIDLenMinus1 = append(ext.DimensionIDLenMinus1, byte(5-dimBitOffset[j]))
+		}
+		dimBitOffset[j+1] = dimBitOffset[j] + int(ext.DimensionIDLenMinus1[j]) + 1
+	}
+	ext.NuhLayerIDPresentFlag = r.ReadFlag()
+
+	maxLayersMinus1 := int(vps.MaxLayersMinus1)
+	if maxLayersMinus1 > 62 { // MaxLayersMinus1, Eq. F-1
+		maxLayersMinus1 = 62
+	}
+	for i := 1; i <= maxLayersMinus1 && r.AccError() == nil; i++ {
+		layer := VPSLayer{LayerIDInNuh: byte(i)}
+		if ext.NuhLayerIDPresentFlag {
+			layer.LayerIDInNuh = byte(r.Read(6))
+		}
+		j := 0
+		for smIdx := 0; smIdx < NUM_SCALABILITY_TYPES; smIdx++ {
+			if !ext.ScalabilityMaskFlag(smIdx) {
+				continue
+			}
+			if ext.SplittingFlag {
+				mask := byte(1)<<uint(dimBitOffset[j+1]) - 1
+				layer.ScalabilityIDs[smIdx] = (layer.LayerIDInNuh & mask) >> uint(dimBitOffset[j])
+			} else {
+				layer.ScalabilityIDs[smIdx] = byte(r.Read(int(ext.DimensionIDLenMinus1[j]) + 1))
+			}
+			j++
+		}
+		ext.Layers = append(ext.Layers, layer)
+	}
+	return ext, r.AccError()
+}
+
+// Layer - layer description of nuh_layer_id layerID
+func (v *VPS) Layer(layerID byte) (*VPSLayer, bool) {
+	if !v.ExtensionFlag {
+		return nil, false
+	}
+	for i := range v.Extension.Layers {
+		if v.Extension.Layers[i].LayerIDInNuh == layerID {
+			return &v.Extension.Layers[i], true
+		}
+	}
+	return nil, false
+}
+
+// AuxiliaryLayerID - nuh_layer_id of the first layer with auxiliary pictures of type auxID
+func (v *VPS) AuxiliaryLayerID(auxID byte) (layerID byte, ok bool) {
+	if !v.ExtensionFlag || auxID == AUX_NONE {
+		return 0, false
+	}
+	for _, layer := range v.Extension.Layers {
+		if layer.AuxID() == auxID {
+			return layer.LayerIDInNuh, true
+		}
+	}
+	return 0, false
+}
+
+// AlphaLayerID - nuh_layer_id of the alpha plane layer
+func (v *VPS) AlphaLayerID() (layerID byte, ok bool) {
+	return v.AuxiliaryLayerID(AUX_ALPHA)
+}
@@ -0,0 +1,166 @@
+package id3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"unicode/utf16"
+)
+
+// Text encodings of ID3v2 text frames
+const (
+	ENCODING_ISO_8859_1 = uint8(0)
+	ENCODING_UTF_16     = uint8(1)
+	ENCODING_UTF_16BE   = uint8(2)
+	ENCODING_UTF_8      = uint8(3)
+)
+
+// PrivateFrame - content of a PRIV frame
+type PrivateFrame struct {
+	// URL or reverse DNS name of the owner of the data
+	Owner string
+	Data  []byte
+}
+
+// ParsePrivateFrame - decode the content of a PRIV frame
+func ParsePrivateFrame(frame Frame) (*PrivateFrame, error) {
+	if frame.ID != "PRIV" {
+		return nil, fmt.Errorf("frame %q is not PRIV", frame.ID)
+	}
+	i := bytes.IndexByte(frame.Data, 0)
+	if i < 0 {
+		return nil, fmt.Errorf("PRIV owner identifier is not terminated")
+	}
+	return &PrivateFrame{
+		Owner: decodeISO88591(frame.Data[:i]),
+		Data:  frame.Data[i+1:],
+	}, nil
+}
+
+// Frame - encode as a PRIV frame
+func (p *PrivateFrame) Frame() Frame {
+	data := make([]byte, 0, len(p.Owner)+1+len(p.Data))
+	data = append(data, p.Owner...)
+	data = append(data, 0)
+	data = append(data, p.Data...)
+	return Frame{ID: "PRIV", Data: data}
+}
+
+// UserTextFrame - content of a TXXX frame
+type UserTextFrame struct {
+	Description string
+	Value       string
+}
+
+// ParseUserTextFrame - decode the content of a TXXX frame in any of the
+// text encodings allowed by ID3v2.4
+func ParseUserTextFrame(frame Frame) (*UserTextFrame, error) {
+	if frame.ID != "TXXX" {
+		return nil, fmt.Errorf("frame %q is not TXXX", frame.ID)
+	}
+	if len(frame.Data) < 1 {
+		return nil, fmt.Errorf("TXXX frame is empty")
+	}
+	encoding, data := frame.Data[0], frame.Data[1:]
+	terminator := []byte{0}
+	if encoding == ENCODING_UTF_16 || encoding == ENCODING_UTF_16BE {
+		terminator = []byte{0, 0}
+	}
+	i := indexAligned(data, terminator)
+	if i < 0 {
+		return nil, fmt.Errorf("TXXX description is not terminated")
+	}
+	description, err := decodeText(encoding, data[:i])
+	if err != nil {
+		return nil, err
+	}
+	value := data[i+len(terminator):]
+	if j := indexAligned(value, terminator); j >= 0 {
+		value = value[:j]
+	}
+	decoded, err := decodeText(encoding, value)
+	if err != nil {
+		return nil, err
+	}
+	return &UserTextFrame{Description: description, Value: decoded}, nil
+}
+
+// Frame - encode as a UTF-8 TXXX frame
+func (t *UserTextFrame) Frame() Frame {
+	data := make([]byte, 0, 1+len(t.Description)+1+len(t.Value))
+	data = append(data, ENCODING_UTF_8)
+	data = append(data, t.Description...)
+	data = append(data, 0)
+	data = append(data, t.Value...)
+	return Frame{ID: "TXXX", Data: data}
+}
+
+// TransportStreamTimestampOwner - owner of the PRIV frame HLS uses in packed
+// audio segments to map the ID3 timeline to the MPEG-2 TS 90kHz timeline
+const TransportStreamTimestampOwner = "com.apple.streaming.transportStreamTimestamp"
+
+// NewTransportStreamTimestamp - PRIV frame carrying a 33-bit MPEG-2 TS
+// timestamp
+func NewTransportStreamTimestamp(pts uint64) *PrivateFrame {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, pts&(1<<33-1))
+	return &PrivateFrame{Owner: TransportStreamTimestampOwner, Data: data}
+}
+
+// TransportStreamTimestamp - decode the 33-bit MPEG-2 TS timestamp of a
+// transportStreamTimestamp PRIV frame
+func (p *PrivateFrame) TransportStreamTimestamp() (uint64, error) {
+	if p.Owner != TransportStreamTimestampOwner {
+		return 0, fmt.Errorf("PRIV owner %q is not %s", p.Owner, TransportStreamTimestampOwner)
+	}
+	if len(p.Data) != 8 {
+		return 0, fmt.Errorf("transportStreamTimestamp is %d bytes, expected 8", len(p.Data))
+	}
+	return binary.BigEndian.Uint64(p.Data) & (1<<33 - 1), nil
+}
+
+func indexAligned(data, terminator []byte) int {
+	for i := 0; i+len(terminator) <= len(data); i += len(terminator) {
+		if bytes.Equal(data[i:i+len(terminator)], terminator) {
+			return i
+		}
+	}
+	return -1
+}
+
+func decodeISO88591(data []byte) string {
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+func decodeText(encoding uint8, data []byte) (string, error) {
+	switch encoding {
+	case ENCODING_ISO_8859_1:
+		return decodeISO88591(data), nil
+	case ENCODING_UTF_8:
+		return string(data), nil
+	case ENCODING_UTF_16, ENCODING_UTF_16BE:
+		order := binary.ByteOrder(binary.BigEndian)
+		if encoding == ENCODING_UTF_16 && len(data) >= 2 {
+			switch {
+			case data[0] == 0xFF && data[1] == 0xFE:
+				order, data = binary.LittleEndian, data[2:]
+			case data[0] == 0xFE && data[1] == 0xFF:
+				data = data[2:]
+			}
+		}
+		if len(data)%2 != 0 {
+			return "", fmt.Errorf("UTF-16 text has odd length %d", len(data))
+		}
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			units[i] = order.Uint16(data[2*i:])
+		}
+		return string(utf16.Decode(units)), nil
+	default:
+		return "", fmt.Errorf("unknown ID3v2 text encoding %d", encoding)
+	}
+}
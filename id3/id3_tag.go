@@ -0,0 +1,164 @@
+package id3
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Tag - ID3v2 tag as carried in timed metadata, https://id3.org/id3v2.4.0-structure
+// HLS carries ID3 tags in MPEG-2 TS timed metadata streams and in packed audio
+// segments, and DASH/CMAF carries the very same tags as message_data of an
+// 'emsg' box with the SchemeIDURI below. In both cases the tag is made of PRIV
+// and TXXX frames in the vast majority of streams, which is what this package
+// provides helpers for. Other frames are preserved as opaque data.
+type Tag struct {
+	// Major version of the tag, 3 or 4. Zero is written as 4.
+	Version uint8
+	// Revision of the tag
+	Revision uint8
+	Frames   []Frame
+}
+
+// Frame - ID3v2 frame with undecoded content
+type Frame struct {
+	// Four character frame identifier, e.g. "PRIV" or "TXXX"
+	ID    string
+	Flags uint16
+	Data  []byte
+}
+
+// SchemeIDURI - scheme_id_uri of 'emsg' boxes carrying an ID3 tag as
+// message_data
+const SchemeIDURI = "https://aomedia.org/emsg/ID3"
+
+const (
+	headerSize      = 10
+	frameHeaderSize = 10
+
+	flagUnsynchronisation = 0x80
+	flagExtendedHeader    = 0x40
+)
+
+func (t *Tag) RecordSize() (size uint32) {
+	// "ID3", version, revision, flags, syncsafe size
+	size += headerSize
+	for _, frame := range t.Frames {
+		size += frameHeaderSize + uint32(len(frame.Data))
+	}
+	return
+}
+
+func (t *Tag) RecordRead(r io.Reader) (err error) {
+	var tmp [headerSize]uint8
+	if _, err = io.ReadFull(r, tmp[:]); err != nil {
+		return
+	}
+	if string(tmp[:3]) != "ID3" {
+		return fmt.Errorf("not an ID3v2 tag")
+	}
+	t.Version = tmp[3]
+	t.Revision = tmp[4]
+	if t.Version != 3 && t.Version != 4 {
+		return fmt.Errorf("unsupported ID3v2 version %d", t.Version)
+	}
+	flags := tmp[5]
+	if flags&flagUnsynchronisation != 0 {
+		return fmt.Errorf("unsynchronised ID3v2 tags are not supported")
+	}
+	body := make([]byte, decodeSyncSafe(tmp[6:10]))
+	if _, err = io.ReadFull(r, body); err != nil {
+		return
+	}
+	if flags&flagExtendedHeader != 0 {
+		if len(body) < 4 {
+			return io.ErrUnexpectedEOF
+		}
+		extSize := binary.BigEndian.Uint32(body)
+		if t.Version == 4 {
+			// Size of the extended header including its size field
+			extSize = decodeSyncSafe(body[:4])
+		} else {
+			// Size of the extended header excluding its size field
+			extSize += 4
+		}
+		if uint32(len(body)) < extSize {
+			return io.ErrUnexpectedEOF
+		}
+		body = body[extSize:]
+	}
+	t.Frames = nil
+	for len(body) >= frameHeaderSize && body[0] != 0 {
+		var frameSize uint32
+		if t.Version == 4 {
+			frameSize = decodeSyncSafe(body[4:8])
+		} else {
+			frameSize = binary.BigEndian.Uint32(body[4:8])
+		}
+		if uint32(len(body)-frameHeaderSize) < frameSize {
+			return fmt.Errorf("ID3v2 frame %q size %d exceeds tag", body[:4], frameSize)
+		}
+		t.Frames = append(t.Frames, Frame{
+			ID:    string(body[:4]),
+			Flags: binary.BigEndian.Uint16(body[8:10]),
+			Data:  body[frameHeaderSize : frameHeaderSize+frameSize],
+		})
+		body = body[frameHeaderSize+frameSize:]
+	}
+	return
+}
+
+func (t *Tag) RecordWrite(w io.Writer) (err error) {
+	version := t.Version
+	if version == 0 {
+		version = 4
+	}
+	var tmp [headerSize]uint8
+	copy(tmp[:], "ID3")
+	tmp[3] = version
+	tmp[4] = t.Revision
+	encodeSyncSafe(tmp[6:10], t.RecordSize()-headerSize)
+	if _, err = w.Write(tmp[:]); err != nil {
+		return
+	}
+	for _, frame := range t.Frames {
+		if len(frame.ID) != 4 {
+			return fmt.Errorf("invalid ID3v2 frame ID %q", frame.ID)
+		}
+		copy(tmp[:4], frame.ID)
+		if version == 4 {
+			encodeSyncSafe(tmp[4:8], uint32(len(frame.Data)))
+		} else {
+			binary.BigEndian.PutUint32(tmp[4:8], uint32(len(frame.Data)))
+		}
+		binary.BigEndian.PutUint16(tmp[8:10], frame.Flags)
+		if _, err = w.Write(tmp[:frameHeaderSize]); err != nil {
+			return
+		}
+		if _, err = w.Write(frame.Data); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// FindFrames - all frames with the given identifier
+func (t *Tag) FindFrames(id string) (frames []Frame) {
+	for _, frame := range t.Frames {
+		if frame.ID == id {
+			frames = append(frames, frame)
+		}
+	}
+	return
+}
+
+func decodeSyncSafe(b []byte) uint32 {
+	return uint32(b[0]&0x7f)<<21 | uint32(b[1]&0x7f)<<14 | uint32(b[2]&0x7f)<<7 | uint32(b[3]&0x7f)
+}
+
+func encodeSyncSafe(b []byte, v uint32) {
+	b[0] = uint8(v>>21) & 0x7f
+	b[1] = uint8(v>>14) & 0x7f
+	b[2] = uint8(v>>7) & 0x7f
+	b[3] = uint8(v) & 0x7f
+}
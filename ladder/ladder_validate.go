@@ -0,0 +1,91 @@
+package ladder
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/go-webdl/media-codec/codec"
+)
+
+// Rendition - one video rendition of an adaptive streaming ladder
+type Rendition struct {
+	// Name - identifies the rendition in issues, e.g. its Representation id
+	Name string
+	// SampleEntry, Record - sample entry four character code and configuration record payload
+	SampleEntry string
+	Record      []byte
+	// Bandwidth - declared bitrate in bits per second
+	Bandwidth uint64
+}
+
+// Validate - check that renditions form a consistent ladder
+// All renditions must use the same codec, sample entry type, bit depth, chroma
+// format and colour description, and capability must grow with bandwidth:
+// no rendition may have a smaller picture or a lower level than a rendition
+// with less bandwidth. err is only set if a record cannot be probed.
+func Validate(renditions []Rendition) (issues []string, err error) {
+	type probed struct {
+		*Rendition
+		params *codec.Parameters
+	}
+	ladder := make([]probed, len(renditions))
+	for i := range renditions {
+		params, err := codec.Probe(renditions[i].SampleEntry, renditions[i].Record)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", renditions[i].Name, err)
+		}
+		ladder[i] = probed{&renditions[i], params}
+	}
+	if len(ladder) == 0 {
+		return nil, nil
+	}
+	sort.SliceStable(ladder, func(i, j int) bool { return ladder[i].Bandwidth < ladder[j].Bandwidth })
+
+	// the rendition with the highest bandwidth is the reference for uniform properties
+	ref := ladder[len(ladder)-1]
+	for _, r := range ladder[:len(ladder)-1] {
+		p, q := r.params, ref.params
+		if p.Codec != q.Codec {
+			issues = append(issues, fmt.Sprintf("%s: codec %s, %s uses %s", r.Name, p.Codec, ref.Name, q.Codec))
+			continue
+		}
+		if r.SampleEntry != ref.SampleEntry {
+			issues = append(issues, fmt.Sprintf("%s: sample entry %s, %s uses %s", r.Name, r.SampleEntry, ref.Name, ref.SampleEntry))
+		}
+		if p.BitDepthLuma != q.BitDepthLuma || p.BitDepthChroma != q.BitDepthChroma {
+			issues = append(issues, fmt.Sprintf("%s: %d-bit, %s is %d-bit", r.Name, p.BitDepthLuma, ref.Name, q.BitDepthLuma))
+		}
+		if p.ChromaFormat != q.ChromaFormat {
+			issues = append(issues, fmt.Sprintf("%s: chroma format %d, %s has %d", r.Name, p.ChromaFormat, ref.Name, q.ChromaFormat))
+		}
+		switch {
+		case p.Colour == nil && q.Colour == nil:
+		case p.Colour == nil || q.Colour == nil:
+			issues = append(issues, fmt.Sprintf("%s: colour description signalled by only one of it and %s", r.Name, ref.Name))
+		case p.Colour.IsHDR() != q.Colour.IsHDR():
+			issues = append(issues, fmt.Sprintf("%s: mixes SDR and HDR with %s", r.Name, ref.Name))
+		case *p.Colour != *q.Colour:
+			issues = append(issues, fmt.Sprintf("%s: colour %d/%d/%d, %s has %d/%d/%d", r.Name,
+				p.Colour.ColourPrimaries, p.Colour.TransferCharacteristics, p.Colour.MatrixCoefficients, ref.Name,
+				q.Colour.ColourPrimaries, q.Colour.TransferCharacteristics, q.Colour.MatrixCoefficients))
+		}
+	}
+
+	for i := 1; i < len(ladder); i++ {
+		lo, hi := ladder[i-1], ladder[i]
+		if lo.Bandwidth == hi.Bandwidth {
+			issues = append(issues, fmt.Sprintf("%s and %s: same bandwidth %d", lo.Name, hi.Name, hi.Bandwidth))
+		}
+		if lo.params.Codec != hi.params.Codec {
+			continue
+		}
+		if uint64(hi.params.Width)*uint64(hi.params.Height) < uint64(lo.params.Width)*uint64(lo.params.Height) {
+			issues = append(issues, fmt.Sprintf("%s: %dx%d at %d bps, smaller than %s with %dx%d at %d bps",
+				hi.Name, hi.params.Width, hi.params.Height, hi.Bandwidth, lo.Name, lo.params.Width, lo.params.Height, lo.Bandwidth))
+		}
+		if hi.params.Level < lo.params.Level {
+			issues = append(issues, fmt.Sprintf("%s: level %d below level %d of lower bandwidth %s", hi.Name, hi.params.Level, lo.params.Level, lo.Name))
+		}
+	}
+	return issues, nil
+}
@@ -0,0 +1,81 @@
+package nalu
+
+import "github.com/go-webdl/bits"
+
+// emulationPreventionByte - emulation_prevention_three_byte, inserted in
+// NAL units after two zero bytes followed by 0x00 to 0x03 (ISO/IEC 14496-10
+// and ISO/IEC 23008-2 Sec. 7.4.2)
+const emulationPreventionByte = 0x03
+
+// UnescapeEBSP - remove emulation prevention bytes, returning a new slice
+func UnescapeEBSP(ebsp []byte) []byte {
+	return AppendUnescapedEBSP(make([]byte, 0, len(ebsp)), ebsp)
+}
+
+// AppendUnescapedEBSP - append ebsp to dst with emulation prevention bytes removed
+func AppendUnescapedEBSP(dst, ebsp []byte) []byte {
+	zeroCount := 0
+	for _, b := range ebsp {
+		if zeroCount == 2 && b == emulationPreventionByte {
+			zeroCount = 0
+			continue
+		}
+		dst = append(dst, b)
+		if b == 0 {
+			zeroCount++
+		} else {
+			zeroCount = 0
+		}
+	}
+	return dst
+}
+
+// EscapeRBSP - insert emulation prevention bytes, returning a new slice
+func EscapeRBSP(rbsp []byte) []byte {
+	return AppendEscapedRBSP(make([]byte, 0, len(rbsp)+len(rbsp)/64+1), rbsp)
+}
+
+// AppendEscapedRBSP - append rbsp to dst, inserting an emulation prevention
+// byte wherever two zero bytes are followed by a byte less than or equal to 3,
+// and after a trailing zero byte (RBSP ending in cabac_zero_word)
+func AppendEscapedRBSP(dst, rbsp []byte) []byte {
+	zeroCount := 0
+	for _, b := range rbsp {
+		if zeroCount == 2 && b <= emulationPreventionByte {
+			dst = append(dst, emulationPreventionByte)
+			zeroCount = 0
+		}
+		dst = append(dst, b)
+		if b == 0 {
+			zeroCount++
+		} else {
+			zeroCount = 0
+		}
+	}
+	if len(rbsp) > 0 && rbsp[len(rbsp)-1] == 0 {
+		dst = append(dst, emulationPreventionByte)
+	}
+	return dst
+}
+
+// NeedsEscaping - does rbsp contain a sequence that requires emulation prevention
+func NeedsEscaping(rbsp []byte) bool {
+	zeroCount := 0
+	for _, b := range rbsp {
+		if zeroCount == 2 && b <= emulationPreventionByte {
+			return true
+		}
+		if b == 0 {
+			zeroCount++
+		} else {
+			zeroCount = 0
+		}
+	}
+	return len(rbsp) > 0 && rbsp[len(rbsp)-1] == 0
+}
+
+// RBSPBitPosition - bit position in the RBSP of ebsp of a reader of ebsp
+// Emulation prevention bytes read so far are not counted.
+func RBSPBitPosition(ebsp []byte, r *bits.AccErrEBSPReader) int {
+	return len(UnescapeEBSP(ebsp[:r.NrBytesRead()]))*8 - (8 - r.NrBitsReadInCurrentByte())
+}
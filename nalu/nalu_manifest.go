@@ -0,0 +1,105 @@
+package nalu
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// Manifest - per access unit checksums of an elementary stream, used to
+// validate that independently fetched segments assemble into the expected
+// stream. It is meant to be serialized as JSON.
+type Manifest struct {
+	LengthSize  int             `json:"lengthSize"`
+	AccessUnits []ManifestEntry `json:"accessUnits"`
+}
+
+// ManifestEntry - one access unit (sample) of the manifest
+type ManifestEntry struct {
+	Size   int            `json:"size"`
+	SHA256 string         `json:"sha256"`
+	NALUs  []ManifestNALU `json:"nalus"`
+}
+
+// ManifestNALU - one NAL unit of an access unit
+type ManifestNALU struct {
+	Type   uint8  `json:"type"`
+	Size   int    `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// TypeFunc - codec specific NAL unit type extraction, e.g.
+// func(n []byte) uint8 { return uint8(avc.GetNaluType(n[0])) }
+type TypeFunc func(nalu []byte) uint8
+
+// ManifestMismatchError - reported by Verify for the first access unit that
+// does not match the manifest
+type ManifestMismatchError struct {
+	AccessUnit int
+	// NAL unit index within the access unit, or -1 if the mismatch is on the
+	// access unit level
+	NALU   int
+	Reason string
+}
+
+func (e *ManifestMismatchError) Error() string {
+	if e.NALU < 0 {
+		return fmt.Sprintf("access unit %d: %s", e.AccessUnit, e.Reason)
+	}
+	return fmt.Sprintf("access unit %d NAL unit %d: %s", e.AccessUnit, e.NALU, e.Reason)
+}
+
+// Add - append an access unit given as a length-prefixed sample
+func (m *Manifest) Add(sample []byte, typeOf TypeFunc) error {
+	nalus, err := SplitSample(sample, m.LengthSize)
+	if err != nil {
+		return err
+	}
+	entry := ManifestEntry{Size: len(sample), SHA256: hexDigest(sample)}
+	for _, nalu := range nalus {
+		var naluType uint8
+		if typeOf != nil && len(nalu) > 0 {
+			naluType = typeOf(nalu)
+		}
+		entry.NALUs = append(entry.NALUs, ManifestNALU{Type: naluType, Size: len(nalu), SHA256: hexDigest(nalu)})
+	}
+	m.AccessUnits = append(m.AccessUnits, entry)
+	return nil
+}
+
+// Verify - check that samples match the manifest entries starting at access
+// unit first, returning a *ManifestMismatchError on the first mismatch
+func (m *Manifest) Verify(first int, samples [][]byte) error {
+	if first < 0 || first+len(samples) > len(m.AccessUnits) {
+		return fmt.Errorf("access units %d to %d are outside of the manifest of %d", first, first+len(samples), len(m.AccessUnits))
+	}
+	for i, sample := range samples {
+		entry := &m.AccessUnits[first+i]
+		if len(sample) == entry.Size && hexDigest(sample) == entry.SHA256 {
+			continue
+		}
+		// Locate the offending NAL unit for a useful report
+		nalus, err := SplitSample(sample, m.LengthSize)
+		if err != nil {
+			return &ManifestMismatchError{AccessUnit: first + i, NALU: -1, Reason: err.Error()}
+		}
+		for j, nalu := range nalus {
+			if j >= len(entry.NALUs) {
+				return &ManifestMismatchError{AccessUnit: first + i, NALU: j, Reason: "unexpected extra NAL unit"}
+			}
+			if expected := entry.NALUs[j]; len(nalu) != expected.Size || hexDigest(nalu) != expected.SHA256 {
+				return &ManifestMismatchError{AccessUnit: first + i, NALU: j, Reason: fmt.Sprintf("expected %d bytes of type %d, got %d bytes with a different checksum", expected.Size, expected.Type, len(nalu))}
+			}
+		}
+		if len(nalus) < len(entry.NALUs) {
+			return &ManifestMismatchError{AccessUnit: first + i, NALU: len(nalus), Reason: "missing NAL unit"}
+		}
+		return &ManifestMismatchError{AccessUnit: first + i, NALU: -1, Reason: "checksum mismatch"}
+	}
+	return nil
+}
+
+func hexDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,102 @@
+package nalu
+
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
+// Unit - a NAL unit flowing through a sample transformation
+type Unit struct {
+	Data []byte
+	// Modified marks units created or changed by the transformation. All
+	// other units must be passed through byte for byte.
+	Modified bool
+}
+
+// Transform - a transformation of the NAL units of one sample
+type Transform func(units []Unit) ([]Unit, error)
+
+// Digest - SHA-256 checksum of a NAL unit
+func Digest(nalu []byte) [sha256.Size]byte {
+	return sha256.Sum256(nalu)
+}
+
+// TransformSample - apply transform to the NAL units of a length-prefixed
+// sample and return the resulting sample
+// NAL units the transformation does not change are copied verbatim, never
+// unescaped and re-escaped, so forensic watermarks in slice data or SEI
+// survive a remux. Every output unit not marked Modified is checked to be byte-identical to
+// an input unit, in input order, and the input units are checked to be left
+// untouched. The sample is returned as is if transform changes nothing.
+func TransformSample(sample []byte, lengthSize int, transform Transform) ([]byte, error) {
+	return transformSample(sample, lengthSize, lengthSize, transform)
+}
+
+// transformSample - TransformSample writing the result with NAL unit length size to
+func transformSample(sample []byte, from, to int, transform Transform) ([]byte, error) {
+	nalus, err := SplitSample(sample, from)
+	if err != nil {
+		return nil, err
+	}
+	units := make([]Unit, len(nalus))
+	digests := make([][sha256.Size]byte, len(nalus))
+	for i, nalu := range nalus {
+		units[i].Data = nalu
+		digests[i] = Digest(nalu)
+	}
+	out, err := transform(units)
+	if err != nil {
+		return nil, err
+	}
+	if err = VerifyPassthrough(digests, out); err != nil {
+		return nil, err
+	}
+	for i, nalu := range nalus {
+		if Digest(nalu) != digests[i] {
+			return nil, fmt.Errorf("transformation modified input NAL unit %d in place", i)
+		}
+	}
+	if from == to && unchanged(out, len(nalus)) {
+		return sample, nil
+	}
+	outNalus := make([][]byte, len(out))
+	size := 0
+	for i := range out {
+		outNalus[i] = out[i].Data
+		size += to + len(out[i].Data)
+	}
+	return AppendSample(make([]byte, 0, size), outNalus, to)
+}
+
+// unchanged - are the verified units out all n input units passed through
+func unchanged(out []Unit, n int) bool {
+	if len(out) != n {
+		return false
+	}
+	for _, unit := range out {
+		if unit.Modified {
+			return false
+		}
+	}
+	return true
+}
+
+// VerifyPassthrough - check that the output units not marked Modified match
+// the input digests byte for byte and in order. Input units may be dropped.
+func VerifyPassthrough(inputDigests [][sha256.Size]byte, out []Unit) error {
+	next := 0
+	for i, unit := range out {
+		if unit.Modified {
+			continue
+		}
+		digest := Digest(unit.Data)
+		for next < len(inputDigests) && inputDigests[next] != digest {
+			next++
+		}
+		if next == len(inputDigests) {
+			return fmt.Errorf("output NAL unit %d is neither marked modified nor a verbatim input NAL unit", i)
+		}
+		next++
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package nalu
+
+import (
+	"fmt"
+)
+
+// Subsample - subsample entry of a CENC 'senc' box (ISO/IEC 23001-7)
+type Subsample struct {
+	BytesOfClearData     uint16
+	BytesOfProtectedData uint32
+}
+
+// ProtectedNALU - NAL unit of a protected sample
+type ProtectedNALU struct {
+	Data []byte
+	// Number of leading bytes of Data that are in the clear. CENC keeps at
+	// least the NAL unit header in the clear, so the type of every NAL unit
+	// can be determined without decryption.
+	ClearBytes int
+}
+
+// Encrypted - true if any byte of the NAL unit is protected
+func (n *ProtectedNALU) Encrypted() bool {
+	return n.ClearBytes < len(n.Data)
+}
+
+// Clear - the leading clear bytes of the NAL unit
+func (n *ProtectedNALU) Clear() []byte {
+	return n.Data[:n.ClearBytes]
+}
+
+// SplitProtectedSample - split a length-prefixed sample protected with the
+// given subsample map into NAL units, flagging the encrypted regions. Samples
+// without subsamples are fully encrypted and cannot be split.
+func SplitProtectedSample(sample []byte, lengthSize int, subsamples []Subsample) ([]ProtectedNALU, error) {
+	if len(subsamples) == 0 {
+		return nil, fmt.Errorf("fully encrypted sample cannot be split into NAL units")
+	}
+	type run struct{ start, clearEnd int }
+	var runs []run
+	pos := 0
+	for _, s := range subsamples {
+		runs = append(runs, run{start: pos, clearEnd: pos + int(s.BytesOfClearData)})
+		pos += int(s.BytesOfClearData) + int(s.BytesOfProtectedData)
+	}
+	if pos != len(sample) {
+		return nil, fmt.Errorf("subsamples cover %d bytes of a %d byte sample", pos, len(sample))
+	}
+	// clearUntil - end of the clear bytes starting at offset, which may span
+	// several subsamples without protected data
+	clearUntil := func(offset int) int {
+		for _, r := range runs {
+			if offset >= r.start && offset < r.clearEnd {
+				offset = r.clearEnd
+			}
+		}
+		return offset
+	}
+	nalus, err := SplitSample(sample, lengthSize)
+	if err != nil {
+		return nil, err
+	}
+	protected := make([]ProtectedNALU, len(nalus))
+	offset := 0
+	for i, nalu := range nalus {
+		if clearUntil(offset) < offset+lengthSize {
+			return nil, fmt.Errorf("length of NAL unit %d is encrypted", i)
+		}
+		offset += lengthSize
+		clearBytes := clearUntil(offset) - offset
+		if clearBytes > len(nalu) {
+			clearBytes = len(nalu)
+		}
+		protected[i] = ProtectedNALU{Data: nalu, ClearBytes: clearBytes}
+		offset += len(nalu)
+	}
+	return protected, nil
+}
+
+// ClearNALUs - the NAL units of a protected sample that are entirely in the
+// clear, e.g. SEI and parameter sets, which can be analyzed without keys
+func ClearNALUs(nalus []ProtectedNALU) (clear [][]byte) {
+	for i := range nalus {
+		if !nalus[i].Encrypted() {
+			clear = append(clear, nalus[i].Data)
+		}
+	}
+	return
+}
+
+// ClearParts - the clear leading bytes of every NAL unit of a protected
+// sample, in sample order
+// Encrypted regions are skipped, so the result can be passed to analysis
+// that only reads NAL unit headers, slice headers and clear non-VCL NAL
+// units, e.g. keyframe detection.
+func ClearParts(nalus []ProtectedNALU) [][]byte {
+	parts := make([][]byte, len(nalus))
+	for i := range nalus {
+		parts[i] = nalus[i].Clear()
+	}
+	return parts
+}
@@ -0,0 +1,45 @@
+package nalu
+
+import (
+	"bytes"
+
+	"github.com/go-webdl/bits"
+)
+
+// RBSPReader - reads the syntax elements of an RBSP without emulation
+// prevention bytes, such as an SEI payload, the counterpart of RBSPWriter
+// NAL units with emulation prevention bytes are read with
+// bits.AccErrEBSPReader instead.
+type RBSPReader struct {
+	*bits.AccErrReader
+}
+
+// NewRBSPReader - create an RBSPReader for rbsp
+func NewRBSPReader(rbsp []byte) *RBSPReader {
+	return &RBSPReader{bits.NewAccErrReader(bytes.NewReader(rbsp))}
+}
+
+// ReadExpGolomb - read an unsigned Exp-Golomb-coded value, ue(v). Return 0 if error
+func (r *RBSPReader) ReadExpGolomb() uint {
+	leadingZeroBits := 0
+	for !r.ReadFlag() {
+		if r.AccError() != nil {
+			return 0
+		}
+		leadingZeroBits++
+	}
+	value := uint(1)<<uint(leadingZeroBits) - 1 + r.Read(leadingZeroBits)
+	if r.AccError() != nil {
+		return 0
+	}
+	return value
+}
+
+// ReadSignedGolomb - read a signed Exp-Golomb-coded value, se(v). Return 0 if error
+func (r *RBSPReader) ReadSignedGolomb() int {
+	codeNum := r.ReadExpGolomb()
+	if codeNum%2 == 1 {
+		return int((codeNum + 1) / 2)
+	}
+	return -int(codeNum / 2)
+}
@@ -0,0 +1,109 @@
+package nalu
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/go-webdl/bits"
+)
+
+// RBSPWriter - writes the syntax elements of an RBSP, the counterpart of
+// bits.AccErrEBSPReader
+// Emulation prevention is applied to the whole NAL unit by NALUnit once the
+// RBSP is complete.
+type RBSPWriter struct {
+	buf    bytes.Buffer
+	w      *bits.Writer
+	nrBits int
+}
+
+// NewRBSPWriter - create an RBSPWriter
+func NewRBSPWriter() *RBSPWriter {
+	rw := &RBSPWriter{}
+	rw.w = bits.NewWriter(&rw.buf)
+	return rw
+}
+
+// Write - write the n lowest bits of v, u(n)
+func (rw *RBSPWriter) Write(v uint, n int) {
+	rw.nrBits += n
+	for n > 32 {
+		n -= 32
+		rw.w.Write(v>>uint(n), 32)
+	}
+	rw.w.Write(v, n)
+}
+
+// WriteFlag - write a one bit flag, u(1)
+func (rw *RBSPWriter) WriteFlag(f bool) {
+	if f {
+		rw.Write(1, 1)
+	} else {
+		rw.Write(0, 1)
+	}
+}
+
+// WriteExpGolomb - write an unsigned Exp-Golomb-coded value, ue(v)
+func (rw *RBSPWriter) WriteExpGolomb(v uint) {
+	codeNum := uint64(v) + 1
+	n := 0
+	for c := codeNum; c > 1; c >>= 1 {
+		n++
+	}
+	rw.Write(0, n)
+	rw.Write(uint(codeNum), n+1)
+}
+
+// WriteSignedGolomb - write a signed Exp-Golomb-coded value, se(v)
+func (rw *RBSPWriter) WriteSignedGolomb(v int) {
+	if v > 0 {
+		rw.WriteExpGolomb(uint(2*v - 1))
+	} else {
+		rw.WriteExpGolomb(uint(-2 * v))
+	}
+}
+
+// WriteTrailingBits - write rbsp_trailing_bits, aligning to a byte boundary
+func (rw *RBSPWriter) WriteTrailingBits() {
+	rw.Write(1, 1)
+	rw.w.Flush()
+	rw.nrBits = (rw.nrBits + 7) &^ 7
+}
+
+// NrBits - number of bits written so far
+func (rw *RBSPWriter) NrBits() int {
+	return rw.nrBits
+}
+
+// Bytes - the unescaped bytes written so far, which must end on a byte boundary
+func (rw *RBSPWriter) Bytes() ([]byte, error) {
+	if err := rw.w.Error(); err != nil {
+		return nil, err
+	}
+	if rw.nrBits%8 != 0 {
+		return nil, fmt.Errorf("RBSP of %d bits not byte aligned", rw.nrBits)
+	}
+	return rw.buf.Bytes(), nil
+}
+
+// SEIPayload - the unescaped bytes of an SEI payload
+// If the payload does not end on a byte boundary, payload_bit_equal_to_one
+// and zero bits up to the boundary are appended, as in sei_payload().
+func (rw *RBSPWriter) SEIPayload() ([]byte, error) {
+	if rw.nrBits%8 != 0 {
+		rw.WriteTrailingBits()
+	}
+	if err := rw.w.Error(); err != nil {
+		return nil, err
+	}
+	return rw.buf.Bytes(), nil
+}
+
+// NALUnit - NAL unit of header followed by the escaped RBSP
+// WriteTrailingBits must have been called.
+func (rw *RBSPWriter) NALUnit(header []byte) ([]byte, error) {
+	if err := rw.w.Error(); err != nil {
+		return nil, err
+	}
+	return AppendEscapedRBSP(append([]byte(nil), header...), rw.buf.Bytes()), nil
+}
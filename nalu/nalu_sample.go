@@ -0,0 +1,79 @@
+package nalu
+
+import (
+	"fmt"
+
+	"github.com/go-webdl/media-codec/debuglog"
+)
+
+// SplitSample - split a length-prefixed sample (as stored in MP4 with avcC,
+// hvcC and similar records) into its NAL units. The returned slices alias
+// sample, so NAL units are never copied or re-encoded.
+func SplitSample(sample []byte, lengthSize int) (nalus [][]byte, err error) {
+	if lengthSize != 1 && lengthSize != 2 && lengthSize != 4 {
+		return nil, fmt.Errorf("invalid NAL unit length size %d", lengthSize)
+	}
+	for pos := 0; pos < len(sample); {
+		if pos+lengthSize > len(sample) {
+			return nalus, fmt.Errorf("truncated NAL unit length at offset %d", pos)
+		}
+		var naluLength int
+		for i := 0; i < lengthSize; i++ {
+			naluLength = naluLength<<8 | int(sample[pos+i])
+		}
+		pos += lengthSize
+		if naluLength > len(sample)-pos {
+			return nalus, fmt.Errorf("NAL unit length %d at offset %d exceeds sample", naluLength, pos-lengthSize)
+		}
+		if naluLength == 0 {
+			debuglog.Debug("empty NAL unit in sample", "offset", pos-lengthSize)
+		}
+		nalus = append(nalus, sample[pos:pos+naluLength])
+		pos += naluLength
+	}
+	return
+}
+
+// AppendSample - append nalus to dst as a length-prefixed sample, copying the
+// NAL unit bytes verbatim
+func AppendSample(dst []byte, nalus [][]byte, lengthSize int) ([]byte, error) {
+	if lengthSize != 1 && lengthSize != 2 && lengthSize != 4 {
+		return dst, fmt.Errorf("invalid NAL unit length size %d", lengthSize)
+	}
+	for _, nalu := range nalus {
+		if uint64(len(nalu)) >= 1<<(8*uint(lengthSize)) {
+			return dst, fmt.Errorf("NAL unit of %d bytes does not fit a %d byte length", len(nalu), lengthSize)
+		}
+		for i := lengthSize - 1; i >= 0; i-- {
+			dst = append(dst, byte(len(nalu)>>(8*uint(i))))
+		}
+		dst = append(dst, nalu...)
+	}
+	return dst, nil
+}
+
+// ConvertLengthSize - rewrite a length-prefixed sample from lengthSize from to
+// lengthSize to, e.g. to 2-byte lengths for low-latency pipelines
+// The sample is returned unchanged if the sizes are equal. It is an error if
+// a NAL unit is too long for the new length size.
+func ConvertLengthSize(sample []byte, from, to int) ([]byte, error) {
+	if to != 1 && to != 2 && to != 4 {
+		return nil, fmt.Errorf("invalid NAL unit length size %d", to)
+	}
+	return transformSample(sample, from, to, func(units []Unit) ([]Unit, error) {
+		return units, nil
+	})
+}
+
+// StartCode - Annex B start code prefixed to every NAL unit by AppendAnnexB
+var StartCode = []byte{0, 0, 0, 1}
+
+// AppendAnnexB - append nalus to dst as an Annex B byte stream, each preceded
+// by a four byte start code
+func AppendAnnexB(dst []byte, nalus [][]byte) []byte {
+	for _, nalu := range nalus {
+		dst = append(dst, StartCode...)
+		dst = append(dst, nalu...)
+	}
+	return dst
+}
@@ -0,0 +1,123 @@
+package nalu
+
+import (
+	"bytes"
+	"io"
+)
+
+const scannerReadSize = 64 * 1024
+
+var startCodePrefix = []byte{0, 0, 1}
+
+// Scanner - reads NAL units one at a time from an Annex B byte stream
+// Both 3- and 4-byte start codes are accepted, and NAL units may be split
+// across reads of the underlying reader. Only the NAL unit being returned
+// and the data read ahead are kept in memory.
+type Scanner struct {
+	r        io.Reader
+	buf      []byte
+	start    int // start of unconsumed data in buf
+	end      int // end of valid data in buf
+	searched int // bytes after start already searched for a start code
+	started  bool
+	eof      bool
+	nalu     []byte
+	err      error
+}
+
+// NewScanner - create a Scanner reading from r
+func NewScanner(r io.Reader) *Scanner {
+	return &Scanner{r: r, buf: make([]byte, scannerReadSize)}
+}
+
+// Scan - advance to the next NAL unit, returning false at end of stream or on error
+func (s *Scanner) Scan() bool {
+	for {
+		if s.err != nil {
+			return false
+		}
+		if !s.started {
+			idx := bytes.Index(s.buf[s.start:s.end], startCodePrefix)
+			if idx >= 0 {
+				s.start += idx + len(startCodePrefix)
+				s.started = true
+				continue
+			}
+			if s.eof {
+				return false
+			}
+			// keep a possibly partial start code
+			if s.end-s.start > 2 {
+				s.start = s.end - 2
+			}
+			s.fill()
+			continue
+		}
+		idx := bytes.Index(s.buf[s.start+s.searched:s.end], startCodePrefix)
+		if idx >= 0 {
+			end := s.start + s.searched + idx
+			n := trimTrailingZeros(s.buf[s.start:end])
+			s.start = end + len(startCodePrefix)
+			s.searched = 0
+			if len(n) == 0 {
+				continue
+			}
+			s.nalu = n
+			return true
+		}
+		if s.eof {
+			n := trimTrailingZeros(s.buf[s.start:s.end])
+			s.start = s.end
+			s.searched = 0
+			if len(n) == 0 {
+				return false
+			}
+			s.nalu = n
+			return true
+		}
+		if s.end-s.start > 2 {
+			s.searched = s.end - s.start - 2
+		}
+		s.fill()
+	}
+}
+
+// NALU - the NAL unit found by the last call to Scan
+// The returned slice is only valid until the next call to Scan.
+func (s *Scanner) NALU() []byte {
+	return s.nalu
+}
+
+// Err - the first error encountered other than io.EOF
+func (s *Scanner) Err() error {
+	return s.err
+}
+
+// fill - read more data, compacting or growing the buffer as needed
+func (s *Scanner) fill() {
+	if s.start > 0 {
+		copy(s.buf, s.buf[s.start:s.end])
+		s.end -= s.start
+		s.start = 0
+	}
+	if s.end == len(s.buf) {
+		buf := make([]byte, 2*len(s.buf))
+		copy(buf, s.buf[:s.end])
+		s.buf = buf
+	}
+	n, err := s.r.Read(s.buf[s.end:])
+	s.end += n
+	if err == io.EOF {
+		s.eof = true
+	} else if err != nil {
+		s.err = err
+	}
+}
+
+// trimTrailingZeros - remove trailing_zero_8bits and the leading zero of a 4-byte start code
+func trimTrailingZeros(b []byte) []byte {
+	for len(b) > 0 && b[len(b)-1] == 0 {
+		b = b[:len(b)-1]
+	}
+	return b
+}
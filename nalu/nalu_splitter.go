@@ -0,0 +1,65 @@
+package nalu
+
+import (
+	"bytes"
+)
+
+// Splitter - push parser splitting an Annex B byte stream into NAL units
+// Unlike Scanner, data is handed over in chunks of any size as it arrives,
+// e.g. from a network callback, and NAL units are returned as soon as the
+// start code following them is seen.
+type Splitter struct {
+	buf      []byte
+	searched int // bytes of buf already searched for a start code
+	started  bool
+}
+
+// Push - add the next chunk of the stream, returning the NAL units it completes
+// Returned NAL units do not alias chunk or internal buffers.
+func (s *Splitter) Push(chunk []byte) (nalus [][]byte) {
+	s.buf = append(s.buf, chunk...)
+	if !s.started {
+		idx := bytes.Index(s.buf, startCodePrefix)
+		if idx < 0 {
+			// keep a possibly partial start code
+			if len(s.buf) > 2 {
+				s.buf = append(s.buf[:0], s.buf[len(s.buf)-2:]...)
+			}
+			return nil
+		}
+		s.buf = s.buf[idx+len(startCodePrefix):]
+		s.started = true
+	}
+	start := 0
+	for {
+		idx := bytes.Index(s.buf[start+s.searched:], startCodePrefix)
+		if idx < 0 {
+			break
+		}
+		end := start + s.searched + idx
+		if n := trimTrailingZeros(s.buf[start:end]); len(n) > 0 {
+			nalus = append(nalus, append([]byte(nil), n...))
+		}
+		start = end + len(startCodePrefix)
+		s.searched = 0
+	}
+	if rest := len(s.buf) - start; rest > 2 {
+		s.searched = rest - 2
+	}
+	// compact, so that memory is bounded by the largest NAL unit
+	s.buf = append(s.buf[:0], s.buf[start:]...)
+	return nalus
+}
+
+// Flush - the last NAL unit at the end of the stream, or nil if there is none
+// The Splitter is reset and can be reused for another stream.
+func (s *Splitter) Flush() []byte {
+	var n []byte
+	if s.started {
+		if t := trimTrailingZeros(s.buf); len(t) > 0 {
+			n = append([]byte(nil), t...)
+		}
+	}
+	*s = Splitter{}
+	return n
+}
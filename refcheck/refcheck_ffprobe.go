@@ -0,0 +1,131 @@
+package refcheck
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// profile_idc of the profile names printed by ffprobe
+var ffprobeProfiles = map[string]map[string]int{
+	"h264": {
+		"Baseline":              66,
+		"Constrained Baseline":  66,
+		"Main":                  77,
+		"Extended":              88,
+		"High":                  100,
+		"High 10":               110,
+		"High 4:2:2":            122,
+		"High 4:4:4 Predictive": 244,
+	},
+	"hevc": {
+		"Main":               1,
+		"Main 10":            2,
+		"Main Still Picture": 3,
+		"Rext":               4,
+	},
+}
+
+// H.273 code points of the colour names printed by ffprobe
+var (
+	ffprobePrimaries = map[string]int{
+		"bt709": 1, "bt470m": 4, "bt470bg": 5, "smpte170m": 6, "smpte240m": 7, "film": 8,
+		"bt2020": 9, "smpte428": 10, "smpte431": 11, "smpte432": 12, "ebu3213": 22,
+	}
+	ffprobeTransfers = map[string]int{
+		"bt709": 1, "gamma22": 4, "gamma28": 5, "smpte170m": 6, "smpte240m": 7, "linear": 8,
+		"iec61966-2-4": 11, "bt1361e": 12, "iec61966-2-1": 13, "bt2020-10": 14, "bt2020-12": 15,
+		"smpte2084": 16, "smpte428": 17, "arib-std-b67": 18,
+	}
+	ffprobeMatrices = map[string]int{
+		"gbr": 0, "bt709": 1, "fcc": 4, "bt470bg": 5, "smpte170m": 6, "smpte240m": 7, "ycgco": 8,
+		"bt2020nc": 9, "bt2020c": 10, "smpte2085": 11, "ictcp": 14,
+	}
+)
+
+type ffprobeOutput struct {
+	Streams []struct {
+		CodecName      string `json:"codec_name"`
+		CodecType      string `json:"codec_type"`
+		Profile        string `json:"profile"`
+		Level          int    `json:"level"`
+		Width          int    `json:"width"`
+		Height         int    `json:"height"`
+		PixFmt         string `json:"pix_fmt"`
+		ColorRange     string `json:"color_range"`
+		ColorSpace     string `json:"color_space"`
+		ColorTransfer  string `json:"color_transfer"`
+		ColorPrimaries string `json:"color_primaries"`
+	} `json:"streams"`
+}
+
+// ParseFFProbe - values of the first video stream in the JSON output of
+// ffprobe -show_streams -of json
+// Properties ffprobe reports as unknown are left out.
+func ParseFFProbe(data []byte) (Values, error) {
+	var out ffprobeOutput
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	for _, s := range out.Streams {
+		if s.CodecType != "video" {
+			continue
+		}
+		v := Values{
+			KEY_WIDTH:  strconv.Itoa(s.Width),
+			KEY_HEIGHT: strconv.Itoa(s.Height),
+		}
+		if s.Level > 0 {
+			v[KEY_LEVEL] = strconv.Itoa(s.Level)
+		}
+		if idc, ok := ffprobeProfiles[s.CodecName][s.Profile]; ok {
+			v[KEY_PROFILE] = strconv.Itoa(idc)
+		}
+		if chroma, depth, ok := parsePixFmt(s.PixFmt); ok {
+			v[KEY_CHROMA_FORMAT] = strconv.Itoa(chroma)
+			v[KEY_BIT_DEPTH_LUMA] = strconv.Itoa(depth)
+		}
+		if p, ok := ffprobePrimaries[s.ColorPrimaries]; ok {
+			v[KEY_COLOUR_PRIMARIES] = strconv.Itoa(p)
+		}
+		if t, ok := ffprobeTransfers[s.ColorTransfer]; ok {
+			v[KEY_TRANSFER_CHARACTERISTICS] = strconv.Itoa(t)
+		}
+		if m, ok := ffprobeMatrices[s.ColorSpace]; ok {
+			v[KEY_MATRIX_COEFFICIENTS] = strconv.Itoa(m)
+		}
+		switch s.ColorRange {
+		case "tv":
+			v[KEY_FULL_RANGE] = "false"
+		case "pc":
+			v[KEY_FULL_RANGE] = "true"
+		}
+		return v, nil
+	}
+	return nil, errors.New("no video stream")
+}
+
+// parsePixFmt - chroma format and bit depth of planar YUV and gray pixel
+// formats, e.g. yuv420p10le
+func parsePixFmt(pixFmt string) (chroma, depth int, ok bool) {
+	rest := pixFmt
+	switch {
+	case strings.HasPrefix(rest, "gray"):
+		chroma, rest = 0, rest[len("gray"):]
+	case strings.HasPrefix(rest, "yuv420p"), strings.HasPrefix(rest, "yuvj420p"):
+		chroma, rest = 1, rest[strings.Index(rest, "p")+1:]
+	case strings.HasPrefix(rest, "yuv422p"), strings.HasPrefix(rest, "yuvj422p"):
+		chroma, rest = 2, rest[strings.Index(rest, "p")+1:]
+	case strings.HasPrefix(rest, "yuv444p"), strings.HasPrefix(rest, "yuvj444p"):
+		chroma, rest = 3, rest[strings.Index(rest, "p")+1:]
+	default:
+		return 0, 0, false
+	}
+	rest = strings.TrimSuffix(strings.TrimSuffix(rest, "le"), "be")
+	if rest == "" {
+		return chroma, 8, true
+	}
+	depth, err := strconv.Atoi(rest)
+	return chroma, depth, err == nil
+}
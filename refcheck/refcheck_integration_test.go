@@ -0,0 +1,56 @@
+//go:build refcheck
+// +build refcheck
+
+package refcheck
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestReferenceTools cross-checks the parameters derived from the Annex B
+// elementary streams listed in REFCHECK_STREAMS, separated like PATH, against
+// every reference tool found in PATH. The codec is taken from the file
+// extension: .264, .h264 and .avc for AVC, .265, .h265 and .hevc for HEVC.
+//
+//	REFCHECK_STREAMS=a.264:b.265 go test -tags refcheck ./refcheck
+func TestReferenceTools(t *testing.T) {
+	streams := filepath.SplitList(os.Getenv("REFCHECK_STREAMS"))
+	if len(streams) == 0 {
+		t.Skip("REFCHECK_STREAMS not set")
+	}
+	for _, path := range streams {
+		path := path
+		var codecName string
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".264", ".h264", ".avc":
+			codecName = "avc"
+		case ".265", ".h265", ".hevc":
+			codecName = "hevc"
+		default:
+			t.Errorf("%s: unknown codec of extension", path)
+			continue
+		}
+		for i := range Tools {
+			tool := &Tools[i]
+			t.Run(filepath.Base(path)+"/"+tool.Name, func(t *testing.T) {
+				if !tool.Available() {
+					t.Skipf("%s not in PATH", tool.Name)
+				}
+				ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+				defer cancel()
+				disagreements, err := CheckAnnexB(ctx, tool, path, codecName)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, d := range disagreements {
+					t.Error(d)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package refcheck
+
+import (
+	"errors"
+	"regexp"
+)
+
+var (
+	mp4boxCodecString = regexp.MustCompile(`RFC6381 Codec Parameters:\s*(\S+)`)
+	mp4boxSize        = regexp.MustCompile(`(?:Visual Size|Size)\s+(\d+)\s*x\s*(\d+)|width=(\d+)\s+height=(\d+)`)
+)
+
+// ParseMP4Box - values of the first video track in the output of MP4Box -info
+// Only the codecs string and the visual size are reported by all versions.
+func ParseMP4Box(data []byte) (Values, error) {
+	v := Values{}
+	if m := mp4boxCodecString.FindSubmatch(data); m != nil {
+		v[KEY_CODEC_STRING] = string(m[1])
+	}
+	if m := mp4boxSize.FindSubmatch(data); m != nil {
+		if m[1] != nil {
+			v[KEY_WIDTH], v[KEY_HEIGHT] = string(m[1]), string(m[2])
+		} else {
+			v[KEY_WIDTH], v[KEY_HEIGHT] = string(m[3]), string(m[4])
+		}
+	}
+	if len(v) == 0 {
+		return nil, errors.New("no video track information")
+	}
+	return v, nil
+}
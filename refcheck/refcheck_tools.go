@@ -0,0 +1,60 @@
+package refcheck
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// Tool - a reference tool and how to read its report of a stream file
+type Tool struct {
+	// Name - executable looked up in PATH
+	Name  string
+	Args  func(path string) []string
+	Parse func(data []byte) (Values, error)
+}
+
+// Tools - the reference tools known to this package
+var Tools = []Tool{
+	{"ffprobe", func(path string) []string {
+		return []string{"-v", "error", "-show_streams", "-of", "json", path}
+	}, ParseFFProbe},
+	{"MP4Box", func(path string) []string {
+		return []string{"-info", path}
+	}, ParseMP4Box},
+}
+
+// Available - is the tool found in PATH
+func (t *Tool) Available() bool {
+	_, err := exec.LookPath(t.Name)
+	return err == nil
+}
+
+// Run - values the tool reports for the stream file at path
+func (t *Tool) Run(ctx context.Context, path string) (Values, error) {
+	out, err := exec.CommandContext(ctx, t.Name, t.Args(path)...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", t.Name, err)
+	}
+	return t.Parse(out)
+}
+
+// CheckAnnexB - disagreements between this module and the tool about the
+// Annex B elementary stream of codec "avc" or "hevc" at path
+func CheckAnnexB(ctx context.Context, t *Tool, path, codecName string) ([]Disagreement, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	params, err := ProbeAnnexB(f, codecName)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	theirs, err := t.Run(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return Compare(FromParameters(params), theirs), nil
+}
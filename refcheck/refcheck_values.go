@@ -0,0 +1,134 @@
+package refcheck
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/go-webdl/media-codec/avc"
+	"github.com/go-webdl/media-codec/codec"
+	"github.com/go-webdl/media-codec/hevc"
+	"github.com/go-webdl/media-codec/nalu"
+)
+
+// Keys of Values
+const (
+	KEY_WIDTH                    = "width"
+	KEY_HEIGHT                   = "height"
+	KEY_PROFILE                  = "profile"
+	KEY_LEVEL                    = "level"
+	KEY_CHROMA_FORMAT            = "chroma_format"
+	KEY_BIT_DEPTH_LUMA           = "bit_depth_luma"
+	KEY_COLOUR_PRIMARIES         = "colour_primaries"
+	KEY_TRANSFER_CHARACTERISTICS = "transfer_characteristics"
+	KEY_MATRIX_COEFFICIENTS      = "matrix_coefficients"
+	KEY_FULL_RANGE               = "full_range"
+	KEY_CODEC_STRING             = "codec_string"
+)
+
+// Values - normalized stream properties reported by one source, this module
+// or a reference tool such as ffprobe or MP4Box, keyed by the KEY_ constants
+// with decimal values
+type Values map[string]string
+
+// Disagreement - a property reported differently by two sources
+type Disagreement struct {
+	Key    string
+	Ours   string
+	Theirs string
+}
+
+func (d Disagreement) String() string {
+	return fmt.Sprintf("%s: ours %s, theirs %s", d.Key, d.Ours, d.Theirs)
+}
+
+// Compare - properties known to both sources with different values, ordered by key
+func Compare(ours, theirs Values) (disagreements []Disagreement) {
+	for key, v := range ours {
+		if t, ok := theirs[key]; ok && t != v {
+			disagreements = append(disagreements, Disagreement{Key: key, Ours: v, Theirs: t})
+		}
+	}
+	sort.Slice(disagreements, func(i, j int) bool { return disagreements[i].Key < disagreements[j].Key })
+	return disagreements
+}
+
+// FromParameters - values of normalized codec parameters
+func FromParameters(p *codec.Parameters) Values {
+	v := Values{
+		KEY_WIDTH:          strconv.Itoa(int(p.Width)),
+		KEY_HEIGHT:         strconv.Itoa(int(p.Height)),
+		KEY_PROFILE:        strconv.Itoa(p.Profile),
+		KEY_LEVEL:          strconv.Itoa(p.Level),
+		KEY_CHROMA_FORMAT:  strconv.Itoa(int(p.ChromaFormat)),
+		KEY_BIT_DEPTH_LUMA: strconv.Itoa(int(p.BitDepthLuma)),
+		KEY_CODEC_STRING:   p.CodecString,
+	}
+	if p.Colour != nil {
+		v[KEY_COLOUR_PRIMARIES] = strconv.Itoa(int(p.Colour.ColourPrimaries))
+		v[KEY_TRANSFER_CHARACTERISTICS] = strconv.Itoa(int(p.Colour.TransferCharacteristics))
+		v[KEY_MATRIX_COEFFICIENTS] = strconv.Itoa(int(p.Colour.MatrixCoefficients))
+		v[KEY_FULL_RANGE] = strconv.FormatBool(p.Colour.FullRangeFlag)
+	}
+	return v
+}
+
+// ProbeAnnexB - parameters of an Annex B elementary stream of codec "avc" or
+// "hevc", derived from its first parameter sets
+func ProbeAnnexB(r io.Reader, codecName string) (*codec.Parameters, error) {
+	var vps, sps, pps [][]byte
+	s := nalu.NewScanner(r)
+	for s.Scan() && (len(sps) == 0 || len(pps) == 0 || codecName == "hevc" && len(vps) == 0) {
+		n := append([]byte(nil), s.NALU()...)
+		switch codecName {
+		case "avc":
+			switch avc.GetNaluType(n[0]) {
+			case avc.NALU_SPS:
+				sps = append(sps, n)
+			case avc.NALU_PPS:
+				pps = append(pps, n)
+			}
+		case "hevc":
+			switch hevc.GetNaluType(n[0]) {
+			case hevc.NALU_VPS:
+				vps = append(vps, n)
+			case hevc.NALU_SPS:
+				sps = append(sps, n)
+			case hevc.NALU_PPS:
+				pps = append(pps, n)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported codec %s", codecName)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if len(sps) == 0 {
+		return nil, errors.New("no SPS in stream")
+	}
+	var buf bytes.Buffer
+	sampleEntry := "avc1"
+	if codecName == "avc" {
+		record, err := avc.CreateAVCDecoderConfigurationRecord(sps, pps)
+		if err != nil {
+			return nil, err
+		}
+		if err = record.RecordWrite(&buf); err != nil {
+			return nil, err
+		}
+	} else {
+		sampleEntry = "hvc1"
+		record, err := hevc.CreateHEVCDecoderConfigurationRecord(vps, sps, pps, true, true, true)
+		if err != nil {
+			return nil, err
+		}
+		if err = record.RecordWrite(&buf); err != nil {
+			return nil, err
+		}
+	}
+	return codec.Probe(sampleEntry, buf.Bytes())
+}
@@ -0,0 +1,159 @@
+package repair
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/go-webdl/media-codec/avc"
+	"github.com/go-webdl/media-codec/codec"
+	"github.com/go-webdl/media-codec/hevc"
+)
+
+// Fix - a fixer run by Repair
+type Fix uint
+
+const (
+	// FIX_DEDUP - remove duplicate parameter sets from the record
+	FIX_DEDUP = Fix(1 << iota)
+	// FIX_TIMING - insert VUI timing info into SPSs lacking it
+	FIX_TIMING
+	// FIX_LEVEL - raise levels too low for the stream
+	FIX_LEVEL
+	// FIX_CONFIG - correct record fields that do not match the SPSs
+	FIX_CONFIG
+	// FIX_HVC1 - turn hev1 into hvc1 with complete parameter set arrays;
+	// samples must then be passed through Report.ConvertSample
+	FIX_HVC1
+)
+
+func (f Fix) String() string {
+	switch f {
+	case FIX_DEDUP:
+		return "dedup"
+	case FIX_TIMING:
+		return "timing"
+	case FIX_LEVEL:
+		return "level"
+	case FIX_CONFIG:
+		return "config"
+	case FIX_HVC1:
+		return "hvc1"
+	default:
+		return fmt.Sprintf("Other_%d", f)
+	}
+}
+
+// Options - inputs of the fixers
+// The zero value runs all fixers that need no stream information.
+type Options struct {
+	// DryRun - report changes without applying them
+	DryRun bool
+	// Skip - fixers not to run
+	Skip Fix
+	// FrameRateNum, FrameRateDen - frame rate for inserted VUI timing info,
+	// no timing is inserted if zero
+	FrameRateNum uint32
+	FrameRateDen uint32
+	// BitRate - peak bit rate in bits/s for level recomputation, 0 if unknown
+	BitRate uint64
+}
+
+func (o *Options) runs(f Fix) bool {
+	return o.Skip&f == 0
+}
+
+// Change - one change made or, in dry-run mode, needed
+type Change struct {
+	Fix         Fix
+	Description string
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s", c.Fix, c.Description)
+}
+
+// Report - result of Repair
+type Report struct {
+	// SampleEntry - sample entry to use with the repaired record, e.g. hvc1
+	// after FIX_HVC1, also in dry-run mode
+	SampleEntry string
+	// Record - repaired configuration record, the input record in dry-run mode
+	Record []byte
+	// Changes - changes in the order they were made
+	Changes []Change
+	// hvcC - repaired record the samples must be converted for, nil if
+	// samples are unaffected
+	hvcC *hevc.HEVCDecoderConfigurationRecord
+}
+
+func (r *Report) add(f Fix, descriptions []string) {
+	for _, d := range descriptions {
+		r.Changes = append(r.Changes, Change{f, d})
+	}
+}
+
+// Repair - run the fixers on the configuration record of an AVC or HEVC sample entry
+// Fixers run in the order dedup, timing, level, config, hvc1, so the record
+// fields are derived from the final SPSs. Each fixer reports what it
+// changed; with DryRun the record is left as it is.
+func Repair(sampleEntry string, record []byte, opts Options) (*Report, error) {
+	c, decoded, err := codec.ReadRecord(sampleEntry, record)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{SampleEntry: sampleEntry}
+	switch b := decoded.(type) {
+	case *avc.AVCDecoderConfigurationRecord:
+		err = repairAVC(b, &opts, report)
+	case *hevc.HEVCDecoderConfigurationRecord:
+		err = repairHEVC(b, &opts, report)
+	default:
+		return nil, fmt.Errorf("%s: repair not supported", c.Name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", c.Name, err)
+	}
+	if opts.DryRun {
+		report.Record = record
+		report.hvcC = nil
+		return report, nil
+	}
+	var buf bytes.Buffer
+	buf.Grow(int(decoded.RecordSize()))
+	if err := decoded.RecordWrite(&buf); err != nil {
+		return nil, err
+	}
+	report.Record = buf.Bytes()
+	return report, nil
+}
+
+// ConvertSample - rewrite a length-prefixed sample of the track for the
+// repaired record
+// After FIX_HVC1 in-band parameter sets are removed from samples, which fails
+// if one differs from those of the record. Otherwise, and in dry-run mode,
+// the sample is returned unchanged.
+func (r *Report) ConvertSample(sample []byte) ([]byte, error) {
+	if r.hvcC == nil {
+		return sample, nil
+	}
+	return r.hvcC.ConvertSample(sample, r.SampleEntry)
+}
+
+// dedupNALUs - nalus without byte-identical repetitions, and descriptions of the removed ones
+func dedupNALUs(kind string, nalus [][]byte) (unique [][]byte, removed []string) {
+	for i, n := range nalus {
+		duplicate := false
+		for _, u := range unique {
+			if bytes.Equal(u, n) {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			removed = append(removed, fmt.Sprintf("removed duplicate %s %d", kind, i))
+			continue
+		}
+		unique = append(unique, n)
+	}
+	return unique, removed
+}
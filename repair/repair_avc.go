@@ -0,0 +1,79 @@
+package repair
+
+import (
+	"fmt"
+
+	"github.com/go-webdl/media-codec/avc"
+)
+
+func repairAVC(b *avc.AVCDecoderConfigurationRecord, opts *Options, report *Report) error {
+	if opts.runs(FIX_DEDUP) {
+		var spsNalus, ppsNalus [][]byte
+		for _, entry := range b.SequenceParameterSets {
+			spsNalus = append(spsNalus, entry.NALUnit)
+		}
+		for _, entry := range b.PictureParameterSets {
+			ppsNalus = append(ppsNalus, entry.NALUnit)
+		}
+		spsNalus, removed := dedupNALUs("SPS", spsNalus)
+		report.add(FIX_DEDUP, removed)
+		ppsNalus, removed = dedupNALUs("PPS", ppsNalus)
+		report.add(FIX_DEDUP, removed)
+		b.SequenceParameterSets = b.SequenceParameterSets[:0]
+		for _, nalu := range spsNalus {
+			b.SequenceParameterSets = append(b.SequenceParameterSets, avc.AVCSequenceParameterSet{NALUnit: nalu})
+		}
+		b.PictureParameterSets = b.PictureParameterSets[:0]
+		for _, nalu := range ppsNalus {
+			b.PictureParameterSets = append(b.PictureParameterSets, avc.AVCPictureParameterSet{NALUnit: nalu})
+		}
+	}
+
+	insertTiming := opts.runs(FIX_TIMING) && opts.FrameRateNum > 0 && opts.FrameRateDen > 0
+	if insertTiming && opts.FrameRateNum > 1<<31-1 {
+		return fmt.Errorf("frame rate %d/%d out of range", opts.FrameRateNum, opts.FrameRateDen)
+	}
+	for i := range b.SequenceParameterSets {
+		entry := &b.SequenceParameterSets[i]
+		sps, err := avc.ParseSPSNALUnit(entry.NALUnit)
+		if err != nil {
+			return fmt.Errorf("SPS %d: %w", i, err)
+		}
+		changed := false
+		if insertTiming && !sps.VUI.TimingInfoPresentFlag {
+			// frame rate is time_scale / (2 * num_units_in_tick), Eq. C-1
+			sps.VUIParametersPresentFlag = true
+			sps.VUI.TimingInfoPresentFlag = true
+			sps.VUI.TimeScale = 2 * opts.FrameRateNum
+			sps.VUI.NumUnitsInTick = opts.FrameRateDen
+			sps.VUI.FixedFrameRateFlag = true
+			report.add(FIX_TIMING, []string{fmt.Sprintf("SPS %d: inserted timing for %d/%d fps", i, opts.FrameRateNum, opts.FrameRateDen)})
+			changed = true
+		}
+		if opts.runs(FIX_LEVEL) {
+			changes, err := sps.RaiseLevel(opts.BitRate)
+			if err != nil {
+				return fmt.Errorf("SPS %d: %w", i, err)
+			}
+			for _, change := range changes {
+				report.add(FIX_LEVEL, []string{fmt.Sprintf("SPS %d: %s", i, change)})
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+		if entry.NALUnit, err = avc.CreateSPSNALUnit(sps); err != nil {
+			return fmt.Errorf("SPS %d: %w", i, err)
+		}
+	}
+
+	if opts.runs(FIX_CONFIG) {
+		fixes, err := b.FixSPSSignaling()
+		if err != nil {
+			return err
+		}
+		report.add(FIX_CONFIG, fixes)
+	}
+	return nil
+}
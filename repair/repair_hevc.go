@@ -0,0 +1,136 @@
+package repair
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-webdl/media-codec/hevc"
+)
+
+func repairHEVC(b *hevc.HEVCDecoderConfigurationRecord, opts *Options, report *Report) error {
+	if opts.runs(FIX_DEDUP) {
+		for i := range b.NaluArrays {
+			array := &b.NaluArrays[i]
+			var removed []string
+			array.NALUs, removed = dedupNALUs(array.NALUnitType.String(), array.NALUs)
+			report.add(FIX_DEDUP, removed)
+		}
+	}
+	if opts.runs(FIX_TIMING) && opts.FrameRateNum > 0 && opts.FrameRateDen > 0 {
+		if err := insertHEVCTiming(b, opts, report); err != nil {
+			return err
+		}
+	}
+	if opts.runs(FIX_LEVEL) {
+		if err := raiseHEVCLevels(b, opts, report); err != nil {
+			return err
+		}
+		changes, err := b.SetTier(b.GeneralTierFlag, opts.BitRate)
+		if err != nil {
+			return err
+		}
+		report.add(FIX_LEVEL, changes)
+	}
+	if opts.runs(FIX_CONFIG) {
+		fixes, err := b.FixBitDepthSignaling()
+		if err != nil {
+			return err
+		}
+		report.add(FIX_CONFIG, fixes)
+		fix, err := fixChromaFormat(b)
+		if err != nil {
+			return err
+		}
+		if fix != "" {
+			report.add(FIX_CONFIG, []string{fix})
+		}
+	}
+	if opts.runs(FIX_HVC1) && report.SampleEntry == "hev1" {
+		if err := b.SetArrayCompleteness("hvc1"); err != nil {
+			return err
+		}
+		report.add(FIX_HVC1, []string{"sample entry hev1 to hvc1 with complete parameter set arrays"})
+		report.SampleEntry = "hvc1"
+		report.hvcC = b
+	}
+	return nil
+}
+
+// fixChromaFormat - take the chroma format of the record from its first SPS
+func fixChromaFormat(b *hevc.HEVCDecoderConfigurationRecord) (fix string, err error) {
+	for _, array := range b.NaluArrays {
+		if array.NALUnitType != hevc.NALU_SPS || len(array.NALUs) == 0 {
+			continue
+		}
+		sps, err := hevc.ParseSPSNALUnit(array.NALUs[0])
+		if err != nil {
+			return "", err
+		}
+		if sps.ChromaFormatIndicator == b.ChromaFormatIndicator {
+			return "", nil
+		}
+		fix = fmt.Sprintf("chroma format %d, SPS has %d", b.ChromaFormatIndicator, sps.ChromaFormatIndicator)
+		b.ChromaFormatIndicator = sps.ChromaFormatIndicator
+		return fix, nil
+	}
+	return "", errors.New("no SPS in record")
+}
+
+// insertHEVCTiming - insert VUI timing info for the frame rate of opts into
+// the SPSs lacking it
+func insertHEVCTiming(b *hevc.HEVCDecoderConfigurationRecord, opts *Options, report *Report) error {
+	for _, array := range b.NaluArrays {
+		if array.NALUnitType != hevc.NALU_SPS {
+			continue
+		}
+		for i, data := range array.NALUs {
+			sps, err := hevc.ParseSPSNALUnit(data)
+			if err != nil {
+				return fmt.Errorf("SPS %d: %w", i, err)
+			}
+			if sps.VUIParametersPresentFlag && sps.VUI.TimingInfoPresentFlag {
+				continue
+			}
+			// frame rate is time_scale / num_units_in_tick, Sec. E.3.1
+			sps.VUIParametersPresentFlag = true
+			sps.VUI.TimingInfoPresentFlag = true
+			sps.VUI.TimeScale = opts.FrameRateNum
+			sps.VUI.NumUnitsInTick = opts.FrameRateDen
+			if array.NALUs[i], err = hevc.CreateSPSNALUnit(sps); err != nil {
+				return fmt.Errorf("SPS %d: %w", i, err)
+			}
+			report.add(FIX_TIMING, []string{fmt.Sprintf("SPS %d: inserted timing for %d/%d fps", i, opts.FrameRateNum, opts.FrameRateDen)})
+		}
+	}
+	return nil
+}
+
+// raiseHEVCLevels - raise general_level_idc of the SPSs to the lowest level
+// their parameters allow
+func raiseHEVCLevels(b *hevc.HEVCDecoderConfigurationRecord, opts *Options, report *Report) error {
+	for _, array := range b.NaluArrays {
+		if array.NALUnitType != hevc.NALU_SPS {
+			continue
+		}
+		for i, data := range array.NALUs {
+			sps, err := hevc.ParseSPSNALUnit(data)
+			if err != nil {
+				return fmt.Errorf("SPS %d: %w", i, err)
+			}
+			changes, err := sps.RaiseLevel(opts.BitRate)
+			if err != nil {
+				return fmt.Errorf("SPS %d: %w", i, err)
+			}
+			if len(changes) == 0 {
+				continue
+			}
+			if array.NALUs[i], err = hevc.CreateSPSNALUnit(sps); err != nil {
+				return fmt.Errorf("SPS %d: %w", i, err)
+			}
+			for _, change := range changes {
+				report.add(FIX_LEVEL, []string{fmt.Sprintf("SPS %d: %s", i, change)})
+			}
+		}
+	}
+	return nil
+}
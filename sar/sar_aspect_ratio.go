@@ -0,0 +1,68 @@
+package sar
+
+// EXTENDED_SAR - aspect_ratio_idc signalling sar_width and sar_height explicitly
+const EXTENDED_SAR = byte(255)
+
+// sarTable - sample aspect ratio of aspect_ratio_idc 1 to 16, shared by
+// ISO/IEC 14496-10 Table E-1 and ISO/IEC 23008-2 Table E.1
+var sarTable = [...][2]uint16{
+	{1, 1}, {12, 11}, {10, 11}, {16, 11}, {40, 33}, {24, 11}, {20, 11}, {32, 11},
+	{80, 33}, {18, 11}, {15, 11}, {64, 33}, {160, 99}, {4, 3}, {3, 2}, {2, 1},
+}
+
+// FromIdc - sample aspect ratio of aspect_ratio_idc, with sarWidth and
+// sarHeight used for Extended_SAR
+// 0:0 is returned for unspecified and reserved values.
+func FromIdc(idc byte, sarWidth, sarHeight uint16) (width, height uint16) {
+	switch {
+	case idc == EXTENDED_SAR:
+		if sarWidth == 0 || sarHeight == 0 {
+			return 0, 0
+		}
+		return sarWidth, sarHeight
+	case idc >= 1 && int(idc) <= len(sarTable):
+		return sarTable[idc-1][0], sarTable[idc-1][1]
+	}
+	return 0, 0
+}
+
+// Idc - aspect_ratio_idc, sar_width and sar_height signalling width:height
+// A table entry is used if there is one, with zero sar_width and
+// sar_height, else Extended_SAR. width and height must not be 0.
+func Idc(width, height uint16) (idc byte, sarWidth, sarHeight uint16) {
+	g := uint16(gcd(uint64(width), uint64(height)))
+	for i, r := range sarTable {
+		if r[0] == width/g && r[1] == height/g {
+			return byte(i + 1), 0, 0
+		}
+	}
+	return EXTENDED_SAR, width, height
+}
+
+// DisplayAspectRatio - aspect ratio of a picture of imageWidth x imageHeight
+// scaled by the sample aspect ratio, reduced to lowest terms, e.g. 16:9
+func DisplayAspectRatio(imageWidth, imageHeight uint32, sarWidth, sarHeight uint16) (width, height uint32) {
+	w := uint64(imageWidth) * uint64(sarWidth)
+	h := uint64(imageHeight) * uint64(sarHeight)
+	if w == 0 || h == 0 {
+		return 0, 0
+	}
+	g := gcd(w, h)
+	return uint32(w / g), uint32(h / g)
+}
+
+// DisplaySize - picture size after scaling the width by the sample aspect
+// ratio, as used for the track header of an MP4 track
+func DisplaySize(imageWidth, imageHeight uint32, sarWidth, sarHeight uint16) (width, height uint32) {
+	if sarHeight == 0 {
+		return imageWidth, imageHeight
+	}
+	return uint32(uint64(imageWidth) * uint64(sarWidth) / uint64(sarHeight)), imageHeight
+}
+
+func gcd(a, b uint64) uint64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
@@ -0,0 +1,26 @@
+package scte35
+
+// crc32MPEG2 - CRC-32/MPEG-2 as used by MPEG-2 TS sections (polynomial
+// 0x04C11DB7, initial value 0xFFFFFFFF, no reflection, no final XOR)
+func crc32MPEG2(data []byte) uint32 {
+	crc := uint32(0xFFFFFFFF)
+	for _, b := range data {
+		crc = crcTable[byte(crc>>24)^b] ^ (crc << 8)
+	}
+	return crc
+}
+
+var crcTable = func() (table [256]uint32) {
+	for i := range table {
+		crc := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if crc&0x80000000 != 0 {
+				crc = crc<<1 ^ 0x04C11DB7
+			} else {
+				crc <<= 1
+			}
+		}
+		table[i] = crc
+	}
+	return
+}()
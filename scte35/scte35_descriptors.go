@@ -0,0 +1,201 @@
+package scte35
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/go-webdl/bits"
+)
+
+// SpliceDescriptorTag - splice_descriptor_tag
+type SpliceDescriptorTag uint8
+
+const (
+	AVAIL_DESCRIPTOR        = SpliceDescriptorTag(0x00)
+	DTMF_DESCRIPTOR         = SpliceDescriptorTag(0x01)
+	SEGMENTATION_DESCRIPTOR = SpliceDescriptorTag(0x02)
+	TIME_DESCRIPTOR         = SpliceDescriptorTag(0x03)
+	AUDIO_DESCRIPTOR        = SpliceDescriptorTag(0x04)
+)
+
+// CUEIdentifier - "CUEI", the identifier of descriptors defined by SCTE 35
+const CUEIdentifier = 0x43554549
+
+// SpliceDescriptor - splice_descriptor() with its content after the
+// identifier. Segmentation descriptors are decoded into Segmentation.
+type SpliceDescriptor struct {
+	Tag          SpliceDescriptorTag
+	Identifier   uint32
+	Data         []byte
+	Segmentation *SegmentationDescriptor
+}
+
+// SegmentationType - segmentation_type_id
+type SegmentationType uint8
+
+const (
+	SEGMENTATION_NOT_INDICATED                           = SegmentationType(0x00)
+	SEGMENTATION_CONTENT_IDENTIFICATION                  = SegmentationType(0x01)
+	SEGMENTATION_PROGRAM_START                           = SegmentationType(0x10)
+	SEGMENTATION_PROGRAM_END                             = SegmentationType(0x11)
+	SEGMENTATION_PROGRAM_EARLY_TERMINATION               = SegmentationType(0x12)
+	SEGMENTATION_PROGRAM_BREAKAWAY                       = SegmentationType(0x13)
+	SEGMENTATION_PROGRAM_RESUMPTION                      = SegmentationType(0x14)
+	SEGMENTATION_CHAPTER_START                           = SegmentationType(0x20)
+	SEGMENTATION_CHAPTER_END                             = SegmentationType(0x21)
+	SEGMENTATION_BREAK_START                             = SegmentationType(0x22)
+	SEGMENTATION_BREAK_END                               = SegmentationType(0x23)
+	SEGMENTATION_PROVIDER_ADVERTISEMENT_START            = SegmentationType(0x30)
+	SEGMENTATION_PROVIDER_ADVERTISEMENT_END              = SegmentationType(0x31)
+	SEGMENTATION_DISTRIBUTOR_ADVERTISEMENT_START         = SegmentationType(0x32)
+	SEGMENTATION_DISTRIBUTOR_ADVERTISEMENT_END           = SegmentationType(0x33)
+	SEGMENTATION_PROVIDER_PLACEMENT_OPPORTUNITY_START    = SegmentationType(0x34)
+	SEGMENTATION_PROVIDER_PLACEMENT_OPPORTUNITY_END      = SegmentationType(0x35)
+	SEGMENTATION_DISTRIBUTOR_PLACEMENT_OPPORTUNITY_START = SegmentationType(0x36)
+	SEGMENTATION_DISTRIBUTOR_PLACEMENT_OPPORTUNITY_END   = SegmentationType(0x37)
+	SEGMENTATION_PROVIDER_OVERLAY_PLACEMENT_START        = SegmentationType(0x38)
+	SEGMENTATION_PROVIDER_OVERLAY_PLACEMENT_END          = SegmentationType(0x39)
+	SEGMENTATION_DISTRIBUTOR_OVERLAY_PLACEMENT_START     = SegmentationType(0x3A)
+	SEGMENTATION_DISTRIBUTOR_OVERLAY_PLACEMENT_END       = SegmentationType(0x3B)
+	SEGMENTATION_PROVIDER_AD_BLOCK_START                 = SegmentationType(0x44)
+	SEGMENTATION_PROVIDER_AD_BLOCK_END                   = SegmentationType(0x45)
+	SEGMENTATION_DISTRIBUTOR_AD_BLOCK_START              = SegmentationType(0x46)
+	SEGMENTATION_DISTRIBUTOR_AD_BLOCK_END                = SegmentationType(0x47)
+)
+
+// IsStart - true for the types opening a segment (program, chapter, break,
+// advertisement, placement opportunity, overlay or ad block start)
+func (t SegmentationType) IsStart() bool {
+	switch t {
+	case SEGMENTATION_PROGRAM_START, SEGMENTATION_PROGRAM_RESUMPTION, SEGMENTATION_CHAPTER_START, SEGMENTATION_BREAK_START,
+		SEGMENTATION_PROVIDER_ADVERTISEMENT_START, SEGMENTATION_DISTRIBUTOR_ADVERTISEMENT_START,
+		SEGMENTATION_PROVIDER_PLACEMENT_OPPORTUNITY_START, SEGMENTATION_DISTRIBUTOR_PLACEMENT_OPPORTUNITY_START,
+		SEGMENTATION_PROVIDER_OVERLAY_PLACEMENT_START, SEGMENTATION_DISTRIBUTOR_OVERLAY_PLACEMENT_START,
+		SEGMENTATION_PROVIDER_AD_BLOCK_START, SEGMENTATION_DISTRIBUTOR_AD_BLOCK_START:
+		return true
+	}
+	return false
+}
+
+// hasSubSegments - types followed by sub_segment_num and sub_segments_expected
+func (t SegmentationType) hasSubSegments() bool {
+	switch t {
+	case SEGMENTATION_PROVIDER_PLACEMENT_OPPORTUNITY_START, SEGMENTATION_DISTRIBUTOR_PLACEMENT_OPPORTUNITY_START,
+		SEGMENTATION_PROVIDER_ADVERTISEMENT_START, SEGMENTATION_DISTRIBUTOR_ADVERTISEMENT_START,
+		SEGMENTATION_PROVIDER_OVERLAY_PLACEMENT_START, SEGMENTATION_DISTRIBUTOR_OVERLAY_PLACEMENT_START,
+		SEGMENTATION_PROVIDER_AD_BLOCK_START, SEGMENTATION_DISTRIBUTOR_AD_BLOCK_START:
+		return true
+	}
+	return false
+}
+
+// SegmentationDescriptor - segmentation_descriptor()
+type SegmentationDescriptor struct {
+	SegmentationEventID                    uint32
+	SegmentationEventCancelIndicator       bool
+	SegmentationEventIDComplianceIndicator bool
+	ProgramSegmentationFlag                bool
+	SegmentationDurationFlag               bool
+	DeliveryNotRestrictedFlag              bool
+	WebDeliveryAllowedFlag                 bool
+	NoRegionalBlackoutFlag                 bool
+	ArchiveAllowedFlag                     bool
+	DeviceRestrictions                     uint8
+	Components                             []SegmentationComponent
+	// 40-bit duration in 90kHz units
+	SegmentationDuration uint64
+	SegmentationUPIDType uint8
+	SegmentationUPID     []byte
+	SegmentationTypeID   SegmentationType
+	SegmentNum           uint8
+	SegmentsExpected     uint8
+	SubSegmentNum        uint8
+	SubSegmentsExpected  uint8
+}
+
+type SegmentationComponent struct {
+	ComponentTag uint8
+	PTSOffset    uint64
+}
+
+func parseSpliceDescriptors(data []byte) (descriptors []SpliceDescriptor, err error) {
+	for len(data) > 0 {
+		if len(data) < 6 {
+			return descriptors, fmt.Errorf("splice_descriptor of %d bytes is truncated", len(data))
+		}
+		tag, length := SpliceDescriptorTag(data[0]), int(data[1])
+		if length < 4 || 2+length > len(data) {
+			return descriptors, fmt.Errorf("invalid descriptor_length %d", length)
+		}
+		d := SpliceDescriptor{
+			Tag:        tag,
+			Identifier: uint32(data[2])<<24 | uint32(data[3])<<16 | uint32(data[4])<<8 | uint32(data[5]),
+			Data:       data[6 : 2+length],
+		}
+		if d.Identifier == CUEIdentifier && d.Tag == SEGMENTATION_DESCRIPTOR {
+			if d.Segmentation, err = parseSegmentationDescriptor(d.Data); err != nil {
+				return
+			}
+		}
+		descriptors = append(descriptors, d)
+		data = data[2+length:]
+	}
+	return
+}
+
+func parseSegmentationDescriptor(data []byte) (*SegmentationDescriptor, error) {
+	rd := bytes.NewReader(data)
+	r := bits.NewAccErrReader(rd)
+	sd := &SegmentationDescriptor{}
+	sd.SegmentationEventID = uint32(r.Read(32))
+	sd.SegmentationEventCancelIndicator = r.ReadFlag()
+	sd.SegmentationEventIDComplianceIndicator = r.ReadFlag()
+	r.Read(6) // reserved
+	if sd.SegmentationEventCancelIndicator {
+		return sd, r.AccError()
+	}
+	sd.ProgramSegmentationFlag = r.ReadFlag()
+	sd.SegmentationDurationFlag = r.ReadFlag()
+	sd.DeliveryNotRestrictedFlag = r.ReadFlag()
+	if !sd.DeliveryNotRestrictedFlag {
+		sd.WebDeliveryAllowedFlag = r.ReadFlag()
+		sd.NoRegionalBlackoutFlag = r.ReadFlag()
+		sd.ArchiveAllowedFlag = r.ReadFlag()
+		sd.DeviceRestrictions = uint8(r.Read(2))
+	} else {
+		r.Read(5) // reserved
+	}
+	if !sd.ProgramSegmentationFlag {
+		componentCount := int(r.Read(8))
+		for i := 0; i < componentCount && r.AccError() == nil; i++ {
+			c := SegmentationComponent{ComponentTag: uint8(r.Read(8))}
+			r.Read(7) // reserved
+			c.PTSOffset = uint64(r.Read(33))
+			sd.Components = append(sd.Components, c)
+		}
+	}
+	if sd.SegmentationDurationFlag {
+		sd.SegmentationDuration = uint64(r.Read(40))
+	}
+	sd.SegmentationUPIDType = uint8(r.Read(8))
+	upidLength := int(r.Read(8))
+	if err := r.AccError(); err != nil {
+		return nil, err
+	}
+	start := len(data) - rd.Len()
+	if start+upidLength > len(data) {
+		return nil, fmt.Errorf("segmentation_upid_length %d exceeds descriptor", upidLength)
+	}
+	sd.SegmentationUPID = data[start : start+upidLength]
+	rd.Seek(int64(start+upidLength), 0)
+	sd.SegmentationTypeID = SegmentationType(r.Read(8))
+	sd.SegmentNum = uint8(r.Read(8))
+	sd.SegmentsExpected = uint8(r.Read(8))
+	// Older revisions of SCTE 35 do not carry the sub segment fields, so only
+	// read them when the descriptor is long enough.
+	if sd.SegmentationTypeID.hasSubSegments() && rd.Len() >= 2 {
+		sd.SubSegmentNum = uint8(r.Read(8))
+		sd.SubSegmentsExpected = uint8(r.Read(8))
+	}
+	return sd, r.AccError()
+}
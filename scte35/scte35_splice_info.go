@@ -0,0 +1,352 @@
+package scte35
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/go-webdl/bits"
+)
+
+// SpliceInfoSection - SCTE 35 splice_info_section, carrying splice commands
+// and descriptors signaling splice points (ad-break boundaries) of a program
+// It is carried in MPEG-2 TS sections with table_id 0xFC on the PID announced
+// in the PMT with stream_type 0x86.
+type SpliceInfoSection struct {
+	TableID                uint8
+	SectionSyntaxIndicator bool
+	PrivateIndicator       bool
+	SAPType                uint8
+	SectionLength          uint16
+	ProtocolVersion        uint8
+	EncryptedPacket        bool
+	EncryptionAlgorithm    uint8
+	// 33-bit offset to be added to every pts_time of the section, in 90kHz
+	// units
+	PTSAdjustment     uint64
+	CWIndex           uint8
+	Tier              uint16
+	SpliceCommandType SpliceCommandType
+	SpliceInsert      *SpliceInsert
+	TimeSignal        *TimeSignal
+	SpliceSchedule    *SpliceSchedule
+	PrivateCommand    *PrivateCommand
+	SpliceDescriptors []SpliceDescriptor
+	// Encrypted portion of the section, starting at splice_command_type,
+	// when EncryptedPacket is set. Nothing beyond PTSAdjustment, CWIndex and
+	// Tier is decoded in that case.
+	EncryptedData []byte
+	CRC32         uint32
+}
+
+// SpliceCommandType - splice_command_type
+type SpliceCommandType uint8
+
+const (
+	SPLICE_NULL           = SpliceCommandType(0x00)
+	SPLICE_SCHEDULE       = SpliceCommandType(0x04)
+	SPLICE_INSERT         = SpliceCommandType(0x05)
+	TIME_SIGNAL           = SpliceCommandType(0x06)
+	BANDWIDTH_RESERVATION = SpliceCommandType(0x07)
+	PRIVATE_COMMAND       = SpliceCommandType(0xFF)
+)
+
+func (t SpliceCommandType) String() string {
+	switch t {
+	case SPLICE_NULL:
+		return "SpliceNull_0"
+	case SPLICE_SCHEDULE:
+		return "SpliceSchedule_4"
+	case SPLICE_INSERT:
+		return "SpliceInsert_5"
+	case TIME_SIGNAL:
+		return "TimeSignal_6"
+	case BANDWIDTH_RESERVATION:
+		return "BandwidthReservation_7"
+	case PRIVATE_COMMAND:
+		return "PrivateCommand_255"
+	default:
+		return fmt.Sprintf("Reserved_%d", t)
+	}
+}
+
+// SpliceTime - splice_time()
+type SpliceTime struct {
+	TimeSpecifiedFlag bool
+	// 33-bit presentation time in 90kHz units, before pts_adjustment
+	PTSTime uint64
+}
+
+// BreakDuration - break_duration()
+type BreakDuration struct {
+	AutoReturn bool
+	// 33-bit duration in 90kHz units
+	Duration uint64
+}
+
+// SpliceInsert - splice_insert()
+type SpliceInsert struct {
+	SpliceEventID              uint32
+	SpliceEventCancelIndicator bool
+	OutOfNetworkIndicator      bool
+	ProgramSpliceFlag          bool
+	DurationFlag               bool
+	SpliceImmediateFlag        bool
+	SpliceTime                 SpliceTime
+	Components                 []SpliceInsertComponent
+	BreakDuration              BreakDuration
+	UniqueProgramID            uint16
+	AvailNum                   uint8
+	AvailsExpected             uint8
+}
+
+type SpliceInsertComponent struct {
+	ComponentTag uint8
+	SpliceTime   SpliceTime
+}
+
+// TimeSignal - time_signal()
+type TimeSignal struct {
+	SpliceTime SpliceTime
+}
+
+// SpliceSchedule - splice_schedule()
+type SpliceSchedule struct {
+	Events []SpliceScheduleEvent
+}
+
+type SpliceScheduleEvent struct {
+	SpliceEventID              uint32
+	SpliceEventCancelIndicator bool
+	OutOfNetworkIndicator      bool
+	ProgramSpliceFlag          bool
+	DurationFlag               bool
+	// Seconds since 1980-01-06 00:00:00 UTC
+	UTCSpliceTime   uint32
+	Components      []SpliceScheduleComponent
+	BreakDuration   BreakDuration
+	UniqueProgramID uint16
+	AvailNum        uint8
+	AvailsExpected  uint8
+}
+
+type SpliceScheduleComponent struct {
+	ComponentTag  uint8
+	UTCSpliceTime uint32
+}
+
+// PrivateCommand - private_command()
+type PrivateCommand struct {
+	Identifier   uint32
+	PrivateBytes []byte
+}
+
+// ParseSpliceInfoSection - parse a complete splice_info_section starting at
+// table_id and verify its CRC_32
+func ParseSpliceInfoSection(data []byte) (*SpliceInfoSection, error) {
+	if len(data) < 3 {
+		return nil, fmt.Errorf("splice_info_section of %d bytes is truncated", len(data))
+	}
+	s := &SpliceInfoSection{}
+	r := bits.NewAccErrReader(bytes.NewReader(data))
+	s.TableID = uint8(r.Read(8))
+	if s.TableID != 0xFC {
+		return nil, fmt.Errorf("table_id is 0x%02X not splice_info_section", s.TableID)
+	}
+	s.SectionSyntaxIndicator = r.ReadFlag()
+	s.PrivateIndicator = r.ReadFlag()
+	s.SAPType = uint8(r.Read(2))
+	s.SectionLength = uint16(r.Read(12))
+	sectionEnd := 3 + int(s.SectionLength)
+	if sectionEnd > len(data) {
+		return nil, fmt.Errorf("section_length %d exceeds the %d bytes available", s.SectionLength, len(data)-3)
+	}
+	const commandStart = 13
+	if sectionEnd-4 < commandStart {
+		return nil, fmt.Errorf("section_length %d too short for the section header and CRC_32", s.SectionLength)
+	}
+	s.CRC32 = binary.BigEndian.Uint32(data[sectionEnd-4:])
+	if crc := crc32MPEG2(data[:sectionEnd-4]); crc != s.CRC32 {
+		return nil, fmt.Errorf("CRC_32 mismatch: section has 0x%08X, computed 0x%08X", s.CRC32, crc)
+	}
+	s.ProtocolVersion = uint8(r.Read(8))
+	s.EncryptedPacket = r.ReadFlag()
+	s.EncryptionAlgorithm = uint8(r.Read(6))
+	s.PTSAdjustment = uint64(r.Read(33))
+	s.CWIndex = uint8(r.Read(8))
+	s.Tier = uint16(r.Read(12))
+	spliceCommandLength := int(r.Read(12))
+	if err := r.AccError(); err != nil {
+		return nil, err
+	}
+	if s.EncryptedPacket {
+		s.EncryptedData = data[commandStart : sectionEnd-4]
+		return s, nil
+	}
+	body := data[commandStart : sectionEnd-4]
+	if spliceCommandLength != 0xFFF && 1+spliceCommandLength > len(body) {
+		return nil, fmt.Errorf("splice_command_length %d exceeds section", spliceCommandLength)
+	}
+	rd := bytes.NewReader(body)
+	r = bits.NewAccErrReader(rd)
+	s.SpliceCommandType = SpliceCommandType(r.Read(8))
+	switch s.SpliceCommandType {
+	case SPLICE_NULL, BANDWIDTH_RESERVATION:
+	case SPLICE_SCHEDULE:
+		s.SpliceSchedule = readSpliceSchedule(r)
+	case SPLICE_INSERT:
+		s.SpliceInsert = readSpliceInsert(r)
+	case TIME_SIGNAL:
+		s.TimeSignal = &TimeSignal{SpliceTime: readSpliceTime(r)}
+	case PRIVATE_COMMAND:
+		if spliceCommandLength == 0xFFF || spliceCommandLength < 4 {
+			return nil, fmt.Errorf("private_command requires a valid splice_command_length, got %d", spliceCommandLength)
+		}
+		s.PrivateCommand = &PrivateCommand{Identifier: uint32(r.Read(32))}
+		if err := r.AccError(); err != nil {
+			return nil, err
+		}
+		start := len(body) - rd.Len()
+		end := 1 + spliceCommandLength
+		s.PrivateCommand.PrivateBytes = body[start:end]
+		rd.Seek(int64(end), 0)
+	default:
+		// Skip unknown commands when their length is signaled
+		if spliceCommandLength == 0xFFF {
+			return nil, fmt.Errorf("cannot skip %s without a valid splice_command_length", s.SpliceCommandType)
+		}
+		rd.Seek(int64(1+spliceCommandLength), 0)
+	}
+	if err := r.AccError(); err != nil {
+		return nil, err
+	}
+	descriptorLoopLength := int(r.Read(16))
+	if err := r.AccError(); err != nil {
+		return nil, err
+	}
+	start := len(body) - rd.Len()
+	if start+descriptorLoopLength > len(body) {
+		return nil, fmt.Errorf("descriptor_loop_length %d exceeds section", descriptorLoopLength)
+	}
+	var err error
+	if s.SpliceDescriptors, err = parseSpliceDescriptors(body[start : start+descriptorLoopLength]); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func readSpliceTime(r *bits.AccErrReader) (t SpliceTime) {
+	t.TimeSpecifiedFlag = r.ReadFlag()
+	if t.TimeSpecifiedFlag {
+		r.Read(6) // reserved
+		t.PTSTime = uint64(r.Read(33))
+	} else {
+		r.Read(7) // reserved
+	}
+	return
+}
+
+func readBreakDuration(r *bits.AccErrReader) (d BreakDuration) {
+	d.AutoReturn = r.ReadFlag()
+	r.Read(6) // reserved
+	d.Duration = uint64(r.Read(33))
+	return
+}
+
+func readSpliceInsert(r *bits.AccErrReader) *SpliceInsert {
+	si := &SpliceInsert{}
+	si.SpliceEventID = uint32(r.Read(32))
+	si.SpliceEventCancelIndicator = r.ReadFlag()
+	r.Read(7) // reserved
+	if si.SpliceEventCancelIndicator {
+		return si
+	}
+	si.OutOfNetworkIndicator = r.ReadFlag()
+	si.ProgramSpliceFlag = r.ReadFlag()
+	si.DurationFlag = r.ReadFlag()
+	si.SpliceImmediateFlag = r.ReadFlag()
+	r.Read(4) // reserved
+	if si.ProgramSpliceFlag && !si.SpliceImmediateFlag {
+		si.SpliceTime = readSpliceTime(r)
+	}
+	if !si.ProgramSpliceFlag {
+		componentCount := int(r.Read(8))
+		for i := 0; i < componentCount && r.AccError() == nil; i++ {
+			c := SpliceInsertComponent{ComponentTag: uint8(r.Read(8))}
+			if !si.SpliceImmediateFlag {
+				c.SpliceTime = readSpliceTime(r)
+			}
+			si.Components = append(si.Components, c)
+		}
+	}
+	if si.DurationFlag {
+		si.BreakDuration = readBreakDuration(r)
+	}
+	si.UniqueProgramID = uint16(r.Read(16))
+	si.AvailNum = uint8(r.Read(8))
+	si.AvailsExpected = uint8(r.Read(8))
+	return si
+}
+
+func readSpliceSchedule(r *bits.AccErrReader) *SpliceSchedule {
+	ss := &SpliceSchedule{}
+	spliceCount := int(r.Read(8))
+	for i := 0; i < spliceCount && r.AccError() == nil; i++ {
+		e := SpliceScheduleEvent{}
+		e.SpliceEventID = uint32(r.Read(32))
+		e.SpliceEventCancelIndicator = r.ReadFlag()
+		r.Read(7) // reserved
+		if !e.SpliceEventCancelIndicator {
+			e.OutOfNetworkIndicator = r.ReadFlag()
+			e.ProgramSpliceFlag = r.ReadFlag()
+			e.DurationFlag = r.ReadFlag()
+			r.Read(5) // reserved
+			if e.ProgramSpliceFlag {
+				e.UTCSpliceTime = uint32(r.Read(32))
+			} else {
+				componentCount := int(r.Read(8))
+				for j := 0; j < componentCount && r.AccError() == nil; j++ {
+					e.Components = append(e.Components, SpliceScheduleComponent{
+						ComponentTag:  uint8(r.Read(8)),
+						UTCSpliceTime: uint32(r.Read(32)),
+					})
+				}
+			}
+			if e.DurationFlag {
+				e.BreakDuration = readBreakDuration(r)
+			}
+			e.UniqueProgramID = uint16(r.Read(16))
+			e.AvailNum = uint8(r.Read(8))
+			e.AvailsExpected = uint8(r.Read(8))
+		}
+		ss.Events = append(ss.Events, e)
+	}
+	return ss
+}
+
+// SplicePTS - presentation time of the splice point signaled by a
+// splice_insert (program splice mode) or time_signal command, with
+// pts_adjustment applied. ok is false if the command carries no time.
+func (s *SpliceInfoSection) SplicePTS() (pts uint64, ok bool) {
+	var t SpliceTime
+	switch {
+	case s.TimeSignal != nil:
+		t = s.TimeSignal.SpliceTime
+	case s.SpliceInsert != nil && s.SpliceInsert.ProgramSpliceFlag && !s.SpliceInsert.SpliceImmediateFlag:
+		t = s.SpliceInsert.SpliceTime
+	}
+	if !t.TimeSpecifiedFlag {
+		return 0, false
+	}
+	return (t.PTSTime + s.PTSAdjustment) & (1<<33 - 1), true
+}
+
+// ParseTSPayload - parse the splice_info_section starting in the payload of a
+// TS packet with payload_unit_start_indicator set, honoring the pointer_field.
+// Sections spanning several TS packets have to be reassembled by the caller.
+func ParseTSPayload(payload []byte) (*SpliceInfoSection, error) {
+	if len(payload) < 1 || int(payload[0])+1 > len(payload) {
+		return nil, fmt.Errorf("invalid pointer_field in TS payload")
+	}
+	return ParseSpliceInfoSection(payload[1+int(payload[0]):])
+}
@@ -0,0 +1,58 @@
+package sei
+
+import "fmt"
+
+// AlternativeTransferCharacteristics - alternative_transfer_characteristics() SEI payload
+//
+// Used for backward-compatible HLG: the VUI signals a SDR transfer function,
+// typically BT.709 or BT.2020, which legacy decoders apply, while HDR capable
+// ones use the preferred ARIB STD-B67 (HLG) transfer function signalled here.
+type AlternativeTransferCharacteristics struct {
+	// PreferredTransferCharacteristics - code point of ITU-T H.273
+	PreferredTransferCharacteristics byte
+}
+
+// ParseAlternativeTransferCharacteristics - decode an alternative_transfer_characteristics() payload
+func ParseAlternativeTransferCharacteristics(payload []byte) (*AlternativeTransferCharacteristics, error) {
+	if len(payload) < 1 {
+		return nil, fmt.Errorf("alternative_transfer_characteristics payload is empty")
+	}
+	return &AlternativeTransferCharacteristics{PreferredTransferCharacteristics: payload[0]}, nil
+}
+
+// Message - wrap the payload into an SEI message
+func (a *AlternativeTransferCharacteristics) Message() Message {
+	return Message{
+		PayloadType: SEI_ALTERNATIVE_TRANSFER_CHARACTERISTICS,
+		Payload:     []byte{a.PreferredTransferCharacteristics},
+	}
+}
+
+// FindAlternativeTransferCharacteristics - the first alternative_transfer_characteristics message among msgs
+func FindAlternativeTransferCharacteristics(msgs []Message) (*AlternativeTransferCharacteristics, bool) {
+	for _, msg := range msgs {
+		if msg.PayloadType != SEI_ALTERNATIVE_TRANSFER_CHARACTERISTICS {
+			continue
+		}
+		if a, err := ParseAlternativeTransferCharacteristics(msg.Payload); err == nil {
+			return a, true
+		}
+	}
+	return nil, false
+}
+
+// EffectiveTransferCharacteristics - transfer function of the content given
+// the VUI transfer_characteristics and an optional alternative_transfer_characteristics
+// The preferred transfer characteristics override the VUI unless they are
+// reserved (0, 3) or unspecified (2). a may be nil.
+func EffectiveTransferCharacteristics(vuiTransferCharacteristics byte, a *AlternativeTransferCharacteristics) byte {
+	if a == nil {
+		return vuiTransferCharacteristics
+	}
+	switch a.PreferredTransferCharacteristics {
+	case 0, 2, 3:
+		return vuiTransferCharacteristics
+	default:
+		return a.PreferredTransferCharacteristics
+	}
+}
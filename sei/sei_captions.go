@@ -0,0 +1,115 @@
+package sei
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// A53T35Prefix - itu_t_t35_country_code (United States),
+// itu_t_t35_provider_code (ATSC) and user_identifier "GA94" starting
+// ATSC A/53 caption user data
+var A53T35Prefix = []byte{0xB5, 0x00, 0x31, 'G', 'A', '9', '4'}
+
+// A53_CC_DATA - user_data_type_code of cc_data()
+const A53_CC_DATA = byte(0x03)
+
+// CCType - cc_type of a caption data triplet, CEA-708 Sec. 4.4
+type CCType byte
+
+const (
+	CC_TYPE_NTSC_FIELD_1       = CCType(0)
+	CC_TYPE_NTSC_FIELD_2       = CCType(1)
+	CC_TYPE_DTVCC_PACKET_DATA  = CCType(2)
+	CC_TYPE_DTVCC_PACKET_START = CCType(3)
+)
+
+func (t CCType) String() string {
+	switch t {
+	case CC_TYPE_NTSC_FIELD_1:
+		return "CEA608Field1_0"
+	case CC_TYPE_NTSC_FIELD_2:
+		return "CEA608Field2_1"
+	case CC_TYPE_DTVCC_PACKET_DATA:
+		return "DTVCCData_2"
+	default:
+		return "DTVCCStart_3"
+	}
+}
+
+// CCData - one cc_data triplet: two bytes of CEA-608 field data or of a
+// CEA-708 DTVCC packet
+type CCData struct {
+	Valid bool
+	Type  CCType
+	Data  [2]byte
+}
+
+// ParseA53CCData - extract the cc_data triplets of an ATSC A/53 user data
+// message, returning false if the message does not carry cc_data
+func ParseA53CCData(m *Message) ([]CCData, bool, error) {
+	if m.PayloadType != SEI_USER_DATA_REGISTERED_ITU_T_T35 || !bytes.HasPrefix(m.Payload, A53T35Prefix) {
+		return nil, false, nil
+	}
+	data := m.Payload[len(A53T35Prefix):]
+	if len(data) < 1 || data[0] != A53_CC_DATA {
+		return nil, false, nil
+	}
+	data = data[1:]
+	if len(data) < 2 {
+		return nil, true, fmt.Errorf("cc_data truncated")
+	}
+	processCCData := data[0]&0x40 != 0
+	ccCount := int(data[0] & 0x1f)
+	data = data[2:] // flags, cc_count and em_data
+	if len(data) < 3*ccCount {
+		return nil, true, fmt.Errorf("cc_data with cc_count %d truncated at %d bytes", ccCount, len(data))
+	}
+	if !processCCData {
+		return nil, true, nil
+	}
+	ccs := make([]CCData, 0, ccCount)
+	for i := 0; i < ccCount; i++ {
+		b := data[3*i : 3*i+3]
+		ccs = append(ccs, CCData{
+			Valid: b[0]&0x04 != 0,
+			Type:  CCType(b[0] & 0x03),
+			Data:  [2]byte{b[1], b[2]},
+		})
+	}
+	return ccs, true, nil
+}
+
+// CaptionChannels - valid cc_data triplets of an access unit by channel
+type CaptionChannels struct {
+	// Field1, Field2 - CEA-608 byte pairs of NTSC field 1, carrying CC1, CC2,
+	// T1 and T2, and of field 2, carrying CC3, CC4, T3 and T4, with parity
+	Field1 [][2]byte
+	Field2 [][2]byte
+	// DTVCC - CEA-708 DTVCC triplets; one of CC_TYPE_DTVCC_PACKET_START
+	// begins a packet, which may continue in following access units
+	DTVCC []CCData
+}
+
+// Empty - are there no captions
+func (c *CaptionChannels) Empty() bool {
+	return len(c.Field1) == 0 && len(c.Field2) == 0 && len(c.DTVCC) == 0
+}
+
+// SplitCCData - sort cc_data triplets into the CEA-608 fields and the
+// CEA-708 DTVCC channel, dropping those without cc_valid
+func SplitCCData(ccs []CCData) (c CaptionChannels) {
+	for _, cc := range ccs {
+		if !cc.Valid {
+			continue
+		}
+		switch cc.Type {
+		case CC_TYPE_NTSC_FIELD_1:
+			c.Field1 = append(c.Field1, cc.Data)
+		case CC_TYPE_NTSC_FIELD_2:
+			c.Field2 = append(c.Field2, cc.Data)
+		default:
+			c.DTVCC = append(c.DTVCC, cc)
+		}
+	}
+	return c
+}
@@ -0,0 +1,63 @@
+package sei
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// ContentLightLevelInfo - content_light_level_info() SEI payload
+//
+// MaxCLL and MaxFALL of HDR10 in cd/m², 0 if unknown. The payload has the
+// same layout as the 'clli' box of ISO/IEC 14496-12.
+type ContentLightLevelInfo struct {
+	MaxContentLightLevel    uint16
+	MaxPicAverageLightLevel uint16
+}
+
+// contentLightLevelInfoSize - payload size in bytes
+const contentLightLevelInfoSize = 4
+
+// ParseContentLightLevelInfo - decode a content_light_level_info() payload or 'clli' box body
+func ParseContentLightLevelInfo(payload []byte) (*ContentLightLevelInfo, error) {
+	if len(payload) < contentLightLevelInfoSize {
+		return nil, fmt.Errorf("content_light_level_info payload is %d bytes, need %d", len(payload), contentLightLevelInfoSize)
+	}
+	return &ContentLightLevelInfo{
+		MaxContentLightLevel:    binary.BigEndian.Uint16(payload),
+		MaxPicAverageLightLevel: binary.BigEndian.Uint16(payload[2:]),
+	}, nil
+}
+
+// Bytes - the payload, also the body of a 'clli' box
+func (c *ContentLightLevelInfo) Bytes() []byte {
+	payload := make([]byte, contentLightLevelInfoSize)
+	binary.BigEndian.PutUint16(payload, c.MaxContentLightLevel)
+	binary.BigEndian.PutUint16(payload[2:], c.MaxPicAverageLightLevel)
+	return payload
+}
+
+// Message - wrap the payload into an SEI message
+func (c *ContentLightLevelInfo) Message() Message {
+	return Message{
+		PayloadType: SEI_CONTENT_LIGHT_LEVEL_INFO,
+		Payload:     c.Bytes(),
+	}
+}
+
+// String - the values in the format of the x265 max-cll option, e.g. 1000,400
+func (c *ContentLightLevelInfo) String() string {
+	return fmt.Sprintf("%d,%d", c.MaxContentLightLevel, c.MaxPicAverageLightLevel)
+}
+
+// FindContentLightLevelInfo - the first content_light_level_info message among msgs
+func FindContentLightLevelInfo(msgs []Message) (*ContentLightLevelInfo, bool) {
+	for _, msg := range msgs {
+		if msg.PayloadType != SEI_CONTENT_LIGHT_LEVEL_INFO {
+			continue
+		}
+		if c, err := ParseContentLightLevelInfo(msg.Payload); err == nil {
+			return c, true
+		}
+	}
+	return nil, false
+}
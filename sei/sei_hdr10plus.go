@@ -0,0 +1,300 @@
+package sei
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/go-webdl/bits"
+)
+
+// HDR10PlusT35Prefix - itu_t_t35_country_code (United States),
+// itu_t_t35_terminal_provider_code (Samsung),
+// itu_t_t35_terminal_provider_oriented_code and application_identifier
+// starting SMPTE ST 2094-40 dynamic metadata
+var HDR10PlusT35Prefix = []byte{0xB5, 0x00, 0x3C, 0x00, 0x01, 0x04}
+
+// IsHDR10Plus - does the message carry HDR10+ (SMPTE ST 2094-40) dynamic metadata
+func (m *Message) IsHDR10Plus() bool {
+	return m.PayloadType == SEI_USER_DATA_REGISTERED_ITU_T_T35 && bytes.HasPrefix(m.Payload, HDR10PlusT35Prefix)
+}
+
+// HDR10PlusMetadata - SMPTE ST 2094-40 dynamic metadata of a frame, as
+// carried in the HDR10+ user data of ANSI/CTA-861-G Annex S
+//
+// The targeted system display maximum luminance is in cd/m², maxscl,
+// average_maxrgb and the maxRGB percentiles in units of 0.00001 of the
+// normalized linear range, the peak luminance matrices in units of 1/15.
+type HDR10PlusMetadata struct {
+	ApplicationVersion byte
+	// Windows - processing windows, 1 to 3; the first one is the whole
+	// frame and has no geometry
+	Windows []HDR10PlusWindow
+	// TargetedSystemDisplayMaximumLuminance - 27 bits
+	TargetedSystemDisplayMaximumLuminance uint32
+	// TargetedSystemDisplayActualPeakLuminance - rows of up to 25 columns,
+	// nil when not present
+	TargetedSystemDisplayActualPeakLuminance [][]byte
+	// MasteringDisplayActualPeakLuminance - rows of up to 25 columns, nil
+	// when not present
+	MasteringDisplayActualPeakLuminance [][]byte
+}
+
+// HDR10PlusWindow - processing window parameters
+type HDR10PlusWindow struct {
+	// Geometry, ignored for the first window
+	UpperLeftCornerX             uint16
+	UpperLeftCornerY             uint16
+	LowerRightCornerX            uint16
+	LowerRightCornerY            uint16
+	CenterOfEllipseX             uint16
+	CenterOfEllipseY             uint16
+	RotationAngle                byte
+	SemimajorAxisInternalEllipse uint16
+	SemimajorAxisExternalEllipse uint16
+	SemiminorAxisExternalEllipse uint16
+	OverlapProcessOption         bool
+
+	// MaxSCL - maximum of each of the red, green and blue components, 17 bits
+	MaxSCL [3]uint32
+	// AverageMaxRGB - 17 bits
+	AverageMaxRGB uint32
+	// DistributionMaxRGB - up to 15 percentiles of maxRGB
+	DistributionMaxRGB []HDR10PlusPercentile
+	// FractionBrightPixels - 10 bits
+	FractionBrightPixels uint16
+
+	ToneMappingFlag bool
+	// KneePointX, KneePointY - 12 bits, only with ToneMappingFlag
+	KneePointX uint16
+	KneePointY uint16
+	// BezierCurveAnchors - up to 15 anchors of 10 bits, only with ToneMappingFlag
+	BezierCurveAnchors []uint16
+
+	ColorSaturationMappingFlag bool
+	// ColorSaturationWeight - 6 bits, only with ColorSaturationMappingFlag
+	ColorSaturationWeight byte
+}
+
+// HDR10PlusPercentile - distribution_maxrgb_percentages and distribution_maxrgb_percentiles entry
+type HDR10PlusPercentile struct {
+	// Percentage - 7 bits
+	Percentage byte
+	// Percentile - 17 bits
+	Percentile uint32
+}
+
+// ParseHDR10Plus - decode the HDR10+ metadata of a message, returning false
+// if the message does not carry HDR10+
+func ParseHDR10Plus(m *Message) (*HDR10PlusMetadata, bool, error) {
+	if !m.IsHDR10Plus() {
+		return nil, false, nil
+	}
+	data := m.Payload[len(HDR10PlusT35Prefix):]
+	if len(data) < 1 {
+		return nil, true, fmt.Errorf("HDR10+ metadata lacks application_version")
+	}
+	h := &HDR10PlusMetadata{ApplicationVersion: data[0]}
+	r := bits.NewAccErrReader(bytes.NewReader(data[1:]))
+	numWindows := int(r.Read(2))
+	if err := r.AccError(); err != nil {
+		return nil, true, fmt.Errorf("HDR10+ metadata: %w", err)
+	}
+	if numWindows == 0 {
+		return nil, true, fmt.Errorf("HDR10+ metadata without processing windows")
+	}
+	h.Windows = make([]HDR10PlusWindow, numWindows)
+	for w := 1; w < numWindows; w++ {
+		win := &h.Windows[w]
+		win.UpperLeftCornerX = uint16(r.Read(16))
+		win.UpperLeftCornerY = uint16(r.Read(16))
+		win.LowerRightCornerX = uint16(r.Read(16))
+		win.LowerRightCornerY = uint16(r.Read(16))
+		win.CenterOfEllipseX = uint16(r.Read(16))
+		win.CenterOfEllipseY = uint16(r.Read(16))
+		win.RotationAngle = byte(r.Read(8))
+		win.SemimajorAxisInternalEllipse = uint16(r.Read(16))
+		win.SemimajorAxisExternalEllipse = uint16(r.Read(16))
+		win.SemiminorAxisExternalEllipse = uint16(r.Read(16))
+		win.OverlapProcessOption = r.ReadFlag()
+	}
+	h.TargetedSystemDisplayMaximumLuminance = uint32(r.Read(27))
+	if r.ReadFlag() {
+		h.TargetedSystemDisplayActualPeakLuminance = readHDR10PlusPeakLuminance(r)
+	}
+	for w := range h.Windows {
+		win := &h.Windows[w]
+		for c := range win.MaxSCL {
+			win.MaxSCL[c] = uint32(r.Read(17))
+		}
+		win.AverageMaxRGB = uint32(r.Read(17))
+		numPercentiles := int(r.Read(4))
+		for i := 0; i < numPercentiles; i++ {
+			win.DistributionMaxRGB = append(win.DistributionMaxRGB, HDR10PlusPercentile{
+				Percentage: byte(r.Read(7)),
+				Percentile: uint32(r.Read(17)),
+			})
+		}
+		win.FractionBrightPixels = uint16(r.Read(10))
+	}
+	if r.ReadFlag() {
+		h.MasteringDisplayActualPeakLuminance = readHDR10PlusPeakLuminance(r)
+	}
+	for w := range h.Windows {
+		win := &h.Windows[w]
+		win.ToneMappingFlag = r.ReadFlag()
+		if win.ToneMappingFlag {
+			win.KneePointX = uint16(r.Read(12))
+			win.KneePointY = uint16(r.Read(12))
+			numAnchors := int(r.Read(4))
+			for i := 0; i < numAnchors; i++ {
+				win.BezierCurveAnchors = append(win.BezierCurveAnchors, uint16(r.Read(10)))
+			}
+		}
+		win.ColorSaturationMappingFlag = r.ReadFlag()
+		if win.ColorSaturationMappingFlag {
+			win.ColorSaturationWeight = byte(r.Read(6))
+		}
+	}
+	if err := r.AccError(); err != nil {
+		return nil, true, fmt.Errorf("HDR10+ metadata: %w", err)
+	}
+	return h, true, nil
+}
+
+// readHDR10PlusPeakLuminance - read a num_rows, num_cols actual peak luminance matrix
+func readHDR10PlusPeakLuminance(r *bits.AccErrReader) [][]byte {
+	numRows := int(r.Read(5))
+	numCols := int(r.Read(5))
+	rows := make([][]byte, numRows)
+	for i := range rows {
+		rows[i] = make([]byte, numCols)
+		for j := range rows[i] {
+			rows[i][j] = byte(r.Read(4))
+		}
+	}
+	return rows
+}
+
+// Message - wrap the metadata into a user_data_registered_itu_t_t35 SEI message
+// Windows must hold 1 to 3 entries, DistributionMaxRGB and
+// BezierCurveAnchors at most 15 and the peak luminance matrices at most 31
+// rows of equal length up to 31; excess entries are dropped.
+func (h *HDR10PlusMetadata) Message() Message {
+	var buf bytes.Buffer
+	buf.Write(HDR10PlusT35Prefix)
+	buf.WriteByte(h.ApplicationVersion)
+	w := bits.NewWriter(&buf)
+	windows := h.Windows
+	if len(windows) > 3 {
+		windows = windows[:3]
+	}
+	w.Write(uint(len(windows)), 2)
+	for i := 1; i < len(windows); i++ {
+		win := &windows[i]
+		w.Write(uint(win.UpperLeftCornerX), 16)
+		w.Write(uint(win.UpperLeftCornerY), 16)
+		w.Write(uint(win.LowerRightCornerX), 16)
+		w.Write(uint(win.LowerRightCornerY), 16)
+		w.Write(uint(win.CenterOfEllipseX), 16)
+		w.Write(uint(win.CenterOfEllipseY), 16)
+		w.Write(uint(win.RotationAngle), 8)
+		w.Write(uint(win.SemimajorAxisInternalEllipse), 16)
+		w.Write(uint(win.SemimajorAxisExternalEllipse), 16)
+		w.Write(uint(win.SemiminorAxisExternalEllipse), 16)
+		writeFlag(w, win.OverlapProcessOption)
+	}
+	w.Write(uint(h.TargetedSystemDisplayMaximumLuminance), 27)
+	writeHDR10PlusPeakLuminance(w, h.TargetedSystemDisplayActualPeakLuminance)
+	for i := range windows {
+		win := &windows[i]
+		for _, maxSCL := range win.MaxSCL {
+			w.Write(uint(maxSCL), 17)
+		}
+		w.Write(uint(win.AverageMaxRGB), 17)
+		percentiles := win.DistributionMaxRGB
+		if len(percentiles) > 15 {
+			percentiles = percentiles[:15]
+		}
+		w.Write(uint(len(percentiles)), 4)
+		for _, p := range percentiles {
+			w.Write(uint(p.Percentage), 7)
+			w.Write(uint(p.Percentile), 17)
+		}
+		w.Write(uint(win.FractionBrightPixels), 10)
+	}
+	writeHDR10PlusPeakLuminance(w, h.MasteringDisplayActualPeakLuminance)
+	for i := range windows {
+		win := &windows[i]
+		writeFlag(w, win.ToneMappingFlag)
+		if win.ToneMappingFlag {
+			w.Write(uint(win.KneePointX), 12)
+			w.Write(uint(win.KneePointY), 12)
+			anchors := win.BezierCurveAnchors
+			if len(anchors) > 15 {
+				anchors = anchors[:15]
+			}
+			w.Write(uint(len(anchors)), 4)
+			for _, anchor := range anchors {
+				w.Write(uint(anchor), 10)
+			}
+		}
+		writeFlag(w, win.ColorSaturationMappingFlag)
+		if win.ColorSaturationMappingFlag {
+			w.Write(uint(win.ColorSaturationWeight), 6)
+		}
+	}
+	w.Flush()
+	return Message{
+		PayloadType: SEI_USER_DATA_REGISTERED_ITU_T_T35,
+		Payload:     buf.Bytes(),
+	}
+}
+
+// writeHDR10PlusPeakLuminance - write the present flag and, for a non-nil matrix, the matrix
+func writeHDR10PlusPeakLuminance(w *bits.Writer, rows [][]byte) {
+	writeFlag(w, rows != nil)
+	if rows == nil {
+		return
+	}
+	if len(rows) > 31 {
+		rows = rows[:31]
+	}
+	numCols := 0
+	if len(rows) > 0 {
+		numCols = len(rows[0])
+	}
+	if numCols > 31 {
+		numCols = 31
+	}
+	w.Write(uint(len(rows)), 5)
+	w.Write(uint(numCols), 5)
+	for _, row := range rows {
+		for j := 0; j < numCols; j++ {
+			v := byte(0)
+			if j < len(row) {
+				v = row[j]
+			}
+			w.Write(uint(v), 4)
+		}
+	}
+}
+
+// writeFlag - write a single bit
+func writeFlag(w *bits.Writer, flag bool) {
+	if flag {
+		w.Write(1, 1)
+	} else {
+		w.Write(0, 1)
+	}
+}
+
+// FindHDR10Plus - the first HDR10+ metadata among msgs
+// ok is true if a message carries HDR10+, err reports it malformed.
+func FindHDR10Plus(msgs []Message) (h *HDR10PlusMetadata, ok bool, err error) {
+	for i := range msgs {
+		if h, ok, err = ParseHDR10Plus(&msgs[i]); ok {
+			return h, ok, err
+		}
+	}
+	return nil, false, nil
+}
@@ -0,0 +1,109 @@
+package sei
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// MasteringDisplayColourVolume - mastering_display_colour_volume() SEI payload
+//
+// SMPTE ST 2086 metadata of HDR10, describing the display the content was
+// graded on. The payload has the same layout as the 'mdcv' box of
+// ISO/IEC 14496-12, so Bytes can be used as its body. Chromaticity
+// coordinates are in units of 0.00002, luminances in units of 0.0001 cd/m².
+type MasteringDisplayColourVolume struct {
+	// DisplayPrimariesX, DisplayPrimariesY - primaries, by convention in
+	// the order green, blue, red
+	DisplayPrimariesX            [3]uint16
+	DisplayPrimariesY            [3]uint16
+	WhitePointX                  uint16
+	WhitePointY                  uint16
+	MaxDisplayMasteringLuminance uint32
+	MinDisplayMasteringLuminance uint32
+}
+
+// masteringDisplayColourVolumeSize - payload size in bytes
+const masteringDisplayColourVolumeSize = 24
+
+// ParseMasteringDisplayColourVolume - decode a mastering_display_colour_volume() payload or 'mdcv' box body
+func ParseMasteringDisplayColourVolume(payload []byte) (*MasteringDisplayColourVolume, error) {
+	if len(payload) < masteringDisplayColourVolumeSize {
+		return nil, fmt.Errorf("mastering_display_colour_volume payload is %d bytes, need %d", len(payload), masteringDisplayColourVolumeSize)
+	}
+	m := &MasteringDisplayColourVolume{}
+	for c := 0; c < 3; c++ {
+		m.DisplayPrimariesX[c] = binary.BigEndian.Uint16(payload[4*c:])
+		m.DisplayPrimariesY[c] = binary.BigEndian.Uint16(payload[4*c+2:])
+	}
+	m.WhitePointX = binary.BigEndian.Uint16(payload[12:])
+	m.WhitePointY = binary.BigEndian.Uint16(payload[14:])
+	m.MaxDisplayMasteringLuminance = binary.BigEndian.Uint32(payload[16:])
+	m.MinDisplayMasteringLuminance = binary.BigEndian.Uint32(payload[20:])
+	return m, nil
+}
+
+// Bytes - the payload, also the body of an 'mdcv' box
+func (m *MasteringDisplayColourVolume) Bytes() []byte {
+	payload := make([]byte, masteringDisplayColourVolumeSize)
+	for c := 0; c < 3; c++ {
+		binary.BigEndian.PutUint16(payload[4*c:], m.DisplayPrimariesX[c])
+		binary.BigEndian.PutUint16(payload[4*c+2:], m.DisplayPrimariesY[c])
+	}
+	binary.BigEndian.PutUint16(payload[12:], m.WhitePointX)
+	binary.BigEndian.PutUint16(payload[14:], m.WhitePointY)
+	binary.BigEndian.PutUint32(payload[16:], m.MaxDisplayMasteringLuminance)
+	binary.BigEndian.PutUint32(payload[20:], m.MinDisplayMasteringLuminance)
+	return payload
+}
+
+// Message - wrap the payload into an SEI message
+func (m *MasteringDisplayColourVolume) Message() Message {
+	return Message{
+		PayloadType: SEI_MASTERING_DISPLAY_COLOUR_VOLUME,
+		Payload:     m.Bytes(),
+	}
+}
+
+// Primary - CIE 1931 xy chromaticity of primary c, 0 green, 1 blue, 2 red
+func (m *MasteringDisplayColourVolume) Primary(c int) (x, y float64) {
+	return float64(m.DisplayPrimariesX[c]) * 0.00002, float64(m.DisplayPrimariesY[c]) * 0.00002
+}
+
+// WhitePoint - CIE 1931 xy chromaticity of the white point
+func (m *MasteringDisplayColourVolume) WhitePoint() (x, y float64) {
+	return float64(m.WhitePointX) * 0.00002, float64(m.WhitePointY) * 0.00002
+}
+
+// MaxLuminance - maximum display mastering luminance in cd/m²
+func (m *MasteringDisplayColourVolume) MaxLuminance() float64 {
+	return float64(m.MaxDisplayMasteringLuminance) * 0.0001
+}
+
+// MinLuminance - minimum display mastering luminance in cd/m²
+func (m *MasteringDisplayColourVolume) MinLuminance() float64 {
+	return float64(m.MinDisplayMasteringLuminance) * 0.0001
+}
+
+// String - the values in the format of the x265 master-display option,
+// e.g. G(13250,34500)B(7500,3000)R(34000,16000)WP(15635,16450)L(10000000,1)
+func (m *MasteringDisplayColourVolume) String() string {
+	return fmt.Sprintf("G(%d,%d)B(%d,%d)R(%d,%d)WP(%d,%d)L(%d,%d)",
+		m.DisplayPrimariesX[0], m.DisplayPrimariesY[0],
+		m.DisplayPrimariesX[1], m.DisplayPrimariesY[1],
+		m.DisplayPrimariesX[2], m.DisplayPrimariesY[2],
+		m.WhitePointX, m.WhitePointY,
+		m.MaxDisplayMasteringLuminance, m.MinDisplayMasteringLuminance)
+}
+
+// FindMasteringDisplayColourVolume - the first mastering_display_colour_volume message among msgs
+func FindMasteringDisplayColourVolume(msgs []Message) (*MasteringDisplayColourVolume, bool) {
+	for _, msg := range msgs {
+		if msg.PayloadType != SEI_MASTERING_DISPLAY_COLOUR_VOLUME {
+			continue
+		}
+		if m, err := ParseMasteringDisplayColourVolume(msg.Payload); err == nil {
+			return m, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,202 @@
+package sei
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/go-webdl/media-codec/debuglog"
+	"github.com/go-webdl/media-codec/nalu"
+)
+
+// PayloadType - SEI payloadType as defined in ISO/IEC 14496-10 Annex D and
+// ISO/IEC 23008-2 Annex D
+type PayloadType uint
+
+const (
+	SEI_BUFFERING_PERIOD                     = PayloadType(0)
+	SEI_PIC_TIMING                           = PayloadType(1)
+	SEI_PAN_SCAN_RECT                        = PayloadType(2)
+	SEI_FILLER_PAYLOAD                       = PayloadType(3)
+	SEI_USER_DATA_REGISTERED_ITU_T_T35       = PayloadType(4)
+	SEI_USER_DATA_UNREGISTERED               = PayloadType(5)
+	SEI_RECOVERY_POINT                       = PayloadType(6)
+	SEI_SCENE_INFO                           = PayloadType(9)
+	SEI_FULL_FRAME_FREEZE                    = PayloadType(13)
+	SEI_FRAME_PACKING_ARRANGEMENT            = PayloadType(45)
+	SEI_DISPLAY_ORIENTATION                  = PayloadType(47)
+	SEI_ACTIVE_PARAMETER_SETS                = PayloadType(129)
+	SEI_DECODING_UNIT_INFO                   = PayloadType(130)
+	SEI_DECODED_PICTURE_HASH                 = PayloadType(132)
+	SEI_TIME_CODE                            = PayloadType(136)
+	SEI_MASTERING_DISPLAY_COLOUR_VOLUME      = PayloadType(137)
+	SEI_CONTENT_LIGHT_LEVEL_INFO             = PayloadType(144)
+	SEI_ALTERNATIVE_TRANSFER_CHARACTERISTICS = PayloadType(147)
+	SEI_AMBIENT_VIEWING_ENVIRONMENT          = PayloadType(148)
+	SEI_CONTENT_COLOUR_VOLUME                = PayloadType(149)
+	SEI_ALPHA_CHANNEL_INFO                   = PayloadType(165)
+)
+
+func (t PayloadType) String() string {
+	switch t {
+	case SEI_BUFFERING_PERIOD:
+		return "BufferingPeriod_0"
+	case SEI_PIC_TIMING:
+		return "PicTiming_1"
+	case SEI_PAN_SCAN_RECT:
+		return "PanScanRect_2"
+	case SEI_FILLER_PAYLOAD:
+		return "FillerPayload_3"
+	case SEI_USER_DATA_REGISTERED_ITU_T_T35:
+		return "UserDataRegisteredITUTT35_4"
+	case SEI_USER_DATA_UNREGISTERED:
+		return "UserDataUnregistered_5"
+	case SEI_RECOVERY_POINT:
+		return "RecoveryPoint_6"
+	case SEI_SCENE_INFO:
+		return "SceneInfo_9"
+	case SEI_FULL_FRAME_FREEZE:
+		return "FullFrameFreeze_13"
+	case SEI_FRAME_PACKING_ARRANGEMENT:
+		return "FramePackingArrangement_45"
+	case SEI_DISPLAY_ORIENTATION:
+		return "DisplayOrientation_47"
+	case SEI_ACTIVE_PARAMETER_SETS:
+		return "ActiveParameterSets_129"
+	case SEI_DECODING_UNIT_INFO:
+		return "DecodingUnitInfo_130"
+	case SEI_DECODED_PICTURE_HASH:
+		return "DecodedPictureHash_132"
+	case SEI_TIME_CODE:
+		return "TimeCode_136"
+	case SEI_MASTERING_DISPLAY_COLOUR_VOLUME:
+		return "MasteringDisplayColourVolume_137"
+	case SEI_CONTENT_LIGHT_LEVEL_INFO:
+		return "ContentLightLevelInfo_144"
+	case SEI_ALTERNATIVE_TRANSFER_CHARACTERISTICS:
+		return "AlternativeTransferCharacteristics_147"
+	case SEI_AMBIENT_VIEWING_ENVIRONMENT:
+		return "AmbientViewingEnvironment_148"
+	case SEI_CONTENT_COLOUR_VOLUME:
+		return "ContentColourVolume_149"
+	case SEI_ALPHA_CHANNEL_INFO:
+		return "AlphaChannelInfo_165"
+	default:
+		return fmt.Sprintf("Other_%d", t)
+	}
+}
+
+// Message - a single sei_message() with its raw (RBSP) payload
+type Message struct {
+	PayloadType PayloadType
+	Payload     []byte
+}
+
+// Size - number of bytes the message occupies in a sei_rbsp()
+func (m *Message) Size() (size uint32) {
+	// payloadType and payloadSize are coded as a run of 0xFF bytes followed
+	// by a last byte in the range 0..254.
+	size += uint32(m.PayloadType)/255 + 1
+	size += uint32(len(m.Payload))/255 + 1
+	size += uint32(len(m.Payload))
+	return
+}
+
+// Write - write sei_message() to w
+func (m *Message) Write(w io.Writer) (err error) {
+	if err = writeVariableLength(w, uint(m.PayloadType)); err != nil {
+		return
+	}
+	if err = writeVariableLength(w, uint(len(m.Payload))); err != nil {
+		return
+	}
+	_, err = w.Write(m.Payload)
+	return
+}
+
+func writeVariableLength(w io.Writer, v uint) (err error) {
+	for ; v >= 255; v -= 255 {
+		if err = binary.Write(w, binary.BigEndian, uint8(0xFF)); err != nil {
+			return
+		}
+	}
+	return binary.Write(w, binary.BigEndian, uint8(v))
+}
+
+// ParseMessages - split a sei_rbsp() (NAL unit payload after the NAL unit
+// header, with emulation prevention bytes removed) into its messages
+func ParseMessages(rbsp []byte) (msgs []Message, err error) {
+	pos := 0
+	for pos < len(rbsp) && !isTrailingBits(rbsp[pos:]) {
+		var payloadType, payloadSize uint
+		if payloadType, pos, err = readVariableLength(rbsp, pos); err != nil {
+			return
+		}
+		if payloadSize, pos, err = readVariableLength(rbsp, pos); err != nil {
+			return
+		}
+		if uint(len(rbsp)-pos) < payloadSize {
+			return msgs, fmt.Errorf("SEI payload %s size %d exceeds remaining %d bytes", PayloadType(payloadType), payloadSize, len(rbsp)-pos)
+		}
+		msgs = append(msgs, Message{
+			PayloadType: PayloadType(payloadType),
+			Payload:     rbsp[pos : pos+int(payloadSize)],
+		})
+		pos += int(payloadSize)
+	}
+	if pos >= len(rbsp) {
+		debuglog.Debug("SEI: rbsp_trailing_bits missing", "messages", len(msgs))
+	}
+	return
+}
+
+func readVariableLength(data []byte, pos int) (v uint, next int, err error) {
+	for {
+		if pos >= len(data) {
+			return 0, pos, io.ErrUnexpectedEOF
+		}
+		b := data[pos]
+		pos++
+		v += uint(b)
+		if b != 0xFF {
+			return v, pos, nil
+		}
+	}
+}
+
+// isTrailingBits - true if data consists of rbsp_trailing_bits() only,
+// optionally followed by cabac_zero_words
+func isTrailingBits(data []byte) bool {
+	if data[0] != 0x80 {
+		return false
+	}
+	for _, b := range data[1:] {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteRBSP - write msgs as a sei_rbsp() including rbsp_trailing_bits()
+func WriteRBSP(w io.Writer, msgs []Message) (err error) {
+	for i := range msgs {
+		if err = msgs[i].Write(w); err != nil {
+			return
+		}
+	}
+	return binary.Write(w, binary.BigEndian, uint8(0x80))
+}
+
+// CreateNALUnit - build a complete SEI NAL unit from the codec specific NAL
+// unit header and msgs, inserting emulation prevention bytes as needed
+func CreateNALUnit(naluHeader []byte, msgs []Message) ([]byte, error) {
+	var rbsp bytes.Buffer
+	if err := WriteRBSP(&rbsp, msgs); err != nil {
+		return nil, err
+	}
+	naluData := make([]byte, 0, len(naluHeader)+rbsp.Len()+rbsp.Len()/2)
+	naluData = append(naluData, naluHeader...)
+	return nalu.AppendEscapedRBSP(naluData, rbsp.Bytes()), nil
+}
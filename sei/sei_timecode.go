@@ -0,0 +1,54 @@
+package sei
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// COUNTING_TYPE_DROP_FRAME - counting_type dropping n_frames 0 and 1 at the
+// start of each minute except every tenth, as for NTSC drop-frame time code
+const COUNTING_TYPE_DROP_FRAME = byte(4)
+
+// Timecode - SMPTE ST 12-1 style time code of a picture, as carried in the
+// clock timestamps of the H.264 picture timing and H.265 time code SEI messages
+type Timecode struct {
+	Hours     byte
+	Minutes   byte
+	Seconds   byte
+	Frames    uint16
+	DropFrame bool
+}
+
+// String - HH:MM:SS:FF, with a semicolon before the frames for drop-frame time code
+func (t Timecode) String() string {
+	sep := ':'
+	if t.DropFrame {
+		sep = ';'
+	}
+	return fmt.Sprintf("%02d:%02d:%02d%c%02d", t.Hours, t.Minutes, t.Seconds, sep, t.Frames)
+}
+
+// ParseTimecode - parse HH:MM:SS:FF, or HH:MM:SS;FF for drop-frame time code
+func ParseTimecode(s string) (Timecode, error) {
+	var t Timecode
+	if i := strings.LastIndexAny(s, ":;."); i >= 0 && s[i] != ':' {
+		t.DropFrame = true
+		s = s[:i] + ":" + s[i+1:]
+	}
+	parts := strings.Split(s, ":")
+	if len(parts) != 4 {
+		return t, fmt.Errorf("time code %q is not HH:MM:SS:FF", s)
+	}
+	var v [4]uint64
+	limits := [4]uint64{31, 59, 59, 511}
+	for i, part := range parts {
+		n, err := strconv.ParseUint(part, 10, 16)
+		if err != nil || n > limits[i] {
+			return t, fmt.Errorf("time code %q: field %q out of range", s, part)
+		}
+		v[i] = n
+	}
+	t.Hours, t.Minutes, t.Seconds, t.Frames = byte(v[0]), byte(v[1]), byte(v[2]), uint16(v[3])
+	return t, nil
+}
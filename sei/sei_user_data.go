@@ -0,0 +1,181 @@
+package sei
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// UserDataUnregistered - user_data_unregistered() SEI payload
+//
+// This is the SEI commonly used to carry declarative, stream wide information
+// such as the encoder name and its settings, or provenance data stamped by a
+// packaging pipeline.
+type UserDataUnregistered struct {
+	// UUID identifying the semantics of the payload, as specified in ISO/IEC
+	// 11578.
+	UUID [16]byte
+
+	// user_data_payload_byte
+	Payload []byte
+}
+
+var (
+	// UUID used by x264 for its version and options string
+	X264UUID = [16]byte{0xdc, 0x45, 0xe9, 0xbd, 0xe6, 0xd9, 0x48, 0xb7, 0x96, 0x2c, 0xd8, 0x20, 0xd9, 0x23, 0xee, 0xef}
+	// UUID used by x265 for its version and options string
+	X265UUID = [16]byte{0x2c, 0xa2, 0xde, 0x09, 0xb5, 0x17, 0x47, 0xdb, 0xbb, 0x55, 0xa4, 0xfe, 0x7f, 0xc2, 0xfc, 0x4e}
+)
+
+// ParseUserDataUnregistered - decode a user_data_unregistered() payload
+func ParseUserDataUnregistered(payload []byte) (*UserDataUnregistered, error) {
+	if len(payload) < 16 {
+		return nil, fmt.Errorf("user_data_unregistered payload is %d bytes, shorter than its UUID", len(payload))
+	}
+	u := &UserDataUnregistered{Payload: payload[16:]}
+	copy(u.UUID[:], payload[:16])
+	return u, nil
+}
+
+// Message - wrap the payload into an SEI message
+func (u *UserDataUnregistered) Message() Message {
+	payload := make([]byte, 0, 16+len(u.Payload))
+	payload = append(payload, u.UUID[:]...)
+	payload = append(payload, u.Payload...)
+	return Message{
+		PayloadType: SEI_USER_DATA_UNREGISTERED,
+		Payload:     payload,
+	}
+}
+
+// EncoderSettings - version and options string written by x264 and x265 into
+// a user_data_unregistered SEI
+type EncoderSettings struct {
+	// Encoder name, e.g. "x264" or "x265"
+	Encoder string
+	// Version information, e.g. "core 164 r3095 baf4e23" or "3.5+1-f0c1022b6"
+	Version string
+	// Options as written after "options:". Bare x265 style flags are mapped
+	// to "1", and their "no-" prefixed form to "0".
+	Options map[string]string
+}
+
+// ParseEncoderSettings - parse the x264/x265 info string carried in a
+// user_data_unregistered payload, returning false if the payload is not one
+func ParseEncoderSettings(u *UserDataUnregistered) (*EncoderSettings, bool) {
+	info := string(bytes.TrimRight(u.Payload, "\x00"))
+	if !strings.HasPrefix(info, "x264 ") && !strings.HasPrefix(info, "x265 ") {
+		return nil, false
+	}
+	s := &EncoderSettings{
+		Encoder: info[:4],
+		Options: make(map[string]string),
+	}
+	options := ""
+	if i := strings.Index(info, " - options:"); i >= 0 {
+		options = info[i+len(" - options:"):]
+		info = info[:i]
+	}
+	if fields := strings.Split(info, " - "); len(fields) > 1 {
+		s.Version = fields[1]
+		// x265 appends build information, e.g. ":[Linux][GCC 9.3.0][64 bit] 8bit"
+		if i := strings.IndexByte(s.Version, ':'); i >= 0 && s.Encoder == "x265" {
+			s.Version = s.Version[:i]
+		}
+	}
+	for _, opt := range strings.Fields(options) {
+		if i := strings.IndexByte(opt, '='); i >= 0 {
+			s.Options[opt[:i]] = opt[i+1:]
+		} else if strings.HasPrefix(opt, "no-") {
+			s.Options[opt[3:]] = "0"
+		} else {
+			s.Options[opt] = "1"
+		}
+	}
+	return s, true
+}
+
+// UserDataRegisteredT35 - user_data_registered_itu_t_t35() SEI payload
+type UserDataRegisteredT35 struct {
+	// itu_t_t35_country_code
+	CountryCode byte
+	// itu_t_t35_country_code_extension_byte, only present when CountryCode is 0xFF
+	CountryCodeExtension byte
+	// itu_t_t35_payload_byte, starting with the terminal provider code
+	Payload []byte
+}
+
+// ParseUserDataRegisteredT35 - decode a user_data_registered_itu_t_t35() payload
+func ParseUserDataRegisteredT35(payload []byte) (*UserDataRegisteredT35, error) {
+	if len(payload) < 1 {
+		return nil, fmt.Errorf("user_data_registered_itu_t_t35 payload is empty")
+	}
+	u := &UserDataRegisteredT35{CountryCode: payload[0], Payload: payload[1:]}
+	if u.CountryCode == 0xFF {
+		if len(payload) < 2 {
+			return nil, fmt.Errorf("user_data_registered_itu_t_t35 payload lacks country code extension")
+		}
+		u.CountryCodeExtension = payload[1]
+		u.Payload = payload[2:]
+	}
+	return u, nil
+}
+
+// Message - wrap the payload into an SEI message
+func (u *UserDataRegisteredT35) Message() Message {
+	payload := make([]byte, 0, 2+len(u.Payload))
+	payload = append(payload, u.CountryCode)
+	if u.CountryCode == 0xFF {
+		payload = append(payload, u.CountryCodeExtension)
+	}
+	payload = append(payload, u.Payload...)
+	return Message{
+		PayloadType: SEI_USER_DATA_REGISTERED_ITU_T_T35,
+		Payload:     payload,
+	}
+}
+
+// Int - integer value of an option, false if absent or not an integer
+func (s *EncoderSettings) Int(name string) (int, bool) {
+	v, ok := s.Options[name]
+	if !ok {
+		return 0, false
+	}
+	i, err := strconv.Atoi(v)
+	return i, err == nil
+}
+
+// Float - numeric value of an option such as crf, false if absent or not a number
+func (s *EncoderSettings) Float(name string) (float64, bool) {
+	v, ok := s.Options[name]
+	if !ok {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	return f, err == nil
+}
+
+// Bool - value of an on/off option, false if absent
+// x264 writes these as 0 and 1, x265 as bare and "no-" prefixed flags.
+func (s *EncoderSettings) Bool(name string) (value, ok bool) {
+	i, ok := s.Int(name)
+	return i != 0, ok
+}
+
+// FindEncoderSettings - encoder settings carried in any of msgs
+func FindEncoderSettings(msgs []Message) (*EncoderSettings, bool) {
+	for i := range msgs {
+		if msgs[i].PayloadType != SEI_USER_DATA_UNREGISTERED {
+			continue
+		}
+		u, err := ParseUserDataUnregistered(msgs[i].Payload)
+		if err != nil {
+			continue
+		}
+		if s, ok := ParseEncoderSettings(u); ok {
+			return s, true
+		}
+	}
+	return nil, false
+}
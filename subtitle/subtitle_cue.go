@@ -0,0 +1,51 @@
+package subtitle
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Cue - a timed piece of subtitle text, the common output of the subtitle
+// decoders of this module
+type Cue struct {
+	Start time.Duration
+	End   time.Duration
+	// Text lines separated by "\n"
+	Text string
+}
+
+// WriteSRT - write cues as a SubRip document
+func WriteSRT(w io.Writer, cues []Cue) (err error) {
+	for i, cue := range cues {
+		if _, err = fmt.Fprintf(w, "%d\n%s --> %s\n%s\n\n", i+1, formatTimestamp(cue.Start, ','), formatTimestamp(cue.End, ','), cue.Text); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// WriteWebVTT - write cues as a WebVTT document
+func WriteWebVTT(w io.Writer, cues []Cue) (err error) {
+	if _, err = io.WriteString(w, "WEBVTT\n\n"); err != nil {
+		return
+	}
+	for _, cue := range cues {
+		// "-->" is not allowed in cue text and a blank line would end the cue
+		text := strings.ReplaceAll(cue.Text, "-->", "--&gt;")
+		text = strings.ReplaceAll(text, "\n\n", "\n")
+		if _, err = fmt.Fprintf(w, "%s --> %s\n%s\n\n", formatTimestamp(cue.Start, '.'), formatTimestamp(cue.End, '.'), text); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func formatTimestamp(d time.Duration, fractionSeparator byte) string {
+	if d < 0 {
+		d = 0
+	}
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d%c%03d", ms/3600000, ms/60000%60, ms/1000%60, fractionSeparator, ms%1000)
+}
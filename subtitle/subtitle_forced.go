@@ -0,0 +1,282 @@
+package subtitle
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ForcedOptions - parameters of AnalyzeForced
+type ForcedOptions struct {
+	// Duration of the programme. Defaults to the end of the last cue.
+	Duration time.Duration
+	// Reference is the complete subtitle track of the same language, if
+	// available. Comparing against it is the strongest indicator: a forced
+	// track has few cues, and those coincide with the foreign-language cues
+	// of the reference.
+	Reference []Cue
+	// Cue density below which a track is considered sparse, defaults to 4
+	// cues per minute
+	MaxCuesPerMinute float64
+	// Fraction of the programme covered by cues below which a track is
+	// considered sparse, defaults to 0.15
+	MaxCoverage float64
+	// Gap between cues that separates two segments of foreign dialogue,
+	// defaults to 30 seconds
+	SegmentGap time.Duration
+}
+
+// ForcedAnalysis - indicators of a forced-narrative subtitle track, i.e. a
+// track only translating foreign-language dialogue and on-screen text
+type ForcedAnalysis struct {
+	Cues          int
+	CuesPerMinute float64
+	// Fraction of the programme duration covered by cues
+	Coverage float64
+	// Number of clusters of cues, each being a probable foreign-language
+	// segment of the programme
+	Segments   int
+	LongestGap time.Duration
+	// Cues that look like translated signs or captions: all upper case, or
+	// entirely bracketed or in italics
+	SignCues int
+	// Cues marking foreign-language dialogue, see isForeignCue
+	ForeignCues int
+	// Number of cues relative to Reference, or -1 without a reference
+	ReferenceRatio float64
+	// Fraction of cues overlapping a foreign-language cue of Reference, or
+	// -1 without foreign-language cues in the reference
+	ForeignOverlap float64
+	// Accumulated score of the indicators above
+	Score float64
+	// Probable is set when the score suggests a forced-narrative track
+	Probable bool
+}
+
+// AnalyzeForced - flag probable forced-narrative tracks from their cue
+// density, coverage and clustering, and from foreign-language cues in the
+// track and in Reference
+func AnalyzeForced(cues []Cue, opts ForcedOptions) (a ForcedAnalysis) {
+	if opts.MaxCuesPerMinute == 0 {
+		opts.MaxCuesPerMinute = 4
+	}
+	if opts.MaxCoverage == 0 {
+		opts.MaxCoverage = 0.15
+	}
+	if opts.SegmentGap == 0 {
+		opts.SegmentGap = 30 * time.Second
+	}
+	a.ReferenceRatio = -1
+	a.ForeignOverlap = -1
+	a.Cues = len(cues)
+	if len(cues) == 0 {
+		return
+	}
+	sorted := make([]Cue, len(cues))
+	copy(sorted, cues)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	duration := opts.Duration
+	if duration == 0 {
+		for _, cue := range sorted {
+			if cue.End > duration {
+				duration = cue.End
+			}
+		}
+	}
+	script := dominantScript(sorted)
+	var covered, prevEnd time.Duration
+	for i, cue := range sorted {
+		start := cue.Start
+		if start < prevEnd {
+			start = prevEnd
+		}
+		if cue.End > start {
+			covered += cue.End - start
+		}
+		if gap := cue.Start - prevEnd; i == 0 || gap >= opts.SegmentGap {
+			a.Segments++
+		}
+		if gap := cue.Start - prevEnd; gap > a.LongestGap {
+			a.LongestGap = gap
+		}
+		if cue.End > prevEnd {
+			prevEnd = cue.End
+		}
+		if isSignCue(cue.Text) {
+			a.SignCues++
+		}
+		if isForeignCue(cue.Text, script) {
+			a.ForeignCues++
+		}
+	}
+	if gap := duration - prevEnd; gap > a.LongestGap {
+		a.LongestGap = gap
+	}
+	if duration > 0 {
+		a.CuesPerMinute = float64(len(cues)) / duration.Minutes()
+		a.Coverage = float64(covered) / float64(duration)
+	}
+
+	if a.CuesPerMinute <= opts.MaxCuesPerMinute {
+		a.Score++
+	}
+	if a.Coverage <= opts.MaxCoverage {
+		a.Score++
+	}
+	if a.LongestGap >= 5*time.Minute {
+		a.Score += 0.5
+	}
+	if 2*a.SignCues >= len(cues) {
+		a.Score += 0.5
+	}
+	if 2*a.ForeignCues >= len(cues) {
+		a.Score += 0.5
+	}
+	if len(opts.Reference) > 0 {
+		a.ReferenceRatio = float64(len(cues)) / float64(len(opts.Reference))
+		if a.ReferenceRatio <= 0.25 {
+			a.Score += 2
+		} else {
+			a.Score -= 2
+		}
+		a.ForeignOverlap = foreignOverlap(sorted, opts.Reference)
+		if a.ForeignOverlap >= 0.5 {
+			a.Score++
+		}
+	}
+	a.Probable = a.Score >= 2
+	return
+}
+
+func isSignCue(text string) bool {
+	text = strings.TrimSpace(text)
+	if (strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]")) ||
+		(strings.HasPrefix(text, "<i>") && strings.HasSuffix(text, "</i>")) {
+		return true
+	}
+	letters := false
+	for _, r := range text {
+		if unicode.IsLower(r) {
+			return false
+		}
+		letters = letters || unicode.IsUpper(r)
+	}
+	return letters
+}
+
+// languageNames - languages recognised in annotations of foreign dialogue
+var languageNames = []string{
+	"Afrikaans", "Albanian", "Arabic", "Armenian", "Bengali", "Bulgarian",
+	"Cantonese", "Chinese", "Croatian", "Czech", "Danish", "Dutch", "English",
+	"Farsi", "Finnish", "French", "Gaelic", "German", "Greek", "Hebrew",
+	"Hindi", "Hungarian", "Icelandic", "Indonesian", "Italian", "Japanese",
+	"Klingon", "Korean", "Latin", "Mandarin", "Norwegian", "Persian",
+	"Polish", "Portuguese", "Punjabi", "Romanian", "Russian", "Serbian",
+	"Sign Language", "Spanish", "Swahili", "Swedish", "Tagalog", "Thai",
+	"Turkish", "Ukrainian", "Urdu", "Vietnamese", "Welsh", "Yiddish",
+}
+
+// foreignMarker - annotation of foreign dialogue such as "[in Spanish]" or
+// "(speaking French)"
+var foreignMarker = regexp.MustCompile(`(?i)[\[(][^\])]*\b(?:in|speaking|speaks|spoken)\s+(?:` +
+	strings.Join(languageNames, "|") + `)\b[^\])]*[\])]`)
+
+// markup - formatting tags of SRT and WebVTT text and ASS override blocks
+var markup = regexp.MustCompile(`<[^>]*>|\{[^}]*\}`)
+
+// scripts - writing systems told apart by cueScript. Chinese and Japanese
+// mix Han and kana, so they count as one.
+var scripts = []struct {
+	name   string
+	tables []*unicode.RangeTable
+}{
+	{"Latin", []*unicode.RangeTable{unicode.Latin}},
+	{"Cyrillic", []*unicode.RangeTable{unicode.Cyrillic}},
+	{"Greek", []*unicode.RangeTable{unicode.Greek}},
+	{"Arabic", []*unicode.RangeTable{unicode.Arabic}},
+	{"Hebrew", []*unicode.RangeTable{unicode.Hebrew}},
+	{"Devanagari", []*unicode.RangeTable{unicode.Devanagari}},
+	{"Thai", []*unicode.RangeTable{unicode.Thai}},
+	{"Hangul", []*unicode.RangeTable{unicode.Hangul}},
+	{"CJK", []*unicode.RangeTable{unicode.Han, unicode.Hiragana, unicode.Katakana}},
+}
+
+// cueScript - the script of most letters of text, "" if it has no letters
+// in a known script
+func cueScript(text string) string {
+	counts := make([]int, len(scripts))
+	best := -1
+	for _, r := range markup.ReplaceAllString(text, "") {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		for i := range scripts {
+			if unicode.IsOneOf(scripts[i].tables, r) {
+				counts[i]++
+				if best < 0 || counts[i] > counts[best] {
+					best = i
+				}
+				break
+			}
+		}
+	}
+	if best < 0 {
+		return ""
+	}
+	return scripts[best].name
+}
+
+// dominantScript - the script most cues are written in
+func dominantScript(cues []Cue) string {
+	counts := make(map[string]int)
+	best := ""
+	for _, cue := range cues {
+		script := cueScript(cue.Text)
+		if script == "" {
+			continue
+		}
+		counts[script]++
+		if counts[script] > counts[best] {
+			best = script
+		}
+	}
+	return best
+}
+
+// isForeignCue - does the cue mark foreign dialogue, either annotated as
+// such or transcribed in a script other than the one of the track
+func isForeignCue(text, trackScript string) bool {
+	if foreignMarker.MatchString(text) {
+		return true
+	}
+	script := cueScript(text)
+	return script != "" && trackScript != "" && script != trackScript
+}
+
+// foreignOverlap - fraction of cues overlapping a foreign-language cue of
+// reference, -1 if reference has none
+func foreignOverlap(cues, reference []Cue) float64 {
+	script := dominantScript(reference)
+	var foreign []Cue
+	for _, cue := range reference {
+		if isForeignCue(cue.Text, script) {
+			foreign = append(foreign, cue)
+		}
+	}
+	if len(foreign) == 0 {
+		return -1
+	}
+	overlapping := 0
+	for _, cue := range cues {
+		for _, f := range foreign {
+			if cue.Start < f.End && f.Start < cue.End {
+				overlapping++
+				break
+			}
+		}
+	}
+	return float64(overlapping) / float64(len(cues))
+}
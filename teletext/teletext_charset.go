@@ -0,0 +1,60 @@
+package teletext
+
+import (
+	"math/bits"
+	"strings"
+)
+
+// Positions of the Latin G0 set replaced by national option sub-sets
+var nationalPositions = [13]byte{0x23, 0x24, 0x40, 0x5B, 0x5C, 0x5D, 0x5E, 0x5F, 0x60, 0x7B, 0x7C, 0x7D, 0x7E}
+
+// National option sub-sets selected by C12-C14 with the default Western
+// European G0 designation, EN 300 706 Table 36
+var nationalSubsets = [8][13]rune{
+	// English
+	{'£', '$', '@', '←', '½', '→', '↑', '#', '—', '¼', '‖', '¾', '÷'},
+	// German
+	{'#', '$', '§', 'Ä', 'Ö', 'Ü', '^', '_', '°', 'ä', 'ö', 'ü', 'ß'},
+	// Swedish, Finnish, Hungarian
+	{'#', '¤', 'É', 'Ä', 'Ö', 'Å', 'Ü', '_', 'é', 'ä', 'ö', 'å', 'ü'},
+	// Italian
+	{'£', '$', 'é', '°', 'ç', '→', '↑', '#', 'ù', 'à', 'ò', 'è', 'ì'},
+	// French
+	{'é', 'ï', 'à', 'ë', 'ê', 'ù', 'î', '#', 'è', 'â', 'ô', 'û', 'ç'},
+	// Portuguese, Spanish
+	{'ç', '$', '¡', 'á', 'é', 'í', 'ó', 'ú', '¿', 'ü', 'ñ', 'è', 'à'},
+	// Czech, Slovak
+	{'#', 'ů', 'č', 'ť', 'ž', 'ý', 'í', 'ř', 'é', 'á', 'ě', 'ú', 'š'},
+	// Reserved, use English
+	{'£', '$', '@', '←', '½', '→', '↑', '#', '—', '¼', '‖', '¾', '÷'},
+}
+
+// DecodeRow - decode 40 bytes of odd parity row data into text using the
+// Latin G0 set with the given national option sub-set. Spacing attributes and
+// characters with parity errors become spaces; trailing spaces are removed.
+func DecodeRow(data []byte, subset uint8) string {
+	var sb strings.Builder
+	for _, b := range data {
+		if bits.OnesCount8(b)%2 != 1 {
+			sb.WriteByte(' ')
+			continue
+		}
+		c := b & 0x7F
+		if c < 0x20 {
+			sb.WriteByte(' ')
+			continue
+		}
+		r := rune(c)
+		for i, pos := range nationalPositions {
+			if c == pos {
+				r = nationalSubsets[subset&0x7][i]
+				break
+			}
+		}
+		if c == 0x7F {
+			r = '■'
+		}
+		sb.WriteRune(r)
+	}
+	return strings.TrimRight(sb.String(), " ")
+}
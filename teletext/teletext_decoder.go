@@ -0,0 +1,83 @@
+package teletext
+
+import (
+	"strings"
+	"time"
+
+	"github.com/go-webdl/media-codec/subtitle"
+)
+
+// Decoder - turn teletext subtitle pages into cues
+//
+// A subtitle page is displayed from the presentation time of the PES packet
+// carrying its header until the header of the next transmission of the same
+// page, which replaces or erases it.
+type Decoder struct {
+	// Page to decode in hexadecimal notation, e.g. 0x888. When zero, the
+	// decoder locks onto the first page with the subtitle flag set.
+	Page uint16
+	// OnCue is called for every completed cue
+	OnCue func(subtitle.Cue)
+
+	receiving bool
+	shownAt   time.Duration
+	subset    uint8
+	rows      [25]string
+}
+
+// Decode - feed the PES_data_field of one teletext PES packet presented at pts
+func (d *Decoder) Decode(pesData []byte, pts time.Duration) error {
+	packets, err := ParsePESData(pesData)
+	for i := range packets {
+		d.handlePacket(&packets[i], pts)
+	}
+	return err
+}
+
+// Flush - emit the page currently displayed, ending it at pts
+func (d *Decoder) Flush(pts time.Duration) {
+	d.emit(pts)
+	d.receiving = false
+}
+
+func (d *Decoder) handlePacket(p *Packet, pts time.Duration) {
+	if p.PacketNumber == 0 {
+		header, err := p.ParsePageHeader()
+		if err != nil {
+			return
+		}
+		if d.Page == 0 && header.Subtitle {
+			d.Page = header.Page
+		}
+		if header.Page == d.Page {
+			d.emit(pts)
+			d.receiving = true
+			d.shownAt = pts
+			d.subset = header.NationalOptionCharacterSubset
+			return
+		}
+		// Another page of the same magazine, or of any magazine in serial
+		// mode, terminates the transmission of our page
+		if d.receiving && (header.MagazineSerial || uint8(header.Page>>8) == uint8(d.Page>>8)) {
+			d.receiving = false
+		}
+		return
+	}
+	if !d.receiving || p.Magazine != uint8(d.Page>>8) || p.PacketNumber > 24 {
+		return
+	}
+	d.rows[p.PacketNumber] = DecodeRow(p.Data[:], d.subset)
+}
+
+func (d *Decoder) emit(pts time.Duration) {
+	var lines []string
+	for i, row := range d.rows {
+		if row = strings.TrimSpace(row); row != "" {
+			lines = append(lines, row)
+		}
+		d.rows[i] = ""
+	}
+	if len(lines) > 0 && d.OnCue != nil && pts > d.shownAt {
+		d.OnCue(subtitle.Cue{Start: d.shownAt, End: pts, Text: strings.Join(lines, "\n")})
+	}
+}
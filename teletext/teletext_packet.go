@@ -0,0 +1,150 @@
+package teletext
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// DataUnitID - data_unit_id of EN 300 472
+type DataUnitID uint8
+
+const (
+	DATA_UNIT_TELETEXT_NON_SUBTITLE = DataUnitID(0x02)
+	DATA_UNIT_TELETEXT_SUBTITLE     = DataUnitID(0x03)
+	DATA_UNIT_STUFFING              = DataUnitID(0xFF)
+)
+
+// Packet - one teletext packet, with bits already in transmission order
+type Packet struct {
+	DataUnitID  DataUnitID
+	FieldParity bool
+	LineOffset  uint8
+	// Magazine number 1-8
+	Magazine uint8
+	// Packet number 0-31. Packet 0 is the page header, 1-25 are rows.
+	PacketNumber uint8
+	Data         [40]byte
+}
+
+// ParsePESData - split the PES_data_field of a teletext PES packet into
+// teletext packets
+// ETSI EN 300 472 carries EBU Teletext in private_stream_1 PES packets as a
+// data_identifier followed by data units of one 42 byte packet each.
+func ParsePESData(data []byte) (packets []Packet, err error) {
+	if len(data) < 1 {
+		return nil, fmt.Errorf("empty teletext PES data")
+	}
+	if dataIdentifier := data[0]; dataIdentifier < 0x10 || dataIdentifier > 0x1F {
+		return nil, fmt.Errorf("data_identifier 0x%02X is not EBU data", dataIdentifier)
+	}
+	data = data[1:]
+	for len(data) >= 2 {
+		id, length := DataUnitID(data[0]), int(data[1])
+		if 2+length > len(data) {
+			return packets, fmt.Errorf("data_unit_length %d exceeds PES data", length)
+		}
+		unit := data[2 : 2+length]
+		data = data[2+length:]
+		if (id != DATA_UNIT_TELETEXT_NON_SUBTITLE && id != DATA_UNIT_TELETEXT_SUBTITLE) || length != 44 {
+			continue
+		}
+		// Data units are transmitted LSB first
+		var field [44]byte
+		for i := range field {
+			field[i] = bits.Reverse8(unit[i])
+		}
+		if field[1] != 0x27 {
+			// framing_code 0xE4 after bit reversal
+			continue
+		}
+		address, ok := decodeHamming84Pair(field[2], field[3])
+		if !ok {
+			continue
+		}
+		p := Packet{
+			DataUnitID:   id,
+			FieldParity:  unit[0]&0x20 != 0,
+			LineOffset:   unit[0] & 0x1F,
+			Magazine:     address & 0x7,
+			PacketNumber: address >> 3,
+		}
+		if p.Magazine == 0 {
+			p.Magazine = 8
+		}
+		copy(p.Data[:], field[4:])
+		packets = append(packets, p)
+	}
+	return
+}
+
+// PageHeader - content of packet X/0
+type PageHeader struct {
+	// Page number in hexadecimal notation including the magazine, e.g. 0x888
+	Page    uint16
+	Subcode uint16
+	// C4
+	ErasePage bool
+	// C5
+	Newsflash bool
+	// C6
+	Subtitle bool
+	// C7
+	SuppressHeader bool
+	// C11, pages of all magazines are transmitted interleaved
+	MagazineSerial bool
+	// C12-C14
+	NationalOptionCharacterSubset uint8
+}
+
+// ParsePageHeader - decode the page header of packet X/0
+func (p *Packet) ParsePageHeader() (*PageHeader, error) {
+	if p.PacketNumber != 0 {
+		return nil, fmt.Errorf("packet %d/%d is not a page header", p.Magazine, p.PacketNumber)
+	}
+	var n [8]uint8
+	for i := range n {
+		var ok bool
+		if n[i], ok = decodeHamming84(p.Data[i]); !ok {
+			return nil, fmt.Errorf("uncorrectable error in page header of magazine %d", p.Magazine)
+		}
+	}
+	return &PageHeader{
+		Page:                          uint16(p.Magazine)<<8 | uint16(n[1])<<4 | uint16(n[0]),
+		Subcode:                       uint16(n[5]&0x3)<<12 | uint16(n[4])<<8 | uint16(n[3]&0x7)<<4 | uint16(n[2]),
+		ErasePage:                     n[3]&0x8 != 0,
+		Newsflash:                     n[5]&0x4 != 0,
+		Subtitle:                      n[5]&0x8 != 0,
+		SuppressHeader:                n[6]&0x1 != 0,
+		MagazineSerial:                n[7]&0x1 != 0,
+		NationalOptionCharacterSubset: (n[7] >> 1) & 0x7,
+	}, nil
+}
+
+var hamming84Encode = func() (table [16]uint8) {
+	for d := range table {
+		d1, d2, d3, d4 := uint8(d)&1, uint8(d)>>1&1, uint8(d)>>2&1, uint8(d)>>3&1
+		p1 := 1 ^ d1 ^ d3 ^ d4
+		p2 := 1 ^ d1 ^ d2 ^ d4
+		p3 := 1 ^ d1 ^ d2 ^ d3
+		p4 := 1 ^ p1 ^ d1 ^ p2 ^ d2 ^ p3 ^ d3 ^ d4
+		table[d] = p1 | d1<<1 | p2<<2 | d2<<3 | p3<<4 | d3<<5 | p4<<6 | d4<<7
+	}
+	return
+}()
+
+// decodeHamming84 - decode a Hamming 8/4 protected nibble, correcting single
+// bit errors
+func decodeHamming84(b uint8) (uint8, bool) {
+	for d, code := range hamming84Encode {
+		if bits.OnesCount8(code^b) <= 1 {
+			return uint8(d), true
+		}
+	}
+	return 0, false
+}
+
+func decodeHamming84Pair(lo, hi uint8) (uint8, bool) {
+	l, ok1 := decodeHamming84(lo)
+	h, ok2 := decodeHamming84(hi)
+	return h<<4 | l, ok1 && ok2
+}
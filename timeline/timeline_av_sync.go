@@ -0,0 +1,117 @@
+package timeline
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TrackTiming - presentation timing of one track of a video and audio pair
+type TrackTiming struct {
+	// Timescale - media timescale of FirstPTS, Priming and edit media times
+	Timescale uint32
+	// MovieTimescale - timescale of edit segment durations
+	MovieTimescale uint32
+	// FirstPTS - presentation time of the earliest presented sample
+	FirstPTS int64
+	// Priming - leading samples that are not content, e.g. the encoder
+	// delay of AAC (commonly 1024 or 2112) or Opus pre-skip. Zero for video.
+	Priming uint32
+	// Edits - edit list of the track, empty if there is none
+	Edits []EditListEntry
+}
+
+// AVSyncReport - expected alignment of a video and audio track
+type AVSyncReport struct {
+	// VideoStart - movie time at which the first video frame is presented
+	VideoStart time.Duration
+	// AudioStart - movie time at which the first audio content sample,
+	// after priming, is presented
+	AudioStart time.Duration
+	// Offset - AudioStart minus VideoStart, positive if audio is late
+	Offset time.Duration
+	// Warnings - conditions that commonly cause sync problems
+	Warnings []string
+}
+
+// SyncTolerance - offsets up to this magnitude are not reported as a warning
+var SyncTolerance = 20 * time.Millisecond
+
+// CompareAVSync - compute the expected A/V offset of a video and audio track pair
+// Only the leading empty edits and the first media edit are taken into
+// account, which is what players honour in practice.
+func CompareAVSync(video, audio TrackTiming) (*AVSyncReport, error) {
+	report := &AVSyncReport{}
+	var err error
+	if report.VideoStart, err = video.movieTime(video.FirstPTS, "video", report); err != nil {
+		return nil, err
+	}
+	audioContentStart := audio.FirstPTS + int64(audio.Priming)
+	if report.AudioStart, err = audio.movieTime(audioContentStart, "audio", report); err != nil {
+		return nil, err
+	}
+	report.Offset = report.AudioStart - report.VideoStart
+
+	if audio.Priming > 0 {
+		if mediaTime, ok := audio.firstMediaTime(); !ok || mediaTime < audioContentStart {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("audio priming of %d samples is not removed by an edit", audio.Priming))
+		}
+	}
+	if report.Offset > SyncTolerance || report.Offset < -SyncTolerance {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("A/V offset %s exceeds tolerance %s", report.Offset, SyncTolerance))
+	}
+	return report, nil
+}
+
+// firstMediaTime - media time of the first non-empty edit
+func (t *TrackTiming) firstMediaTime() (int64, bool) {
+	for _, e := range t.Edits {
+		if e.MediaTime >= 0 {
+			return e.MediaTime, true
+		}
+	}
+	return 0, false
+}
+
+// movieTime - movie time at which mediaTime is presented
+func (t *TrackTiming) movieTime(mediaTime int64, name string, report *AVSyncReport) (time.Duration, error) {
+	if t.Timescale == 0 {
+		return 0, errors.New(name + ": zero timescale")
+	}
+	if len(t.Edits) > 0 && t.MovieTimescale == 0 {
+		return 0, errors.New(name + ": edit list without movie timescale")
+	}
+	var empty time.Duration
+	editMediaTime := int64(0)
+	mediaEdits := 0
+	for _, e := range t.Edits {
+		if e.MediaTime < 0 {
+			if mediaEdits == 0 {
+				empty += scale(int64(e.SegmentDuration), t.MovieTimescale)
+			}
+			continue
+		}
+		if mediaEdits == 0 {
+			editMediaTime = e.MediaTime
+			if e.MediaRateInteger != 1 {
+				report.Warnings = append(report.Warnings, fmt.Sprintf("%s: edit media rate %d ignored", name, e.MediaRateInteger))
+			}
+		}
+		mediaEdits++
+	}
+	if mediaEdits > 1 {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("%s: %d media edits, only the first is considered", name, mediaEdits))
+	}
+	if mediaTime < editMediaTime {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("%s: first sample at %s is cut by the edit starting at %s",
+			name, scale(mediaTime, t.Timescale), scale(editMediaTime, t.Timescale)))
+		mediaTime = editMediaTime
+	}
+	return empty + scale(mediaTime-editMediaTime, t.Timescale), nil
+}
+
+// scale - convert a time in timescale units to a duration
+func scale(t int64, timescale uint32) time.Duration {
+	ts := int64(timescale)
+	return time.Duration(t/ts)*time.Second + time.Duration(t%ts)*time.Second/time.Duration(ts)
+}
@@ -0,0 +1,73 @@
+package timeline
+
+import (
+	"fmt"
+)
+
+// Concatenate - rebase the samples of parts, each in decode order and in the
+// same timescale, onto one continuous timeline, e.g. the independently
+// encoded parts of a multi-part download, whose timestamps restart from zero
+// Each part is shifted so that its first sample is decoded right after the
+// last sample of the previous part. If that would present any of its samples
+// before the previous part's presentation end, the shift is increased so that
+// presentation is continuous instead. The shift applied to each part is
+// returned alongside, e.g. to rebase other tracks of the same part.
+func Concatenate(parts [][]Sample) (samples []Sample, shifts []int64, err error) {
+	shifts = make([]int64, len(parts))
+	var decodeEnd, presentationEnd int64
+	first := true
+	for p, part := range parts {
+		if len(part) == 0 {
+			continue
+		}
+		partFirstPTS := part[0].PTS()
+		for i := range part {
+			if i > 0 && part[i].DTS < part[i-1].DTS {
+				return nil, nil, fmt.Errorf("part %d: DTS decreasing at sample %d", p, i)
+			}
+			if pts := part[i].PTS(); pts < partFirstPTS {
+				partFirstPTS = pts
+			}
+		}
+		shift := -part[0].DTS
+		if !first {
+			shift = decodeEnd - part[0].DTS
+			if partFirstPTS+shift < presentationEnd {
+				shift = presentationEnd - partFirstPTS
+			}
+		}
+		shifts[p] = shift
+		first = false
+
+		for i := range part {
+			s := part[i]
+			s.DTS += shift
+			if s.Duration == 0 {
+				s.Duration = fallbackDuration(part, i)
+			}
+			samples = append(samples, s)
+			if end := s.DTS + int64(s.Duration); end > decodeEnd {
+				decodeEnd = end
+			}
+			if end := s.PTS() + int64(s.Duration); end > presentationEnd {
+				presentationEnd = end
+			}
+		}
+	}
+	return samples, shifts, nil
+}
+
+// fallbackDuration - duration for a sample without one: the DTS delta to the
+// next sample, or the duration of the previous sample for the last one
+func fallbackDuration(part []Sample, i int) uint32 {
+	if i+1 < len(part) {
+		return uint32(part[i+1].DTS - part[i].DTS)
+	}
+	if i > 0 {
+		if part[i-1].Duration != 0 {
+			return part[i-1].Duration
+		}
+		return uint32(part[i].DTS - part[i-1].DTS)
+	}
+	return 0
+}
@@ -0,0 +1,126 @@
+package timeline
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sample - timing of one sample (access unit) in decode order
+// Times are in the media timescale. Sync is typically set from
+// avc.IsRandomAccessUnit or hevc.IsRAPSample.
+type Sample struct {
+	DTS               int64
+	CompositionOffset int64
+	Duration          uint32
+	Sync              bool
+}
+
+// PTS - presentation time of the sample
+func (s *Sample) PTS() int64 {
+	return s.DTS + s.CompositionOffset
+}
+
+// EditListEntry - entry of an edit list box (elst)
+// SegmentDuration is in the movie timescale, MediaTime in the media timescale.
+type EditListEntry struct {
+	SegmentDuration   uint64
+	MediaTime         int64
+	MediaRateInteger  int16
+	MediaRateFraction int16
+}
+
+// TrimPlan - decodable sample range and edit covering a requested trim
+type TrimPlan struct {
+	// First, End - samples First up to but not including End (decode order)
+	// must be kept. First is a sync sample.
+	First, End int
+	// LeadingFrames - kept samples presented before the trim start, needed
+	// for decoding only and hidden by the edit
+	LeadingFrames int
+	// TrailingFrames - kept samples presented at or after the trim end,
+	// needed to decode earlier presented samples and hidden by the edit
+	TrailingFrames int
+	// DTSShift - to subtract from the DTS of kept samples so that the first
+	// kept sample is decoded at time 0
+	DTSShift int64
+	// Edit - single edit presenting exactly the trimmed range, with
+	// MediaTime relative to the shifted timeline
+	Edit EditListEntry
+}
+
+var (
+	// ErrEmptyTrim - trim end is not after trim start
+	ErrEmptyTrim = errors.New("trim end not after start")
+	// ErrNoSyncSample - no sync sample precedes the trim start
+	ErrNoSyncSample = errors.New("no sync sample at or before trim start")
+)
+
+// PlanTrim - convert the presentation range [start, end) in the media
+// timescale into the nearest decodable sample range and its edit list
+// The range is clamped to the presentation span of samples. Decoding starts at
+// the last sync sample in decode order presented at or before start.
+func PlanTrim(samples []Sample, start, end int64, mediaTimescale, movieTimescale uint32) (*TrimPlan, error) {
+	if len(samples) == 0 {
+		return nil, errors.New("no samples")
+	}
+	if mediaTimescale == 0 || movieTimescale == 0 {
+		return nil, errors.New("zero timescale")
+	}
+	firstPTS, lastPTS := samples[0].PTS(), samples[0].PTS()+int64(samples[0].Duration)
+	for i := range samples {
+		if pts := samples[i].PTS(); pts < firstPTS {
+			firstPTS = pts
+		}
+		if ptsEnd := samples[i].PTS() + int64(samples[i].Duration); ptsEnd > lastPTS {
+			lastPTS = ptsEnd
+		}
+	}
+	if start < firstPTS {
+		start = firstPTS
+	}
+	if end > lastPTS {
+		end = lastPTS
+	}
+	if end <= start {
+		return nil, ErrEmptyTrim
+	}
+
+	plan := &TrimPlan{First: -1}
+	for i := range samples {
+		if samples[i].Sync && samples[i].PTS() <= start {
+			plan.First = i
+		}
+	}
+	if plan.First < 0 {
+		return nil, ErrNoSyncSample
+	}
+	// every sample presented inside the range must be decoded
+	plan.End = plan.First + 1
+	for i := plan.First; i < len(samples); i++ {
+		pts := samples[i].PTS()
+		if pts < end && pts+int64(samples[i].Duration) > start {
+			plan.End = i + 1
+		}
+	}
+	for i := plan.First; i < plan.End; i++ {
+		pts := samples[i].PTS()
+		switch {
+		case pts+int64(samples[i].Duration) <= start:
+			plan.LeadingFrames++
+		case pts >= end:
+			plan.TrailingFrames++
+		}
+	}
+
+	plan.DTSShift = samples[plan.First].DTS
+	mediaTime := start - plan.DTSShift
+	if mediaTime < 0 {
+		return nil, fmt.Errorf("trim start %d presented before first decoded sample at %d", start, plan.DTSShift)
+	}
+	plan.Edit = EditListEntry{
+		SegmentDuration:  uint64(end-start) * uint64(movieTimescale) / uint64(mediaTimescale),
+		MediaTime:        mediaTime,
+		MediaRateInteger: 1,
+	}
+	return plan, nil
+}
@@ -0,0 +1,128 @@
+package vp9
+
+import (
+	"fmt"
+
+	"github.com/go-webdl/media-codec/colr"
+)
+
+// ColorSpace - VP9 color_space
+// VP9 Bitstream & Decoding Process Specification Sec. 7.2.2
+type ColorSpace byte
+
+const (
+	CS_UNKNOWN   = ColorSpace(0)
+	CS_BT_601    = ColorSpace(1)
+	CS_BT_709    = ColorSpace(2)
+	CS_SMPTE_170 = ColorSpace(3)
+	CS_SMPTE_240 = ColorSpace(4)
+	CS_BT_2020   = ColorSpace(5)
+	CS_RESERVED  = ColorSpace(6)
+	CS_RGB       = ColorSpace(7)
+)
+
+func (c ColorSpace) String() string {
+	switch c {
+	case CS_UNKNOWN:
+		return "Unknown_0"
+	case CS_BT_601:
+		return "BT601_1"
+	case CS_BT_709:
+		return "BT709_2"
+	case CS_SMPTE_170:
+		return "SMPTE170_3"
+	case CS_SMPTE_240:
+		return "SMPTE240_4"
+	case CS_BT_2020:
+		return "BT2020_5"
+	case CS_RGB:
+		return "RGB_7"
+	default:
+		return fmt.Sprintf("Other_%d", c)
+	}
+}
+
+// ColorConfig - VP9 color_config
+// VP9 Bitstream & Decoding Process Specification Sec. 6.2.2
+type ColorConfig struct {
+	BitDepth     byte
+	ColorSpace   ColorSpace
+	ColorRange   bool
+	SubsamplingX bool
+	SubsamplingY bool
+}
+
+// NCLX - colour description equivalent to the VUI colour description triplet
+// The VP9 bitstream only signals the matrix. Primaries and transfer
+// characteristics are the ones conventionally paired with that matrix; if the
+// container carries explicit values (vpcC) those should take precedence.
+func (c *ColorConfig) NCLX() colr.NCLX {
+	n := colr.Unspecified()
+	switch c.ColorSpace {
+	case CS_BT_601:
+		n.ColourPrimaries = colr.COLOUR_PRIMARIES_BT470BG
+		n.TransferCharacteristics = colr.TRANSFER_SMPTE170M
+		n.MatrixCoefficients = colr.MATRIX_BT470BG
+	case CS_BT_709:
+		n.ColourPrimaries = colr.COLOUR_PRIMARIES_BT709
+		n.TransferCharacteristics = colr.TRANSFER_BT709
+		n.MatrixCoefficients = colr.MATRIX_BT709
+	case CS_SMPTE_170:
+		n.ColourPrimaries = colr.COLOUR_PRIMARIES_SMPTE170M
+		n.TransferCharacteristics = colr.TRANSFER_SMPTE170M
+		n.MatrixCoefficients = colr.MATRIX_SMPTE170M
+	case CS_SMPTE_240:
+		n.ColourPrimaries = colr.COLOUR_PRIMARIES_SMPTE240M
+		n.TransferCharacteristics = colr.TRANSFER_SMPTE240M
+		n.MatrixCoefficients = colr.MATRIX_SMPTE240M
+	case CS_BT_2020:
+		n.ColourPrimaries = colr.COLOUR_PRIMARIES_BT2020
+		n.TransferCharacteristics = colr.TRANSFER_BT2020_10BIT
+		if c.BitDepth >= 12 {
+			n.TransferCharacteristics = colr.TRANSFER_BT2020_12BIT
+		}
+		n.MatrixCoefficients = colr.MATRIX_BT2020_NCL
+	case CS_RGB:
+		n.ColourPrimaries = colr.COLOUR_PRIMARIES_BT709
+		n.TransferCharacteristics = colr.TRANSFER_SRGB
+		n.MatrixCoefficients = colr.MATRIX_IDENTITY
+	}
+	n.FullRangeFlag = c.ColorRange
+	return n
+}
+
+// SetNCLX - set color space and range from a VUI colour description
+// Only the matrix coefficients can be represented; matrices without a VP9
+// color space map to CS_UNKNOWN and ok is false.
+func (c *ColorConfig) SetNCLX(n colr.NCLX) (ok bool) {
+	c.ColorSpace, ok = ColorSpaceFromMatrix(n.MatrixCoefficients)
+	c.ColorRange = n.FullRangeFlag
+	if c.ColorSpace == CS_RGB {
+		c.ColorRange = true
+		c.SubsamplingX = false
+		c.SubsamplingY = false
+	}
+	return ok
+}
+
+// ColorSpaceFromMatrix - VP9 color space for matrix_coefficients
+func ColorSpaceFromMatrix(matrixCoefficients uint16) (cs ColorSpace, ok bool) {
+	switch matrixCoefficients {
+	case colr.MATRIX_IDENTITY:
+		return CS_RGB, true
+	case colr.MATRIX_BT709:
+		return CS_BT_709, true
+	case colr.MATRIX_BT470BG:
+		return CS_BT_601, true
+	case colr.MATRIX_SMPTE170M:
+		return CS_SMPTE_170, true
+	case colr.MATRIX_SMPTE240M:
+		return CS_SMPTE_240, true
+	case colr.MATRIX_BT2020_NCL:
+		return CS_BT_2020, true
+	case colr.MATRIX_UNSPECIFIED:
+		return CS_UNKNOWN, true
+	default:
+		return CS_UNKNOWN, false
+	}
+}